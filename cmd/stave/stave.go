@@ -2,8 +2,11 @@ package stave
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/charmbracelet/fang"
 	"github.com/yaklabco/stave/cmd/stave/version"
@@ -14,6 +17,9 @@ import (
 )
 
 const (
+	// jsonFlag is the flag that makes --version print JSON.
+	jsonFlag = "json"
+
 	shortDescription = "Stave is a Go-native, make-like command runner. " +
 		"It is a fork of mage. See https://github.com/yaklabco/stave"
 )
@@ -41,6 +47,7 @@ func NewRootCmd(ctx context.Context, opts ...Option) *cobra.Command {
 	}
 
 	var runParams stave.RunParams
+	var goEnv []string
 	rootCmd := &cobra.Command{
 		Use:   "stave [flags] [target]",
 		Short: shortDescription,
@@ -74,7 +81,19 @@ func NewRootCmd(ctx context.Context, opts ...Option) *cobra.Command {
 			return targets, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Cobra drops "--"; put it back so the compiled stavefile passes
+			// what follows to the targets instead of parsing it as flags.
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				args = slices.Insert(args, dash, "--")
+			}
 			runParams.Args = args
+			if asJSON, _ := cmd.Flags().GetBool(jsonFlag); asJSON {
+				return &stave.Error{Kind: stave.KindUsage, Code: 2, Err: errors.New("--json only applies when running with --version")}
+			}
+			var err error
+			if runParams.GoEnv, err = parseGoEnv(goEnv); err != nil {
+				return &stave.Error{Kind: stave.KindUsage, Code: 2, Err: err}
+			}
 			runParams.WriterForLogger = os.Stdout
 			runParams.BaseCtx = cmd.Context() //nolint:fatcontext // intentionally setting context from cmd
 
@@ -83,31 +102,83 @@ func NewRootCmd(ctx context.Context, opts ...Option) *cobra.Command {
 	}
 
 	// Flags.
+	rootCmd.PersistentFlags().BoolVar(&runParams.ListAll, "all", false, "with --list, also show hidden targets")
+	rootCmd.PersistentFlags().BoolVar(&runParams.ListArgs, "args", false, "with --list, show the name and type of each target argument")
+	rootCmd.PersistentFlags().StringVar(&runParams.Asmflags, "asmflags", "", "set asmflags for the stavefile binary")
+	rootCmd.PersistentFlags().BoolVar(&runParams.AutoMod, "auto-mod", false, "run go mod init and go mod tidy for stavefiles outside a Go module")
+	rootCmd.PersistentFlags().StringSliceVar(&runParams.BuildTags, "build-tags", nil, "build tags for the binary produced with --compile, besides stave")
+	rootCmd.PersistentFlags().StringVar(&runParams.BuildMode, "buildmode", "", "set -buildmode for binary produced with --compile, like pie")
+	rootCmd.PersistentFlags().DurationVar(&runParams.CleanupGrace, "cleanup-grace", 0, "how long cancelled targets get to clean up (default 5s)")
+	rootCmd.PersistentFlags().StringVar(&runParams.ConfigFile, "config-file", "", "load project config from this file instead of stave.yaml")
+	rootCmd.PersistentFlags().StringVar(&runParams.ContainerPull, "container-pull", "", "when to pull the images of targets marked stave:container: missing, always or never")
 	rootCmd.PersistentFlags().BoolVarP(&runParams.Debug, "debug", "d", st.Debug(), "turn on debug messages")
 	rootCmd.PersistentFlags().StringVarP(&runParams.Dir, "dir", "C", "", "directory to read stavefiles from")
+	rootCmd.PersistentFlags().BoolVar(&runParams.InitDirLayout, "dir-layout", false, "with --init, create the stavefile in a stavefiles directory")
 	rootCmd.PersistentFlags().BoolVar(&runParams.DryRun, "dryrun", false, "print commands instead of executing them")
+	rootCmd.PersistentFlags().BoolVar(&runParams.DryRunDeps, "dryrun-deps", false, "like --dryrun, and print the targets st.Deps would run instead of running them")
+	rootCmd.PersistentFlags().StringArrayVar(&runParams.EnvFiles, "env-file", nil, "load variables from this dotenv file into the stavefile's environment (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&runParams.ErrorFormat, "error-format", "", "how the stavefile reports the error a run fails with: text or json (default text)")
 	rootCmd.PersistentFlags().BoolVarP(&runParams.Force, "force", "f", false, "force recreation of compiled stavefile")
+	rootCmd.PersistentFlags().StringVar(&runParams.Gcflags, "gcflags", "", "set gcflags for the stavefile binary")
 	rootCmd.PersistentFlags().StringVar(&runParams.GOARCH, "goarch", "", "set GOARCH for binary produced with --compile")
 	rootCmd.PersistentFlags().StringVar(&runParams.GoCmd, "gocmd", st.GoCmd(), "use the given go binary to compile the output")
+	rootCmd.PersistentFlags().StringArrayVar(&goEnv, "goenv", nil, "set KEY=VALUE in the environment of go build, like CC=clang for cross-compiling with cgo (repeatable)")
 	rootCmd.PersistentFlags().StringVar(&runParams.GOOS, "goos", "", "set GOOS for binary produced with --compile")
+	rootCmd.PersistentFlags().Bool(jsonFlag, false, "with --version, print the version and build info as JSON")
+	rootCmd.PersistentFlags().StringVar(&runParams.ListImport, "import", "", "with --list, show only the targets of this import (alias, name or path)")
+	rootCmd.PersistentFlags().BoolVar(&runParams.ListImports, "imports", false, "with --list, show the imports section")
 	rootCmd.PersistentFlags().BoolVarP(&runParams.Info, "info", "i", st.Info(), "show docstring for a specific target")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Keep, "keep", false, "keep intermediate stave files around after running")
-	rootCmd.PersistentFlags().StringVar(&runParams.Ldflags, "ldflags", "", "set ldflags for binary produced with --compile")
+	rootCmd.PersistentFlags().BoolVar(&runParams.ListLocal, "local", false, "with --list, show the local targets section")
+	rootCmd.PersistentFlags().StringVar(&runParams.LogFormat, "log-format", stave.LogFormatPretty, "format of stave's own log messages: pretty or json")
+	rootCmd.PersistentFlags().StringVar(&runParams.MainfileName, "mainfile-name", "", "fixed file name for the generated mainfile (useful with --keep)")
+	rootCmd.PersistentFlags().StringVar(&runParams.Ldflags, "ldflags", "", "set ldflags for the stavefile binary, and for targets to apply with st.Ldflags")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Multiline, "multiline", st.Multiline(), "retain line returns in help text")
+	rootCmd.PersistentFlags().BoolVar(&runParams.ListNamespaces, "namespaces", false, "with --list, show the namespaces section")
+	rootCmd.PersistentFlags().BoolVar(&runParams.NoContainer, "no-container", false, "run targets marked stave:container here, instead of in their container")
+	rootCmd.PersistentFlags().BoolVar(&runParams.NoTimings, "no-timings", false, "with --list, hide how long each target last took")
+	rootCmd.PersistentFlags().BoolVar(&runParams.ConfigOrigin, "origin", false, "with --config, show where each config value comes from")
+	rootCmd.PersistentFlags().IntVar(&runParams.OutputsKeep, "outputs-keep", 0, "number of sets of declared target outputs to keep per target (default 5)")
+	rootCmd.PersistentFlags().BoolVar(&runParams.PrintExpanded, "print-expanded", false, "print the targets and arguments @task files expand to, instead of running them")
+	rootCmd.PersistentFlags().IntVarP(&runParams.Parallelism, "parallelism", "p", 0, "number of CPUs the stavefile and its commands use, overriding STAVE_NUM_PROCESSORS (default: all)")
+	rootCmd.PersistentFlags().BoolVar(&runParams.HooksSubmodules, "recurse-submodules", false, "with --hooks install, also install the hooks in each initialized submodule")
+	rootCmd.PersistentFlags().BoolVarP(&runParams.Quiet, "quiet", "q", false, "only show stave's own errors, not its info messages and warnings (wins over --verbose)")
+	rootCmd.PersistentFlags().BoolVar(&runParams.Strict, "strict", false, "fail on stavefile lint findings, like targets that call os.Exit, instead of warning")
+	rootCmd.PersistentFlags().BoolVar(&runParams.StrictSignatures, "strict-signatures", false, "fail on exported functions that aren't valid targets, instead of warning")
+	rootCmd.PersistentFlags().IntVar(&runParams.TimeTargets, "time-targets", 0, "run the targets this many times, each in a new process, and report min/median/max/mean durations")
+	rootCmd.PersistentFlags().BoolVar(&runParams.TimeTargetsJSON, "time-targets-json", false, "with --time-targets, report the timings as JSON")
 	rootCmd.PersistentFlags().DurationVarP(&runParams.Timeout, "timeout", "t", 0, "timeout in duration parsable format (e.g. 5m30s)")
+	rootCmd.PersistentFlags().DurationVar(&runParams.PerTargetTimeout, "timeout-per-target", 0, "timeout for each target, within --timeout (-tt)")
 	rootCmd.PersistentFlags().BoolVarP(&runParams.Verbose, "verbose", "v", st.Verbose(), "show verbose output when running stave targets")
+	rootCmd.PersistentFlags().StringSliceVar(&runParams.VerifyTargets, "verify-targets", nil, "with --compile, fail unless the binary has these targets (comma-separated)")
+	rootCmd.PersistentFlags().BoolVar(&runParams.Vet, "vet", false, "run go vet on the stavefiles before compiling them, and fail on its findings")
+	rootCmd.PersistentFlags().StringArrayVar(&runParams.WatchDirs, "watch-dir", nil, "limit watch mode to this directory and its subdirectories, relative to --workdir (repeatable)")
 	rootCmd.PersistentFlags().StringVarP(&runParams.WorkDir, "workdir", "w", "", "working directory where stavefiles will run")
+	rootCmd.PersistentFlags().BoolVar(&runParams.Yes, "yes", false, "run targets marked stave:confirm without asking")
 
 	// Flags that are actually commands ("pseudo-flags").
 	rootCmd.PersistentFlags().BoolVar(&runParams.Clean, "clean", false, "clean out old generated binaries from CACHE_DIR")
 	rootCmd.PersistentFlags().StringVar(&runParams.CompileOut, "compile", "", "output a static binary to the given path")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Config, "config", false, "manage stave configuration")
 	rootCmd.PersistentFlags().BoolVar(&runParams.DirEnv, "direnv", false, "delegate to direnv for managing environment variables")
+	rootCmd.PersistentFlags().BoolVar(&runParams.DumpTargets, "dump-targets", false, "print what stave parsed from the stavefiles, for debugging")
+	rootCmd.PersistentFlags().BoolVar(&runParams.EnsureCompiled, "ensure-compiled", false, "compile the stavefiles if needed and print the path of the binary, without running a target")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Exec, "exec", false, "execute commands under stave")
+	rootCmd.PersistentFlags().BoolVar(&runParams.FixTags, "fix-tags", false, "rewrite the stave build constraints of the stavefiles as //go:build and // +build pairs")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Hooks, "hooks", false, "manage git hooks (install, list, run, etc.)")
 	rootCmd.PersistentFlags().BoolVar(&runParams.Init, "init", false, "create a starting template if no stave files exist")
 	rootCmd.PersistentFlags().BoolVarP(&runParams.List, "list", "l", false, "list stave targets in this directory")
 
+	// --version prints through the version template, which cobra handles
+	// before RunE.
+	cobra.AddTemplateFunc("staveVersion", versionOutput)
+	rootCmd.SetVersionTemplate(`{{staveVersion .}}`)
+
+	// Bad flags are usage errors, like those stave.Run reports.
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &stave.Error{Kind: stave.KindUsage, Code: 2, Err: err}
+	})
+
 	// Mark --exec as hidden for now, since it doesn't do anything interesting (yet!), and users may therefore be confused by its existence.
 	// Revisit this as Stave's functionality expands.
 	err := rootCmd.PersistentFlags().MarkHidden("exec")
@@ -118,6 +189,59 @@ func NewRootCmd(ctx context.Context, opts ...Option) *cobra.Command {
 	return rootCmd
 }
 
+// versionOutput returns what --version prints for cmd: a version line, or
+// the version and build info as JSON with --json.
+func versionOutput(cmd *cobra.Command) (string, error) {
+	if asJSON, _ := cmd.Flags().GetBool(jsonFlag); asJSON {
+		return version.JSON(cmd.Context())
+	}
+	return cmd.DisplayName() + " version " + cmd.Version + "\n", nil
+}
+
+// NormalizeArgs rewrites the command-line arguments that cobra can't parse
+// as they are: "-tt", the short form of --timeout-per-target, which it would
+// read as -t with the value "t". Arguments after "--" are left alone.
+func NormalizeArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if arg == "-tt" || strings.HasPrefix(arg, "-tt=") {
+			arg = "--timeout-per-target" + strings.TrimPrefix(arg, "-tt")
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// parseGoEnv returns the KEY=VALUE pairs given with --goenv as a map. A later
+// pair for the same key wins.
+func parseGoEnv(pairs []string) (map[string]string, error) {
+	goEnv := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--goenv %q must be KEY=VALUE", pair)
+		}
+		goEnv[key] = value
+	}
+	return goEnv, nil
+}
+
+// ExitCode returns the status stave exits with after ExecuteWithFang returns
+// err: 0 for nil, the Code of a *stave.Error, and 1 for anything else.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var stErr *stave.Error
+	if errors.As(err, &stErr) {
+		return stErr.Code
+	}
+	return 1
+}
+
 // ExecuteWithFang runs the root Cobra command with Fang-specific options.
 // It accepts a context and a root Cobra command as input parameters.
 // Returns an error if the command execution fails.
@@ -2,9 +2,15 @@ package stave
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -71,6 +77,309 @@ func TestParse(t *testing.T) {
 	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
 }
 
+func TestStrictSignaturesFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.StrictSignatures)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--strict-signatures", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestStrictFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Strict)
+		assert.False(t, params.StrictSignatures)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--strict", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestPrintExpandedFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.PrintExpanded)
+		assert.Equal(t, []string{"@ci-tasks.txt"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--print-expanded", "@ci-tasks.txt"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestCleanupGraceFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, 30*time.Second, params.CleanupGrace)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--cleanup-grace", "30s", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestPerTargetTimeoutFlag(t *testing.T) {
+	ctx := t.Context()
+	for _, args := range [][]string{
+		{"-tt", "30s", "build"},
+		{"-tt=30s", "build"},
+		{"--timeout-per-target", "30s", "build"},
+	} {
+		runFunc := func(params stave.RunParams) error {
+			assert.Equal(t, 30*time.Second, params.PerTargetTimeout, "args: %q", args)
+			assert.Zero(t, params.Timeout, "args: %q", args)
+			assert.Equal(t, []string{"build"}, params.Args, "args: %q", args)
+			return nil
+		}
+		rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+		rootCmd.SetArgs(NormalizeArgs(args))
+		require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+	}
+}
+
+func TestNormalizeArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"-t", "1m", "--timeout-per-target", "10s", "build", "--timeout-per-target=5s", "--", "-tt"},
+		NormalizeArgs([]string{"-t", "1m", "-tt", "10s", "build", "-tt=5s", "--", "-tt"}),
+	)
+}
+
+func TestConfigOriginFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Config)
+		assert.True(t, params.ConfigOrigin)
+		assert.Equal(t, []string{"show"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--config", "--origin", "show"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestMainfileNameFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, "stave_main_gen.go", params.MainfileName)
+		assert.True(t, params.Keep)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--keep", "--mainfile-name", "stave_main_gen.go", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestOutputsKeepFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, 3, params.OutputsKeep)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--outputs-keep", "3", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestDumpTargetsFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.DumpTargets)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--dump-targets"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestListSectionFlags(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.List)
+		assert.True(t, params.ListLocal)
+		assert.True(t, params.ListNamespaces)
+		assert.False(t, params.ListImports)
+		assert.Equal(t, "deploy", params.ListImport)
+		assert.Equal(t, []string{"build"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-l", "--local", "--namespaces", "--import", "deploy", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestNoTimingsFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.List)
+		assert.True(t, params.NoTimings)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-l", "--no-timings"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestListAllFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.List)
+		assert.True(t, params.ListAll)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-l", "--all"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestEnsureCompiledFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.EnsureCompiled)
+		assert.Equal(t, "linux", params.GOOS)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--ensure-compiled", "--goos", "linux"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestEnvFileFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, []string{".env.ci", "secrets.env"}, params.EnvFiles)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--env-file", ".env.ci", "--env-file", "secrets.env", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestGoEnvFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, map[string]string{"CC": "zig cc", "CGO_CFLAGS": "-O2,-g"}, params.GoEnv)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--goenv", "CC=clang", "--goenv", "CGO_CFLAGS=-O2,-g", "--goenv", "CC=zig cc", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+
+	rootCmd = NewRootCmd(ctx, withRunFunc(func(stave.RunParams) error { return nil }))
+	rootCmd.SetArgs([]string{"--goenv", "CC", "build"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	err := ExecuteWithFang(ctx, rootCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be KEY=VALUE")
+	assert.Equal(t, 2, ExitCode(err))
+}
+
+func TestLogFormatFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, "json", params.LogFormat)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--log-format", "json", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestErrorFormatFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, "json", params.ErrorFormat)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--error-format", "json", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestYesFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Yes)
+		assert.Equal(t, []string{"clean"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"clean", "--yes"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestAutoModFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.AutoMod)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--auto-mod", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestQuietFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Quiet)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-q", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestParallelismFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, 4, params.Parallelism)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-p", "4", "test"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestConfigFileFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.Equal(t, "ci/stave.yaml", params.ConfigFile)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--config-file", "ci/stave.yaml", "build"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestDryRunDepsFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.DryRunDeps)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--dryrun-deps", "deploy"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
+func TestDashDashKept(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Verbose)
+		assert.Equal(t, []string{"deploy", "--", "-v"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"-v", "deploy", "--", "-v"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
 func TestClean(t *testing.T) {
 	ctx := t.Context()
 
@@ -166,6 +475,19 @@ func TestHooksFlagWithVerbose(t *testing.T) {
 	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
 }
 
+func TestHooksRecurseSubmodulesFlag(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(params stave.RunParams) error {
+		assert.True(t, params.Hooks)
+		assert.True(t, params.HooksSubmodules)
+		assert.Equal(t, []string{"install"}, params.Args)
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--hooks", "install", "--recurse-submodules"})
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+}
+
 func TestConfigFlag(t *testing.T) {
 	ctx := t.Context()
 	runFunc := func(params stave.RunParams) error {
@@ -189,3 +511,67 @@ func TestConfigFlagNoSubcommand(t *testing.T) {
 	rootCmd.SetArgs([]string{"--config"})
 	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
 }
+
+func TestVersionJSON(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(stave.RunParams) error {
+		t.Fatal("run should not be called with --version")
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--version", "--json"})
+	stdout := &bytes.Buffer{}
+	rootCmd.SetOut(stdout)
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &got), stdout.String())
+	for _, key := range []string{"version", "commit", "build_date", "go_version", "dirty", "module", "vcs", "deps"} {
+		assert.Contains(t, got, key)
+	}
+	assert.Equal(t, runtime.Version(), got["go_version"])
+
+	rootCmd = NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--version"})
+	stdout.Reset()
+	rootCmd.SetOut(stdout)
+	require.NoError(t, ExecuteWithFang(ctx, rootCmd))
+	assert.True(t, strings.HasPrefix(stdout.String(), "stave version "), stdout.String())
+}
+
+func TestJSONNeedsVersion(t *testing.T) {
+	ctx := t.Context()
+	rootCmd := NewRootCmd(ctx, withRunFunc(func(stave.RunParams) error { return nil }))
+	rootCmd.SetArgs([]string{"--json", "build"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	err := ExecuteWithFang(ctx, rootCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--json only applies when running with --version")
+	assert.Equal(t, 2, ExitCode(err))
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, 1, ExitCode(errors.New("boom")))
+	assert.Equal(t, 2, ExitCode(&stave.Error{Kind: stave.KindUsage, Code: 2, Err: errors.New("bad flag")}))
+	assert.Equal(t, 7, ExitCode(fmt.Errorf("wrapped: %w", &stave.Error{Kind: stave.KindTarget, Code: 7, Err: errors.New("exit status 7")})))
+}
+
+func TestFlagErrorIsUsageError(t *testing.T) {
+	ctx := t.Context()
+	runFunc := func(stave.RunParams) error {
+		t.Fatal("run should not be called with a bad flag")
+		return nil
+	}
+	rootCmd := NewRootCmd(ctx, withRunFunc(runFunc))
+	rootCmd.SetArgs([]string{"--no-such-flag"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	err := ExecuteWithFang(ctx, rootCmd)
+
+	var stErr *stave.Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, stave.KindUsage, stErr.Kind)
+	assert.Equal(t, 2, ExitCode(err))
+}
@@ -1,8 +1,12 @@
 package version
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"time"
 
@@ -28,6 +32,9 @@ var Commit = "" //nolint:gochecknoglobals // Populated by goreleaser ldflags.
 //	-ldflags "-X github.com/yaklabco/stave/cmd/stave.BuildDate=<RFC3339>"
 var BuildDate = "" //nolint:gochecknoglobals // Populated by goreleaser ldflags.
 
+// dirtySuffix marks a version or commit built from a modified working tree.
+const dirtySuffix = "-dirty"
+
 // EffectiveVersion returns the best-effort version string for the binary.
 // Precedence:
 //  1. If Version was set via -ldflags and is not "dev"/empty, use it as-is.
@@ -58,7 +65,7 @@ func EffectiveVersion(_ context.Context) string {
 				rev = s.Value
 			case "vcs.modified":
 				if s.Value == "true" {
-					dirty = "-dirty"
+					dirty = dirtySuffix
 				}
 			}
 		}
@@ -89,6 +96,19 @@ func EffectiveCommit(_ context.Context) string {
 	return ""
 }
 
+// Dirty reports whether the binary was built from a git working tree with
+// uncommitted changes, according to Go build info.
+func Dirty() bool {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi != nil {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.modified" {
+				return s.Value == "true"
+			}
+		}
+	}
+	return false
+}
+
 // EffectiveBuildTime returns the build time as RFC3339 string when available.
 // Precedence:
 // 1) BuildDate from ldflags if provided.
@@ -141,9 +161,13 @@ func OverallVersionString(ctx context.Context) string {
 	parts = append(parts, EffectiveVersion(ctx))
 
 	// Commit
-	if c := EffectiveCommit(ctx); c != "" {
+	c := EffectiveCommit(ctx)
+	if c != "" {
 		parts = append(parts, c)
 	}
+	if Dirty() && !strings.HasSuffix(cmp.Or(c, EffectiveVersion(ctx)), dirtySuffix) {
+		parts = append(parts, "dirty")
+	}
 
 	// Build time
 	if t, ok := EffectiveBuildTimeParsed(); ok {
@@ -173,9 +197,13 @@ func OverallVersionStringColorized(ctx context.Context) string {
 	parts = append(parts, versionStyle.Render(EffectiveVersion(ctx)))
 
 	// Commit
-	if c := EffectiveCommit(ctx); c != "" {
+	c := EffectiveCommit(ctx)
+	if c != "" {
 		parts = append(parts, commitStyle.Render(c))
 	}
+	if Dirty() && !strings.HasSuffix(cmp.Or(c, EffectiveVersion(ctx)), dirtySuffix) {
+		parts = append(parts, commitStyle.Render("dirty"))
+	}
 
 	// Build time
 	if t, ok := EffectiveBuildTimeParsed(); ok {
@@ -188,3 +216,74 @@ func OverallVersionStringColorized(ctx context.Context) string {
 	// Use a subdued separator consistent with help body text
 	return strings.Join(parts, sepStyle.Render("-"))
 }
+
+// keyModules are the dependencies whose versions Info reports, as those that
+// most shape how stave parses, compiles and runs stavefiles.
+var keyModules = []string{ //nolint:gochecknoglobals // Intended as a constant.
+	"github.com/charmbracelet/fang",
+	"github.com/spf13/cobra",
+	"github.com/spf13/viper",
+	"golang.org/x/tools",
+}
+
+// Info is the version and build information of the binary, as
+// `stave --version --json` prints it for bug reports and CI checks.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Dirty     bool   `json:"dirty"`
+	// Module is the main module's path and version, from Go build info.
+	Module string `json:"module"`
+	// ModuleVersion is "(devel)" for binaries built from source.
+	ModuleVersion string `json:"module_version"`
+	// VCS holds the vcs.* build settings, like vcs.revision and vcs.time,
+	// without their prefix.
+	VCS map[string]string `json:"vcs"`
+	// Deps maps each of keyModules the binary was built with to its version.
+	Deps map[string]string `json:"deps"`
+}
+
+// BuildInfo returns the version and build information of the binary.
+func BuildInfo(ctx context.Context) Info {
+	info := Info{
+		Version:   EffectiveVersion(ctx),
+		Commit:    EffectiveCommit(ctx),
+		BuildDate: EffectiveBuildTime(),
+		GoVersion: runtime.Version(),
+		Dirty:     Dirty(),
+		VCS:       map[string]string{},
+		Deps:      map[string]string{},
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok || bi == nil {
+		return info
+	}
+	info.Module = bi.Main.Path
+	info.ModuleVersion = bi.Main.Version
+	for _, s := range bi.Settings {
+		if key, ok := strings.CutPrefix(s.Key, "vcs."); ok {
+			info.VCS[key] = s.Value
+		}
+	}
+	for _, dep := range bi.Deps {
+		if !slices.Contains(keyModules, dep.Path) {
+			continue
+		}
+		info.Deps[dep.Path] = dep.Version
+		if dep.Replace != nil {
+			info.Deps[dep.Path] = dep.Replace.Version
+		}
+	}
+	return info
+}
+
+// JSON returns BuildInfo as indented JSON, with a trailing newline.
+func JSON(ctx context.Context) (string, error) {
+	data, err := json.MarshalIndent(BuildInfo(ctx), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
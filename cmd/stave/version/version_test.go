@@ -0,0 +1,33 @@
+package version
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	out, err := JSON(t.Context())
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &got))
+	for _, key := range []string{"version", "commit", "build_date", "go_version", "dirty", "module", "module_version", "vcs", "deps"} {
+		assert.Contains(t, got, key)
+	}
+	assert.Equal(t, EffectiveVersion(t.Context()), got["version"])
+	assert.NotEmpty(t, got["go_version"])
+}
+
+func TestJSONUsesLdflags(t *testing.T) {
+	oldVersion, oldCommit, oldDate := Version, Commit, BuildDate
+	t.Cleanup(func() { Version, Commit, BuildDate = oldVersion, oldCommit, oldDate })
+	Version, Commit, BuildDate = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+
+	info := BuildInfo(t.Context())
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2026-01-02T03:04:05Z", info.BuildDate)
+}
@@ -1,14 +1,20 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 	"github.com/yaklabco/stave/pkg/env"
 )
@@ -37,17 +43,209 @@ type Config struct {
 	// IgnoreDefault ignores the default target in stavefiles.
 	IgnoreDefault bool `mapstructure:"ignore_default"`
 
+	// OnNoTarget is what running stave without a target does: OnNoTargetDefault
+	// runs the default target, OnNoTargetList lists the targets and
+	// OnNoTargetHelp shows the usage, whether or not there is a default target.
+	OnNoTarget string `mapstructure:"on_no_target"`
+
+	// FuzzyTargets lets targets be run by a unique prefix or abbreviation of
+	// their name, like "tg" for "TestGo".
+	FuzzyTargets bool `mapstructure:"fuzzy_targets"`
+
 	// EnableColor enables colored output in terminal.
 	EnableColor bool `mapstructure:"enable_color"`
 
 	// TargetColor is the ANSI color name for target names.
 	TargetColor string `mapstructure:"target_color"`
 
+	// MinFreeDisk is the least free space (e.g. "500MB") the cache and build
+	// temp directories must have before stave compiles a stavefile. "0"
+	// disables the check.
+	MinFreeDisk string `mapstructure:"min_free_disk"`
+
+	// EnvFiles lists dotenv files whose variables are added to the
+	// environment of stavefile runs, relative to the project directory. Later
+	// files override earlier ones, and the real environment overrides them
+	// all. Missing files are skipped, unless the entry is prefixed with "!".
+	EnvFiles []string `mapstructure:"env_files"`
+
+	// AutoMod lets stave run "go mod init" and "go mod tidy" in the stavefiles
+	// directory when it is not in a Go module and fails to compile because of
+	// that.
+	AutoMod bool `mapstructure:"auto_mod"`
+
+	// MainfileName is a fixed file name for the mainfile stave generates in
+	// the stavefiles directory, instead of one derived from the stavefiles'
+	// hash, for directories where that name clashes or trips up other tools.
+	MainfileName string `mapstructure:"mainfile_name"`
+
+	// Verify is what stave checks in the stavefiles before compiling them:
+	// VerifyOff nothing more than the compile does, VerifyVet runs go vet and
+	// fails on its findings, and VerifyWarn runs go vet and only warns.
+	Verify string `mapstructure:"verify"`
+
+	// Ldflags, Gcflags and Asmflags are passed to go build as -ldflags,
+	// -gcflags and -asmflags when compiling the stavefile binary, unless the
+	// command line flags of the same names are given.
+	Ldflags  GoFlags `mapstructure:"ldflags"`
+	Gcflags  GoFlags `mapstructure:"gcflags"`
+	Asmflags GoFlags `mapstructure:"asmflags"`
+
 	// Hooks defines Git hooks and the Stave targets they should run.
 	Hooks HooksConfig `mapstructure:"hooks"`
 
+	// BinaryCache configures a remote cache that compiled stavefiles are
+	// fetched from before compiling, and optionally uploaded to after.
+	BinaryCache BinaryCacheConfig `mapstructure:"binary_cache"`
+
+	// ImportAliases gives aliases, by import path, to the stave:import
+	// packages whose import comment doesn't set one, so that generated
+	// stavefiles needn't be edited to namespace clashing targets. Keys are
+	// lowercased, and matched case-insensitively.
+	ImportAliases map[string]string `mapstructure:"import_aliases"`
+
+	// ExternalTargets are targets backed by commands rather than Go functions,
+	// by name, for projects that keep some of their tasks in other languages.
+	// Names are lowercased, and matched case-insensitively.
+	ExternalTargets map[string]ExternalTarget `mapstructure:"external_targets"`
+
 	// configFile is the path to the config file that was loaded (if any).
 	configFile string
+
+	// userConfigFile is the path to the user config file that was loaded (if
+	// any).
+	userConfigFile string
+
+	// origins records, by key, where the values that weren't defaults came
+	// from. Nested keys look like "binary_cache.url".
+	origins map[string]Origin
+}
+
+// Origin is the source a config value came from.
+type Origin string
+
+// Values of Origin, from lowest to highest precedence.
+const (
+	OriginDefault Origin = "default"
+	OriginUser    Origin = "user"
+	OriginProject Origin = "project"
+	OriginEnv     Origin = "env"
+	OriginFlag    Origin = "flag"
+)
+
+// UserConfigEnv is the environment variable that sets the path of the user
+// config file, instead of config.yaml in the user config directory.
+const UserConfigEnv = "STAVE_USER_CONFIG"
+
+// UserConfigPath returns the path of the user config file: the value of
+// STAVE_USER_CONFIG if it is set, or config.yaml in the user config directory.
+func UserConfigPath() string {
+	if path := os.Getenv(UserConfigEnv); path != "" {
+		return path
+	}
+	return ResolveXDGPaths().ConfigFilePath()
+}
+
+// Values of Config.OnNoTarget.
+const (
+	OnNoTargetDefault = "default"
+	OnNoTargetList    = "list"
+	OnNoTargetHelp    = "help"
+)
+
+// Values of Config.Verify.
+const (
+	VerifyOff  = "off"
+	VerifyVet  = "vet"
+	VerifyWarn = "warn"
+)
+
+// BinaryCacheTypeHTTP is the BinaryCacheConfig type for a cache served over
+// plain HTTP GET and PUT requests.
+const BinaryCacheTypeHTTP = "http"
+
+// BinaryCacheConfig configures the remote cache for compiled stavefiles.
+type BinaryCacheConfig struct {
+	// Type is the kind of cache. Only BinaryCacheTypeHTTP is supported, and it
+	// is assumed if Type is empty.
+	Type string `mapstructure:"type"`
+
+	// URL is the base URL that binaries are stored under. The cache is
+	// disabled if it is empty.
+	URL string `mapstructure:"url"`
+
+	// Write enables uploading binaries compiled locally after a cache miss.
+	Write bool `mapstructure:"write"`
+}
+
+// ExternalTarget is a target that runs a command instead of a Go function.
+type ExternalTarget struct {
+	// Command is the shell command the target runs, with the arguments given
+	// after the target's name appended.
+	Command string `mapstructure:"command"`
+
+	// Description is the target's synopsis in `stave -l`.
+	Description string `mapstructure:"description,omitempty"`
+}
+
+// ExternalTarget returns the external target named name, matched
+// case-insensitively, and whether there is one.
+func (c *Config) ExternalTarget(name string) (ExternalTarget, bool) {
+	target, ok := c.ExternalTargets[strings.ToLower(name)]
+	return target, ok
+}
+
+// Enabled reports whether a remote cache is configured.
+func (b BinaryCacheConfig) Enabled() bool {
+	return b.URL != ""
+}
+
+// MinFreeDiskBytes returns MinFreeDisk in bytes, or 0 if it is unset or
+// invalid (Validate reports invalid values).
+func (c *Config) MinFreeDiskBytes() uint64 {
+	size, err := ParseByteSize(c.MinFreeDisk)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// BuildHash returns a hash of the settings that change what the stavefiles
+// compile to, or "" if none are set, for the name of the compiled binary. Other
+// settings, like verbose or hooks, are left out, so that changing them doesn't
+// make stave compile the stavefiles again. Of the current settings, only
+// import_aliases change the generated mainfile.
+func (c *Config) BuildHash() string {
+	if len(c.ImportAliases) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, importPath := range slices.Sorted(maps.Keys(c.ImportAliases)) {
+		fmt.Fprintf(hash, "import_aliases.%s=%s\n", importPath, c.ImportAliases[importPath])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Origin returns where the value of the config key came from, such as
+// "verbose" or "binary_cache.url".
+func (c *Config) Origin(key string) Origin {
+	if origin, ok := c.origins[key]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// UserConfigFile returns the path to the user configuration file that was
+// loaded, or an empty string if there was none.
+func (c *Config) UserConfigFile() string {
+	return c.userConfigFile
+}
+
+func (c *Config) setOrigin(key string, origin Origin) {
+	if c.origins == nil {
+		c.origins = make(map[string]Origin)
+	}
+	c.origins[key] = origin
 }
 
 // ConfigFile returns the path to the configuration file that was loaded,
@@ -129,6 +327,10 @@ type LoadOptions struct {
 	// If empty, the current working directory is used.
 	ProjectDir string
 
+	// ConfigPath is the project config file to load. If set, it is loaded
+	// instead of searching ProjectDir for stave.yaml, and it must exist.
+	ConfigPath string
+
 	// Stderr is where warnings are written.
 	// If nil, os.Stderr is used.
 	Stderr io.Writer
@@ -141,24 +343,34 @@ type LoadOptions struct {
 
 	// SkipEnv skips reading environment variables.
 	SkipEnv bool
+
+	// Flags are the values set by command-line flags, by config key, like
+	// "verbose". They override every other source, and must have the type of
+	// the config field.
+	Flags map[string]any
 }
 
 // Load reads configuration from all sources and returns a Config struct.
 // Configuration is loaded in the following order (later sources override earlier):
 //  1. Defaults
-//  2. User config file (~/.config/stave/config.yaml)
+//  2. User config file (~/.config/stave/config.yaml, or STAVE_USER_CONFIG)
 //  3. Project config file (./stave.yaml)
 //  4. Environment variables (STAVEFILE_* and STAVEFILE_*)
 //
+// The config files are merged key by key: maps are merged, while other values,
+// including lists, are replaced. Hooks are only read from the project config,
+// so that a user config can't make every repository run commands on commit.
+//
 // If opts is nil, default options are used.
 func Load(opts *LoadOptions) (*Config, error) {
 	opts = normalizeLoadOptions(opts)
 
-	viperInstance := viper.New()
+	viperInstance := newViper()
 	setDefaults(viperInstance)
 	viperInstance.SetConfigType("yaml")
 
-	configFileUsed, err := loadConfigFiles(viperInstance, opts)
+	origins := make(map[string]Origin)
+	userFileUsed, configFileUsed, err := loadConfigFiles(viperInstance, opts, origins)
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +379,15 @@ func Load(opts *LoadOptions) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.userConfigFile = userFileUsed
+	for key, origin := range origins {
+		if _, fromEnv := cfg.origins[key]; !fromEnv {
+			cfg.setOrigin(key, origin)
+		}
+	}
+	if err := cfg.applyFlags(opts.Flags); err != nil {
+		return nil, err
+	}
 
 	return validateAndFinalize(cfg, opts)
 }
@@ -182,52 +403,123 @@ func normalizeLoadOptions(opts *LoadOptions) *LoadOptions {
 	return opts
 }
 
-// loadConfigFiles loads user and project config files into viper.
-// Returns the path to the most recently loaded config file.
-func loadConfigFiles(viperInstance *viper.Viper, opts *LoadOptions) (string, error) {
-	var configFileUsed string
+// loadConfigFiles loads user and project config files into viper, recording
+// in origins which of them each key was last set by.
+// Returns the path to the user config file, and to the most recently loaded
+// config file.
+func loadConfigFiles(viperInstance *viper.Viper, opts *LoadOptions, origins map[string]Origin) (string, string, error) {
+	var userFileUsed, configFileUsed string
 
 	if !opts.SkipUserConfig {
-		usedFile, err := loadUserConfig(viperInstance)
+		usedFile, err := loadUserConfig(viperInstance, opts.Stderr, origins)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
+		userFileUsed = usedFile
 		if usedFile != "" {
 			configFileUsed = usedFile
 		}
 	}
 
 	if !opts.SkipProjectConfig {
-		usedFile, err := loadProjectConfig(viperInstance, opts.ProjectDir)
+		var usedFile string
+		var err error
+		if opts.ConfigPath != "" {
+			usedFile, err = loadConfigFile(viperInstance, opts.ConfigPath, origins)
+		} else {
+			usedFile, err = loadProjectConfig(viperInstance, opts.ProjectDir, origins)
+		}
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 		if usedFile != "" {
 			configFileUsed = usedFile
 		}
 	}
 
-	return configFileUsed, nil
+	return userFileUsed, configFileUsed, nil
 }
 
-// loadUserConfig loads user config from XDG path (~/.config/stave/config.yaml).
-func loadUserConfig(viperInstance *viper.Viper) (string, error) {
-	paths := ResolveXDGPaths()
-	viperInstance.SetConfigName(ConfigFileName)
-	viperInstance.AddConfigPath(paths.ConfigDir())
+// loadUserConfig loads user config from XDG path (~/.config/stave/config.yaml),
+// or from the file STAVE_USER_CONFIG names, which must exist, and merges it
+// with existing config. Any hooks in it are ignored, with a warning.
+func loadUserConfig(viperInstance *viper.Viper, stderr io.Writer, origins map[string]Origin) (string, error) {
+	userViper := newViper()
+	userViper.SetConfigType("yaml")
+	if path := os.Getenv(UserConfigEnv); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("user config file %s (from %s): %w", path, UserConfigEnv, err)
+		}
+		userViper.SetConfigFile(path)
+	} else {
+		userViper.SetConfigName(ConfigFileName)
+		userViper.AddConfigPath(ResolveXDGPaths().ConfigDir())
+	}
 
-	if err := viperInstance.ReadInConfig(); err != nil {
+	if err := userViper.ReadInConfig(); err != nil {
 		var configFileNotFoundError viper.ConfigFileNotFoundError
 		if !errors.As(err, &configFileNotFoundError) {
 			return "", fmt.Errorf("failed to read user config file: %w", err)
 		}
 		return "", nil
 	}
-	return viperInstance.ConfigFileUsed(), nil
+
+	settings := userViper.AllSettings()
+	if _, ok := settings["hooks"]; ok {
+		delete(settings, "hooks")
+		ValidationResults{Warnings: []ValidationWarning{{
+			Field:   "hooks",
+			Message: fmt.Sprintf("ignored in user config %s; hooks can only be set in the project's stave.yaml", userViper.ConfigFileUsed()),
+		}}}.WriteWarnings(stderr)
+	}
+	if err := mergeSettings(viperInstance, settings, OriginUser, origins); err != nil {
+		return "", fmt.Errorf("failed to merge user config file: %w", err)
+	}
+	return userViper.ConfigFileUsed(), nil
+}
+
+// mergeSettings merges settings, read from a config file, into viperInstance,
+// and records origin for each of their keys.
+func mergeSettings(viperInstance *viper.Viper, settings map[string]any, origin Origin, origins map[string]Origin) error {
+	if err := viperInstance.MergeConfigMap(settings); err != nil {
+		return err //nolint:wrapcheck // callers add the file to the error
+	}
+	recordOrigins(settings, "", origin, origins)
+	return nil
+}
+
+// recordOrigins records origin for each leaf key in settings, prefixed with
+// prefix. Hooks are recorded as a whole.
+func recordOrigins(settings map[string]any, prefix string, origin Origin, origins map[string]Origin) {
+	for key, value := range settings {
+		if nested, ok := value.(map[string]any); ok && prefix+key != "hooks" {
+			recordOrigins(nested, prefix+key+".", origin, origins)
+			continue
+		}
+		origins[prefix+key] = origin
+	}
+}
+
+// newViper returns a viper instance for reading config. Its key delimiter is
+// "::" rather than ".", so that keys can contain dots, like the import paths
+// of import_aliases.
+func newViper() *viper.Viper {
+	return viper.NewWithOptions(viper.KeyDelimiter("::"))
+}
+
+// readSettings reads the config file at path.
+func readSettings(path string) (map[string]any, error) {
+	fileViper := newViper()
+	fileViper.SetConfigType("yaml")
+	fileViper.SetConfigFile(path)
+	if err := fileViper.ReadInConfig(); err != nil {
+		return nil, err //nolint:wrapcheck // callers add the file to the error
+	}
+	return fileViper.AllSettings(), nil
 }
 
 // loadProjectConfig loads project config (./stave.yaml) and merges with existing config.
-func loadProjectConfig(viperInstance *viper.Viper, projectDir string) (string, error) {
+func loadProjectConfig(viperInstance *viper.Viper, projectDir string, origins map[string]Origin) (string, error) {
 	if projectDir == "" {
 		var err error
 		projectDir, err = os.Getwd()
@@ -242,13 +534,33 @@ func loadProjectConfig(viperInstance *viper.Viper, projectDir string) (string, e
 		return "", nil //nolint:nilerr // stat error means file missing, not a failure
 	}
 
-	viperInstance.SetConfigFile(projectConfigPath)
-	if err := viperInstance.MergeInConfig(); err != nil {
+	settings, err := readSettings(projectConfigPath)
+	if err == nil {
+		err = mergeSettings(viperInstance, settings, OriginProject, origins)
+	}
+	if err != nil {
 		return "", fmt.Errorf("failed to read project config file: %w", err)
 	}
 	return projectConfigPath, nil
 }
 
+// loadConfigFile loads the project config from an explicit path and merges
+// it with existing config. Unlike stave.yaml, the file must exist.
+func loadConfigFile(viperInstance *viper.Viper, path string, origins map[string]Origin) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	settings, err := readSettings(path)
+	if err == nil {
+		err = mergeSettings(viperInstance, settings, OriginProject, origins)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return path, nil
+}
+
 // unmarshalConfig unmarshals viper config into a Config struct and applies env overrides.
 func unmarshalConfig(
 	viperInstance *viper.Viper,
@@ -256,9 +568,16 @@ func unmarshalConfig(
 	configFileUsed string,
 ) (*Config, error) {
 	var cfg Config
-	if err := viperInstance.Unmarshal(&cfg); err != nil {
+	var metadata mapstructure.Metadata
+	if err := viperInstance.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.Metadata = &metadata
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(goFlagsHook, dc.DecodeHook)
+	}); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if err := unknownKeysError(metadata.Unused); err != nil {
+		return nil, err
+	}
 
 	if !opts.SkipEnv {
 		applyEnvironmentOverrides(&cfg)
@@ -293,28 +612,54 @@ func validateAndFinalize(cfg *Config, opts *LoadOptions) (*Config, error) {
 // applyEnvironmentOverrides applies environment variable overrides to the config.
 // Environment variables take precedence over config file values.
 func applyEnvironmentOverrides(cfg *Config) {
-	applyStringEnv("STAVEFILE_CACHE", &cfg.CacheDir)
-	applyStringEnv("STAVEFILE_GOCMD", &cfg.GoCmd)
-	applyStringEnv("STAVEFILE_TARGET_COLOR", &cfg.TargetColor)
+	cfg.applyStringEnv("STAVEFILE_CACHE", "cache_dir", &cfg.CacheDir)
+	cfg.applyStringEnv("STAVEFILE_GOCMD", "go_cmd", &cfg.GoCmd)
+	cfg.applyStringEnv("STAVEFILE_TARGET_COLOR", "target_color", &cfg.TargetColor)
+	cfg.applyStringEnv("STAVEFILE_MIN_FREE_DISK", "min_free_disk", &cfg.MinFreeDisk)
+	cfg.applyStringEnv("STAVEFILE_ON_NO_TARGET", "on_no_target", &cfg.OnNoTarget)
+	cfg.applyStringEnv("STAVEFILE_MAINFILE_NAME", "mainfile_name", &cfg.MainfileName)
+	cfg.applyStringEnv("STAVEFILE_VERIFY", "verify", &cfg.Verify)
+	cfg.applyStringEnv("STAVEFILE_LDFLAGS", "ldflags", (*string)(&cfg.Ldflags))
+	cfg.applyStringEnv("STAVEFILE_GCFLAGS", "gcflags", (*string)(&cfg.Gcflags))
+	cfg.applyStringEnv("STAVEFILE_ASMFLAGS", "asmflags", (*string)(&cfg.Asmflags))
+
+	cfg.applyBoolEnv("STAVEFILE_VERBOSE", "verbose", &cfg.Verbose)
+	cfg.applyBoolEnv("STAVEFILE_MULTILINE", "multiline", &cfg.Multiline)
+	cfg.applyBoolEnv("STAVEFILE_DEBUG", "debug", &cfg.Debug)
+	cfg.applyBoolEnv("STAVEFILE_HASHFAST", "hash_fast", &cfg.HashFast)
+	cfg.applyBoolEnv("STAVEFILE_IGNOREDEFAULT", "ignore_default", &cfg.IgnoreDefault)
+	cfg.applyBoolEnv("STAVEFILE_FUZZY_TARGETS", "fuzzy_targets", &cfg.FuzzyTargets)
+	cfg.applyBoolEnv("STAVEFILE_ENABLE_COLOR", "enable_color", &cfg.EnableColor)
+}
 
-	applyBoolEnv("STAVEFILE_VERBOSE", &cfg.Verbose)
-	applyBoolEnv("STAVEFILE_MULTILINE", &cfg.Multiline)
-	applyBoolEnv("STAVEFILE_DEBUG", &cfg.Debug)
-	applyBoolEnv("STAVEFILE_HASHFAST", &cfg.HashFast)
-	applyBoolEnv("STAVEFILE_IGNOREDEFAULT", &cfg.IgnoreDefault)
-	applyBoolEnv("STAVEFILE_ENABLE_COLOR", &cfg.EnableColor)
+// applyFlags sets the config values of flags, keyed by config key, and
+// records them as set by flags.
+func (c *Config) applyFlags(flags map[string]any) error {
+	fields := reflect.ValueOf(c).Elem()
+	for key, value := range flags {
+		field, ok := fieldByKey(fields, key)
+		if !ok || reflect.TypeOf(value) != field.Type() {
+			return fmt.Errorf("invalid flag value %v for config key %q", value, key)
+		}
+		field.Set(reflect.ValueOf(value))
+		c.setOrigin(key, OriginFlag)
+	}
+	return nil
 }
 
-// applyStringEnv applies an environment variable value to a string pointer if set.
-func applyStringEnv(envVar string, target *string) {
+// applyStringEnv applies an environment variable value to a string pointer
+// if set, recording the config key as set from the environment.
+func (c *Config) applyStringEnv(envVar, key string, target *string) {
 	if v := os.Getenv(envVar); v != "" {
 		*target = v
+		c.setOrigin(key, OriginEnv)
 	}
 }
 
-// applyBoolEnv applies an environment variable value to a bool pointer if set.
+// applyBoolEnv applies an environment variable value to a bool pointer if set,
+// recording the config key as set from the environment.
 // Unset, empty, or invalid values leave the config value unchanged.
-func applyBoolEnv(envVar string, target *bool) {
+func (c *Config) applyBoolEnv(envVar, key string, target *bool) {
 	v, ok := os.LookupEnv(envVar)
 	if !ok || v == "" {
 		return
@@ -324,6 +669,7 @@ func applyBoolEnv(envVar string, target *bool) {
 		return
 	}
 	*target = b
+	c.setOrigin(key, OriginEnv)
 }
 
 // DefaultConfig returns a Config with all default values.
@@ -335,8 +681,13 @@ func DefaultConfig() *Config {
 		Debug:         DefaultDebug,
 		HashFast:      DefaultHashFast,
 		IgnoreDefault: DefaultIgnoreDefault,
+		OnNoTarget:    DefaultOnNoTarget,
+		FuzzyTargets:  DefaultFuzzyTargets,
 		EnableColor:   DefaultEnableColor,
 		TargetColor:   DefaultTargetColor,
+		MinFreeDisk:   DefaultMinFreeDisk,
+		AutoMod:       DefaultAutoMod,
+		Verify:        DefaultVerify,
 	}
 }
 
@@ -397,6 +748,15 @@ hash_fast: false
 # Ignore the default target in stavefiles.
 ignore_default: false
 
+# What running stave without a target does: default runs the default
+# target, list lists the targets and help shows the usage, whether or not
+# the stavefile has a default target.
+on_no_target: default
+
+# Run targets by a unique prefix or abbreviation of their name, like
+# "tg" for "TestGo".
+fuzzy_targets: false
+
 # Enable colored output in terminal.
 enable_color: false
 
@@ -405,5 +765,50 @@ enable_color: false
 #          BrightBlack, BrightRed, BrightGreen, BrightYellow,
 #          BrightBlue, BrightMagenta, BrightCyan, BrightWhite
 target_color: Cyan
+
+# Least free space the cache and build temp directories need before
+# stave compiles a stavefile. Set to 0 to disable the check.
+min_free_disk: 100MB
+
+# Dotenv files to load into the environment of stavefile runs, relative
+# to the project directory. Later files override earlier ones; variables
+# already set in the environment win. Prefix a file with ! to require it.
+# env_files: [.env, .env.local]
+
+# Run "go mod init" and "go mod tidy" in the stavefiles directory when it
+# is not in a Go module and can't compile without one.
+auto_mod: false
+
+# Fixed file name for the mainfile stave generates next to the stavefiles,
+# instead of one derived from their hash.
+# mainfile_name: stave_main_gen.go
+
+# Flags for the go build of the stavefile binary, as a string go build
+# splits itself, or a list with one argument per item.
+# ldflags: -s -w -X "main.Version=1.2 beta"
+# gcflags: [all=-N, -l]
+
+# What to check in the stavefiles before compiling them: off, vet to run
+# go vet and fail on its findings, or warn to run go vet and only warn.
+verify: off
+
+# Remote cache for compiled stavefiles, used when hash_fast is on. Binaries
+# are fetched with GET <url>/<name> and, if write is true, uploaded with PUT.
+# binary_cache:
+#   type: http
+#   url: https://cache.example.com/stave/
+#   write: false
+
+# Aliases for stave:import packages whose import comment sets none, by
+# import path, to namespace targets that clash with those of other imports.
+# import_aliases:
+#   github.com/acme/buildtools: acme
+
+# Targets that run a command instead of a Go function, by name. The
+# arguments given after the target's name are appended to the command.
+# external_targets:
+#   deploy:
+#     command: ./scripts/deploy.sh
+#     description: Deploy the site
 `
 }
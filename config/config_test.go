@@ -2,8 +2,11 @@ package config
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -90,6 +93,9 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.TargetColor != DefaultTargetColor {
 		t.Errorf("TargetColor = %q, want %q", cfg.TargetColor, DefaultTargetColor)
 	}
+	if cfg.OnNoTarget != DefaultOnNoTarget {
+		t.Errorf("OnNoTarget = %q, want %q", cfg.OnNoTarget, DefaultOnNoTarget)
+	}
 }
 
 func TestLoad_EnvironmentVariables(t *testing.T) {
@@ -264,3 +270,643 @@ func TestValidationResults_WriteWarnings(t *testing.T) {
 		t.Error("WriteWarnings should produce output")
 	}
 }
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"0", 0},
+		{"1048576", 1 << 20},
+		{"500MB", 500 << 20},
+		{"500mb", 500 << 20},
+		{"2 GiB", 2 << 30},
+		{"1.5K", 1536},
+		{"10B", 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "lots", "-5MB", "5XB"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail", in)
+		}
+	}
+}
+
+func TestConfig_Validate_InvalidMinFreeDisk(t *testing.T) {
+	cfg := &Config{MinFreeDisk: "plenty"}
+
+	result := cfg.Validate()
+	if !result.HasErrors() {
+		t.Error("Expected validation error for invalid min_free_disk")
+	}
+}
+
+func TestConfig_Validate_MainfileName(t *testing.T) {
+	for _, value := range []string{"", "stave_main_gen.go"} {
+		if result := (&Config{MainfileName: value}).Validate(); result.HasErrors() {
+			t.Errorf("Validate(mainfile_name: %q) = %v, want no errors", value, result.Errors)
+		}
+	}
+
+	for _, value := range []string{"sub/main.go", "main.txt"} {
+		result := (&Config{MainfileName: value}).Validate()
+		if !result.HasErrors() {
+			t.Errorf("Expected validation error for mainfile_name %q", value)
+			continue
+		}
+		if result.Errors[0].Field != "mainfile_name" {
+			t.Errorf("Field = %q, want %q", result.Errors[0].Field, "mainfile_name")
+		}
+	}
+}
+
+func TestLoad_EnvFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+env_files: [.env, "!.env.required"]
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{".env", "!.env.required"}
+	if !reflect.DeepEqual(cfg.EnvFiles, want) {
+		t.Errorf("EnvFiles = %q, want %q", cfg.EnvFiles, want)
+	}
+}
+
+func TestConfig_Validate_EmptyEnvFile(t *testing.T) {
+	cfg := &Config{EnvFiles: []string{".env", "!"}}
+
+	result := cfg.Validate()
+	if !result.HasErrors() {
+		t.Fatal("Expected validation error for empty env file")
+	}
+	if result.Errors[0].Field != "env_files[1]" {
+		t.Errorf("Field = %q, want %q", result.Errors[0].Field, "env_files[1]")
+	}
+}
+
+func TestLoad_AutoMod(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte("auto_mod: true\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.AutoMod {
+		t.Error("AutoMod = false, want true")
+	}
+}
+
+func TestLoad_FuzzyTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte("fuzzy_targets: true\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.FuzzyTargets {
+		t.Error("FuzzyTargets = false, want true")
+	}
+
+	t.Setenv("STAVEFILE_FUZZY_TARGETS", "false")
+	cfg, err = Load(&LoadOptions{ProjectDir: tmpDir, SkipUserConfig: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.FuzzyTargets {
+		t.Error("FuzzyTargets = true, want false from STAVEFILE_FUZZY_TARGETS")
+	}
+}
+
+func TestLoad_OnNoTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte("on_no_target: list\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OnNoTarget != OnNoTargetList {
+		t.Errorf("OnNoTarget = %q, want %q", cfg.OnNoTarget, OnNoTargetList)
+	}
+
+	t.Setenv("STAVEFILE_ON_NO_TARGET", OnNoTargetHelp)
+	cfg, err = Load(&LoadOptions{ProjectDir: tmpDir, SkipUserConfig: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OnNoTarget != OnNoTargetHelp {
+		t.Errorf("OnNoTarget = %q, want %q from STAVEFILE_ON_NO_TARGET", cfg.OnNoTarget, OnNoTargetHelp)
+	}
+}
+
+func TestConfig_Validate_OnNoTarget(t *testing.T) {
+	for _, value := range []string{"", OnNoTargetDefault, OnNoTargetList, OnNoTargetHelp} {
+		if result := (&Config{OnNoTarget: value}).Validate(); result.HasErrors() {
+			t.Errorf("Validate(on_no_target: %q) = %v, want no errors", value, result.Errors)
+		}
+	}
+
+	result := (&Config{OnNoTarget: "deploy"}).Validate()
+	if !result.HasErrors() {
+		t.Fatal("Expected validation error for invalid on_no_target")
+	}
+	if result.Errors[0].Field != "on_no_target" {
+		t.Errorf("Field = %q, want %q", result.Errors[0].Field, "on_no_target")
+	}
+}
+
+func TestLoad_Verify(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg, err := Load(&LoadOptions{ProjectDir: tmpDir, SkipUserConfig: true, SkipEnv: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Verify != VerifyOff {
+		t.Errorf("Verify = %q, want %q by default", cfg.Verify, VerifyOff)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte("verify: vet\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	t.Setenv("STAVEFILE_VERIFY", VerifyWarn)
+	cfg, err = Load(&LoadOptions{ProjectDir: tmpDir, SkipUserConfig: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Verify != VerifyWarn {
+		t.Errorf("Verify = %q, want %q from STAVEFILE_VERIFY", cfg.Verify, VerifyWarn)
+	}
+}
+
+func TestConfig_Validate_Verify(t *testing.T) {
+	for _, value := range []string{"", VerifyOff, VerifyVet, VerifyWarn} {
+		if result := (&Config{Verify: value}).Validate(); result.HasErrors() {
+			t.Errorf("Validate(verify: %q) = %v, want no errors", value, result.Errors)
+		}
+	}
+
+	result := (&Config{Verify: "lint"}).Validate()
+	if !result.HasErrors() {
+		t.Fatal("Expected validation error for invalid verify")
+	}
+	if result.Errors[0].Field != "verify" {
+		t.Errorf("Field = %q, want %q", result.Errors[0].Field, "verify")
+	}
+}
+
+func TestLoad_ConfigPath(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, "stave.yaml"), []byte("go_cmd: from-project-dir\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "ci", "stave.yaml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte("verbose: true\ntarget_color: Red\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     projectDir,
+		ConfigPath:     configPath,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Verbose {
+		t.Error("Verbose = false, want true")
+	}
+	if cfg.TargetColor != "Red" {
+		t.Errorf("TargetColor = %q, want %q", cfg.TargetColor, "Red")
+	}
+	// stave.yaml in the project dir is not read
+	if cfg.GoCmd != DefaultGoCmd {
+		t.Errorf("GoCmd = %q, want default %q", cfg.GoCmd, DefaultGoCmd)
+	}
+	if cfg.ConfigFile() != configPath {
+		t.Errorf("ConfigFile() = %q, want %q", cfg.ConfigFile(), configPath)
+	}
+}
+
+func TestLoad_ConfigPathMissing(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	_, err := Load(&LoadOptions{
+		ConfigPath:     configPath,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err == nil {
+		t.Fatal("Load() error = nil, want an error for a missing config file")
+	}
+	if !strings.Contains(err.Error(), configPath) {
+		t.Errorf("Load() error = %v, want it to name %s", err, configPath)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoad_UnknownKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte("hash_fats: true\nverbose: true\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err == nil {
+		t.Fatal("Load() error = nil, want an error for an unknown key")
+	}
+	want := `config: hash_fats: unknown key (did you mean "hash_fast"?)`
+	if err.Error() != want {
+		t.Errorf("Load() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoad_UnknownHookTargetKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+hooks:
+  pre-commit:
+    - target: fmt
+      workdri: tools
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err == nil {
+		t.Fatal("Load() error = nil, want an error for an unknown key")
+	}
+	if !strings.Contains(err.Error(), "workdri: unknown key") || !strings.Contains(err.Error(), `did you mean "workdir"?`) {
+		t.Errorf("Load() error = %v, want it to name workdri and suggest workdir", err)
+	}
+}
+
+func TestNearestKey(t *testing.T) {
+	candidates := []string{"cache_dir", "go_cmd", "hash_fast", "verbose"}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"hash_fats", "hash_fast"},
+		{"verbos", "verbose"},
+		{"Cache_Dir", "cache_dir"},
+		{"completely_different", ""},
+	}
+	for _, tt := range tests {
+		if got := nearestKey(tt.key, candidates); got != tt.want {
+			t.Errorf("nearestKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_BinaryCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+binary_cache:
+  type: http
+  url: https://cache.example.com/stave/
+  write: true
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := BinaryCacheConfig{Type: BinaryCacheTypeHTTP, URL: "https://cache.example.com/stave/", Write: true}
+	if cfg.BinaryCache != want {
+		t.Errorf("BinaryCache = %+v, want %+v", cfg.BinaryCache, want)
+	}
+	if !cfg.BinaryCache.Enabled() {
+		t.Error("BinaryCache.Enabled() = false, want true")
+	}
+}
+
+func TestConfig_Validate_BinaryCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache BinaryCacheConfig
+		field string
+	}{
+		{"unsupported type", BinaryCacheConfig{Type: "s3", URL: "https://cache.example.com/"}, "binary_cache.type"},
+		{"relative url", BinaryCacheConfig{URL: "cache/stave"}, "binary_cache.url"},
+		{"unsupported scheme", BinaryCacheConfig{URL: "ftp://cache.example.com/"}, "binary_cache.url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{BinaryCache: tt.cache}
+			result := cfg.Validate()
+			if !result.HasErrors() {
+				t.Fatal("Expected validation error")
+			}
+			if result.Errors[0].Field != tt.field {
+				t.Errorf("Field = %q, want %q", result.Errors[0].Field, tt.field)
+			}
+		})
+	}
+
+	cfg := &Config{BinaryCache: BinaryCacheConfig{URL: "http://localhost:8080/stave"}}
+	if result := cfg.Validate(); result.HasErrors() {
+		t.Errorf("unexpected validation errors: %s", result.ErrorMessage())
+	}
+}
+
+func TestLoad_UserConfigMerge(t *testing.T) {
+	userDir := t.TempDir()
+	userConfig := filepath.Join(userDir, "user.yaml")
+	userContent := `
+verbose: true
+go_cmd: /user/go
+env_files: [user.env, shared.env]
+binary_cache:
+  url: https://user.example.com/stave/
+  write: true
+`
+	if err := os.WriteFile(userConfig, []byte(userContent), 0o600); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+	t.Setenv(UserConfigEnv, userConfig)
+
+	projectDir := t.TempDir()
+	projectContent := `
+go_cmd: /project/go
+env_files: [project.env]
+binary_cache:
+  url: https://project.example.com/stave/
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "stave.yaml"), []byte(projectContent), 0o600); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+	t.Setenv("STAVEFILE_TARGET_COLOR", "Red")
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir: projectDir,
+		Flags:      map[string]any{"debug": true},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !cfg.Verbose {
+		t.Error("Verbose = false, want true from the user config")
+	}
+	if cfg.GoCmd != "/project/go" {
+		t.Errorf("GoCmd = %q, want %q from the project config", cfg.GoCmd, "/project/go")
+	}
+	// Lists are replaced, not appended to
+	if !reflect.DeepEqual(cfg.EnvFiles, []string{"project.env"}) {
+		t.Errorf("EnvFiles = %v, want [project.env]", cfg.EnvFiles)
+	}
+	// Maps are merged key by key
+	want := BinaryCacheConfig{URL: "https://project.example.com/stave/", Write: true}
+	if cfg.BinaryCache != want {
+		t.Errorf("BinaryCache = %+v, want %+v", cfg.BinaryCache, want)
+	}
+	if cfg.UserConfigFile() != userConfig {
+		t.Errorf("UserConfigFile() = %q, want %q", cfg.UserConfigFile(), userConfig)
+	}
+
+	origins := map[string]Origin{
+		"verbose":            OriginUser,
+		"go_cmd":             OriginProject,
+		"env_files":          OriginProject,
+		"binary_cache.url":   OriginProject,
+		"binary_cache.write": OriginUser,
+		"binary_cache.type":  OriginDefault,
+		"target_color":       OriginEnv,
+		"debug":              OriginFlag,
+		"hash_fast":          OriginDefault,
+	}
+	for key, want := range origins {
+		if got := cfg.Origin(key); got != want {
+			t.Errorf("Origin(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoad_UserConfigHooksIgnored(t *testing.T) {
+	userConfig := filepath.Join(t.TempDir(), "user.yaml")
+	userContent := `
+verbose: true
+hooks:
+  pre-commit:
+    - target: fmt
+`
+	if err := os.WriteFile(userConfig, []byte(userContent), 0o600); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+	t.Setenv(UserConfigEnv, userConfig)
+
+	var stderr bytes.Buffer
+	cfg, err := Load(&LoadOptions{
+		ProjectDir: t.TempDir(),
+		SkipEnv:    true,
+		Stderr:     &stderr,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Hooks) != 0 {
+		t.Errorf("Hooks = %v, want none from the user config", cfg.Hooks)
+	}
+	if !cfg.Verbose {
+		t.Error("Verbose = false, want true from the user config")
+	}
+	if !strings.Contains(stderr.String(), "hooks") || !strings.Contains(stderr.String(), userConfig) {
+		t.Errorf("stderr = %q, want a warning naming hooks and %s", stderr.String(), userConfig)
+	}
+}
+
+func TestLoad_UserConfigEnvMissing(t *testing.T) {
+	userConfig := filepath.Join(t.TempDir(), "missing.yaml")
+	t.Setenv(UserConfigEnv, userConfig)
+
+	_, err := Load(&LoadOptions{ProjectDir: t.TempDir(), SkipEnv: true})
+	if err == nil {
+		t.Fatal("Load() error = nil, want an error for a missing user config file")
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoad_ImportAliases(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+import_aliases:
+  github.com/Acme/build-tools/v2: acme
+  example.com/lint: lint
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// keys keep their dots, and are lowercased
+	want := map[string]string{
+		"github.com/acme/build-tools/v2": "acme",
+		"example.com/lint":               "lint",
+	}
+	if !reflect.DeepEqual(cfg.ImportAliases, want) {
+		t.Errorf("ImportAliases = %v, want %v", cfg.ImportAliases, want)
+	}
+}
+
+func TestConfig_Validate_ImportAliases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ImportAliases = map[string]string{"example.com/tools": "a:b"}
+	result := cfg.Validate()
+	if !result.HasErrors() {
+		t.Fatal("expected an error for an alias with a colon")
+	}
+	if result.Errors[0].Field != "import_aliases.example.com/tools" {
+		t.Errorf("Field = %q, want %q", result.Errors[0].Field, "import_aliases.example.com/tools")
+	}
+}
+
+func TestLoad_ExternalTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+external_targets:
+  Deploy:
+    command: ./deploy.sh --env prod
+    description: Deploy the site
+  lint-py:
+    command: ruff check
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]ExternalTarget{
+		"deploy":  {Command: "./deploy.sh --env prod", Description: "Deploy the site"},
+		"lint-py": {Command: "ruff check"},
+	}
+	if !reflect.DeepEqual(cfg.ExternalTargets, want) {
+		t.Errorf("ExternalTargets = %v, want %v", cfg.ExternalTargets, want)
+	}
+	if target, ok := cfg.ExternalTarget("DEPLOY"); !ok || target.Command != "./deploy.sh --env prod" {
+		t.Errorf("ExternalTarget(%q) = %v, %v", "DEPLOY", target, ok)
+	}
+	if _, ok := cfg.ExternalTarget("build"); ok {
+		t.Errorf("ExternalTarget(%q) found a target that isn't configured", "build")
+	}
+}
+
+func TestConfig_Validate_ExternalTargets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ExternalTargets = map[string]ExternalTarget{
+		"deploy":    {Command: " "},
+		"run tests": {Command: "make test"},
+	}
+	result := cfg.Validate()
+	fields := make([]string, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		fields = append(fields, e.Field)
+	}
+	want := []string{"external_targets.deploy.command", "external_targets.run tests"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("error fields = %v, want %v", fields, want)
+	}
+}
+
+func TestConfig_BuildHash(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.BuildHash(); got != "" {
+		t.Errorf("BuildHash() = %q without build settings, want empty", got)
+	}
+
+	cfg.ImportAliases = map[string]string{"example.com/tools": "tools", "example.com/lint": "lint"}
+	hash := cfg.BuildHash()
+	if hash == "" {
+		t.Fatal("BuildHash() is empty with import_aliases set")
+	}
+
+	cfg.Verbose = true
+	cfg.Verify = VerifyWarn
+	if got := cfg.BuildHash(); got != hash {
+		t.Errorf("BuildHash() = %q after changing settings that don't affect the build, want %q", got, hash)
+	}
+
+	cfg.ImportAliases["example.com/tools"] = "tls"
+	if got := cfg.BuildHash(); got == hash {
+		t.Error("BuildHash() didn't change with import_aliases")
+	}
+}
@@ -19,11 +19,28 @@ const (
 	// DefaultIgnoreDefault is the default ignore default target setting.
 	DefaultIgnoreDefault = false
 
+	// DefaultOnNoTarget is the default for what running stave without a target does.
+	DefaultOnNoTarget = OnNoTargetDefault
+
+	// DefaultFuzzyTargets is the default fuzzy target matching setting.
+	DefaultFuzzyTargets = false
+
 	// DefaultEnableColor is the default color output setting.
 	DefaultEnableColor = false
 
 	// DefaultTargetColor is the default ANSI color for target names.
 	DefaultTargetColor = "Cyan"
+
+	// DefaultMinFreeDisk is the default minimum free disk space needed to compile.
+	DefaultMinFreeDisk = "100MB"
+
+	// DefaultAutoMod is the default setting for bootstrapping a go.mod for
+	// stavefiles outside a module.
+	DefaultAutoMod = false
+
+	// DefaultVerify is the default for what stave checks in the stavefiles
+	// before compiling them.
+	DefaultVerify = VerifyOff
 )
 
 // setDefaults configures default values in the viper instance.
@@ -34,6 +51,11 @@ func setDefaults(viperInstance *viper.Viper) {
 	viperInstance.SetDefault("debug", DefaultDebug)
 	viperInstance.SetDefault("hash_fast", DefaultHashFast)
 	viperInstance.SetDefault("ignore_default", DefaultIgnoreDefault)
+	viperInstance.SetDefault("on_no_target", DefaultOnNoTarget)
+	viperInstance.SetDefault("fuzzy_targets", DefaultFuzzyTargets)
 	viperInstance.SetDefault("enable_color", DefaultEnableColor)
 	viperInstance.SetDefault("target_color", DefaultTargetColor)
+	viperInstance.SetDefault("min_free_disk", DefaultMinFreeDisk)
+	viperInstance.SetDefault("auto_mod", DefaultAutoMod)
+	viperInstance.SetDefault("verify", DefaultVerify)
 }
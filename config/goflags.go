@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GoFlags is the value of a go build flag that takes a list of arguments,
+// like -ldflags. In stave.yaml it is either a string, which go build splits
+// itself, honouring quotes, or a list with one argument per item, which is
+// quoted and joined the same way:
+//
+//	ldflags: -s -w -X "main.Version=1.2 beta"
+//	ldflags: [-s, -w, -X, main.Version=1.2 beta]
+type GoFlags string
+
+// goFlagsHook decodes a list of arguments into GoFlags by quoting and joining
+// them. A string is left as it is, rather than split at commas like the
+// strings decoded into lists, so that quotes and commas in it survive.
+func goFlagsHook(from, to reflect.Type, data any) (any, error) {
+	if to != reflect.TypeFor[GoFlags]() || from.Kind() != reflect.Slice {
+		return data, nil
+	}
+	items := reflect.ValueOf(data)
+	args := make([]string, items.Len())
+	for i := range items.Len() {
+		args[i] = fmt.Sprint(items.Index(i).Interface())
+	}
+	return joinGoFlags(args)
+}
+
+// joinGoFlags joins args into one flag value that go build splits back into
+// args, quoting those that are empty or contain spaces or quotes with
+// whichever quote they don't contain. go build doesn't unescape quoted
+// arguments, so one with both kinds of quote can't be passed.
+func joinGoFlags(args []string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		hasSingle, hasDouble := strings.Contains(arg, "'"), strings.Contains(arg, `"`)
+		switch {
+		case arg != "" && !hasSingle && !hasDouble && !strings.ContainsAny(arg, " \t\n\r"):
+			quoted[i] = arg
+		case !hasSingle:
+			quoted[i] = "'" + arg + "'"
+		case !hasDouble:
+			quoted[i] = `"` + arg + `"`
+		default:
+			return "", fmt.Errorf("argument %q contains both single and double quotes, so it can't be passed to go build", arg)
+		}
+	}
+	return strings.Join(quoted, " "), nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinGoFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-s", "-w"}, "-s -w"},
+		{[]string{"-X", "main.Version=1.2 beta"}, "-X 'main.Version=1.2 beta'"},
+		{[]string{"-X", "main.Quote=it's"}, `-X "main.Quote=it's"`},
+		{[]string{"-X", `main.Quote="hi"`}, `-X 'main.Quote="hi"'`},
+		{[]string{"-X", "main.Empty="}, "-X main.Empty="},
+		{[]string{""}, "''"},
+	}
+	for _, tt := range tests {
+		got, err := joinGoFlags(tt.args)
+		if err != nil {
+			t.Errorf("joinGoFlags(%q) error = %v", tt.args, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("joinGoFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	if _, err := joinGoFlags([]string{`it's "quoted"`}); err == nil {
+		t.Error("joinGoFlags() should fail for an argument with both kinds of quote")
+	}
+}
+
+func TestLoad_GoFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+ldflags: -s -X "main.Version=1.2, beta"
+gcflags: [all=-N, -l]
+asmflags: [-D, NAME=a b]
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+		SkipEnv:        true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// a string is kept as it is, commas and quotes included.
+	if want := GoFlags(`-s -X "main.Version=1.2, beta"`); cfg.Ldflags != want {
+		t.Errorf("Ldflags = %q, want %q", cfg.Ldflags, want)
+	}
+	if want := GoFlags("all=-N -l"); cfg.Gcflags != want {
+		t.Errorf("Gcflags = %q, want %q", cfg.Gcflags, want)
+	}
+	if want := GoFlags("-D 'NAME=a b'"); cfg.Asmflags != want {
+		t.Errorf("Asmflags = %q, want %q", cfg.Asmflags, want)
+	}
+	if cfg.Origin("ldflags") != OriginProject {
+		t.Errorf("Origin(ldflags) = %q, want %q", cfg.Origin("ldflags"), OriginProject)
+	}
+
+	t.Setenv("STAVEFILE_LDFLAGS", "-X 'main.Version=from env'")
+	cfg, err = Load(&LoadOptions{
+		ProjectDir:     tmpDir,
+		SkipUserConfig: true,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := GoFlags("-X 'main.Version=from env'"); cfg.Ldflags != want {
+		t.Errorf("Ldflags = %q, want %q from STAVEFILE_LDFLAGS", cfg.Ldflags, want)
+	}
+}
@@ -8,16 +8,38 @@ import (
 	"github.com/samber/lo"
 )
 
-// HookTarget represents a single target to run for a Git hook.
+// HookTarget represents a single step to run for a Git hook: a Stave target,
+// or a shell command.
 type HookTarget struct {
 	// Target is the name of the Stave target to run.
 	Target string `mapstructure:"target"`
 
-	// Args are additional CLI arguments passed to the target invocation.
+	// Run is a shell command to run instead of a target.
+	Run string `mapstructure:"run,omitempty"`
+
+	// Args are additional CLI arguments passed to the target invocation, or
+	// positional parameters for the command.
 	Args []string `mapstructure:"args,omitempty"`
 
 	// WorkDir is the working directory for the target invocation; if empty, current dir is assumed.
 	WorkDir string `mapstructure:"workdir,omitempty"`
+
+	// PassStdin asks for Git's stdin to be forwarded to the target, which it
+	// always is; the key is accepted so that configs setting it still load.
+	PassStdin bool `mapstructure:"passStdin,omitempty"`
+}
+
+// IsCommand reports whether the step runs a shell command rather than a target.
+func (t HookTarget) IsCommand() bool {
+	return strings.TrimSpace(t.Run) != ""
+}
+
+// Name returns the target name, or the command for a command step.
+func (t HookTarget) Name() string {
+	if t.IsCommand() {
+		return t.Run
+	}
+	return t.Target
 }
 
 // HooksConfig maps Git hook names to their configured targets.
@@ -115,10 +137,17 @@ func ValidateHooks(hooks HooksConfig) ValidationResults {
 
 		// Validate each target in the hook
 		for i, target := range targets {
-			if strings.TrimSpace(target.Target) == "" {
+			hasTarget := strings.TrimSpace(target.Target) != ""
+			switch {
+			case hasTarget && target.IsCommand():
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("hooks.%s[%d].run", hookName, i),
+					Message: "target and run cannot both be set",
+				})
+			case !hasTarget && !target.IsCommand():
 				result.Errors = append(result.Errors, ValidationError{
 					Field:   fmt.Sprintf("hooks.%s[%d].target", hookName, i),
-					Message: "target name cannot be empty",
+					Message: "target name cannot be empty; set target or run",
 				})
 			}
 		}
@@ -186,6 +186,31 @@ func TestValidateHooks_EmptyTargetName(t *testing.T) {
 	}
 }
 
+func TestValidateHooks_RunCommand(t *testing.T) {
+	t.Parallel()
+
+	result := ValidateHooks(HooksConfig{
+		"pre-commit": {
+			{Run: "gofmt -l ."},
+		},
+	})
+	if result.HasErrors() {
+		t.Errorf("ValidateHooks returned errors for a run step: %s", result.ErrorMessage())
+	}
+
+	result = ValidateHooks(HooksConfig{
+		"pre-commit": {
+			{Target: "fmt", Run: "gofmt -l ."},
+		},
+	})
+	if !result.HasErrors() {
+		t.Fatal("ValidateHooks should return error when both target and run are set")
+	}
+	if result.Errors[0].Field != "hooks.pre-commit[0].run" {
+		t.Errorf("Error field = %q, want %q", result.Errors[0].Field, "hooks.pre-commit[0].run")
+	}
+}
+
 func TestValidateHooks_EmptyHookName(t *testing.T) {
 	t.Parallel()
 
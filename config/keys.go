@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxSuggestionDistance is the largest edit distance between an unknown key
+// and a known one for the known key to be suggested.
+const maxSuggestionDistance = 3
+
+// unknownKeysError returns an error listing the unused config keys reported by
+// the decoder, each with the nearest known key as a suggestion, or nil if
+// there are none. Nested keys look like "hooks[pre-commit][0].trget".
+func unknownKeysError(unused []string) error {
+	if len(unused) == 0 {
+		return nil
+	}
+	keys := slices.Sorted(slices.Values(unused))
+
+	var results ValidationResults
+	for _, key := range keys {
+		name, candidates := key, mapstructureKeys(reflect.TypeFor[Config]())
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			name, candidates = key[i+1:], mapstructureKeys(nestedType(key))
+		}
+		msg := "unknown key"
+		if suggestion := nearestKey(name, candidates); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		results.Errors = append(results.Errors, ValidationError{Field: key, Message: msg})
+	}
+	return errors.New(results.ErrorMessage())
+}
+
+// nestedType returns the struct type that holds the last key of the nested
+// key, found by following its top-level key into the Config field of that name
+// and through any maps and slices. It returns nil if there is no such struct.
+func nestedType(key string) reflect.Type {
+	top, _, _ := strings.Cut(key, ".")
+	top, _, _ = strings.Cut(top, "[")
+	t := reflect.TypeFor[Config]()
+	for i := range t.NumField() {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ","); tag != top {
+			continue
+		}
+		ft := t.Field(i).Type
+		for ft.Kind() == reflect.Map || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			return ft
+		}
+	}
+	return nil
+}
+
+// mapstructureKeys returns the config keys of the fields of the struct type t,
+// which may be nil.
+func mapstructureKeys(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	keys := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+		if tag != "" && tag != "-" {
+			keys = append(keys, tag)
+		}
+	}
+	return keys
+}
+
+// fieldByKey returns the field of the struct value v that has the config key
+// key, and whether there is one.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := range v.NumField() {
+		if tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("mapstructure"), ","); tag == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// nearestKey returns the candidate closest to key by edit distance, or "" if
+// none is close enough to be a plausible typo.
+func nearestKey(key string, candidates []string) string {
+	best, bestDist := "", maxSuggestionDistance+1
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(key), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
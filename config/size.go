@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteSizeUnits are the suffixes accepted by ParseByteSize, longest first so
+// that "MB" is matched before "B". Units are binary: 1KB is 1024 bytes.
+//
+//nolint:gochecknoglobals // package-level lookup table for size parsing
+var byteSizeUnits = []struct {
+	suffix string
+	factor uint64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "500MB", "2GiB" or "1048576" into a
+// number of bytes. Units are case-insensitive and binary (1KB is 1024 bytes).
+func ParseByteSize(s string) (uint64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	factor := uint64(1)
+	for _, unit := range byteSizeUnits {
+		if trimmed, ok := strings.CutSuffix(str, unit.suffix); ok {
+			str = strings.TrimSpace(trimmed)
+			factor = unit.factor
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number with an optional unit like 500MB", s)
+	}
+	return uint64(value * float64(factor)), nil
+}
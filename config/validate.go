@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"io"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/samber/lo"
@@ -102,6 +106,102 @@ func (c *Config) Validate() ValidationResults {
 		}
 	}
 
+	// Validate min_free_disk
+	if c.MinFreeDisk != "" {
+		if _, err := ParseByteSize(c.MinFreeDisk); err != nil {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "min_free_disk",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Validate mainfile_name
+	if c.MainfileName != "" && (c.MainfileName != filepath.Base(c.MainfileName) || filepath.Ext(c.MainfileName) != ".go") {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "mainfile_name",
+			Message: fmt.Sprintf("invalid file name %q, must be a plain file name ending in .go", c.MainfileName),
+		})
+	}
+
+	// Validate on_no_target
+	switch c.OnNoTarget {
+	case "", OnNoTargetDefault, OnNoTargetList, OnNoTargetHelp:
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field: "on_no_target",
+			Message: fmt.Sprintf(
+				"invalid value %q, must be one of: %s, %s, %s",
+				c.OnNoTarget, OnNoTargetDefault, OnNoTargetList, OnNoTargetHelp,
+			),
+		})
+	}
+
+	// Validate verify
+	switch c.Verify {
+	case "", VerifyOff, VerifyVet, VerifyWarn:
+	default:
+		result.Errors = append(result.Errors, ValidationError{
+			Field: "verify",
+			Message: fmt.Sprintf(
+				"invalid value %q, must be one of: %s, %s, %s",
+				c.Verify, VerifyOff, VerifyVet, VerifyWarn,
+			),
+		})
+	}
+
+	// Validate env_files
+	for i, f := range c.EnvFiles {
+		if strings.TrimSpace(strings.TrimPrefix(f, "!")) == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("env_files[%d]", i),
+				Message: "env file path cannot be empty",
+			})
+		}
+	}
+
+	// Validate binary_cache
+	if c.BinaryCache.Type != "" && c.BinaryCache.Type != BinaryCacheTypeHTTP {
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "binary_cache.type",
+			Message: fmt.Sprintf("unsupported cache type %q, must be %q", c.BinaryCache.Type, BinaryCacheTypeHTTP),
+		})
+	}
+	if c.BinaryCache.Enabled() {
+		if u, err := url.Parse(c.BinaryCache.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "binary_cache.url",
+				Message: fmt.Sprintf("invalid URL %q, must be an http or https URL", c.BinaryCache.URL),
+			})
+		}
+	}
+
+	// Validate import_aliases
+	for _, importPath := range slices.Sorted(maps.Keys(c.ImportAliases)) {
+		if alias := c.ImportAliases[importPath]; strings.TrimSpace(alias) == "" || strings.ContainsAny(alias, ": \t") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "import_aliases." + importPath,
+				Message: fmt.Sprintf("invalid alias %q, must be a single word without colons", alias),
+			})
+		}
+	}
+
+	// Validate external_targets
+	for _, name := range slices.Sorted(maps.Keys(c.ExternalTargets)) {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t") {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "external_targets." + name,
+				Message: fmt.Sprintf("invalid target name %q, must be a single word", name),
+			})
+		}
+		if strings.TrimSpace(c.ExternalTargets[name].Command) == "" {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "external_targets." + name + ".command",
+				Message: "command cannot be empty",
+			})
+		}
+	}
+
 	// Validate hooks configuration
 	if c.Hooks != nil {
 		hooksResult := ValidateHooks(c.Hooks)
@@ -15,6 +15,10 @@
 // `STAVEFILE_DRYRUN_POSSIBLE` will not be set in that situation), while still
 // enabling true dryrun functionality for "inner" Stave runs (i.e., runs of the
 // compiled stavefile binary).
+//
+// IsDepsDryRun() is the stronger mode behind `-dryrun-deps`: on top of
+// IsDryRun(), st.Deps prints the targets it would run instead of running them.
+// It needs `STAVEFILE_DRYRUN_DEPS` to be set, or SetDepsRequested(true).
 package dryrun
 
 import (
@@ -35,11 +39,19 @@ const RequestedEnv = "STAVEFILE_DRYRUN"
 // PossibleEnv is the environment variable that indicates we are in a context where a dry run is possible.
 const PossibleEnv = "STAVEFILE_DRYRUN_POSSIBLE"
 
+// DepsRequestedEnv is the environment variable that indicates the user requested that st.Deps print, but not run, targets.
+const DepsRequestedEnv = "STAVEFILE_DRYRUN_DEPS"
+
 // SetRequested sets the dryrun requested state to the specified boolean value.
 func SetRequested(value bool) {
 	dryRunRequestedValue = value
 }
 
+// SetDepsRequested sets the deps dryrun requested state to the specified boolean value.
+func SetDepsRequested(value bool) {
+	dryRunDepsRequestedValue = value
+}
+
 // SetPossible sets the dryrun possible value to the specified boolean value.
 func SetPossible(value bool) {
 	dryRunPossible = value
@@ -56,6 +68,17 @@ func IsRequested() bool {
 	return dryRunRequestedEnvValue || dryRunRequestedValue
 }
 
+// IsDepsRequested checks if deps dry-run mode was requested, either explicitly or via an environment variable.
+func IsDepsRequested() bool {
+	dryRunDepsRequestedEnvOnce.Do(func() {
+		if os.Getenv(DepsRequestedEnv) != "" {
+			dryRunDepsRequestedEnvValue = true
+		}
+	})
+
+	return dryRunDepsRequestedEnvValue || dryRunDepsRequestedValue
+}
+
 // IsPossible checks if dry-run mode is supported in the current context.
 func IsPossible() bool {
 	dryRunPossibleOnce.Do(func() {
@@ -93,3 +116,8 @@ func IsDryRun() bool {
 
 	return possible && requested
 }
+
+// IsDepsDryRun determines if dry-run mode is on and extends to st.Deps targets.
+func IsDepsDryRun() bool {
+	return IsDryRun() && IsDepsRequested()
+}
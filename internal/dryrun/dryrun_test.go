@@ -75,6 +75,28 @@ func TestIsDryRunRequiresBoth(t *testing.T) {
 	}
 }
 
+func TestIsDepsDryRunRequiresDryRun(t *testing.T) {
+	for _, tc := range []struct {
+		env  []string
+		want string
+	}{
+		{[]string{DepsRequestedEnv + "=1", PossibleEnv + "=1"}, "false"},
+		{[]string{DepsRequestedEnv + "=1", RequestedEnv + "=1"}, "false"},
+		{[]string{RequestedEnv + "=1", PossibleEnv + "=1"}, "false"},
+		{[]string{DepsRequestedEnv + "=1", RequestedEnv + "=1", PossibleEnv + "=1"}, trueStr},
+	} {
+		cmd := exec.Command(os.Args[0], "-printIsDepsDryRun")
+		cmd.Env = append(os.Environ(), tc.env...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("subprocess failed: %v", err)
+		}
+		if strings.TrimSpace(string(out)) != tc.want {
+			t.Fatalf("with %v, expected %s, got %q", tc.env, tc.want, strings.TrimSpace(string(out)))
+		}
+	}
+}
+
 func TestWrap(t *testing.T) {
 	tempDir := t.TempDir()
 	binDir := filepath.Join(tempDir, "bin")
@@ -9,6 +9,11 @@ var (
 	dryRunRequestedEnvValue bool
 	dryRunRequestedEnvOnce  sync.Once
 
+	// Once-protected variables for whether the user requested that dryrun mode extend to st.Deps.
+	dryRunDepsRequestedValue    bool
+	dryRunDepsRequestedEnvValue bool
+	dryRunDepsRequestedEnvOnce  sync.Once
+
 	// Once-protected variables for whether dryrun mode is possible.
 	dryRunPossible     bool
 	dryRunPossibleOnce sync.Once
@@ -11,12 +11,14 @@ var (
 	printIsDryRunRequested bool
 	printIsDryRunPossible  bool
 	printIsDryRun          bool
+	printIsDepsDryRun      bool
 )
 
 func init() {
 	flag.BoolVar(&printIsDryRunRequested, "printIsDryRunRequested", false, "")
 	flag.BoolVar(&printIsDryRunPossible, "printIsDryRunPossible", false, "")
 	flag.BoolVar(&printIsDryRun, "printIsDryRun", false, "")
+	flag.BoolVar(&printIsDepsDryRun, "printIsDepsDryRun", false, "")
 }
 
 func TestMain(m *testing.M) {
@@ -33,5 +35,9 @@ func TestMain(m *testing.M) {
 		_, _ = fmt.Fprintln(os.Stdout, IsDryRun())
 		return
 	}
+	if printIsDepsDryRun {
+		_, _ = fmt.Fprintln(os.Stdout, IsDepsDryRun())
+		return
+	}
 	os.Exit(m.Run())
 }
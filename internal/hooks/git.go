@@ -92,8 +92,10 @@ func findGitDirs(ctx context.Context, absDir string) (gitDirs, error) {
 
 	gitDir, err := gitOutput(ctx, absDir, "rev-parse", "--git-common-dir")
 	if err != nil {
-		// Fallback to --git-dir if --git-common-dir fails (older git versions)
-		gitDir, err = gitOutput(ctx, absDir, "rev-parse", "--git-dir")
+		// Older gits lack --git-common-dir, and their --git-dir is the
+		// worktree's own git directory, which has no hooks; follow the .git
+		// file instead.
+		gitDir, err = commonGitDir(rootDir)
 		if err != nil {
 			return gitDirs{}, fmt.Errorf("finding git directory: %w", err)
 		}
@@ -107,6 +109,44 @@ func findGitDirs(ctx context.Context, absDir string) (gitDirs, error) {
 	return gitDirs{rootDir: rootDir, gitDir: gitDir}, nil
 }
 
+// commonGitDir returns the git directory of the repository whose working tree
+// is at rootDir, shared by all its worktrees. Where .git is a file, as in
+// linked worktrees and submodules, it follows the "gitdir:" line in it, and
+// then, for a linked worktree, the commondir file in the directory it names.
+func commonGitDir(rootDir string) (string, error) {
+	dotGit := filepath.Join(rootDir, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	pointer, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s has no gitdir: line", dotGit)
+	}
+	gitDir := strings.TrimSpace(pointer)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(rootDir, gitDir)
+	}
+
+	commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err == nil {
+		common := strings.TrimSpace(string(commonDir))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		gitDir = common
+	}
+	return filepath.Clean(gitDir), nil
+}
+
 // resolveCanonicalPaths resolves symlinks and cleans paths.
 // This is important on macOS where /var is a symlink to /private/var.
 func resolveCanonicalPaths(rootDir, gitDir string) (string, string, error) {
@@ -124,9 +164,10 @@ func resolveCanonicalPaths(rootDir, gitDir string) (string, string, error) {
 	return filepath.Clean(rootDir), filepath.Clean(gitDir), nil
 }
 
-// getCustomHooksPath returns the configured core.hooksPath or empty string.
+// getCustomHooksPath returns the configured core.hooksPath, with a leading ~
+// expanded, or empty string.
 func getCustomHooksPath(ctx context.Context, absDir string) string {
-	customHooksPath, err := gitOutput(ctx, absDir, "config", "--get", "core.hooksPath")
+	customHooksPath, err := gitOutput(ctx, absDir, "config", "--path", "--get", "core.hooksPath")
 	if err != nil {
 		return ""
 	}
@@ -173,6 +214,28 @@ func (r *GitRepo) HasCustomHooksPath() bool {
 	return r.customHooksPath != ""
 }
 
+// Submodules returns the repositories of the initialized submodules of r, and
+// of their submodules in turn.
+func (r *GitRepo) Submodules(ctx context.Context) ([]*GitRepo, error) {
+	out, err := gitOutput(ctx, r.RootDir, "submodule", "foreach", "--quiet", "--recursive", `echo "$displaypath"`)
+	if err != nil {
+		return nil, fmt.Errorf("listing submodules: %w", err)
+	}
+
+	var repos []*GitRepo
+	for _, path := range strings.Split(out, "\n") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		repo, err := FindGitRepoContext(ctx, filepath.Join(r.RootDir, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, fmt.Errorf("submodule %s: %w", path, err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
 // gitOutput runs a git command and returns the trimmed stdout.
 // Returns an error if the command fails.
 func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
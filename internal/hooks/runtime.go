@@ -7,6 +7,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +23,10 @@ const StaveHooksEnv = "STAVE_HOOKS"
 // StaveQuietEnv is the environment variable that suppresses hook output when set to "1".
 const StaveQuietEnv = "STAVE_QUIET"
 
+// HookArgEnvPrefix is the prefix of the environment variables that hold the
+// arguments git passed to the hook: STAVE_HOOK_ARG1 for the first, and so on.
+const HookArgEnvPrefix = "STAVE_HOOK_ARG"
+
 // CI environment variable names used to detect CI environments.
 // When any of these are set, hooks run in quiet mode.
 const (
@@ -74,6 +79,11 @@ type Runtime struct {
 	// TargetRunner is the function that runs a Stave target.
 	// Production code should always set this; if nil, a no-op test stub is used.
 	TargetRunner TargetRunnerFunc
+
+	// CommandRunner is the function that runs the shell command of a step
+	// configured with run instead of target.
+	// Production code should always set this; if nil, a no-op test stub is used.
+	CommandRunner CommandRunnerFunc
 }
 
 // TargetRunnerFunc runs a Stave target and returns its exit code.
@@ -86,6 +96,17 @@ type TargetRunnerFunc func(
 	stdout, stderr io.Writer,
 ) (int, error)
 
+// CommandRunnerFunc runs a shell command for a hook step and returns its exit
+// code. The args are the command's positional parameters.
+type CommandRunnerFunc func(
+	ctx context.Context,
+	workDir string,
+	command string,
+	args []string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) (int, error)
+
 // RunResult holds the outcome of running a hook.
 type RunResult struct {
 	// Hook is the name of the hook that was run.
@@ -106,7 +127,7 @@ type RunResult struct {
 
 // TargetResult holds the result of running a single target.
 type TargetResult struct {
-	// Name is the target name.
+	// Name is the target name, or the command for a command step.
 	Name string
 
 	// Args are the arguments passed to the target.
@@ -161,7 +182,7 @@ func (r *Runtime) Run(ctx context.Context, hookName string, args []string) (*Run
 	if !isQuietMode() && r.Stdout != nil {
 		targetNames := make([]string, len(targets))
 		for i, t := range targets {
-			targetNames[i] = t.Target
+			targetNames[i] = t.Name()
 		}
 		_, _ = fmt.Fprintf(r.Stdout, "🪝 Running Git hooks: Stave (%s: %s)\n",
 			hookName, strings.Join(targetNames, ", "))
@@ -171,8 +192,7 @@ func (r *Runtime) Run(ctx context.Context, hookName string, args []string) (*Run
 		slog.String("hook", hookName),
 		slog.Int("target_count", len(targets)))
 
-	runner := r.getRunner()
-	r.executeTargets(ctx, result, hookName, targets, args, runner, startTime)
+	r.executeTargets(ctx, result, hookName, targets, args, startTime)
 
 	if result.ExitCode == 0 && st.Verbose() {
 		log.SimpleConsoleLogger.Printf("Hook completed: %s (%d targets, %v)",
@@ -222,6 +242,14 @@ func (r *Runtime) getRunner() TargetRunnerFunc {
 	return defaultTargetRunner
 }
 
+// getCommandRunner returns the command runner, using default if none is set.
+func (r *Runtime) getCommandRunner() CommandRunnerFunc {
+	if r.CommandRunner != nil {
+		return r.CommandRunner
+	}
+	return defaultCommandRunner
+}
+
 // executeTargets runs all targets sequentially, stopping on first failure.
 func (r *Runtime) executeTargets(
 	ctx context.Context,
@@ -229,11 +257,10 @@ func (r *Runtime) executeTargets(
 	hookName string,
 	targets []config.HookTarget,
 	args []string,
-	runner TargetRunnerFunc,
 	startTime time.Time,
 ) {
 	for _, target := range targets {
-		targetResult := r.executeTarget(ctx, hookName, target, args, runner)
+		targetResult := r.executeTarget(ctx, hookName, target, args)
 		result.Targets = append(result.Targets, targetResult)
 
 		if !targetResult.Success() {
@@ -244,8 +271,12 @@ func (r *Runtime) executeTargets(
 			result.TotalTime = time.Since(startTime)
 
 			if r.Stderr != nil {
-				_, _ = fmt.Fprintf(r.Stderr, "stave: hook %s failed at target %s (exit %d)\n",
-					hookName, target.Target, result.ExitCode)
+				kind := "target"
+				if target.IsCommand() {
+					kind = "command"
+				}
+				_, _ = fmt.Fprintf(r.Stderr, "stave: hook %s failed at %s %s (exit %d)\n",
+					hookName, kind, target.Name(), result.ExitCode)
 			}
 			return
 		}
@@ -253,13 +284,13 @@ func (r *Runtime) executeTargets(
 	result.TotalTime = time.Since(startTime)
 }
 
-// executeTarget runs a single target and returns its result.
+// executeTarget runs a single target, or the command of a command step, and
+// returns its result.
 func (r *Runtime) executeTarget(
 	ctx context.Context,
 	hookName string,
 	target config.HookTarget,
 	args []string,
-	runner TargetRunnerFunc,
 ) TargetResult {
 	targetStart := time.Now()
 
@@ -272,12 +303,21 @@ func (r *Runtime) executeTarget(
 		slog.String("hook", hookName),
 		slog.String("workdir", target.WorkDir),
 		slog.String("target", target.Target),
+		slog.String("run", target.Run),
 		slog.Any("args", targetArgs))
 
-	exitCode, err := runner(ctx, target.WorkDir, target.Target, targetArgs, r.Stdin, r.Stdout, r.Stderr)
+	var (
+		exitCode int
+		err      error
+	)
+	if target.IsCommand() {
+		exitCode, err = r.getCommandRunner()(ctx, target.WorkDir, target.Run, targetArgs, r.Stdin, r.Stdout, r.Stderr)
+	} else {
+		exitCode, err = r.getRunner()(ctx, target.WorkDir, target.Target, targetArgs, r.Stdin, r.Stdout, r.Stderr)
+	}
 
 	result := TargetResult{
-		Name:     target.Target,
+		Name:     target.Name(),
 		Args:     targetArgs,
 		ExitCode: exitCode,
 		Duration: time.Since(targetStart),
@@ -285,7 +325,7 @@ func (r *Runtime) executeTarget(
 	}
 
 	slog.Debug("target completed",
-		slog.String("target", target.Target),
+		slog.String("target", target.Name()),
 		slog.Int("exit_code", exitCode),
 		slog.Duration("duration", result.Duration))
 
@@ -304,12 +344,28 @@ func IsDebugMode() bool {
 	return strings.ToLower(val) == "debug"
 }
 
+// HookArgsEnv returns the environment variables that expose the hook
+// arguments args to targets, numbered from 1.
+func HookArgsEnv(args []string) map[string]string {
+	vars := make(map[string]string, len(args))
+	for i, arg := range args {
+		vars[HookArgEnvPrefix+strconv.Itoa(i+1)] = arg
+	}
+	return vars
+}
+
 // defaultTargetRunner is a no-op stub for testing purposes only.
 // Production code should always inject a real runner via Runtime.TargetRunner.
 func defaultTargetRunner(_ context.Context, _, _ string, _ []string, _ io.Reader, _, _ io.Writer) (int, error) {
 	return 0, nil
 }
 
+// defaultCommandRunner is a no-op stub for testing purposes only.
+// Production code should always inject a real runner via Runtime.CommandRunner.
+func defaultCommandRunner(_ context.Context, _, _ string, _ []string, _ io.Reader, _, _ io.Writer) (int, error) {
+	return 0, nil
+}
+
 // NewRuntime creates a new Runtime with the given configuration.
 func NewRuntime(cfg *config.Config) *Runtime {
 	return &Runtime{
@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/yaklabco/stave/config"
@@ -373,6 +376,88 @@ func TestRuntime_Run_WithError(t *testing.T) {
 	}
 }
 
+func TestRuntime_Run_CommandStep_Success(t *testing.T) {
+	t.Parallel()
+
+	var targetCalls, commandCalls []targetCall
+	runtime := &Runtime{
+		Config: &config.Config{
+			Hooks: config.HooksConfig{
+				"pre-commit": {
+					{Target: "fmt"},
+					{Run: "gofmt -l .", Args: []string{"--fast"}, WorkDir: "sub"},
+				},
+			},
+		},
+		TargetRunner:  mockRunnerCapture(&targetCalls),
+		CommandRunner: CommandRunnerFunc(mockRunnerCapture(&commandCalls)),
+	}
+
+	result, err := runtime.Run(t.Context(), "pre-commit", []string{".git/COMMIT_EDITMSG"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !result.Success() {
+		t.Error("Result should be successful")
+	}
+	if len(targetCalls) != 1 || targetCalls[0].target != "fmt" {
+		t.Errorf("Target calls = %+v, want only fmt", targetCalls)
+	}
+	if len(commandCalls) != 1 {
+		t.Fatalf("Expected 1 command call, got %d", len(commandCalls))
+	}
+	if commandCalls[0].target != "gofmt -l ." {
+		t.Errorf("Command = %q, want %q", commandCalls[0].target, "gofmt -l .")
+	}
+	if commandCalls[0].workDir != "sub" {
+		t.Errorf("WorkDir = %q, want %q", commandCalls[0].workDir, "sub")
+	}
+	wantArgs := []string{"--fast", ".git/COMMIT_EDITMSG"}
+	if !slices.Equal(commandCalls[0].args, wantArgs) {
+		t.Errorf("Args = %q, want %q", commandCalls[0].args, wantArgs)
+	}
+	if result.Targets[1].Name != "gofmt -l ." {
+		t.Errorf("Result name = %q, want %q", result.Targets[1].Name, "gofmt -l .")
+	}
+}
+
+func TestRuntime_Run_CommandStep_Failure(t *testing.T) {
+	t.Parallel()
+
+	var stderr bytes.Buffer
+	var targetCalls []targetCall
+	runtime := &Runtime{
+		Config: &config.Config{
+			Hooks: config.HooksConfig{
+				"pre-commit": {
+					{Run: "gofmt -l ."},
+					{Target: "lint"},
+				},
+			},
+		},
+		Stderr:        &stderr,
+		TargetRunner:  mockRunnerCapture(&targetCalls),
+		CommandRunner: CommandRunnerFunc(mockRunner(3)),
+	}
+
+	result, err := runtime.Run(t.Context(), "pre-commit", nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", result.ExitCode)
+	}
+	if len(targetCalls) != 0 {
+		t.Errorf("Target after failing command should not run, got %+v", targetCalls)
+	}
+	want := "stave: hook pre-commit failed at command gofmt -l . (exit 3)"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("Stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
+
 func TestIsHooksDisabled(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -490,3 +575,17 @@ func TestTargetResult_Success(t *testing.T) {
 		})
 	}
 }
+
+func TestHookArgsEnv(t *testing.T) {
+	got := HookArgsEnv([]string{".git/COMMIT_EDITMSG", "message"})
+	want := map[string]string{
+		"STAVE_HOOK_ARG1": ".git/COMMIT_EDITMSG",
+		"STAVE_HOOK_ARG2": "message",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("HookArgsEnv() = %v, want %v", got, want)
+	}
+	if got := HookArgsEnv(nil); len(got) != 0 {
+		t.Errorf("HookArgsEnv(nil) = %v, want empty", got)
+	}
+}
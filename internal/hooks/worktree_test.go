@@ -42,3 +42,75 @@ func TestFindGitRepo_Worktree(t *testing.T) {
 	expectedHooksPath := filepath.Join(mainRepoDir, ".git", "hooks")
 	require.Equal(t, expectedHooksPath, repo.HooksPath(), "HooksPath should point to main repo's hooks")
 }
+
+// testGitCommit creates a repository in dir with one commit.
+func testGitCommit(t *testing.T, dir string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	testGitInit(t, dir)
+	testRunGit(t, dir, "config", "user.email", "test@example.com")
+	testRunGit(t, dir, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0o644))
+	testRunGit(t, dir, "add", "file.txt")
+	testRunGit(t, dir, "commit", "-m", "initial commit")
+}
+
+func TestCommonGitDir(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	mainRepoDir := filepath.Join(tmpDir, "main-repo")
+	testGitCommit(t, mainRepoDir)
+	worktreeDir := filepath.Join(tmpDir, "worktree")
+	testRunGit(t, mainRepoDir, "worktree", "add", worktreeDir)
+
+	gitDir, err := commonGitDir(mainRepoDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(mainRepoDir, ".git"), gitDir)
+
+	// the worktree's .git file points at .git/worktrees/worktree, whose
+	// commondir file points back at the main .git directory.
+	gitDir, err = commonGitDir(worktreeDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(mainRepoDir, ".git"), gitDir)
+
+	// a relative gitdir: line, as in submodules.
+	subDir := filepath.Join(tmpDir, "sub")
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "modules", "sub"), 0o755))
+	require.NoError(t, os.MkdirAll(subDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(subDir, ".git"), []byte("gitdir: ../modules/sub\n"), 0o644))
+	gitDir, err = commonGitDir(subDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(tmpDir, "modules", "sub"), gitDir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(subDir, ".git"), []byte("nonsense\n"), 0o644))
+	_, err = commonGitDir(subDir)
+	require.ErrorContains(t, err, "has no gitdir: line")
+}
+
+func TestFindGitRepo_Submodules(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	libDir := filepath.Join(tmpDir, "lib")
+	testGitCommit(t, libDir)
+	mainRepoDir := filepath.Join(tmpDir, "main-repo")
+	testGitCommit(t, mainRepoDir)
+	testRunGit(t, mainRepoDir, "-c", "protocol.file.allow=always", "submodule", "add", libDir, "vendor/lib")
+
+	repo, err := FindGitRepo(mainRepoDir)
+	require.NoError(t, err)
+	submodules, err := repo.Submodules(t.Context())
+	require.NoError(t, err)
+	require.Len(t, submodules, 1)
+
+	// the submodule's hooks are in the superproject's .git/modules.
+	require.Equal(t, filepath.Join(mainRepoDir, "vendor", "lib"), submodules[0].RootDir)
+	require.Equal(t, filepath.Join(mainRepoDir, ".git", "modules", "vendor", "lib", "hooks"), submodules[0].HooksPath())
+
+	// submodules that aren't initialized are left out.
+	testRunGit(t, mainRepoDir, "submodule", "deinit", "--force", "--all")
+	submodules, err = repo.Submodules(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, submodules)
+}
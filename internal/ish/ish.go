@@ -110,7 +110,7 @@ func ExitStatus(err error) int {
 // Rm removes the given file or directory even if non-empty.
 func Rm(path string) error {
 	if dryrun.IsDryRun() {
-		_, err := fmt.Println("DRYRUN: rm", path) //nolint:forbidigo // This is intentional console output.
+		_, err := fmt.Println("DRYRUN: rm -rf", path) //nolint:forbidigo // This is intentional console output.
 		return err
 	}
 
@@ -175,6 +175,18 @@ func RunV(ctx context.Context, theEnv map[string]string, wd, cmd string, args ..
 	return err
 }
 
+// RunWithPrefix is like RunV, but starts every line the command writes to
+// stdout or stderr with "[prefix] ". In dry-run mode, that includes the
+// command it prints instead of running it.
+func RunWithPrefix(ctx context.Context, theEnv map[string]string, wd, prefix, cmd string, args ...string) error {
+	stdout := newPrefixWriter(os.Stdout, prefix)
+	stderr := newPrefixWriter(os.Stderr, prefix)
+	_, err := Exec(ctx, theEnv, wd, os.Stdin, stdout, stderr, cmd, args...)
+	_ = stdout.flush()
+	_ = stderr.flush()
+	return err
+}
+
 func Output(ctx context.Context, theEnv map[string]string, wd, cmd string, args ...string) (string, error) {
 	buf := &bytes.Buffer{}
 	_, err := Exec(ctx, theEnv, wd, os.Stdin, buf, os.Stderr, cmd, args...)
@@ -0,0 +1,146 @@
+package ish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/env"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Pipeline runs cmds, each a command and its args, at the same time, with the
+// stdout of each piped to the stdin of the next, and returns the stdout of the
+// last. The first stage to fail stops the others, and its error is returned. A
+// stage killed by SIGPIPE because a later one stopped reading, like yes in
+// "yes | head -n1", doesn't count as failing.
+func Pipeline(ctx context.Context, cmds [][]string) (string, error) {
+	if len(cmds) == 0 {
+		return "", errors.New("empty pipeline")
+	}
+	expanded := make([][]string, len(cmds))
+	for i, cmd := range cmds {
+		if len(cmd) == 0 {
+			return "", fmt.Errorf("empty command at stage %d of pipeline", i+1)
+		}
+		expanded[i] = make([]string, len(cmd))
+		for j := range cmd {
+			expanded[i][j] = os.Expand(cmd[j], os.Getenv)
+		}
+	}
+	line := pipelineString(expanded)
+
+	if dryrun.IsDryRun() {
+		_, err := fmt.Println("DRYRUN:", line) //nolint:forbidigo // This is intentional console output.
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ambientEnv := env.ToAssignments(env.GetMap())
+	stages := make([]*exec.Cmd, len(expanded))
+	for i, cmd := range expanded {
+		stages[i] = exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+		stages[i].Env = ambientEnv
+		stages[i].Stderr = os.Stderr
+	}
+	stages[0].Stdin = os.Stdin
+	out := &bytes.Buffer{}
+	stages[len(stages)-1].Stdout = out
+
+	// the parent's ends of the pipes are closed once the stages have started,
+	// so that each stage sees EOF when the one before it exits.
+	var pipeEnds []*os.File
+	closePipeEnds := func() {
+		for _, end := range pipeEnds {
+			_ = end.Close()
+		}
+	}
+	for i := range stages[:len(stages)-1] {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			closePipeEnds()
+			return "", fmt.Errorf("creating pipe for pipeline %q: %w", line, err)
+		}
+		pipeEnds = append(pipeEnds, reader, writer)
+		stages[i].Stdout = writer
+		stages[i+1].Stdin = reader
+	}
+
+	if st.Verbose() {
+		log.SimpleConsoleLogger.Println("exec:", line)
+	}
+	for i, stage := range stages {
+		if err := stage.Start(); err != nil {
+			closePipeEnds()
+			cancel()
+			for _, started := range stages[:i] {
+				_ = started.Wait()
+			}
+			return "", fmt.Errorf(`failed to run "%s" in pipeline "%s": %w`, strings.Join(expanded[i], " "), line, err)
+		}
+	}
+	closePipeEnds()
+
+	type stageResult struct {
+		index int
+		err   error
+	}
+	results := make(chan stageResult, len(stages))
+	for i, stage := range stages {
+		go func() {
+			results <- stageResult{index: i, err: stage.Wait()}
+		}()
+	}
+
+	var failed *stageResult
+	for range stages {
+		result := <-results
+		if failed != nil || result.err == nil {
+			continue
+		}
+		if result.index < len(stages)-1 && killedBySIGPIPE(result.err) {
+			continue
+		}
+		failed = &result
+		cancel()
+	}
+	if failed == nil {
+		return strings.TrimSuffix(out.String(), "\n"), nil
+	}
+
+	stage := strings.Join(expanded[failed.index], " ")
+	if !CmdRan(failed.err) {
+		return "", fmt.Errorf(`running "%s" in pipeline "%s" failed: %w`, stage, line, failed.err)
+	}
+	code := ExitStatus(failed.err)
+	return "", st.Fatalf(code, `running "%s" in pipeline "%s" failed with exit code %d`, stage, line, code)
+}
+
+// pipelineString returns cmds as a shell would write the pipeline.
+func pipelineString(cmds [][]string) string {
+	stages := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		stages = append(stages, strings.Join(cmd, " "))
+	}
+	return strings.Join(stages, " | ")
+}
+
+// killedBySIGPIPE reports whether err is that of a command killed by SIGPIPE,
+// for writing to a pipe whose reader had exited.
+func killedBySIGPIPE(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGPIPE
+}
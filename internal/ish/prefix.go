@@ -0,0 +1,60 @@
+package ish
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter starts every line written to it with a prefix. Each line is
+// written to the underlying writer in a single Write, so lines from commands
+// running concurrently don't get mixed up; a final line without a newline is
+// held until flush.
+type prefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte("[" + prefix + "] ")}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(data), nil
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return len(data), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// flush writes out the final line, if it didn't end with a newline.
+func (p *prefixWriter) flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	out := make([]byte, 0, len(p.prefix)+len(line))
+	out = append(out, p.prefix...)
+	out = append(out, line...)
+	_, err := p.w.Write(out)
+	return err
+}
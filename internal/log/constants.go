@@ -4,21 +4,28 @@ const (
 	Alias      = "alias"
 	Args       = "args"
 	Cmd        = "cmd"
+	Constraint = "constraint"
 	Dir        = "dir"
 	Duration   = "duration"
 	Elem       = "elem"
 	Error      = "error"
 	Filename   = "filename"
 	Func       = "func"
+	Hint       = "hint"
 	ImportPath = "import_path"
 	ImportTag  = "import_tag"
 	Line       = "line"
 	Method     = "method"
 	Name       = "name"
 	Path       = "path"
+	Pattern    = "pattern"
 	Pkg        = "pkg"
+	Position   = "position"
+	Reason     = "reason"
 	Stderr     = "stderr"
 	Stdin      = "stdin"
 	Stdout     = "stdout"
+	Target     = "target"
 	Type       = "type"
+	URL        = "url"
 )
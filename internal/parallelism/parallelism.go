@@ -1,6 +1,7 @@
 package parallelism
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"runtime"
@@ -18,17 +19,25 @@ func getNumProcessors() int {
 	return runtime.NumCPU()
 }
 
-func Apply(theEnv map[string]string) error {
-	strFromEnv := strings.TrimSpace(os.Getenv(StaveNumProcessorsEnvVar))
-	var numProcessors int
-	if strFromEnv != "" {
-		var err error
-		numProcessors, err = strconv.Atoi(strFromEnv)
-		if err != nil {
-			return err
+// Apply sets GOMAXPROCS for this process, and STAVE_NUM_PROCESSORS and
+// GOMAXPROCS in theEnv, to the parallelism stave runs with. That is
+// numProcessors if it is positive (the -p flag), otherwise STAVE_NUM_PROCESSORS
+// if it is set, otherwise the number of CPUs.
+func Apply(theEnv map[string]string, numProcessors int) error {
+	if numProcessors < 0 {
+		return fmt.Errorf("parallelism must be at least 1, got %d", numProcessors)
+	}
+	if numProcessors == 0 {
+		strFromEnv := strings.TrimSpace(os.Getenv(StaveNumProcessorsEnvVar))
+		if strFromEnv != "" {
+			var err error
+			numProcessors, err = strconv.Atoi(strFromEnv)
+			if err != nil {
+				return err
+			}
+		} else {
+			numProcessors = getNumProcessors()
 		}
-	} else {
-		numProcessors = getNumProcessors()
 	}
 
 	slog.Debug("setting parallelism-related env vars", slog.Int("num_processors", numProcessors))
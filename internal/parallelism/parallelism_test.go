@@ -29,7 +29,7 @@ func TestApply(t *testing.T) {
 
 		require.NoError(t, os.Unsetenv(StaveNumProcessorsEnvVar))
 		theEnv := make(map[string]string)
-		err := Apply(theEnv)
+		err := Apply(theEnv, 0)
 		require.NoError(t, err)
 
 		expectedNum := runtime.NumCPU()
@@ -40,7 +40,7 @@ func TestApply(t *testing.T) {
 	t.Run("FromEnv", func(t *testing.T) {
 		t.Setenv(StaveNumProcessorsEnvVar, "4")
 		theEnv := make(map[string]string)
-		err := Apply(theEnv)
+		err := Apply(theEnv, 0)
 		require.NoError(t, err)
 
 		assert.Equal(t, "4", theEnv[StaveNumProcessorsEnvVar])
@@ -48,10 +48,27 @@ func TestApply(t *testing.T) {
 		assert.Equal(t, 4, runtime.GOMAXPROCS(0))
 	})
 
+	t.Run("Override", func(t *testing.T) {
+		t.Setenv(StaveNumProcessorsEnvVar, "4")
+		theEnv := make(map[string]string)
+		err := Apply(theEnv, 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, "2", theEnv[StaveNumProcessorsEnvVar])
+		assert.Equal(t, "2", theEnv[GoMaxProcsEnvVar])
+		assert.Equal(t, 2, runtime.GOMAXPROCS(0))
+	})
+
+	t.Run("NegativeOverride", func(t *testing.T) {
+		theEnv := make(map[string]string)
+		err := Apply(theEnv, -1)
+		assert.Error(t, err)
+	})
+
 	t.Run("InvalidEnv", func(t *testing.T) {
 		t.Setenv(StaveNumProcessorsEnvVar, "invalid")
 		theEnv := make(map[string]string)
-		err := Apply(theEnv)
+		err := Apply(theEnv, 0)
 		assert.Error(t, err)
 	})
 }
@@ -0,0 +1,276 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// argDirective is the name of the directive that constrains the values a
+// target accepts for one of its arguments:
+//
+//	// stave:arg env pattern=^(dev|prod)$
+//	// stave:arg replicas min=1 max=50
+const argDirective = "arg"
+
+// argDirectiveLine is a stave:arg line found in a target's doc comment.
+type argDirectiveLine struct {
+	text string // what follows "stave:arg"
+	pos  token.Position
+}
+
+// isArgDirective reports whether the text following "stave:" in a comment is a
+// stave:arg directive, and returns the rest of it.
+func isArgDirective(text string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, argDirective)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// detectArgDirectives collects the stave:arg lines in the doc comment of every
+// function in files, keyed by getFuncKey. Like detectDirectives, it must run
+// before doc.NewFromFiles.
+func detectArgDirectives(fset *token.FileSet, files []*ast.File) map[string][]argDirectiveLine {
+	out := make(map[string][]argDirectiveLine)
+	for _, file := range files {
+		for _, d := range file.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, comment := range fn.Doc.List {
+				text, ok := strings.CutPrefix(comment.Text, "//")
+				if !ok {
+					continue
+				}
+				text, ok = strings.CutPrefix(strings.TrimSpace(text), directivePrefix)
+				if !ok {
+					continue
+				}
+				if rest, ok := isArgDirective(text); ok {
+					key := getFuncKey(fn)
+					out[key] = append(out[key], argDirectiveLine{text: rest, pos: fset.Position(comment.Pos())})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// applyArgDirectives records the constraints in a target's stave:arg lines on
+// its arguments. Invalid lines are reported with a warning and ignored.
+func applyArgDirectives(funcInfo *Function, funcname string, lines []argDirectiveLine) {
+	for _, line := range lines {
+		if err := applyArgDirective(funcInfo, line.text); err != nil {
+			slog.Warn(
+				"ignoring invalid stave:arg directive",
+				slog.String(log.Func, funcname),
+				slog.String(log.Position, line.pos.String()),
+				slog.String(log.Reason, err.Error()),
+			)
+		}
+	}
+}
+
+func applyArgDirective(funcInfo *Function, text string) error {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return fmt.Errorf("expected %q", "stave:arg <name> key=value...")
+	}
+	var arg *Arg
+	for i := range funcInfo.Args {
+		if funcInfo.Args[i].Name == fields[0] {
+			arg = &funcInfo.Args[i]
+		}
+	}
+	if arg == nil {
+		return fmt.Errorf("%s has no argument named %q", funcInfo.Name, fields[0])
+	}
+
+	// Validate everything before recording anything, so that an invalid line
+	// is ignored as a whole.
+	constrained := *arg
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return fmt.Errorf("expected key=value, got %q", field)
+		}
+		switch key {
+		case "pattern":
+			if arg.Type != stringType {
+				return fmt.Errorf("pattern only applies to string arguments, %s is %s", arg.Name, arg.Type)
+			}
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+			constrained.Pattern = value
+		case "min", "max":
+			if err := checkArgBound(arg.Type, value); err != nil {
+				return fmt.Errorf("invalid %s for %s: %w", key, arg.Name, err)
+			}
+			if key == "min" {
+				constrained.Min = value
+			} else {
+				constrained.Max = value
+			}
+		default:
+			return fmt.Errorf("unknown constraint %q, expected pattern, min or max", key)
+		}
+	}
+	if constrained.Min != "" && constrained.Max != "" {
+		minimum, _ := argBoundValue(arg.Type, constrained.Min)
+		maximum, _ := argBoundValue(arg.Type, constrained.Max)
+		if minimum > maximum {
+			return fmt.Errorf("min %s is greater than max %s", constrained.Min, constrained.Max)
+		}
+	}
+	*arg = constrained
+	return nil
+}
+
+// checkArgBound checks that value is a valid min or max for an argument of
+// type argType.
+func checkArgBound(argType, value string) error {
+	switch argType {
+	case intType, float64Type, timeType:
+		_, err := argBoundValue(argType, value)
+		return err
+	default:
+		return fmt.Errorf("min and max only apply to int, float64 and time.Duration arguments, not %s", argType)
+	}
+}
+
+// argBoundValue parses a min or max, as a float64 so bounds of any type can
+// be compared.
+func argBoundValue(argType, value string) (float64, error) {
+	switch argType {
+	case intType:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an int", value)
+		}
+		return float64(n), nil
+	case float64Type:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a float64", value)
+		}
+		return f, nil
+	case timeType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", value)
+		}
+		return float64(d), nil
+	default:
+		return 0, fmt.Errorf("%s arguments can't have bounds", argType)
+	}
+}
+
+// goLiteral returns a min or max as a Go expression of the argument's type.
+func (a Arg) goLiteral(value string) string {
+	switch a.Type {
+	case timeType:
+		d, _ := time.ParseDuration(value)
+		return fmt.Sprintf("_time.Duration(%d)", int64(d))
+	case float64Type:
+		f, _ := strconv.ParseFloat(value, 64)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	default:
+		n, _ := strconv.Atoi(value)
+		return strconv.Itoa(n)
+	}
+}
+
+// HasConstraints reports whether the argument has any stave:arg constraints.
+func (a Arg) HasConstraints() bool {
+	return a.Pattern != "" || a.Min != "" || a.Max != ""
+}
+
+// Constraints describes the argument's stave:arg constraints, for help output.
+func (a Arg) Constraints() string {
+	var parts []string
+	if a.Pattern != "" {
+		parts = append(parts, "must match "+a.Pattern)
+	}
+	switch {
+	case a.Min != "" && a.Max != "":
+		parts = append(parts, fmt.Sprintf("between %s and %s", a.Min, a.Max))
+	case a.Min != "":
+		parts = append(parts, "at least "+a.Min)
+	case a.Max != "":
+		parts = append(parts, "at most "+a.Max)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ArgConstraintsHelp returns the "Arguments:" section of a target's -i output,
+// listing the constraints on its arguments, or "" if there are none.
+func (f Function) ArgConstraintsHelp() string {
+	width := 0
+	for _, arg := range f.Args {
+		if arg.HasConstraints() {
+			width = max(width, len(arg.Name))
+		}
+	}
+	if width == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Arguments:\n\n")
+	for _, arg := range f.Args {
+		if arg.HasConstraints() {
+			fmt.Fprintf(&b, "\t%-*s  %s\n", width, arg.Name, arg.Constraints())
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// constraintCode returns the code that checks the converted value of the
+// iArg'th argument against its constraints, calling fail if it doesn't meet
+// them.
+func (a Arg) constraintCode(iArg int, target string, fail usageFailer) string {
+	failed := func(constraint string) string {
+		return fail("invalid value %q for argument %s of target %s: %s",
+			fmt.Sprintf("_targetArgs[%d], %q, %q, %q", iArg, a.Name, target, constraint))
+	}
+	var out string
+	if a.Pattern != "" {
+		out += fmt.Sprintf(`
+				if !_regexp.MustCompile(%q).MatchString(theArg%d) {%s
+				}`, a.Pattern, iArg, failed("must match "+a.Pattern))
+	}
+	if a.Min != "" {
+		out += fmt.Sprintf(`
+				if theArg%d < %s {%s
+				}`, iArg, a.goLiteral(a.Min), failed("must be at least "+a.Min))
+	}
+	if a.Max != "" {
+		out += fmt.Sprintf(`
+				if theArg%d > %s {%s
+				}`, iArg, a.goLiteral(a.Max), failed("must be at most "+a.Max))
+	}
+	return out
+}
+
+// convertFailCode returns the code that reports that the iArg'th argument of
+// target can't be converted to its type, calling fail. The message names the
+// target, the argument and its position, and any constraints on it.
+func (a Arg) convertFailCode(iArg int, target string, fail usageFailer) string {
+	prefix := fmt.Sprintf("target %q: argument %q (position %d): can't convert ", target, a.Name, iArg+1)
+	suffix := " to " + a.Type
+	if a.HasConstraints() {
+		suffix += " (" + a.Constraints() + ")"
+	}
+	return fail("%s%q%s", fmt.Sprintf("%q, _targetArgs[%d], %q", prefix, iArg, suffix))
+}
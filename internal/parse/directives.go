@@ -0,0 +1,220 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+const directivePrefix = "stave:"
+
+const (
+	retriesDirective     = "retries"
+	retryDelayDirective  = "retry-delay"
+	requiresEnvDirective = "requires-env"
+	allowExitDirective   = "allow-exit"
+	hiddenDirective      = "hidden"
+	containerDirective   = "container"
+	exclusiveDirective   = "exclusive"
+	serialDirective      = "serial"
+	requireDirective     = "require"
+	confirmDirective     = "confirm"
+)
+
+// requireCleanGit is the stave:require value for a target that must run in a
+// git working tree with no uncommitted changes, and requireBranchPrefix
+// starts the one for a target that must run on a given git branch.
+const (
+	requireCleanGit     = "clean-git"
+	requireBranchPrefix = "branch:"
+)
+
+// directives are the stave:key[=value] lines found in a target's doc comment,
+// keyed by directive name.
+type directives map[string]string
+
+// detectDirectives collects the directives in the doc comment of every
+// function in files, keyed by getFuncKey. It must run before doc.NewFromFiles,
+// which drops "//stave:x" lines (Go directive syntax) from doc comments.
+func detectDirectives(files []*ast.File) map[string]directives {
+	out := make(map[string]directives)
+	for _, file := range files {
+		for _, d := range file.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			found := make(directives)
+			for _, comment := range fn.Doc.List {
+				key, value, ok := parseDirective(comment.Text)
+				if !ok {
+					continue
+				}
+				if _, known := knownDirectives[key]; !known {
+					slog.Warn(
+						"ignoring unknown stave directive",
+						slog.String(log.Func, getFuncKey(fn)),
+						slog.String(log.Name, directivePrefix+key),
+					)
+					continue
+				}
+				if key == requireDirective && found[key] != "" {
+					// several stave:require lines all apply.
+					value = found[key] + "," + value
+				}
+				found[key] = value
+			}
+			if len(found) > 0 {
+				out[getFuncKey(fn)] = found
+			}
+		}
+	}
+	return out
+}
+
+// parseDirective splits a "// stave:key=value" or "//stave:key" comment into
+// its key and value.
+func parseDirective(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "//") {
+		return "", "", false
+	}
+	text = strings.TrimSpace(text[2:])
+	if !strings.HasPrefix(text, directivePrefix) {
+		return "", "", false
+	}
+	text = strings.TrimPrefix(text, directivePrefix)
+	if _, isArg := isArgDirective(text); isArg {
+		// stave:arg can appear several times; see detectArgDirectives.
+		return "", "", false
+	}
+	key, value, _ := strings.Cut(text, "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" || key == "import" || key == "multiline" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// stripDirectives removes stave:key[=value] and stave:arg lines from doc text, so that
+// directives written with a space after the "//" don't show up in help output.
+func stripDirectives(docText string) string {
+	lines := strings.Split(docText, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if _, _, ok := parseDirective("//" + line); ok {
+			continue
+		}
+		if text, ok := strings.CutPrefix(strings.TrimSpace(line), directivePrefix); ok {
+			if _, isArg := isArgDirective(text); isArg {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// applyDirectives validates a target's directives and records them on funcInfo.
+func applyDirectives(funcInfo *Function, funcname string, dirs directives) error {
+	if value, ok := dirs[retriesDirective]; ok {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf(
+				"invalid %s%s value %q on %s: must be a non-negative integer",
+				directivePrefix, retriesDirective, value, funcname,
+			)
+		}
+		funcInfo.Retries = retries
+	}
+	if value, ok := dirs[retryDelayDirective]; ok {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return fmt.Errorf(
+				"invalid %s%s value %q on %s: must be a non-negative duration like 5s",
+				directivePrefix, retryDelayDirective, value, funcname,
+			)
+		}
+		if funcInfo.Retries == 0 {
+			slog.Warn(
+				"stave:retry-delay has no effect without stave:retries",
+				slog.String(log.Func, funcname),
+			)
+		}
+		funcInfo.RetryDelay = delay
+	}
+	if value, ok := dirs[requiresEnvDirective]; ok {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if !isEnvVarName(name) {
+				return fmt.Errorf(
+					"invalid %s%s value %q on %s: must be a comma-separated list of environment variable names",
+					directivePrefix, requiresEnvDirective, value, funcname,
+				)
+			}
+			funcInfo.RequiresEnv = append(funcInfo.RequiresEnv, name)
+		}
+	}
+	if _, ok := dirs[hiddenDirective]; ok {
+		funcInfo.Hidden = true
+	}
+	if value, ok := dirs[containerDirective]; ok {
+		if value == "" || strings.ContainsAny(value, " \t") {
+			return fmt.Errorf(
+				"invalid %s%s value %q on %s: must be a docker image like golang:1.23",
+				directivePrefix, containerDirective, value, funcname,
+			)
+		}
+		funcInfo.Container = value
+	}
+	if _, ok := dirs[exclusiveDirective]; ok {
+		funcInfo.Exclusive = true
+	}
+	if _, ok := dirs[serialDirective]; ok {
+		funcInfo.Serial = true
+	}
+	if value, ok := dirs[requireDirective]; ok {
+		for _, require := range strings.Split(value, ",") {
+			require = strings.TrimSpace(require)
+			branch, isBranch := strings.CutPrefix(require, requireBranchPrefix)
+			if require != requireCleanGit && (!isBranch || branch == "" || strings.ContainsAny(branch, " \t")) {
+				return fmt.Errorf(
+					"invalid %s%s value %q on %s: must be a comma-separated list of clean-git and branch:<name>",
+					directivePrefix, requireDirective, value, funcname,
+				)
+			}
+			if !slices.Contains(funcInfo.Requires, require) {
+				funcInfo.Requires = append(funcInfo.Requires, require)
+			}
+		}
+	}
+	if value, ok := dirs[confirmDirective]; ok {
+		if value == "" {
+			return fmt.Errorf(
+				"invalid %s%s value on %s: must be the question to ask, like %sconfirm=Delete dist?",
+				directivePrefix, confirmDirective, funcname, directivePrefix,
+			)
+		}
+		funcInfo.Confirm = value
+	}
+	return nil
+}
+
+// isEnvVarName reports whether name is a portable environment variable name:
+// letters, digits and underscores, not starting with a digit.
+func isEnvVarName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// ExitCall is a call, in a target or in a helper the target calls, that ends
+// the process without returning to stave, skipping its cleanup and exit status
+// handling.
+type ExitCall struct {
+	Target string         // Target is the target's name, prefixed with the receiver for namespace methods.
+	Call   string         // Call is the exiting call, like "os.Exit".
+	Via    string         // Via is the helper the call is made in, or empty if it's in the target itself.
+	Pos    token.Position // Pos is where the call is made.
+}
+
+// funcExits is what detectExitCalls finds in a single function body.
+type funcExits struct {
+	exits []ExitCall // exits are the exiting calls, without Target set.
+	calls []string   // calls are the package-level functions it calls, by name.
+}
+
+// detectExitCalls finds the exiting calls in every function in files, keyed by
+// getFuncKey, along with the package-level functions each one calls. It must
+// run before doc.NewFromFiles, which drops function bodies.
+func detectExitCalls(fset *token.FileSet, files []*ast.File) map[string]funcExits {
+	out := make(map[string]funcExits)
+	for _, file := range files {
+		names := exitImportNames(file)
+		for _, d := range file.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			var found funcExits
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				switch fun := call.Fun.(type) {
+				case *ast.Ident:
+					if fun.Obj == nil || fun.Obj.Kind == ast.Fun {
+						found.calls = append(found.calls, fun.Name)
+					}
+				case *ast.SelectorExpr:
+					pkg, ok := fun.X.(*ast.Ident)
+					if !ok {
+						return true
+					}
+					path, ok := names[pkg.Name]
+					if !ok {
+						return true
+					}
+					if _, exits := exitFuncs[path][fun.Sel.Name]; exits {
+						found.exits = append(found.exits, ExitCall{
+							Call: path + "." + fun.Sel.Name,
+							Pos:  fset.Position(call.Pos()),
+						})
+					}
+				}
+				return true
+			})
+			if len(found.exits) > 0 || len(found.calls) > 0 {
+				out[getFuncKey(fn)] = found
+			}
+		}
+	}
+	return out
+}
+
+// exitImportNames maps the names file uses for the packages in exitFuncs to
+// their import paths.
+func exitImportNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if _, ok := exitFuncs[path]; !ok {
+			continue
+		}
+		name := path
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// setExitCalls records the exiting calls made by each target, directly or in
+// the package-level functions it calls (one level deep), skipping functions
+// marked stave:allow-exit.
+func setExitCalls(pkgInfo *PkgInfo, found map[string]funcExits, funcDirectives map[string]directives) {
+	allowed := func(key string) bool {
+		_, ok := funcDirectives[key][allowExitDirective]
+		return ok
+	}
+	for _, theFunc := range pkgInfo.Funcs {
+		key := theFunc.Name
+		if theFunc.Receiver != "" {
+			key = theFunc.Receiver + "." + theFunc.Name
+		}
+		if allowed(key) {
+			continue
+		}
+		target := found[key]
+		for _, exit := range target.exits {
+			exit.Target = key
+			pkgInfo.ExitCalls = append(pkgInfo.ExitCalls, exit)
+		}
+		seen := make(map[string]struct{})
+		for _, callee := range target.calls {
+			if _, dup := seen[callee]; dup || callee == key || allowed(callee) {
+				continue
+			}
+			seen[callee] = struct{}{}
+			for _, exit := range found[callee].exits {
+				exit.Target = key
+				exit.Via = callee
+				pkgInfo.ExitCalls = append(pkgInfo.ExitCalls, exit)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ const (
 	float64Type = "float64"
 	boolType    = "bool"
 	timeType    = "time.Duration"
+	instantType = "time.Time"
 )
 
 var argTypes = map[string]string{
@@ -15,4 +16,27 @@ var argTypes = map[string]string{
 	float64Type:        float64Type,
 	boolType:           boolType,
 	"&{time Duration}": timeType,
+	"&{time Time}":     instantType,
+}
+
+// knownDirectives lists the stave:key[=value] directives accepted in target
+// doc comments.
+var knownDirectives = map[string]struct{}{
+	retriesDirective:     {},
+	retryDelayDirective:  {},
+	requiresEnvDirective: {},
+	allowExitDirective:   {},
+	hiddenDirective:      {},
+	containerDirective:   {},
+	exclusiveDirective:   {},
+	serialDirective:      {},
+	requireDirective:     {},
+	confirmDirective:     {},
+}
+
+// exitFuncs are the calls, keyed by import path, that end the process without
+// returning to stave.
+var exitFuncs = map[string]map[string]struct{}{
+	"os":  {"Exit": {}},
+	"log": {"Fatal": {}, "Fatalf": {}, "Fatalln": {}},
 }
@@ -0,0 +1,120 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// parseImportSelector splits the selector of a stave:import comment, like
+// "Build,Test", into the lowercased names it selects.
+func parseImportSelector(selector string) []string {
+	var names []string
+	for _, name := range strings.Split(selector, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// selectImportTargets drops the targets that the stave:import comments of
+// imports don't select from them, with the aliases, namespaces and lint
+// findings that go with them, so that they aren't registered, listed or
+// checked for duplicates. It returns an error listing the names selected from
+// each import that it has no target or namespace for.
+func selectImportTargets(imports []*Import) error {
+	var errs []error
+	for _, imp := range imports {
+		if err := selectTargets(imp); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// selectTargets keeps only the targets of imp that its Targets select, by
+// name, by namespace and name like "docker:build", or by namespace, which
+// keeps all of the namespace's targets. Imports that select nothing keep all
+// of them.
+func selectTargets(imp *Import) error {
+	if len(imp.Targets) == 0 {
+		return nil
+	}
+	used := make(map[string]bool, len(imp.Targets))
+	// selected reports whether the function named name, with its receiver if
+	// it's a namespace method, is selected, marking the selector name used.
+	selected := func(receiver, name string) bool {
+		candidates := []string{strings.ToLower(name)}
+		if receiver != "" {
+			candidates = []string{strings.ToLower(receiver + ":" + name), strings.ToLower(receiver)}
+		}
+		for _, sel := range candidates {
+			if slices.Contains(imp.Targets, sel) {
+				used[sel] = true
+				return true
+			}
+		}
+		return false
+	}
+	// splitKey splits "Recv.Name", as exit calls and invalid funcs are named,
+	// into its receiver and name.
+	splitKey := func(key string) (string, string) {
+		if receiver, name, ok := strings.Cut(key, "."); ok {
+			return receiver, name
+		}
+		return "", key
+	}
+
+	info := &imp.Info
+	available := make([]string, 0, len(info.Funcs)+len(info.Namespaces))
+	kept := make(Functions, 0, len(imp.Targets))
+	keptNamespaces := make(map[string]bool)
+	for _, fn := range info.Funcs {
+		name := fn.Name
+		if fn.Receiver != "" {
+			name = fn.Receiver + ":" + fn.Name
+		}
+		available = append(available, strings.ToLower(name))
+		if selected(fn.Receiver, fn.Name) {
+			kept = append(kept, fn)
+			keptNamespaces[fn.Receiver] = true
+		}
+	}
+	for _, ns := range info.Namespaces {
+		available = append(available, strings.ToLower(ns.Name))
+	}
+
+	var unknown []string
+	for _, name := range imp.Targets {
+		if !used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(available)
+		return fmt.Errorf(
+			"%s %s selects %s, which it has no target or namespace for; its targets and namespaces are: %s",
+			importTag, imp.Path, strings.Join(unknown, ", "), strings.Join(slices.Compact(available), ", "),
+		)
+	}
+
+	info.Funcs = kept
+	info.Namespaces = slices.DeleteFunc(info.Namespaces, func(ns Namespace) bool {
+		return !keptNamespaces[ns.Name]
+	})
+	for name, fn := range info.Aliases {
+		if !slices.ContainsFunc(kept, func(k *Function) bool { return k.Name == fn.Name && k.Receiver == fn.Receiver }) {
+			delete(info.Aliases, name)
+		}
+	}
+	info.InvalidFuncs = slices.DeleteFunc(info.InvalidFuncs, func(invalid InvalidFunc) bool {
+		return !selected(splitKey(invalid.Name))
+	})
+	info.ExitCalls = slices.DeleteFunc(info.ExitCalls, func(exit ExitCall) bool {
+		return !selected(splitKey(exit.Target))
+	})
+	return nil
+}
@@ -5,19 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/doc"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gobwas/glob"
 	"github.com/samber/lo"
 	"github.com/yaklabco/stave/internal"
 	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/env"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -39,29 +47,66 @@ type PkgInfo struct {
 	// PkgName is the package name (e.g., "main", "stavefile").
 	PkgName string
 	// Files are the parsed Go files that make up the package under analysis.
-	Files       []*ast.File
-	DocPkg      *doc.Package
+	Files       []*ast.File  `json:"-"`
+	DocPkg      *doc.Package `json:"-"`
 	Description string
 	Funcs       Functions
+	Namespaces  []Namespace // Namespaces are the package's st.Namespace types, in the order go/doc lists them.
 	DefaultFunc *Function
 	Aliases     map[string]*Function
+	RequiredEnv []string // RequiredEnv lists the variables of the package's RequiredEnv var, checked before any target runs.
 	Imports     Imports
 	Multiline   bool
+	// InvalidFuncs are exported functions skipped because their signatures
+	// aren't valid for targets.
+	InvalidFuncs []InvalidFunc
+	// ExitCalls are the calls targets make, directly or one call deep, that
+	// end the process without returning to stave.
+	ExitCalls []ExitCall
+
+	fset *token.FileSet
+	// relativeImports are collected before go/doc strips free-standing comments.
+	relativeImports []relativeImport
+}
+
+// InvalidFunc describes an exported function that was skipped because its
+// signature isn't valid for a target.
+type InvalidFunc struct {
+	Name   string         // Name is the function name, prefixed with the receiver for namespace methods.
+	Pos    token.Position // Pos is where the function is declared.
+	Reason string         // Reason explains, in plain English, what's wrong with the signature.
+	Hint   string         // Hint suggests how to fix the signature.
 }
 
 // Function represents a job function from a stave file.
 type Function struct {
-	PkgAlias   string
-	Package    string
-	ImportPath string
-	Name       string
-	Receiver   string
-	IsError    bool
-	IsContext  bool
-	Synopsis   string // Synopsis is a one sentence description of the function, without its leading function name.
-	Comment    string // Comment is the full comment on the function, with newlines replaced by spaces and trimmed.
-	Args       []Arg
-	IsWatch    bool
+	PkgAlias    string
+	Package     string
+	ImportPath  string
+	Name        string
+	Receiver    string
+	IsError     bool
+	IsContext   bool
+	Synopsis    string // Synopsis is a one sentence description of the function, without its leading function name.
+	Comment     string // Comment is the full comment on the function, with newlines replaced by spaces and trimmed.
+	Args        []Arg
+	IsWatch     bool
+	WatchGlobs  []string      // WatchGlobs are the patterns the target passes to watch.Watch, with DynamicWatchGlob for those that aren't string literals.
+	Retries     int           // Retries is how many times to re-run the target if it fails.
+	RetryDelay  time.Duration // RetryDelay is how long to wait between retries.
+	RequiresEnv []string      // RequiresEnv lists environment variables that must be set for the target to run.
+	Hidden      bool          // Hidden leaves the target out of `stave -l` unless --all is given.
+	Container   string        // Container is the docker image the target runs in, from stave:container.
+	Exclusive   bool          // Exclusive keeps other targets from running while the target (and its dependencies) run.
+	Serial      bool          // Serial keeps runs of the target, with any arguments, from overlapping each other.
+	Requires    []string      // Requires are the stave:require preconditions checked before the target runs, like clean-git and branch:main.
+	Confirm     string        // Confirm is the stave:confirm question the target asks before it runs, unless --yes is given.
+}
+
+// Namespace is a type of st.Namespace, whose methods are targets.
+type Namespace struct {
+	Name    string // Name is the type's name, which prefixes the target names of its methods.
+	Comment string // Comment is the doc comment on the type, formatted like Function.Comment.
 }
 
 var _ sort.Interface = (Functions)(nil)
@@ -84,6 +129,11 @@ func (s Functions) Swap(i, j int) {
 // Arg is an argument to a Function.
 type Arg struct {
 	Name, Type string
+
+	// Constraints from stave:arg directives, checked after conversion.
+	Pattern string // Pattern is a regexp that string arguments must match.
+	Min     string // Min is the smallest value allowed for numeric and duration arguments.
+	Max     string // Max is the largest value allowed for numeric and duration arguments.
 }
 
 // ID returns user-readable information about where this function is defined.
@@ -115,18 +165,77 @@ func (f Function) TargetName() string {
 // ExecCode returns code for the template switch to run the target.
 // It wraps each target call to match the func(context.Context) error that
 // runTarget requires.
-//
-
 func (f Function) ExecCode() string {
-	name := f.Name
-	if f.Receiver != "" {
-		name = f.Receiver + "{}." + name
+	out := f.parseArgsCode(exitOnUsageError) + f.requiresCode(`
+					return err`) + f.confirmCode(`
+					return err`) + f.wrapFnCode() + f.containerCode()
+	if f.Retries > 0 {
+		out += fmt.Sprintf(`
+				retryCtx, _ := getContext()
+				ret := runTargetWithRetries(retryCtx, logger, %q, wrapFn, %d, %d)`, f.TargetName(), f.Retries, int64(f.RetryDelay))
+	} else {
+		out += `
+				ret := runTarget(logger, "` + f.TargetName() + `", wrapFn)`
 	}
-	if f.Package != "" {
-		name = f.Package + "." + name
+	return out
+}
+
+// ResolveCode returns code for the st.RunTarget dispatcher's switch, which
+// resolves the target and its args to an stPkg.ResolvedTarget. Unlike
+// ExecCode, bad args and missing environment variables are returned as
+// errors rather than exiting, so the calling target can handle them.
+func (f Function) ResolveCode(stPkg string) string {
+	failed := func(format, args string) string {
+		return fmt.Sprintf(`
+					return %s.ResolvedTarget{}, _fmt.Errorf(%q, %s)`, stPkg, format, args)
+	}
+	out := f.parseArgsCode(failed) + f.requiresCode(fmt.Sprintf(`
+					return %s.ResolvedTarget{}, err`, stPkg)) + f.confirmCode(fmt.Sprintf(`
+					return %s.ResolvedTarget{}, err`, stPkg)) + f.wrapFnCode() + f.containerCode()
+	if f.Retries > 0 {
+		out += fmt.Sprintf(`
+				run := func(ctx _context.Context) error {
+					return asError(runTargetWithRetries(ctx, logger, %q, wrapFn, %d, %d))
+				}`, f.TargetName(), f.Retries, int64(f.RetryDelay))
+	} else {
+		out += `
+				run := wrapFn`
+	}
+	args := make([]string, 0, len(f.Args))
+	for x := range len(f.Args) {
+		args = append(args, fmt.Sprintf("theArg%d", x))
 	}
+	out += fmt.Sprintf(`
+				return %s.ResolvedTarget{Func: %s, Args: []any{%s}, Run: run}, nil`,
+		stPkg, f.FuncExpr(), strings.Join(args, ", "))
+	return out
+}
 
+// usageFailer returns the code run when a target's args or environment are
+// unusable, given a format string and its args as Go expressions.
+type usageFailer func(format, args string) string
+
+// exitOnUsageError logs the message and exits as a usage error, as for a
+// mistake on the command line.
+func exitOnUsageError(format, args string) string {
+	return fmt.Sprintf(`
+					logger.Printf(%q, %s)
+					exitUsage()`, format+"\n", args)
+}
+
+// parseArgsCode returns the code that checks the target's required
+// environment variables and converts and checks its args, calling fail if
+// any of them are unusable.
+func (f Function) parseArgsCode(fail usageFailer) string {
+	target := strings.ToLower(f.TargetName())
 	var parseargs string
+	for _, envVar := range f.RequiresEnv {
+		msg := fmt.Sprintf("missing required environment variable %s for target %s", envVar, target)
+		parseargs += fmt.Sprintf(`
+				if _os.Getenv(%q) == "" {%s
+				}
+				`, envVar, fail("%s", fmt.Sprintf("%q", msg)))
+	}
 	for iArg, theArg := range f.Args {
 		switch theArg.Type {
 		case stringType:
@@ -134,41 +243,85 @@ func (f Function) ExecCode() string {
 			theArg%d := _targetArgs[%d]`, iArg, iArg)
 		case intType:
 			parseargs += fmt.Sprintf(`
-				theArg%d, err := strconv.Atoi(_targetArgs[%d])
-				if err != nil {
-					logger.Printf("can't convert argument %%q to int\n", _targetArgs[%d])
-					os.Exit(2)
+				theArg%d, err := _strconv.Atoi(_targetArgs[%d])
+				if err != nil {%s
 				}
-				`, iArg, iArg, iArg)
+				`, iArg, iArg, theArg.convertFailCode(iArg, target, fail))
 		case float64Type:
 			parseargs += fmt.Sprintf(`
-				theArg%d, err := strconv.ParseFloat(_targetArgs[%d], 64)
-				if err != nil {
-					logger.Printf("can't convert argument %%q to float64\n", _targetArgs[%d])
-					os.Exit(2)
+				theArg%d, err := _strconv.ParseFloat(_targetArgs[%d], 64)
+				if err != nil {%s
 				}
-				`, iArg, iArg, iArg)
+				`, iArg, iArg, theArg.convertFailCode(iArg, target, fail))
 		case boolType:
 			parseargs += fmt.Sprintf(`
-				theArg%d, err := strconv.ParseBool(_targetArgs[%d])
-				if err != nil {
-					logger.Printf("can't convert argument %%q to bool\n", _targetArgs[%d])
-					os.Exit(2)
+				theArg%d, err := _strconv.ParseBool(_targetArgs[%d])
+				if err != nil {%s
 				}
-				`, iArg, iArg, iArg)
+				`, iArg, iArg, theArg.convertFailCode(iArg, target, fail))
 		case timeType:
 			parseargs += fmt.Sprintf(`
-				theArg%d, err := time.ParseDuration(_targetArgs[%d])
+				theArg%d, err := _time.ParseDuration(_targetArgs[%d])
+				if err != nil {%s
+				}
+				`, iArg, iArg, theArg.convertFailCode(iArg, target, fail))
+		case instantType:
+			// RFC 3339, or just a date
+			parseargs += fmt.Sprintf(`
+				theArg%d, err := _time.Parse(_time.RFC3339, _targetArgs[%d])
 				if err != nil {
-					logger.Printf("can't convert argument %%q to time.Duration\n", _targetArgs[%d])
-					os.Exit(2)
+					theArg%d, err = _time.Parse(_time.DateOnly, _targetArgs[%d])
 				}
-				`, iArg, iArg, iArg)
+				if err != nil {%s
+				}
+				`, iArg, iArg, iArg, iArg, theArg.convertFailCode(iArg, target, fail))
 		}
+		parseargs += theArg.constraintCode(iArg, target, fail)
+	}
+	return parseargs
+}
+
+// requiresCode returns the code that checks the target's stave:require
+// preconditions, running fail with the refusal in err if any isn't met. It
+// returns "" for targets without any.
+func (f Function) requiresCode(fail string) string {
+	if len(f.Requires) == 0 {
+		return ""
+	}
+	requires := make([]string, len(f.Requires))
+	for i, require := range f.Requires {
+		requires[i] = strconv.Quote(require)
+	}
+	return fmt.Sprintf(`
+				if err := checkRequires(%q, %s); err != nil {%s
+				}`, strings.ToLower(f.TargetName()), strings.Join(requires, ", "), fail)
+}
+
+// confirmCode returns the code that asks the target's stave:confirm question,
+// running fail with the refusal in err unless it's answered yes. It returns
+// "" for targets that don't ask one.
+func (f Function) confirmCode(fail string) string {
+	if f.Confirm == "" {
+		return ""
+	}
+	return fmt.Sprintf(`
+				if err := confirmTarget(%q, %q); err != nil {%s
+				}`, strings.ToLower(f.TargetName()), f.Confirm, fail)
+}
+
+// wrapFnCode returns the code declaring wrapFn, which calls the target with
+// the converted args.
+func (f Function) wrapFnCode() string {
+	name := f.Name
+	if f.Receiver != "" {
+		name = f.Receiver + "{}." + name
+	}
+	if f.Package != "" {
+		name = f.Package + "." + name
 	}
 
-	out := parseargs + `
-				wrapFn := func(ctx context.Context) error {
+	out := `
+				wrapFn := func(ctx _context.Context) error {
 					`
 	if f.IsError {
 		out += "return "
@@ -188,35 +341,116 @@ func (f Function) ExecCode() string {
 					return nil`
 	}
 	out += `
-				}
-				ret := runTarget(logger, "` + f.TargetName() + `", wrapFn)`
+				}`
 	return out
 }
 
+// containerCode returns the code that, for a target marked stave:container,
+// replaces wrapFn with one running the target in its container, unless
+// containers are turned off. It returns "" for other targets.
+func (f Function) containerCode() string {
+	if f.Container == "" {
+		return ""
+	}
+	return fmt.Sprintf(`
+				if runContainers {
+					wrapFn = func(ctx _context.Context) error {
+						return runInContainer(ctx, %q, %q, _targetArgs)
+					}
+				}`, f.Container, f.TargetName())
+}
+
+// FuncExpr returns the expression for the target function as it would be
+// passed to st.Deps, a method expression for namespace methods, so that
+// st.RunTarget, st.SetExclusive and st.SetSerial identify it the same way.
+func (f Function) FuncExpr() string {
+	name := f.Name
+	if f.Receiver != "" {
+		name = f.Receiver + "." + name
+	}
+	if f.Package != "" {
+		name = f.Package + "." + name
+	}
+	return name
+}
+
 // PrimaryPackage parses a package.  If files is non-empty, it will only parse the files given.
-func PrimaryPackage(ctx context.Context, gocmd, path string, files []string, multiline bool) (*PkgInfo, error) {
+// importAliases gives aliases, by import path, to the stave:import packages
+// whose import comment doesn't set one, as stave.yaml's import_aliases does.
+// goEnv is set in the environment of the go list commands that find the
+// imported packages, as it is in that of go build.
+func PrimaryPackage(
+	ctx context.Context,
+	gocmd, path string,
+	files []string,
+	multiline bool,
+	importAliases map[string]string,
+	goEnv map[string]string,
+) (*PkgInfo, error) {
 	info, err := Package(path, files, multiline)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := setImports(ctx, gocmd, path, info); err != nil {
+	if err := setImports(ctx, gocmd, path, info, importAliases, goEnv); err != nil {
 		return nil, err
 	}
 
 	setDefault(info)
 	setAliases(info)
+	if err := mergeImportedAliases(info); err != nil {
+		return nil, err
+	}
+	setRequiredEnv(info)
+	// Aliases can refer to imported targets, so they are only known once the
+	// imports are, after checkDupes has already run.
+	if err := checkAliasConflicts(info.Aliases, buildFuncMap(info, info.Imports)); err != nil {
+		return nil, err
+	}
 	return info, nil
 }
 
-func checkDupes(info *PkgInfo, imports []*Import) error {
-	funcs := buildFuncMap(info, imports)
-
-	if err := checkAliasConflicts(info.Aliases, funcs); err != nil {
-		return err
+// mergeImportedAliases adds the aliases each import declares in its own
+// Aliases var to info's, prefixed with the import's alias if it has one, as
+// its targets are. The root package's aliases win over imported ones of the
+// same name; two imports declaring the same alias is an error. An import's
+// Default is never used.
+func mergeImportedAliases(info *PkgInfo) error {
+	from := make(map[string]string)
+	for name := range info.Aliases {
+		from[strings.ToLower(name)] = "<current>"
+	}
+	for _, imp := range info.Imports {
+		for _, name := range slices.Sorted(maps.Keys(imp.Info.Aliases)) {
+			aliasName := name
+			if imp.Alias != "" {
+				aliasName = imp.Alias + ":" + name
+			}
+			lower := strings.ToLower(aliasName)
+			switch from[lower] {
+			case "":
+			case "<current>":
+				slog.Debug(
+					"imported alias overridden by the stavefile's",
+					slog.String(log.Alias, aliasName),
+					slog.String(log.Pkg, imp.Path),
+				)
+				continue
+			default:
+				return fmt.Errorf("alias %q is declared by both %s and %s", lower, from[lower], imp.Path)
+			}
+			if info.Aliases == nil {
+				info.Aliases = make(map[string]*Function)
+			}
+			info.Aliases[aliasName] = imp.Info.Aliases[name]
+			from[lower] = imp.Path
+		}
 	}
+	return nil
+}
 
-	return findDuplicates(funcs)
+func checkDupes(info *PkgInfo, imports []*Import) error {
+	return findDuplicates(buildFuncMap(info, imports))
 }
 
 // buildFuncMap creates a map of target names to functions for duplicate detection.
@@ -239,8 +473,17 @@ func buildFuncMap(info *PkgInfo, imports []*Import) map[string][]*Function {
 }
 
 // checkAliasConflicts checks if any aliases conflict with existing targets.
+// Target names are matched case-insensitively, so an alias like "build:a" is
+// compared against the namespaced target names (e.g. Build.A).
 func checkAliasConflicts(aliases map[string]*Function, funcs map[string][]*Function) error {
-	for aliasName, aliasFunc := range aliases {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		aliasName, aliasFunc := strings.ToLower(name), aliases[name]
 		if len(funcs[aliasName]) != 0 {
 			ids := make([]string, 0, len(funcs[aliasName]))
 			for _, f := range funcs[aliasName] {
@@ -269,20 +512,64 @@ func findDuplicates(funcs map[string][]*Function) error {
 
 	errs := make([]string, 0, len(dupes))
 	for _, dupeName := range dupes {
-		var ids []string
+		var ids, importPaths []string
 		for _, f := range funcs[dupeName] {
 			ids = append(ids, f.ID())
+			if f.ImportPath != "" && !slices.Contains(importPaths, f.ImportPath) {
+				importPaths = append(importPaths, f.ImportPath)
+			}
 		}
 		sort.Strings(ids)
+		sort.Strings(importPaths)
 		errs = append(errs, fmt.Sprintf(
-			"%q target has multiple definitions: %s\n", dupeName, strings.Join(ids, ", ")))
+			"%q target has multiple definitions: %s\n%s", dupeName, strings.Join(ids, ", "), importAliasHint(importPaths)))
 	}
 	sort.Strings(errs)
 	return errors.New(strings.Join(errs, "\n"))
 }
 
+// importAliasHint suggests how to give each of the imports at importPaths an
+// alias of its own, so that their targets no longer clash, or returns "" if
+// there are none.
+func importAliasHint(importPaths []string) string {
+	if len(importPaths) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("  hint: give the imports different aliases, in their import comments:\n")
+	for _, importPath := range importPaths {
+		fmt.Fprintf(&b, "    // %s %s\n    _ %q\n", importTag, suggestedAlias(importPath), importPath)
+	}
+	b.WriteString("  or in stave.yaml:\n    import_aliases:\n")
+	for _, importPath := range importPaths {
+		fmt.Fprintf(&b, "      %s: %s\n", importPath, suggestedAlias(importPath))
+	}
+	return b.String()
+}
+
+// suggestedAlias is the alias importAliasHint suggests for importPath: its last
+// element, lowercased, without a major version suffix.
+func suggestedAlias(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	alias := elems[len(elems)-1]
+	if version, ok := strings.CutPrefix(alias, "v"); ok && len(elems) > 1 {
+		if _, err := strconv.Atoi(version); err == nil {
+			alias = elems[len(elems)-2]
+		}
+	}
+	return strings.ToLower(alias)
+}
+
 // Package compiles information about a stave package.
 func Package(path string, files []string, multiline bool) (*PkgInfo, error) {
+	return parsePackage(path, files, multiline, true)
+}
+
+// parsePackage is Package, but only looks for directives in doc comments if
+// withDirectives is set. The st and watch packages, which stavefiles import
+// for their API rather than for targets, are parsed without them, so that
+// their docs, which talk about directives, aren't taken for any.
+func parsePackage(path string, files []string, multiline, withDirectives bool) (*PkgInfo, error) {
 	start := time.Now()
 	defer func() {
 		slog.Debug("parsed stavefiles", slog.Duration(log.Duration, time.Since(start)))
@@ -301,10 +588,27 @@ func Package(path string, files []string, multiline bool) (*PkgInfo, error) {
 	}
 
 	watchTargets := detectWatchTargets(pkgFiles)
+	funcDirectives := map[string]directives{}
+	argDirectives := map[string][]argDirectiveLine{}
+	if withDirectives {
+		funcDirectives = detectDirectives(pkgFiles)
+		argDirectives = detectArgDirectives(fset, pkgFiles)
+	}
+	relImports := findRelativeImports(pkgFiles)
+	exitCalls := detectExitCalls(fset, pkgFiles)
+	// stavefiles share package main with the generated mainfile; the
+	// stave:import packages, which can't be main, don't.
+	if pkgName == "main" {
+		if err := checkReservedNames(fset, pkgFiles); err != nil {
+			return nil, err
+		}
+	}
 
 	// Build documentation package from files to avoid relying on deprecated ast.Package
-	// Note: doc.NewFromFiles modifies pkgFiles in-place (nils out bodies), so we
-	// call detectWatchTargets before it.
+	// Note: doc.NewFromFiles modifies pkgFiles in-place (nils out bodies and drops
+	// unexported declarations, free-standing comments and directive lines), so
+	// we call detectWatchTargets, detectDirectives, detectArgDirectives,
+	// findRelativeImports, detectExitCalls and checkReservedNames before it.
 	thePackage, err := doc.NewFromFiles(fset, pkgFiles, "./")
 	if err != nil {
 		return nil, err
@@ -314,6 +618,9 @@ func Package(path string, files []string, multiline bool) (*PkgInfo, error) {
 		Files:     pkgFiles,
 		DocPkg:    thePackage,
 		Multiline: multiline,
+
+		fset:            fset,
+		relativeImports: relImports,
 	}
 
 	if multiline {
@@ -322,8 +629,13 @@ func Package(path string, files []string, multiline bool) (*PkgInfo, error) {
 		pkgInfo.Description = oneLineDoc(thePackage.Doc)
 	}
 
-	setNamespaces(pkgInfo, watchTargets)
-	setFuncs(pkgInfo, watchTargets)
+	if err := setNamespaces(pkgInfo, watchTargets, funcDirectives, argDirectives); err != nil {
+		return nil, err
+	}
+	if err := setFuncs(pkgInfo, watchTargets, funcDirectives, argDirectives); err != nil {
+		return nil, err
+	}
+	setExitCalls(pkgInfo, exitCalls, funcDirectives)
 
 	hasDupes, names := checkDupeTargets(pkgInfo)
 	if hasDupes {
@@ -343,11 +655,14 @@ func Package(path string, files []string, multiline bool) (*PkgInfo, error) {
 	return pkgInfo, nil
 }
 
-func getNamedImports(ctx context.Context, gocmd, path string, pkgs map[string]string, multiline bool) ([]*Import, error) {
+func getNamedImports(
+	ctx context.Context, gocmd, path string, pkgs map[string]string, multiline bool, goEnv map[string]string,
+) ([]*Import, error) {
 	theImports := make([]*Import, 0, len(pkgs))
-	for pkg, alias := range pkgs {
+	for _, pkg := range slices.Sorted(maps.Keys(pkgs)) {
+		alias := pkgs[pkg]
 		slog.Debug("getting import package", slog.String(log.Pkg, pkg), slog.String(log.Alias, alias))
-		imp, err := getImport(ctx, gocmd, path, pkg, alias, multiline)
+		imp, err := getImport(ctx, gocmd, path, pkg, alias, multiline, goEnv)
 		if err != nil {
 			return nil, err
 		}
@@ -357,11 +672,14 @@ func getNamedImports(ctx context.Context, gocmd, path string, pkgs map[string]st
 }
 
 // getImport returns the metadata about a package that has been stave:import'ed.
-func getImport(ctx context.Context, gocmd, path, importpath, alias string, multiline bool) (*Import, error) {
-	out, err := internal.OutputDebug(ctx, gocmd, "-C", path, "list", "-f", "{{.Dir}}||{{.Name}}", importpath)
+// goEnv is set in the environment of go list, on top of the current one.
+func getImport(
+	ctx context.Context, gocmd, path, importpath, alias string, multiline bool, goEnv map[string]string,
+) (*Import, error) {
+	out, err := internal.OutputDebugEnv(ctx, goEnv, gocmd, "-C", path, "list", "-f", "{{.Dir}}||{{.Name}}", importpath)
 	if err != nil {
 		if strings.Contains(err.Error(), "build constraints exclude all Go files") {
-			out, err = internal.OutputDebug(ctx, gocmd, "-C", path, "list", "-tags", "stave", "-f", "{{.Dir}}||{{.Name}}", importpath)
+			out, err = internal.OutputDebugEnv(ctx, goEnv, gocmd, "-C", path, "list", "-tags", "stave", "-f", "{{.Dir}}||{{.Name}}", importpath)
 		}
 		if err != nil {
 			return nil, err
@@ -380,10 +698,10 @@ func getImport(ctx context.Context, gocmd, path, importpath, alias string, multi
 	// we use go list to get the list of files, since go/parser doesn't differentiate between
 	// go files with build tags etc, and go list does. This prevents weird problems if you
 	// have more than one package in a folder because of build tags.
-	out, err = internal.OutputDebug(ctx, gocmd, "-C", path, "list", "-f", `{{join .GoFiles "||"}}`, importpath)
+	out, err = internal.OutputDebugEnv(ctx, goEnv, gocmd, "-C", path, "list", "-f", `{{join .GoFiles "||"}}`, importpath)
 	if err != nil {
 		if strings.Contains(err.Error(), "build constraints exclude all Go files") {
-			out, err = internal.OutputDebug(ctx, gocmd, "-C", path, "list", "-tags", "stave", "-f", `{{join .GoFiles "||"}}`, importpath)
+			out, err = internal.OutputDebugEnv(ctx, goEnv, gocmd, "-C", path, "list", "-tags", "stave", "-f", `{{join .GoFiles "||"}}`, importpath)
 		}
 		if err != nil {
 			return nil, err
@@ -391,7 +709,7 @@ func getImport(ctx context.Context, gocmd, path, importpath, alias string, multi
 	}
 	files := strings.Split(out, "||")
 
-	info, err := Package(dir, files, multiline)
+	info, err := parsePackage(dir, files, multiline, importpath != stPkgPath && importpath != watchPkgPath)
 	if err != nil {
 		return nil, err
 	}
@@ -405,7 +723,8 @@ func getImport(ctx context.Context, gocmd, path, importpath, alias string, multi
 		info.Funcs[idx].PkgAlias = alias
 		info.Funcs[idx].ImportPath = importpath
 	}
-	return &Import{Alias: alias, Name: name, Path: importpath, Info: *info}, nil
+	setAliases(info)
+	return &Import{Alias: alias, Name: name, Path: importpath, Dir: dir, Info: *info}, nil
 }
 
 // Import represents the data about a stave:import package.
@@ -414,7 +733,16 @@ type Import struct {
 	Name       string
 	UniqueName string // a name unique across all imports
 	Path       string
-	Info       PkgInfo
+	Dir        string // Dir is the directory of the package's source files.
+	// ModulePath and ModuleDir are set for relative imports that live outside
+	// the main module; the compile step wires them in with a replace directive.
+	ModulePath string
+	ModuleDir  string
+	// Targets are the names of the targets and namespaces that the import's
+	// comment selects, like "build,test" in "//stave:import ci build,test",
+	// lowercased. Only those are exposed; all of them are if it's empty.
+	Targets []string
+	Info    PkgInfo
 }
 
 var _ sort.Interface = (Imports)(nil)
@@ -434,23 +762,39 @@ func (s Imports) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 
-func setFuncs(pkgInfo *PkgInfo, watchTargets map[string]struct{}) {
+func setFuncs(
+	pkgInfo *PkgInfo,
+	watchTargets map[string][]string,
+	funcDirectives map[string]directives,
+	argDirectives map[string][]argDirectiveLine,
+) error {
 	for _, theFunc := range pkgInfo.DocPkg.Funcs {
 		if theFunc.Recv != "" {
 			slog.Debug("skipping method", slog.String(log.Func, theFunc.Name), slog.String("recv", theFunc.Recv))
 			// skip methods
 			continue
 		}
-		funcInfo, ok := funcFromDoc(theFunc, pkgInfo.DocPkg.ImportPath, theFunc.Name, pkgInfo.Multiline)
+		funcInfo, ok := funcFromDoc(pkgInfo, theFunc, theFunc.Name)
 		if !ok {
 			continue
 		}
 		funcInfo.IsWatch = lo.HasKey(watchTargets, theFunc.Name)
+		funcInfo.WatchGlobs = watchTargets[theFunc.Name]
+		if err := applyDirectives(funcInfo, theFunc.Name, funcDirectives[theFunc.Name]); err != nil {
+			return err
+		}
+		applyArgDirectives(funcInfo, theFunc.Name, argDirectives[theFunc.Name])
 		pkgInfo.Funcs = append(pkgInfo.Funcs, funcInfo)
 	}
+	return nil
 }
 
-func setNamespaces(pkgInfo *PkgInfo, watchTargets map[string]struct{}) {
+func setNamespaces(
+	pkgInfo *PkgInfo,
+	watchTargets map[string][]string,
+	funcDirectives map[string]directives,
+	argDirectives map[string][]argDirectiveLine,
+) error {
 	for _, theType := range pkgInfo.DocPkg.Types {
 		if !isNamespace(theType) {
 			continue
@@ -460,22 +804,41 @@ func setNamespaces(pkgInfo *PkgInfo, watchTargets map[string]struct{}) {
 			slog.String(log.ImportPath, pkgInfo.DocPkg.ImportPath),
 			slog.String(log.Type, theType.Name),
 		)
+		ns := Namespace{Name: theType.Name}
+		typeDoc := stripDirectives(theType.Doc)
+		if pkgInfo.Multiline {
+			ns.Comment = strings.TrimSuffix(typeDoc, "\n")
+		} else {
+			ns.Comment = oneLineDoc(typeDoc)
+		}
+		pkgInfo.Namespaces = append(pkgInfo.Namespaces, ns)
 		for _, theMethod := range theType.Methods {
-			funcInfo, ok := funcFromDoc(theMethod, pkgInfo.DocPkg.ImportPath, theType.Name+"."+theMethod.Name, pkgInfo.Multiline)
+			funcInfo, ok := funcFromDoc(pkgInfo, theMethod, theType.Name+"."+theMethod.Name)
 			if !ok {
 				continue
 			}
+			key := theType.Name + "." + theMethod.Name
 			funcInfo.Receiver = theType.Name
-			funcInfo.IsWatch = lo.HasKey(watchTargets, theType.Name+"."+theMethod.Name)
+			funcInfo.IsWatch = lo.HasKey(watchTargets, key)
+			funcInfo.WatchGlobs = watchTargets[key]
+			if err := applyDirectives(funcInfo, key, funcDirectives[key]); err != nil {
+				return err
+			}
+			applyArgDirectives(funcInfo, key, argDirectives[key])
 			pkgInfo.Funcs = append(pkgInfo.Funcs, funcInfo)
 		}
 	}
+	return nil
 }
 
-func funcFromDoc(theFunc *doc.Func, importpath, funcname string, multiline bool) (*Function, bool) {
+// funcFromDoc builds the target for an exported function. Exported functions
+// whose signatures aren't valid for targets are recorded in
+// pkgInfo.InvalidFuncs.
+func funcFromDoc(pkgInfo *PkgInfo, theFunc *doc.Func, funcname string) (*Function, bool) {
 	if !ast.IsExported(theFunc.Name) {
 		return nil, false
 	}
+	importpath := pkgInfo.DocPkg.ImportPath
 	funcInfo, err := funcType(theFunc.Decl.Type)
 	if err != nil {
 		slog.Debug(
@@ -484,6 +847,18 @@ func funcFromDoc(theFunc *doc.Func, importpath, funcname string, multiline bool)
 			slog.String(log.Func, funcname),
 			slog.Any(log.Error, err),
 		)
+		invalid := InvalidFunc{
+			Name:   funcname,
+			Reason: funcname + " " + err.Error(),
+		}
+		if pkgInfo.fset != nil {
+			invalid.Pos = pkgInfo.fset.Position(theFunc.Decl.Pos())
+		}
+		var sigErr *signatureError
+		if errors.As(err, &sigErr) {
+			invalid.Hint = sigErr.hint
+		}
+		pkgInfo.InvalidFuncs = append(pkgInfo.InvalidFuncs, invalid)
 		return nil, false
 	}
 	slog.Debug(
@@ -492,7 +867,8 @@ func funcFromDoc(theFunc *doc.Func, importpath, funcname string, multiline bool)
 		slog.String(log.Func, funcname),
 	)
 	funcInfo.Name = theFunc.Name
-	if multiline {
+	theFunc.Doc = stripDirectives(theFunc.Doc)
+	if pkgInfo.Multiline {
 		funcInfo.Comment = strings.TrimSuffix(theFunc.Doc, "\n")
 	} else {
 		funcInfo.Comment = oneLineDoc(theFunc.Doc)
@@ -501,9 +877,13 @@ func funcFromDoc(theFunc *doc.Func, importpath, funcname string, multiline bool)
 	return funcInfo, true
 }
 
-func setImports(ctx context.Context, gocmd, path string, pkgInfo *PkgInfo) error {
-	var rootImports []string
+func setImports(
+	ctx context.Context, gocmd, path string, pkgInfo *PkgInfo, importAliases, goEnv map[string]string,
+) error {
+	// rootImports is a set, as several stavefiles may import the same package.
+	rootImports := make(map[string]struct{})
 	importNames := make(map[string]string)
+	importTargets := make(map[string][]string)
 	for _, f := range pkgInfo.Files {
 		for _, d := range f.Decls {
 			gen, ok := d.(*ast.GenDecl)
@@ -519,10 +899,13 @@ func setImports(ctx context.Context, gocmd, path string, pkgInfo *PkgInfo) error
 				if len(gen.Specs) == 1 && gen.Lparen == token.NoPos && impspec.Doc == nil {
 					impspec.Doc = gen.Doc
 				}
-				name, alias, ok := getImportPath(impspec)
+				name, alias, targets, ok := getImportPath(impspec)
 				if !ok {
 					continue
 				}
+				if len(targets) > 0 {
+					importTargets[name] = targets
+				}
 				if alias != "" {
 					slog.Debug(
 						"found import alias",
@@ -537,17 +920,35 @@ func setImports(ctx context.Context, gocmd, path string, pkgInfo *PkgInfo) error
 						slog.String(log.ImportTag, importTag),
 						slog.String(log.Name, name),
 					)
-					rootImports = append(rootImports, name)
+					rootImports[name] = struct{}{}
 				}
 			}
 		}
 	}
-	imports, err := getNamedImports(ctx, gocmd, path, importNames, pkgInfo.Multiline)
+	imports, err := getNamedImports(ctx, gocmd, path, importNames, pkgInfo.Multiline, goEnv)
 	if err != nil {
 		return err
 	}
-	for _, s := range rootImports {
-		imp, err := getImport(ctx, gocmd, path, s, "", pkgInfo.Multiline)
+	for _, imp := range imports {
+		imp.Targets = importTargets[imp.Path]
+	}
+	// resolve in a fixed order, so that the generated mainfile and any errors
+	// don't depend on the order the stavefiles were read in.
+	for _, s := range slices.Sorted(maps.Keys(rootImports)) {
+		imp, err := getImport(ctx, gocmd, path, s, "", pkgInfo.Multiline, goEnv)
+		if err != nil {
+			return err
+		}
+		imports = append(imports, imp)
+	}
+	for _, rel := range pkgInfo.relativeImports {
+		slog.Debug(
+			"found relative import",
+			slog.String(log.ImportTag, importTag),
+			slog.String(log.Path, rel.dir),
+			slog.String(log.Alias, rel.alias),
+		)
+		imp, err := getRelativeImport(path, rel, pkgInfo.Multiline)
 		if err != nil {
 			return err
 		}
@@ -560,8 +961,14 @@ func setImports(ctx context.Context, gocmd, path string, pkgInfo *PkgInfo) error
 		// This prevents conflicts like AddRequestedTarget being defined in both st and watch.
 		if imp.Path == stPkgPath || imp.Path == watchPkgPath {
 			imp.Info.Funcs = nil
+			imp.Info.InvalidFuncs = nil
+			imp.Info.Aliases = nil
 		}
 	}
+	if err := selectImportTargets(imports); err != nil {
+		return err
+	}
+	applyImportAliases(imports, importAliases)
 
 	if err := checkDupes(pkgInfo, imports); err != nil {
 		return err
@@ -586,10 +993,50 @@ func setImports(ctx context.Context, gocmd, path string, pkgInfo *PkgInfo) error
 	return nil
 }
 
-func getImportPath(imp *ast.ImportSpec) (string, string, bool) {
+// applyImportAliases gives the imports whose import comment sets no alias the
+// one importAliases has for their import path, if any. Import paths are
+// matched case-insensitively, since config keys are lowercased. Entries that
+// match no import are reported, since they are most likely typos.
+func applyImportAliases(imports []*Import, importAliases map[string]string) {
+	used := make(map[string]bool, len(importAliases))
+	for _, imp := range imports {
+		for importPath, alias := range importAliases {
+			if !strings.EqualFold(importPath, imp.Path) {
+				continue
+			}
+			used[importPath] = true
+			if imp.Alias != "" {
+				continue
+			}
+			slog.Debug(
+				"found import alias in config",
+				slog.String(log.Alias, alias),
+				slog.String(log.Pkg, imp.Path),
+			)
+			imp.Alias = strings.ToLower(alias)
+			for _, f := range imp.Info.Funcs {
+				f.PkgAlias = imp.Alias
+			}
+		}
+	}
+	for _, importPath := range slices.Sorted(maps.Keys(importAliases)) {
+		if !used[importPath] {
+			slog.Warn(
+				"import alias in config matches no stave:import",
+				slog.String(log.Pkg, importPath),
+				slog.String(log.Alias, importAliases[importPath]),
+			)
+		}
+	}
+}
+
+// getImportPath returns the import path of imp if its comment is a
+// stave:import one, with the alias and the selected targets the comment
+// gives, if any.
+func getImportPath(imp *ast.ImportSpec) (string, string, []string, bool) {
 	path, ok := lit2string(imp.Path)
 	if !ok {
-		return "", "", false
+		return "", "", nil, false
 	}
 
 	leadingVals := getImportPathFromCommentGroup(imp.Doc)
@@ -613,25 +1060,34 @@ func getImportPath(imp *ast.ImportSpec) (string, string, bool) {
 		if imp.Name != nil {
 			alias = imp.Name.Name
 		}
-		return path, alias, true
+		return path, alias, nil, true
 	default:
-		return "", "", false
+		return "", "", nil, false
+	}
+
+	if len(vals) > 1 && isRelativeImportPath(vals[1]) {
+		// a relative directory import that happens to sit above an import
+		// spec; findRelativeImports picks these up.
+		return "", "", nil, false
 	}
 
 	switch len(vals) {
 	case 1:
 		// just the import tag, this is a root import
-		return path, "", true
+		return path, "", nil, true
 	case keyValueParts:
 		// also has an alias
-		return path, vals[1], true
+		return path, vals[1], nil, true
+	case keyValueParts + 1:
+		// an alias, and the targets to expose
+		return path, vals[1], parseImportSelector(vals[2]), true
 	default:
 		slog.Warn(
 			"ignoring malformed import tag",
 			slog.String(log.ImportTag, importTag),
 			slog.String(log.Path, path),
 		)
-		return "", "", false
+		return "", "", nil, false
 	}
 }
 
@@ -756,6 +1212,38 @@ func setAliases(pkgInfo *PkgInfo) {
 	pkgInfo.Aliases = parseAliasMap(comp, pkgInfo)
 }
 
+func setRequiredEnv(pkgInfo *PkgInfo) {
+	spec := findValueSpec(pkgInfo.DocPkg.Vars, "RequiredEnv")
+	if spec == nil {
+		return
+	}
+
+	if len(spec.Values) != 1 {
+		slog.Warn("RequiredEnv declaration has multiple values")
+		return
+	}
+
+	comp, isCompLit := spec.Values[0].(*ast.CompositeLit)
+	if !isCompLit {
+		slog.Warn("RequiredEnv declaration is not a []string literal")
+		return
+	}
+
+	for _, elem := range comp.Elts {
+		basicLit, isBasicLit := elem.(*ast.BasicLit)
+		if !isBasicLit || basicLit.Kind != token.STRING {
+			slog.Warn("RequiredEnv element is not a string literal", slog.Any(log.Elem, elem))
+			continue
+		}
+		name, isValid := lit2string(basicLit)
+		if !isValid || !isEnvVarName(name) {
+			slog.Warn("RequiredEnv element is not an environment variable name", slog.Any(log.Elem, elem))
+			continue
+		}
+		pkgInfo.RequiredEnv = append(pkgInfo.RequiredEnv, name)
+	}
+}
+
 func findValueSpec(pkgVars []*doc.Value, name string) *ast.ValueSpec {
 	for _, v := range pkgVars {
 		for _, n := range v.Names {
@@ -796,6 +1284,10 @@ func parseAliasMap(comp *ast.CompositeLit, pkgInfo *PkgInfo) map[string]*Functio
 			slog.Warn("malformed name for alias", slog.Any(log.Elem, elem))
 			continue
 		}
+		if !validAliasName(alias) {
+			slog.Warn("alias name has an empty namespace or target part", slog.String(log.Name, alias))
+			continue
+		}
 		aliasFunc, err := getFunction(kvExpr.Value, pkgInfo)
 		if err != nil {
 			slog.Warn("alias malformed", slog.Any(log.Error, err))
@@ -806,6 +1298,18 @@ func parseAliasMap(comp *ast.CompositeLit, pkgInfo *PkgInfo) map[string]*Functio
 	return aliases
 }
 
+// validAliasName reports whether alias can be typed on the command line. Alias
+// names may be namespaced, like "build:a", in which case every
+// colon-separated part must be non-empty.
+func validAliasName(alias string) bool {
+	for _, part := range strings.Split(alias, ":") {
+		if strings.TrimSpace(part) == "" {
+			return false
+		}
+	}
+	return true
+}
+
 func getFunction(exp ast.Expr, pi *PkgInfo) (*Function, error) {
 	// selector expressions are in LIFO format.
 	// So, in  foo.bar.baz the first selector.Name is actually "baz",
@@ -906,9 +1410,11 @@ func getFunction(exp ast.Expr, pi *PkgInfo) (*Function, error) {
 // getPackage parses a directory of Go files and retrieves package information.
 // Returns the package name, parsed files, and an error if encountered.
 //
-
+// Given files, it parses just those, as they are what gets compiled: stave
+// lists them with Stavefiles, for the GOOS and GOARCH of the build, and passes
+// them to go build by name. Without files, it parses the files that build for
+// the current GOOS and GOARCH with the stave tag.
 func getPackage(path string, files []string, fset *token.FileSet) (string, []*ast.File, error) {
-	// If specific files are provided, parse just those files regardless of build tags.
 	if len(files) > 0 {
 		var out []*ast.File
 		var pkgName string
@@ -936,10 +1442,12 @@ func getPackage(path string, files []string, fset *token.FileSet) (string, []*as
 
 	// Otherwise, attempt to use go/packages to respect build tags.
 	cfg := &packages.Config{
-		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax,
-		Dir:   path,
-		Fset:  fset,
-		Tests: false,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax,
+		Dir:        path,
+		Env:        env.ToAssignments(internal.EnvWithCurrentGOOS()),
+		BuildFlags: []string{"-tags=stave"},
+		Fset:       fset,
+		Tests:      false,
 	}
 	pkgs, err := packages.Load(cfg, ".")
 	if err == nil && len(pkgs) > 0 && packages.PrintErrors(pkgs) == 0 {
@@ -970,8 +1478,11 @@ func getPackage(path string, files []string, fset *token.FileSet) (string, []*as
 		// else fall through to manual parsing
 	}
 
-	// Fallback: manually parse all .go files in the directory (ignoring build tags),
-	// similar to previous behavior before removing parser.ParseDir.
+	// Fallback: manually parse the .go files in the directory whose build
+	// constraints go/packages would have respected, such as outside a module.
+	bctx := build.Default
+	bctx.GOOS, bctx.GOARCH = runtime.GOOS, runtime.GOARCH
+	bctx.BuildTags = []string{"stave"}
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to read directory: %w", err)
@@ -990,6 +1501,10 @@ func getPackage(path string, files []string, fset *token.FileSet) (string, []*as
 		if !strings.HasSuffix(name, ".go") {
 			continue
 		}
+		if match, err := bctx.MatchFile(path, name); err != nil || !match {
+			slog.Debug("skipping file excluded by build constraints", slog.String(log.Path, filepath.Join(path, name)))
+			continue
+		}
 		filesInDir = append(filesInDir, name)
 	}
 	sort.Strings(filesInDir)
@@ -1043,7 +1558,10 @@ func hasContextParam(ft *ast.FuncType) (bool, error) {
 	}
 	if len(param.Names) > 1 {
 		// something like foo, bar context.Context
-		return false, errors.New("ETOOMANYCONTEXTS")
+		return false, &signatureError{
+			reason: "takes more than one context.Context",
+			hint:   "take a single ctx context.Context as the first parameter",
+		}
 	}
 	return true, nil
 }
@@ -1054,17 +1572,29 @@ func hasErrorReturn(ft *ast.FuncType) (bool, error) {
 		// void return is ok
 		return false, nil
 	}
-	if res.NumFields() > 1 {
-		return false, errors.New("ETOOMANYRETURNS")
+	if res.NumFields() == 1 && fmt.Sprint(res.List[0].Type) == "error" {
+		return true, nil
 	}
-	ret := res.List[0]
-	if len(ret.Names) > 1 {
-		return false, errors.New("ETOOMANYERRORS")
+	return false, &signatureError{
+		reason: "returns " + resultsString(res) + "; targets may return only error or nothing",
+		hint:   "return just an error, and print or write out any other results",
 	}
-	if fmt.Sprint(ret.Type) == "error" {
-		return true, nil
+}
+
+// resultsString renders a function's results the way they'd be written in Go,
+// e.g. "string" or "(string, error)".
+func resultsString(res *ast.FieldList) string {
+	var typeStrs []string
+	for _, field := range res.List {
+		typ := types.ExprString(field.Type)
+		for range max(len(field.Names), 1) {
+			typeStrs = append(typeStrs, typ)
+		}
+	}
+	if len(typeStrs) == 1 {
+		return typeStrs[0]
 	}
-	return false, errors.New("EBADRETURNTYPE")
+	return "(" + strings.Join(typeStrs, ", ") + ")"
 }
 
 func funcType(funcTypeNode *ast.FuncType) (*Function, error) {
@@ -1087,7 +1617,7 @@ func funcType(funcTypeNode *ast.FuncType) (*Function, error) {
 		typeStr := fmt.Sprint(param.Type)
 		argType, isSupported := argTypes[typeStr]
 		if !isSupported {
-			return nil, fmt.Errorf("unsupported argument type: %s", typeStr)
+			return nil, unsupportedArgError(param)
 		}
 		// support for foo, bar string
 		for _, name := range param.Names {
@@ -1097,6 +1627,38 @@ func funcType(funcTypeNode *ast.FuncType) (*Function, error) {
 	return theFunc, nil
 }
 
+// signatureError explains why a function's signature isn't valid for a target.
+type signatureError struct {
+	reason string
+	hint   string
+}
+
+func (e *signatureError) Error() string {
+	return e.reason
+}
+
+// unsupportedArgError explains why param can't be a target argument.
+func unsupportedArgError(param *ast.Field) error {
+	typ := types.ExprString(param.Type)
+	if typ == "context.Context" {
+		return &signatureError{
+			reason: "takes a context.Context that isn't its first parameter",
+			hint:   "move the context.Context parameter to the front",
+		}
+	}
+	what := "an argument"
+	if len(param.Names) > 0 {
+		what = "argument " + param.Names[0].Name
+	}
+	return &signatureError{
+		reason: fmt.Sprintf(
+			"takes %s of type %s; target arguments may only be string, int, float64, bool, time.Duration or time.Time",
+			what, typ,
+		),
+		hint: "accept a string and convert it inside the target",
+	}
+}
+
 // sanitizeDocComment sanitizes a doc comment by replacing characters that would screw up formatting
 // in the output file.
 func sanitizeDocComment(s string) string {
@@ -1127,8 +1689,16 @@ func hasComment(pkgFiles []*ast.File, tag string) bool {
 	return false
 }
 
-func detectWatchTargets(files []*ast.File) map[string]struct{} {
-	watchTargets := make(map[string]struct{})
+// DynamicWatchGlob stands in WatchGlobs for a watch.Watch pattern that isn't a
+// string literal, so can't be known before the target runs.
+const DynamicWatchGlob = "<dynamic>"
+
+// detectWatchTargets returns the targets that call watch.Watch or watch.Deps,
+// keyed like getFuncKey, with the patterns they pass to watch.Watch. Patterns
+// that aren't valid globs are warned about here, rather than when the target
+// panics on them.
+func detectWatchTargets(files []*ast.File) map[string][]string {
+	watchTargets := make(map[string][]string)
 	for _, file := range files {
 		watchAlias := getWatchAlias(file)
 		if watchAlias == "" {
@@ -1142,9 +1712,24 @@ func detectWatchTargets(files []*ast.File) map[string]struct{} {
 			}
 
 			key := getFuncKey(fn)
-			if hasWatchCall(fn, watchAlias) {
-				watchTargets[key] = struct{}{}
+			isWatch, globs := watchCalls(fn, watchAlias)
+			if !isWatch {
+				continue
 			}
+			for _, pattern := range globs {
+				if pattern == DynamicWatchGlob {
+					continue
+				}
+				if _, err := glob.Compile(pattern); err != nil {
+					slog.Warn(
+						"invalid watch pattern",
+						slog.String(log.Target, key),
+						slog.String(log.Pattern, pattern),
+						slog.Any(log.Error, err),
+					)
+				}
+			}
+			watchTargets[key] = globs
 		}
 	}
 
@@ -1184,8 +1769,11 @@ func getFuncKey(fn *ast.FuncDecl) string {
 	return key
 }
 
-func hasWatchCall(fn *ast.FuncDecl, watchAlias string) bool {
+// watchCalls reports whether fn calls watch.Watch or watch.Deps, and returns
+// the patterns it passes to watch.Watch, in order and without duplicates.
+func watchCalls(fn *ast.FuncDecl, watchAlias string) (bool, []string) {
 	hasWatch := false
+	var globs []string
 	ast.Inspect(fn.Body, func(n ast.Node) bool {
 		call, ok := n.(*ast.CallExpr)
 		if !ok {
@@ -1196,15 +1784,29 @@ func hasWatchCall(fn *ast.FuncDecl, watchAlias string) bool {
 			return true
 		}
 		ident, ok := sel.X.(*ast.Ident)
-		if !ok {
+		if !ok || ident.Name != watchAlias {
 			return true
 		}
 
-		if ident.Name == watchAlias && (sel.Sel.Name == "Watch" || sel.Sel.Name == "Deps") {
+		switch sel.Sel.Name {
+		case "Deps":
 			hasWatch = true
-			return false
+		case "Watch":
+			hasWatch = true
+			for i, arg := range call.Args {
+				pattern := DynamicWatchGlob
+				spread := call.Ellipsis.IsValid() && i == len(call.Args)-1
+				if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING && !spread {
+					if unquoted, err := strconv.Unquote(lit.Value); err == nil {
+						pattern = unquoted
+					}
+				}
+				if !slices.Contains(globs, pattern) {
+					globs = append(globs, pattern)
+				}
+			}
 		}
 		return true
 	})
-	return hasWatch
+	return hasWatch, globs
 }
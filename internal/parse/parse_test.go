@@ -1,12 +1,25 @@
 package parse
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/doc"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/yaklabco/stave/pkg/st"
@@ -21,15 +34,16 @@ func init() {
 func TestParse(t *testing.T) {
 	ctx := t.Context()
 
-	info, err := PrimaryPackage(ctx, "go", "./testdata", []string{"func.go", "command.go", "alias.go", "repeating_synopsis.go", "subcommands.go", "watch.go"}, false)
+	info, err := PrimaryPackage(ctx, "go", "./testdata", []string{"func.go", "command.go", "alias.go", "repeating_synopsis.go", "subcommands.go", "watch.go"}, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	expected := []Function{
 		{
-			Name:    "WatchTarget",
-			IsWatch: true,
+			Name:       "WatchTarget",
+			IsWatch:    true,
+			WatchGlobs: []string{"*.go", "docs/**/*.md"},
 		},
 		{
 			Name:    "NonWatchTarget",
@@ -108,8 +122,16 @@ func TestParse(t *testing.T) {
 		t.Fatalf("expected alias of void to be Build.Baz")
 	}
 
-	if len(info.Aliases) != 2 {
-		t.Fatalf("expected to only have two aliases, but have %#v", info.Aliases)
+	f, ok = info.Aliases["build:f"]
+	if !ok {
+		t.Fatal("missing alias build:f")
+	}
+	if f.Name != "Foobar" || f.Receiver != "Build" {
+		t.Fatalf("expected alias of build:f to be Build.Foobar")
+	}
+
+	if len(info.Aliases) != 3 {
+		t.Fatalf("expected to only have three aliases, but have %#v", info.Aliases)
 	}
 
 	for _, expectedFunc := range expected {
@@ -230,6 +252,20 @@ func TestCheckDupeTargetsCaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestParseNamespaces(t *testing.T) {
+	info, err := PrimaryPackage(t.Context(), "go", "./testdata", []string{"subcommands.go"}, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Namespace{
+		{Name: "Build", Comment: "Build has the targets that build things."},
+		{Name: "Init"},
+	}
+	if !reflect.DeepEqual(info.Namespaces, want) {
+		t.Fatalf("expected namespaces %#v, got %#v", want, info.Namespaces)
+	}
+}
+
 func TestCheckDupeTargetsWithReceiver(t *testing.T) {
 	info := &PkgInfo{
 		Funcs: Functions{
@@ -243,6 +279,105 @@ func TestCheckDupeTargetsWithReceiver(t *testing.T) {
 	}
 }
 
+func TestCheckAliasConflictsNamespaced(t *testing.T) {
+	info := &PkgInfo{
+		Funcs: Functions{
+			{Name: "All", Receiver: "Build"},
+			{Name: "A", Receiver: "Test"},
+		},
+	}
+	info.Aliases = map[string]*Function{"build:a": info.Funcs[0]}
+	if err := checkAliasConflicts(info.Aliases, buildFuncMap(info, nil)); err != nil {
+		t.Fatalf("expected namespaced alias not to conflict, got %v", err)
+	}
+}
+
+func TestCheckAliasConflictsNamespacedConflict(t *testing.T) {
+	info := &PkgInfo{
+		Funcs: Functions{
+			{Name: "All", Receiver: "Build"},
+			{Name: "A", Receiver: "Build"},
+		},
+	}
+	info.Aliases = map[string]*Function{"Build:A": info.Funcs[0]}
+	err := checkAliasConflicts(info.Aliases, buildFuncMap(info, nil))
+	if err == nil {
+		t.Fatal("expected namespaced alias to conflict with Build.A")
+	}
+	if !strings.Contains(err.Error(), `alias "build:a" duplicates existing target(s): <current>.Build.A`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFindDuplicatesImportHint(t *testing.T) {
+	funcs := map[string][]*Function{
+		"build": {
+			{Name: "Build", ImportPath: "example.com/tools/v2"},
+			{Name: "Build", ImportPath: "example.com/Other"},
+		},
+	}
+	err := findDuplicates(funcs)
+	if err == nil {
+		t.Fatal("expected duplicate targets to be an error")
+	}
+	want := `"build" target has multiple definitions: example.com/Other.Build, example.com/tools/v2.Build
+  hint: give the imports different aliases, in their import comments:
+    // stave:import other
+    _ "example.com/Other"
+    // stave:import tools
+    _ "example.com/tools/v2"
+  or in stave.yaml:
+    import_aliases:
+      example.com/Other: other
+      example.com/tools/v2: tools
+`
+	if err.Error() != want {
+		t.Errorf("findDuplicates() error =\n%s\nwant:\n%s", err, want)
+	}
+
+	// local targets get no hint
+	err = findDuplicates(map[string][]*Function{"build": {{Name: "Build"}, {Name: "build"}}})
+	if err == nil || strings.Contains(err.Error(), "hint") {
+		t.Errorf("findDuplicates() error = %v, want one without a hint", err)
+	}
+}
+
+func TestApplyImportAliases(t *testing.T) {
+	build := &Function{Name: "Build", ImportPath: "example.com/Tools"}
+	lint := &Function{Name: "Lint", PkgAlias: "mine", ImportPath: "example.com/lint"}
+	imports := []*Import{
+		{Path: "example.com/Tools", Info: PkgInfo{Funcs: Functions{build}}},
+		{Path: "example.com/lint", Alias: "mine", Info: PkgInfo{Funcs: Functions{lint}}},
+	}
+	applyImportAliases(imports, map[string]string{
+		"example.com/tools": "tools",
+		"example.com/lint":  "theirs",
+		"example.com/gone":  "gone",
+	})
+
+	if imports[0].Alias != "tools" || build.TargetName() != "tools:Build" {
+		t.Errorf("config alias not applied: Alias = %q, TargetName() = %q", imports[0].Alias, build.TargetName())
+	}
+	// the alias in the import comment wins
+	if imports[1].Alias != "mine" || lint.TargetName() != "mine:Lint" {
+		t.Errorf("comment alias overridden: Alias = %q, TargetName() = %q", imports[1].Alias, lint.TargetName())
+	}
+}
+
+func TestValidAliasName(t *testing.T) {
+	for alias, want := range map[string]bool{
+		"b":       true,
+		"build:a": true,
+		"build:":  false,
+		":a":      false,
+		"a::b":    false,
+	} {
+		if got := validAliasName(alias); got != want {
+			t.Errorf("validAliasName(%q) = %v, want %v", alias, got, want)
+		}
+	}
+}
+
 func TestSanitizeSynopsis(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -291,7 +426,7 @@ func TestGetImportSelf(t *testing.T) {
 	ctx := t.Context()
 	cwd, err := os.Getwd()
 	require.NoError(t, err)
-	imp, err := getImport(ctx, "go", cwd, "github.com/yaklabco/stave/internal/parse/testdata/importself", "", false)
+	imp, err := getImport(ctx, "go", cwd, "github.com/yaklabco/stave/internal/parse/testdata/importself", "", false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -299,3 +434,715 @@ func TestGetImportSelf(t *testing.T) {
 		t.Fatalf("expected package importself, got %v", imp.Info.PkgName)
 	}
 }
+
+func TestImportedAliases(t *testing.T) {
+	info, err := PrimaryPackage(t.Context(), "go", "./testdata/importaliases", []string{"stavefile.go"}, false, nil, nil)
+	require.NoError(t, err)
+
+	targets := make(map[string]string, len(info.Aliases))
+	for name, fn := range info.Aliases {
+		targets[name] = fn.TargetName()
+	}
+	// the import's aliases are namespaced with its alias, and the
+	// stavefile's own alias of the same name wins
+	require.Equal(t, map[string]string{
+		"tools:l": "tools:Lint",
+		"tools:t": "Build",
+	}, targets)
+	// the import's Default doesn't replace the stavefile's
+	require.NotNil(t, info.DefaultFunc)
+	require.Equal(t, "Build", info.DefaultFunc.TargetName())
+}
+
+func TestImportSelector(t *testing.T) {
+	info, err := PrimaryPackage(t.Context(), "go", "./testdata/importselect", []string{"stavefile.go"}, false, nil, nil)
+	require.NoError(t, err)
+
+	// both imports have a Build, but only one of them is selected, so
+	// ci:build isn't a duplicate.
+	var names []string
+	for _, imp := range info.Imports {
+		for _, fn := range imp.Info.Funcs {
+			names = append(names, fn.TargetName())
+		}
+	}
+	slices.Sort(names)
+	require.Equal(t, []string{"ci:Build", "ci:Docker:Build", "ci:Docker:Push", "ci:Lint"}, names)
+
+	for _, imp := range info.Imports {
+		if !strings.HasSuffix(imp.Path, "/tools") {
+			require.Equal(t, []string{"build"}, imp.Targets)
+			continue
+		}
+		require.Equal(t, []string{"lint", "docker"}, imp.Targets)
+		require.Equal(t, []Namespace{{Name: "Docker"}}, imp.Info.Namespaces)
+		require.Empty(t, imp.Info.ExitCalls, "Test isn't selected, so its os.Exit isn't reported")
+	}
+	// the alias of a target that isn't selected is dropped with it.
+	require.Equal(t, []string{"ci:l"}, slices.Collect(maps.Keys(info.Aliases)))
+
+	_, err = PrimaryPackage(t.Context(), "go", "./testdata/importselect", []string{"unknown.go"}, false, nil, nil)
+	require.ErrorContains(t, err, "stave:import github.com/yaklabco/stave/internal/parse/testdata/importselect/tools "+
+		"selects deploy, docker:pull, which it has no target or namespace for; "+
+		"its targets and namespaces are: build, docker, docker:build, docker:push, lint, test")
+}
+
+func TestParseImportSelector(t *testing.T) {
+	require.Equal(t, []string{"build", "test", "docker:push"}, parseImportSelector("Build, test,,Docker:Push,BUILD"))
+	require.Nil(t, parseImportSelector(","))
+}
+
+func TestMergeImportedAliasesConflict(t *testing.T) {
+	lint := &Function{Name: "Lint", ImportPath: "example.com/a"}
+	vet := &Function{Name: "Vet", ImportPath: "example.com/b"}
+	info := &PkgInfo{Imports: Imports{
+		{Path: "example.com/a", Info: PkgInfo{Aliases: map[string]*Function{"check": lint}}},
+		{Path: "example.com/b", Info: PkgInfo{Aliases: map[string]*Function{"Check": vet}}},
+	}}
+	err := mergeImportedAliases(info)
+	require.EqualError(t, err, `alias "check" is declared by both example.com/a and example.com/b`)
+}
+
+func TestGetRelativeImport(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	imp, err := getRelativeImport(cwd, relativeImport{dir: "./testdata/importself"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "importself", imp.Info.PkgName)
+	require.Equal(t, "github.com/yaklabco/stave/internal/parse/testdata/importself", imp.Path)
+	require.Empty(t, imp.ModuleDir, "package in the main module should not need a replace")
+}
+
+func TestFindRelativeImports(t *testing.T) {
+	src := `package main
+
+//stave:import ./buildlib
+// stave:import ../tools Tools
+//stave:import ./buildlib
+//stave:import notrelative
+//stave:import ./ci ci Build,Test
+
+import "fmt"
+
+func Build() { fmt.Println() }
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "stavefile.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	got := findRelativeImports([]*ast.File{f})
+	require.Equal(t, []relativeImport{
+		{dir: "buildlib"},
+		{dir: "../tools", alias: "tools"},
+		{dir: "ci", alias: "ci", targets: []string{"build", "test"}},
+	}, got)
+}
+
+func TestPackageRespectsBuildConstraints(t *testing.T) {
+	// outside a module, go/packages fails, and the files are matched by hand.
+	dir := t.TempDir()
+	other := "windows"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+	for name, src := range map[string]string{
+		"stavefile.go":               "//go:build stave\n\npackage main\n\nfunc Build() {}\n",
+		"stavefile_" + other + ".go": "//go:build stave\n\npackage main\n\nfunc Other() {}\n",
+		"excluded.go":                "//go:build stave && " + other + "\n\npackage main\n\nfunc Excluded() {}\n",
+		"notstave.go":                "//go:build !stave\n\npackage main\n\nfunc Helper() {}\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644))
+	}
+
+	info, err := Package(dir, nil, false)
+	require.NoError(t, err)
+	names := make([]string, 0, len(info.Funcs))
+	for _, f := range info.Funcs {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"Build"}, names)
+}
+
+func TestInvalidFuncsRecorded(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+// Helper looks like a target, but complex128 isn't a supported argument type.
+func Helper(x complex128) {}
+
+func helper(x complex128) {}
+
+func Build() {}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+
+	info, err := Package(dir, []string{"stavefile.go"}, false)
+	require.NoError(t, err)
+	require.Len(t, info.Funcs, 1)
+	require.Len(t, info.InvalidFuncs, 1)
+	invalid := info.InvalidFuncs[0]
+	require.Equal(t, "Helper", invalid.Name)
+	require.Contains(t, invalid.Reason, "complex128")
+	require.NotEmpty(t, invalid.Hint)
+	require.Equal(t, 4, invalid.Pos.Line)
+}
+
+func TestDetectDirectives(t *testing.T) {
+	src := `package main
+
+// Deploy deploys.
+//
+//stave:retries=3
+// stave:retry-delay=5s
+func Deploy() error { return nil }
+
+// NS is a namespace.
+type NS st.Namespace
+
+// Up brings things up.
+//stave:retries=1
+func (NS) Up() {}
+
+// Plain has no directives.
+func Plain() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "stavefile.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	got := detectDirectives([]*ast.File{f})
+	require.Equal(t, map[string]directives{
+		"Deploy": {retriesDirective: "3", retryDelayDirective: "5s"},
+		"NS.Up":  {retriesDirective: "1"},
+	}, got)
+
+	fn := &Function{}
+	require.NoError(t, applyDirectives(fn, "Deploy", got["Deploy"]))
+	require.Equal(t, 3, fn.Retries)
+	require.Equal(t, 5*time.Second, fn.RetryDelay)
+}
+
+func TestApplyDirectivesInvalid(t *testing.T) {
+	err := applyDirectives(&Function{}, "Deploy", directives{retriesDirective: "lots"})
+	require.ErrorContains(t, err, `invalid stave:retries value "lots" on Deploy`)
+
+	err = applyDirectives(&Function{}, "Deploy", directives{retriesDirective: "1", retryDelayDirective: "soon"})
+	require.ErrorContains(t, err, `invalid stave:retry-delay value "soon" on Deploy`)
+}
+
+func TestStripDirectives(t *testing.T) {
+	require.Equal(t, "Deploy deploys.\n", stripDirectives("Deploy deploys.\nstave:retries=3\n"))
+	require.Equal(t, "Deploy deploys.\n", stripDirectives("Deploy deploys.\nstave:arg env pattern=^dev$\n"))
+}
+
+func TestRequiresEnvDirective(t *testing.T) {
+	fn := &Function{}
+	err := applyDirectives(fn, "Deploy", directives{requiresEnvDirective: "AWS_REGION, AWS_PROFILE"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"AWS_REGION", "AWS_PROFILE"}, fn.RequiresEnv)
+
+	err = applyDirectives(&Function{}, "Deploy", directives{requiresEnvDirective: "AWS_REGION,,"})
+	require.ErrorContains(t, err, `invalid stave:requires-env value "AWS_REGION,," on Deploy`)
+
+	err = applyDirectives(&Function{}, "Deploy", directives{requiresEnvDirective: "AWS-REGION"})
+	require.Error(t, err)
+}
+
+func TestContainerDirective(t *testing.T) {
+	fn := &Function{Name: "Build"}
+	require.NoError(t, applyDirectives(fn, "Build", directives{containerDirective: "golang:1.23"}))
+	require.Equal(t, "golang:1.23", fn.Container)
+	require.Contains(t, fn.ExecCode(), `runInContainer(ctx, "golang:1.23", "Build", _targetArgs)`)
+	require.NotContains(t, Function{Name: "Build"}.ExecCode(), "runInContainer")
+
+	err := applyDirectives(&Function{}, "Build", directives{containerDirective: ""})
+	require.ErrorContains(t, err, `invalid stave:container value "" on Build`)
+}
+
+func TestSerialDirective(t *testing.T) {
+	fn := &Function{Name: "Generate"}
+	require.NoError(t, applyDirectives(fn, "Generate", directives{serialDirective: ""}))
+	require.True(t, fn.Serial)
+	require.False(t, fn.Exclusive)
+
+	fn = &Function{Name: "Build"}
+	require.NoError(t, applyDirectives(fn, "Build", directives{}))
+	require.False(t, fn.Serial)
+}
+
+func TestRequireDirective(t *testing.T) {
+	src := `package main
+
+// Release releases.
+//
+//stave:require=clean-git
+// stave:require=branch:main, clean-git
+func Release() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "stavefile.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	got := detectDirectives([]*ast.File{f})
+	fn := &Function{Name: "Release"}
+	require.NoError(t, applyDirectives(fn, "Release", got["Release"]))
+	require.Equal(t, []string{"clean-git", "branch:main"}, fn.Requires)
+
+	code := fn.ExecCode()
+	require.Contains(t, code, `if err := checkRequires("release", "clean-git", "branch:main"); err != nil {`)
+	require.Contains(t, fn.ResolveCode("_st"), `return _st.ResolvedTarget{}, err`)
+	require.NotContains(t, Function{Name: "Build"}.ExecCode(), "checkRequires")
+
+	for _, value := range []string{"", "dirty-git", "branch:", "branch:my branch"} {
+		err := applyDirectives(&Function{}, "Release", directives{requireDirective: value})
+		require.ErrorContains(t, err, fmt.Sprintf("invalid stave:require value %q on Release", value))
+	}
+}
+
+func TestConfirmDirective(t *testing.T) {
+	src := `package main
+
+// Clean removes build output.
+//
+// stave:confirm=This will delete the dist directory. Continue?
+func Clean() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "stavefile.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	got := detectDirectives([]*ast.File{f})
+	fn := &Function{Name: "Clean"}
+	require.NoError(t, applyDirectives(fn, "Clean", got["Clean"]))
+	require.Equal(t, "This will delete the dist directory. Continue?", fn.Confirm)
+
+	code := fn.ExecCode()
+	require.Contains(t, code, `if err := confirmTarget("clean", "This will delete the dist directory. Continue?"); err != nil {`)
+	require.Contains(t, fn.ResolveCode("_st"), `return _st.ResolvedTarget{}, err`)
+	require.NotContains(t, Function{Name: "Build"}.ExecCode(), "confirmTarget")
+
+	err = applyDirectives(&Function{}, "Clean", directives{confirmDirective: ""})
+	require.ErrorContains(t, err, "invalid stave:confirm value on Clean")
+}
+
+func TestExclusiveDirective(t *testing.T) {
+	fn := &Function{Name: "Migrate"}
+	require.NoError(t, applyDirectives(fn, "Migrate", directives{exclusiveDirective: ""}))
+	require.True(t, fn.Exclusive)
+
+	fn = &Function{Name: "Build"}
+	require.NoError(t, applyDirectives(fn, "Build", directives{}))
+	require.False(t, fn.Exclusive)
+
+	require.Equal(t, "DB.Migrate", Function{Name: "Migrate", Receiver: "DB"}.FuncExpr())
+	require.Equal(t, "tools.DB.Migrate", Function{Name: "Migrate", Receiver: "DB", Package: "tools"}.FuncExpr())
+}
+
+func TestDetectWatchTargets(t *testing.T) {
+	src := `package main
+
+import w "github.com/yaklabco/stave/pkg/watch"
+
+var patterns = []string{"*.txt"}
+
+func Serve() {
+	w.Watch("*.go", "web/{*.html,*.css}")
+	w.Watch("*.go", "[abc")
+}
+
+func Test() {
+	w.Watch(patterns...)
+	w.Deps(Serve)
+}
+
+func Build() {}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "stavefile.go", src, 0)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	watchTargets := detectWatchTargets([]*ast.File{file})
+	require.Equal(t, map[string][]string{
+		"Serve": {"*.go", "web/{*.html,*.css}", "[abc"},
+		"Test":  {DynamicWatchGlob},
+	}, watchTargets)
+	require.Contains(t, buf.String(), `msg="invalid watch pattern" target=Serve pattern=[abc`)
+	require.Equal(t, 1, strings.Count(buf.String(), "invalid watch pattern"))
+}
+
+func TestAPIPackageDocsArentDirectives(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	// the docs of st and watch mention directives, which apply to the
+	// stavefiles' targets, not to them.
+	_, err := PrimaryPackage(t.Context(), "go", "./testdata", []string{"command.go", "watch.go"}, false, nil, nil)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "unknown stave directive")
+}
+
+func TestRequiredEnv(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+var RequiredEnv = []string{"AWS_REGION", "AWS-PROFILE", region, "DEPLOY_ENV"}
+
+const region = "REGION"
+
+func Build() {}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+
+	info, err := Package(dir, []string{"stavefile.go"}, false)
+	require.NoError(t, err)
+	setRequiredEnv(info)
+	require.Equal(t, []string{"AWS_REGION", "DEPLOY_ENV"}, info.RequiredEnv)
+}
+
+func TestRequiresEnvExecCode(t *testing.T) {
+	fn := Function{Name: "Deploy", RequiresEnv: []string{"AWS_REGION"}}
+	code := fn.ExecCode()
+	require.Contains(t, code, `if _os.Getenv("AWS_REGION") == "" {`)
+	require.Contains(t, code, `logger.Printf("%s\n", "missing required environment variable AWS_REGION for target deploy")`)
+}
+
+func TestArgDirectives(t *testing.T) {
+	src := `package main
+
+// Deploy deploys.
+// stave:arg env pattern=^(dev|prod)$
+//stave:arg replicas min=1 max=50
+// stave:retries=1
+func Deploy(env string, replicas int) {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "stavefile.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	lines := detectArgDirectives(fset, []*ast.File{f})
+	require.Len(t, lines["Deploy"], 2)
+	require.Equal(t, "stavefile.go:5:1", lines["Deploy"][1].pos.String())
+	require.Equal(t, map[string]directives{"Deploy": {retriesDirective: "1"}}, detectDirectives([]*ast.File{f}))
+
+	fn := &Function{Name: "Deploy", Args: []Arg{{Name: "env", Type: stringType}, {Name: "replicas", Type: intType}}}
+	applyArgDirectives(fn, "Deploy", lines["Deploy"])
+	require.Equal(t, []Arg{
+		{Name: "env", Type: stringType, Pattern: "^(dev|prod)$"},
+		{Name: "replicas", Type: intType, Min: "1", Max: "50"},
+	}, fn.Args)
+	require.Equal(t, "Arguments:\n\n\tenv       must match ^(dev|prod)$\n\treplicas  between 1 and 50\n\n", fn.ArgConstraintsHelp())
+}
+
+func TestApplyArgDirectiveInvalid(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr string
+	}{
+		{"env", `expected "stave:arg <name> key=value..."`},
+		{"nope min=1", `Deploy has no argument named "nope"`},
+		{"env pattern", `expected key=value, got "pattern"`},
+		{"env pattern=(", "invalid pattern"},
+		{"env min=1", "min and max only apply to int, float64 and time.Duration arguments, not string"},
+		{"replicas pattern=x", "pattern only applies to string arguments, replicas is int"},
+		{"replicas min=one", `invalid min for replicas: "one" is not an int`},
+		{"replicas min=5 max=1", "min 5 is greater than max 1"},
+		{"wait max=soon", `invalid max for wait: "soon" is not a duration`},
+		{"replicas minimum=1", `unknown constraint "minimum", expected pattern, min or max`},
+	}
+	for _, tt := range tests {
+		fn := &Function{Name: "Deploy", Args: []Arg{
+			{Name: "env", Type: stringType},
+			{Name: "replicas", Type: intType},
+			{Name: "wait", Type: timeType},
+		}}
+		err := applyArgDirective(fn, tt.text)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("applyArgDirective(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+		}
+		for _, arg := range fn.Args {
+			if arg.HasConstraints() {
+				t.Errorf("applyArgDirective(%q) recorded constraints on %s", tt.text, arg.Name)
+			}
+		}
+	}
+}
+
+func TestConvertFailExecCode(t *testing.T) {
+	fn := Function{Name: "Say", Args: []Arg{
+		{Name: "msg", Type: stringType},
+		{Name: "i", Type: intType},
+		{Name: "loud", Type: boolType},
+		{Name: "ratio", Type: float64Type, Min: "0", Max: "1"},
+	}}
+	code := fn.ExecCode()
+	require.Contains(t, code, `logger.Printf("%s%q%s\n", "target \"say\": argument \"i\" (position 2): can't convert ", _targetArgs[1], " to int")`)
+	require.Contains(t, code, `"target \"say\": argument \"loud\" (position 3): can't convert ", _targetArgs[2], " to bool")`)
+	require.Contains(t, code, `"target \"say\": argument \"ratio\" (position 4): can't convert ", _targetArgs[3], " to float64 (between 0 and 1)")`)
+	require.NotContains(t, code, `argument \"msg\"`)
+}
+
+func TestTimeArgExecCode(t *testing.T) {
+	fn := Function{Name: "Backup", Args: []Arg{
+		{Name: "at", Type: instantType},
+		{Name: "every", Type: timeType},
+	}}
+	code := fn.ExecCode()
+	require.Contains(t, code, "theArg0, err := _time.Parse(_time.RFC3339, _targetArgs[0])")
+	require.Contains(t, code, "theArg0, err = _time.Parse(_time.DateOnly, _targetArgs[0])")
+	require.Contains(t, code, `"target \"backup\": argument \"at\" (position 1): can't convert ", _targetArgs[0], " to time.Time")`)
+
+	// the generated code must compile in the mainfile's setting
+	src := `package main
+
+import (
+	_context "context"
+	_log "log"
+	_os "os"
+	_strconv "strconv"
+	_time "time"
+	"time"
+)
+
+func Backup(at time.Time, every time.Duration) {}
+
+func main() {
+	_ = _strconv.Atoi
+	logger := _log.New(_os.Stderr, "", 0)
+	exitUsage := func() { _os.Exit(2) }
+	runTarget := func(_ *_log.Logger, _ string, fn func(_context.Context) error) any {
+		return fn(_context.Background())
+	}
+	_targetArgs := _os.Args[1:]
+	run := func() any {` + code + `
+		return ret
+	}
+	_ = run()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "mainfile.go", src, 0)
+	require.NoError(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("main", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+}
+
+func TestArgConstraintsExecCode(t *testing.T) {
+	fn := Function{Name: "Deploy", Args: []Arg{
+		{Name: "env", Type: stringType, Pattern: `^"dev"$`},
+		{Name: "wait", Type: timeType, Min: "1s"},
+		{Name: "ratio", Type: float64Type, Max: "1e3"},
+	}}
+	code := fn.ExecCode()
+	require.Contains(t, code, "if !_regexp.MustCompile(\"^\\\"dev\\\"$\").MatchString(theArg0) {")
+	require.Contains(t, code, `_targetArgs[0], "env", "deploy", "must match ^\"dev\"$")`)
+	require.Contains(t, code, "if theArg1 < _time.Duration(1000000000) {")
+	require.Contains(t, code, "if theArg2 > 1000 {")
+	require.Contains(t, code, `"must be at most 1e3")`)
+}
+
+func TestResolveCode(t *testing.T) {
+	fn := Function{Name: "Deploy", Receiver: "Cloud", Package: "cloud", Retries: 2, Args: []Arg{
+		{Name: "env", Type: stringType, Pattern: "^(dev|prod)$"},
+		{Name: "replicas", Type: intType},
+	}, RequiresEnv: []string{"AWS_REGION"}}
+	code := fn.ResolveCode("_st")
+	require.NotContains(t, code, "exitUsage()")
+	require.Contains(t, code, `return _st.ResolvedTarget{}, _fmt.Errorf("%s", "missing required environment variable AWS_REGION for target cloud:deploy")`)
+	require.Contains(t, code, `return _st.ResolvedTarget{}, _fmt.Errorf("%s%q%s", "target \"cloud:deploy\": argument \"replicas\" (position 2): can't convert ", _targetArgs[1], " to int")`)
+	require.Contains(t, code, `return _st.ResolvedTarget{}, _fmt.Errorf("invalid value %q for argument %s of target %s: %s", _targetArgs[0], "env", "cloud:deploy", "must match ^(dev|prod)$")`)
+	require.Contains(t, code, `return asError(runTargetWithRetries(ctx, logger, "Cloud:Deploy", wrapFn, 2, 0))`)
+	require.Contains(t, code, "return _st.ResolvedTarget{Func: cloud.Cloud.Deploy, Args: []any{theArg0, theArg1}, Run: run}, nil")
+}
+
+func TestExitCalls(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+import (
+	"log"
+	osx "os"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Build exits directly.
+func Build() {
+	osx.Exit(1)
+}
+
+// Deploy exits in a helper it calls.
+func Deploy() {
+	mustLogin()
+}
+
+// Test calls a helper that is allowed to exit.
+func Test() {
+	fatal()
+}
+
+// Clean exits two calls deep, which isn't checked.
+func Clean() {
+	wrapper()
+}
+
+// Lint returns an error, as targets should.
+func Lint() error {
+	return st.Fatal(2, "lint failed")
+}
+
+// NS is a namespace.
+type NS st.Namespace
+
+// Down exits directly.
+func (NS) Down() {
+	log.Fatalf("down: %v", 1)
+}
+
+func mustLogin() {
+	osx.Exit(3)
+}
+
+// fatal is how this file gives up.
+//
+//stave:allow-exit
+func fatal() {
+	log.Fatal("giving up")
+}
+
+func wrapper() {
+	mustLogin()
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+
+	info, err := Package(dir, []string{"stavefile.go"}, false)
+	require.NoError(t, err)
+	got := make(map[string]ExitCall)
+	for _, exit := range info.ExitCalls {
+		got[exit.Target] = exit
+	}
+	require.Len(t, got, 3)
+
+	require.Equal(t, "os.Exit", got["Build"].Call)
+	require.Empty(t, got["Build"].Via)
+	require.Equal(t, 12, got["Build"].Pos.Line)
+
+	require.Equal(t, "os.Exit", got["Deploy"].Call)
+	require.Equal(t, "mustLogin", got["Deploy"].Via)
+	require.Equal(t, 44, got["Deploy"].Pos.Line)
+
+	require.Equal(t, "log.Fatalf", got["NS.Down"].Call)
+	require.NotContains(t, got, "Test")
+	require.NotContains(t, got, "Clean")
+	require.NotContains(t, got, "Lint")
+}
+
+func TestHiddenDirective(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+import "github.com/yaklabco/stave/pkg/st"
+
+// Build builds.
+func Build() {}
+
+// Plumbing is run by other tools.
+//
+//stave:hidden
+func Plumbing() {}
+
+// NS is a namespace.
+type NS st.Namespace
+
+// Sync is internal too.
+//stave:hidden
+func (NS) Sync() {}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+
+	info, err := Package(dir, []string{"stavefile.go"}, false)
+	require.NoError(t, err)
+	hidden := make(map[string]bool)
+	for _, fn := range info.Funcs {
+		hidden[fn.TargetName()] = fn.Hidden
+	}
+	require.Equal(t, map[string]bool{"Build": false, "Plumbing": true, "NS:Sync": true}, hidden)
+
+	for _, fn := range info.Funcs {
+		require.NotContains(t, fn.Comment, "stave:hidden")
+	}
+}
+
+func TestMainfileBuiltins(t *testing.T) {
+	src, err := os.ReadFile("../../pkg/stave/templates/mainfile.gotmpl")
+	require.NoError(t, err)
+
+	// scanning the template as Go finds the identifiers in its code, leaving
+	// out its comments and strings.
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("mainfile.gotmpl", -1, len(src)), src, nil, 0)
+	used := make(map[string]struct{})
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT && types.Universe.Lookup(lit) != nil {
+			used[lit] = struct{}{}
+		}
+	}
+	for name := range used {
+		require.Contains(t, mainfileBuiltins, name, "the mainfile uses %s, so stavefiles can't declare it", name)
+	}
+}
+
+func TestReservedNames(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+import "fmt"
+
+// Logger and Ctx share their names, lowercased, with the mainfile's locals.
+func Logger() { fmt.Println(signal()) }
+
+func Ctx() {}
+
+// signal and time share theirs with packages it imports, under other names.
+func signal() string { return "logger" }
+
+var time = 1
+
+// max and copy are predeclared, but the mainfile doesn't use them.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var copy = max(1, 2)
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+	info, err := PrimaryPackage(t.Context(), "go", dir, []string{"stavefile.go"}, false, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, info.Funcs, 2)
+
+	src = `package main
+
+func main() {}
+
+type error struct{}
+
+var _os, _ = 1, 2
+
+func init() {}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(src), 0o644))
+	_, err = PrimaryPackage(t.Context(), "go", dir, []string{"stavefile.go"}, false, nil, nil)
+	require.Error(t, err)
+	lines := strings.Split(err.Error(), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "stavefile.go:3:6: main can't be declared in a stavefile: the generated mainfile declares func main")
+	require.Contains(t, lines[1], "stavefile.go:5:6: error can't be declared in a stavefile: it would shadow Go's predeclared error")
+	require.Contains(t, lines[2], "stavefile.go:7:5: _os can't be declared in a stavefile: the generated mainfile imports a package as _os")
+}
@@ -0,0 +1,238 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/log"
+	"golang.org/x/mod/modfile"
+)
+
+// relativeImport is a stave:import directive that names a directory relative
+// to the stavefiles dir (e.g. "//stave:import ./buildlib") rather than a Go
+// import path.
+type relativeImport struct {
+	dir     string
+	alias   string
+	targets []string // targets are the lowercased names of the targets and namespaces to expose, or nil for all of them.
+}
+
+// isRelativeImportPath reports whether p is a "./" or "../" directory path.
+func isRelativeImportPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../")
+}
+
+// findRelativeImports collects the relative stave:import directives in files.
+// Since a stavefile cannot itself import a relative path, these are written
+// as free-standing comments anywhere in the file, with an optional alias and
+// the targets to expose:
+//
+//	//stave:import ./buildlib
+//	//stave:import ../tools tools
+//	//stave:import ../tools tools Lint,Vet
+func findRelativeImports(files []*ast.File) []relativeImport {
+	var (
+		out  []relativeImport
+		seen = make(map[string]struct{})
+	)
+	for _, f := range files {
+		for _, group := range f.Comments {
+			for _, comment := range group.List {
+				if !strings.HasPrefix(comment.Text, "//") {
+					continue
+				}
+				vals := strings.Fields(comment.Text[2:])
+				if len(vals) < 2 || strings.ToLower(vals[0]) != importTag || !isRelativeImportPath(vals[1]) {
+					continue
+				}
+				if len(vals) > 4 {
+					slog.Warn(
+						"ignoring malformed import tag",
+						slog.String(log.ImportTag, importTag),
+						slog.String(log.Path, vals[1]),
+					)
+					continue
+				}
+				imp := relativeImport{dir: path.Clean(vals[1])}
+				if len(vals) >= 3 {
+					imp.alias = strings.ToLower(vals[2])
+				}
+				if len(vals) == 4 {
+					imp.targets = parseImportSelector(vals[3])
+				}
+				key := strings.Join(append([]string{imp.dir, imp.alias}, imp.targets...), " ")
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				out = append(out, imp)
+			}
+		}
+	}
+	return out
+}
+
+// RelativeImportFiles returns the Go files of every package pulled in by a
+// relative stave:import directive in the given stavefiles, so callers can
+// include them when hashing the stavefiles.
+func RelativeImportFiles(dir string, files []string) ([]string, error) {
+	fset := token.NewFileSet()
+	astFiles := make([]*ast.File, 0, len(files))
+	for _, name := range files {
+		theASTFile, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse file %s: %w", name, err)
+		}
+		astFiles = append(astFiles, theASTFile)
+	}
+
+	var out []string
+	for _, imp := range findRelativeImports(astFiles) {
+		pkgDir := filepath.Join(dir, filepath.FromSlash(imp.dir))
+		goFiles, err := relativeImportGoFiles(pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range goFiles {
+			out = append(out, filepath.Join(pkgDir, name))
+		}
+	}
+	return out, nil
+}
+
+// relativeImportGoFiles lists the buildable Go files in pkgDir, retrying with
+// the stave build tag if no files match without it. This mirrors what getImport
+// does with go list for regular import paths.
+func relativeImportGoFiles(pkgDir string) ([]string, error) {
+	bctx := build.Default
+	pkg, err := bctx.ImportDir(pkgDir, 0)
+	if err != nil {
+		var noGoErr *build.NoGoError
+		if !errors.As(err, &noGoErr) {
+			return nil, fmt.Errorf("reading stave:import directory %s: %w", pkgDir, err)
+		}
+		bctx.BuildTags = []string{"stave"}
+		pkg, err = bctx.ImportDir(pkgDir, 0)
+		if err != nil {
+			return nil, fmt.Errorf("reading stave:import directory %s: %w", pkgDir, err)
+		}
+	}
+	return pkg.GoFiles, nil
+}
+
+// getRelativeImport returns the metadata about a package that has been
+// stave:import'ed by a directory path relative to the stavefiles dir.
+func getRelativeImport(stavePath string, imp relativeImport, multiline bool) (*Import, error) {
+	pkgDir, err := filepath.Abs(filepath.Join(stavePath, filepath.FromSlash(imp.dir)))
+	if err != nil {
+		return nil, fmt.Errorf("resolving stave:import %s: %w", imp.dir, err)
+	}
+	importPath, modDir, modPath, err := resolveRelativeImport(stavePath, pkgDir, imp.dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := relativeImportGoFiles(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug(
+		"got relative import package",
+		slog.String(log.Pkg, importPath), slog.String(log.Dir, pkgDir),
+	)
+
+	info, err := Package(pkgDir, files, multiline)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range info.Funcs {
+		info.Funcs[idx].PkgAlias = imp.alias
+		info.Funcs[idx].ImportPath = importPath
+	}
+	setAliases(info)
+	return &Import{
+		Alias:      imp.alias,
+		Name:       info.PkgName,
+		Path:       importPath,
+		Dir:        pkgDir,
+		ModulePath: modPath,
+		ModuleDir:  modDir,
+		Targets:    imp.targets,
+		Info:       *info,
+	}, nil
+}
+
+// resolveRelativeImport works out the Go import path for pkgDir. When pkgDir
+// lives in a different module from the stavefiles, the returned module dir and
+// path are non-empty, and the compile step must wire that module in with a
+// replace directive.
+func resolveRelativeImport(stavePath, pkgDir, rel string) (string, string, string, error) {
+	absStavePath, err := filepath.Abs(stavePath)
+	if err != nil {
+		return "", "", "", fmt.Errorf("resolving stavefiles dir: %w", err)
+	}
+	mainDir, _, err := FindModuleRoot(absStavePath)
+	if err != nil {
+		return "", "", "", err
+	}
+	pkgModDir, pkgModPath, err := FindModuleRoot(pkgDir)
+	if err != nil {
+		return "", "", "", err
+	}
+	switch {
+	case mainDir == "":
+		return "", "", "", fmt.Errorf(
+			"stave:import %s: stavefiles in %s are not part of a Go module, so relative imports cannot be resolved",
+			rel, absStavePath,
+		)
+	case pkgModDir == "":
+		return "", "", "", fmt.Errorf(
+			"stave:import %s: %s is outside the module at %s and has no go.mod of its own to replace it with",
+			rel, pkgDir, mainDir,
+		)
+	}
+
+	relDir, err := filepath.Rel(pkgModDir, pkgDir)
+	if err != nil {
+		return "", "", "", fmt.Errorf("resolving stave:import %s: %w", rel, err)
+	}
+	importPath := path.Join(pkgModPath, filepath.ToSlash(relDir))
+	if pkgModDir == mainDir {
+		return importPath, "", "", nil
+	}
+	return importPath, pkgModDir, pkgModPath, nil
+}
+
+// FindModuleRoot walks up from dir looking for a go.mod file, returning the
+// directory containing it and the module path it declares. Both are empty if
+// no go.mod is found.
+func FindModuleRoot(dir string) (string, string, error) {
+	for cur := dir; ; {
+		goMod := filepath.Join(cur, "go.mod")
+		data, err := os.ReadFile(goMod)
+		switch {
+		case err == nil:
+			modPath := modfile.ModulePath(data)
+			if modPath == "" {
+				return "", "", fmt.Errorf("no module directive in %s", goMod)
+			}
+			return cur, modPath, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", "", fmt.Errorf("reading %s: %w", goMod, err)
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return "", "", nil
+		}
+		cur = parent
+	}
+}
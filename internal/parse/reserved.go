@@ -0,0 +1,122 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// mainfileImports are the names the generated mainfile imports packages as.
+// They are all prefixed with an underscore so that stavefiles are free to use
+// the plain names. Keep them in step with the imports of
+// pkg/stave/templates/mainfile.gotmpl.
+var mainfileImports = map[string]struct{}{
+	"_bytes":    {},
+	"_context":  {},
+	"_debug":    {},
+	"_exec":     {},
+	"_filepath": {},
+	"_flag":     {},
+	"_fmt":      {},
+	"_io":       {},
+	"_json":     {},
+	"_log":      {},
+	"_os":       {},
+	"_regexp":   {},
+	"_runtime":  {},
+	"_signal":   {},
+	"_sort":     {},
+	"_strconv":  {},
+	"_strings":  {},
+	"_syscall":  {},
+	"_time":     {},
+}
+
+// mainfileBuiltins are the predeclared identifiers the generated mainfile,
+// and the code parse generates for it, use. A stavefile declaring one at
+// package level would shadow it there too, while the others, like max or
+// copy, are free to use. Keep them in step with
+// pkg/stave/templates/mainfile.gotmpl.
+var mainfileBuiltins = map[string]struct{}{
+	"any":     {},
+	"append":  {},
+	"bool":    {},
+	"byte":    {},
+	"error":   {},
+	"false":   {},
+	"int":     {},
+	"int64":   {},
+	"iota":    {},
+	"len":     {},
+	"make":    {},
+	"min":     {},
+	"nil":     {},
+	"recover": {},
+	"rune":    {},
+	"string":  {},
+	"true":    {},
+	"uint8":   {},
+}
+
+// reservedReason returns why a package-level declaration in the stavefiles
+// can't be named name, as the generated mainfile, which is compiled into the
+// same package, needs the name for itself, or "" if it can be. Everything else
+// the mainfile declares is local to its main func, where it doesn't clash with
+// the stavefiles' declarations. Go keywords aren't checked, as go/parser
+// already rejects declarations named after them.
+func reservedReason(name string) string {
+	switch {
+	case name == "main":
+		return "the generated mainfile declares func main"
+	case strings.Contains(name, "_staveimport"):
+		return "the generated mainfile names stave:import packages like it"
+	}
+	if _, ok := mainfileBuiltins[name]; ok {
+		return "it would shadow Go's predeclared " + name + " in the generated mainfile too"
+	}
+	if _, ok := mainfileImports[name]; ok {
+		return "the generated mainfile imports a package as " + name
+	}
+	return ""
+}
+
+// checkReservedNames returns an error listing the package-level declarations
+// in files, targets included, whose names would make the generated mainfile
+// fail to compile.
+func checkReservedNames(fset *token.FileSet, files []*ast.File) error {
+	var errs []error
+	check := func(ident *ast.Ident) {
+		if ident.Name == "_" {
+			return
+		}
+		if reason := reservedReason(ident.Name); reason != "" {
+			errs = append(errs, fmt.Errorf("%s: %s can't be declared in a stavefile: %s; rename it",
+				fset.Position(ident.Pos()), ident.Name, reason))
+		}
+	}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				// init funcs may be declared any number of times.
+				if decl.Recv == nil && decl.Name.Name != "init" {
+					check(decl.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						check(spec.Name)
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							check(name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
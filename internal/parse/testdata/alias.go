@@ -3,6 +3,7 @@
 package main
 
 var Aliases = map[string]any{
-	"void": ReturnsVoid,
-	"baz":  Build.Baz,
+	"void":    ReturnsVoid,
+	"baz":     Build.Baz,
+	"build:f": Build.Foobar,
 }
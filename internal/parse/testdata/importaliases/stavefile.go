@@ -0,0 +1,20 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	//stave:import tools
+	_ "github.com/yaklabco/stave/internal/parse/testdata/importaliases/tools"
+)
+
+var Aliases = map[string]any{
+	"tools:t": Build,
+}
+
+var Default = Build
+
+func Build() {
+	fmt.Println("built")
+}
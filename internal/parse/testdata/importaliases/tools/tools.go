@@ -0,0 +1,18 @@
+package tools
+
+import "fmt"
+
+var Aliases = map[string]any{
+	"l": Lint,
+	"t": Test,
+}
+
+var Default = Lint
+
+func Lint() {
+	fmt.Println("lint")
+}
+
+func Test() {
+	fmt.Println("test")
+}
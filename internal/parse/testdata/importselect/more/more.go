@@ -0,0 +1,11 @@
+package more
+
+import "fmt"
+
+func Build() {
+	fmt.Println("more build")
+}
+
+func Test() {
+	fmt.Println("more test")
+}
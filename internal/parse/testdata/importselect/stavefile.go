@@ -0,0 +1,17 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	//stave:import ci Lint,Docker
+	_ "github.com/yaklabco/stave/internal/parse/testdata/importselect/tools"
+
+	//stave:import ci Build
+	_ "github.com/yaklabco/stave/internal/parse/testdata/importselect/more"
+)
+
+func Test() {
+	fmt.Println("tested")
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+var Aliases = map[string]any{
+	"l": Lint,
+	"t": Test,
+}
+
+func Lint() {
+	fmt.Println("lint")
+}
+
+func Test() {
+	fmt.Println("test")
+	os.Exit(1)
+}
+
+func Build() {
+	fmt.Println("build")
+}
+
+type Docker st.Namespace
+
+func (Docker) Build() {
+	fmt.Println("docker build")
+}
+
+func (Docker) Push() {
+	fmt.Println("docker push")
+}
@@ -0,0 +1,8 @@
+//go:build stave
+
+package main
+
+import (
+	//stave:import ci Lint,Deploy,docker:pull
+	_ "github.com/yaklabco/stave/internal/parse/testdata/importselect/tools"
+)
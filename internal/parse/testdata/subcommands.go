@@ -4,6 +4,7 @@ package main
 
 import "github.com/yaklabco/stave/pkg/st"
 
+// Build has the targets that build things.
 type Build st.Namespace
 
 func (Build) Foobar() error {
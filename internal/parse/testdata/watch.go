@@ -7,7 +7,7 @@ import (
 )
 
 func WatchTarget() {
-	watch.Watch("*.go")
+	watch.Watch("*.go", "docs/**/*.md")
 }
 
 func NonWatchTarget() {
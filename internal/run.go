@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"runtime"
 	"strings"
 
@@ -52,7 +53,14 @@ func RunDebug(ctx context.Context, cmd string, args ...string) error {
 }
 
 func OutputDebug(ctx context.Context, cmd string, args ...string) (string, error) {
+	return OutputDebugEnv(ctx, nil, cmd, args...)
+}
+
+// OutputDebugEnv is OutputDebug with extraEnv set in the command's
+// environment, on top of the current one.
+func OutputDebugEnv(ctx context.Context, extraEnv map[string]string, cmd string, args ...string) (string, error) {
 	theEnv := EnvWithCurrentGOOS()
+	maps.Copy(theEnv, extraEnv)
 
 	outBuf := &bytes.Buffer{}
 	errBuf := &bytes.Buffer{}
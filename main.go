@@ -15,10 +15,7 @@ func actualMain() int {
 	ctx := context.Background()
 
 	rootCmd := stave.NewRootCmd(ctx)
+	rootCmd.SetArgs(stave.NormalizeArgs(os.Args[1:]))
 
-	if err := stave.ExecuteWithFang(ctx, rootCmd); err != nil {
-		return 1
-	}
-
-	return 0
+	return stave.ExitCode(stave.ExecuteWithFang(ctx, rootCmd))
 }
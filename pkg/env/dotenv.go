@@ -0,0 +1,238 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadDotenvFile reads and parses the dotenv file at path. See ParseDotenv.
+func ReadDotenvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := ParseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// ParseDotenv parses the contents of a dotenv file: one KEY=VALUE assignment
+// per line, optionally prefixed with "export". Blank lines and lines starting
+// with # are ignored. Values may be
+//
+//   - unquoted, in which case they are trimmed and end at a " #" comment,
+//   - single-quoted, in which case they are taken literally, or
+//   - double-quoted, in which case \n, \r, \t, \", \\ and \$ escapes are
+//     interpreted.
+//
+// Quoted values may span several lines. Nothing is expanded or executed:
+// "$VAR" and "$(cmd)" are kept as they are. Later assignments of a key
+// override earlier ones.
+func ParseDotenv(content string) (map[string]string, error) {
+	p := dotenvParser{src: strings.ReplaceAll(content, "\r\n", "\n"), line: 1}
+	vars := make(map[string]string)
+	for {
+		p.skipBlankAndComments()
+		if p.eof() {
+			return vars, nil
+		}
+		key, value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine skips to the start of the next line.
+func (p *dotenvParser) skipLine() {
+	for !p.eof() {
+		if p.next() == '\n' {
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) skipBlankAndComments() {
+	for !p.eof() {
+		p.skipSpaces()
+		if p.eof() {
+			return
+		}
+		switch p.peek() {
+		case '\n', '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine consumes trailing whitespace and an optional comment after a
+// value.
+func (p *dotenvParser) endOfLine() error {
+	p.skipSpaces()
+	if p.eof() {
+		return nil
+	}
+	switch p.peek() {
+	case '\n', '#':
+		p.skipLine()
+		return nil
+	default:
+		return p.errorf("unexpected %q after quoted value", p.peek())
+	}
+}
+
+func (p *dotenvParser) assignment() (string, string, error) {
+	start := p.pos
+	for !p.eof() && p.peek() != '=' && p.peek() != '\n' {
+		p.pos++
+	}
+	key := strings.TrimSpace(p.src[start:p.pos])
+	if rest, ok := strings.CutPrefix(key, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		key = strings.TrimSpace(rest)
+	}
+	if p.eof() || p.peek() != '=' {
+		return "", "", p.errorf("expected KEY=VALUE, got %q", key)
+	}
+	if !validDotenvKey(key) {
+		return "", "", p.errorf("invalid variable name %q", key)
+	}
+	p.pos++ // '='
+
+	p.skipSpaces()
+	if p.eof() {
+		return key, "", nil
+	}
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.singleQuoted()
+	case '"':
+		value, err = p.doubleQuoted()
+	default:
+		return key, p.unquoted(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, p.endOfLine()
+}
+
+func (p *dotenvParser) unquoted() string {
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		p.pos++
+	}
+	value := strings.TrimSpace(p.src[start:p.pos])
+	p.skipLine()
+	return value
+}
+
+func (p *dotenvParser) singleQuoted() (string, error) {
+	startLine := p.line
+	p.next() // opening quote
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.next()
+			return value, nil
+		}
+		p.next()
+	}
+	p.line = startLine
+	return "", p.errorf("unterminated single-quoted value")
+}
+
+func (p *dotenvParser) doubleQuoted() (string, error) {
+	startLine := p.line
+	p.next() // opening quote
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			switch e := p.next(); e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.line = startLine
+	return "", p.errorf("unterminated double-quoted value")
+}
+
+func validDotenvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == '.' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"simple", "FOO=bar\nBAZ=qux\n", map[string]string{"FOO": "bar", "BAZ": "qux"}},
+		{"no trailing newline", "FOO=bar", map[string]string{"FOO": "bar"}},
+		{"crlf", "FOO=bar\r\nBAZ=qux\r\n", map[string]string{"FOO": "bar", "BAZ": "qux"}},
+		{"comments and blanks", "# a comment\n\n  # indented\nFOO=bar\n", map[string]string{"FOO": "bar"}},
+		{"export prefix", "export FOO=bar\nexport\tBAZ=qux", map[string]string{"FOO": "bar", "BAZ": "qux"}},
+		{"key named export", "export=1", map[string]string{"export": "1"}},
+		{"spaces around", "  FOO = bar  \n", map[string]string{"FOO": "bar"}},
+		{"empty value", "FOO=\nBAR=", map[string]string{"FOO": "", "BAR": ""}},
+		{"inline comment", "FOO=bar # comment", map[string]string{"FOO": "bar"}},
+		{"hash without space", "FOO=bar#baz", map[string]string{"FOO": "bar#baz"}},
+		{"equals in value", "URL=http://x?a=b", map[string]string{"URL": "http://x?a=b"}},
+		{"single quoted", `FOO='bar # not a comment \n'`, map[string]string{"FOO": `bar # not a comment \n`}},
+		{"double quoted", `FOO="a\tb\nc \"d\" \\ \$HOME"`, map[string]string{"FOO": "a\tb\nc \"d\" \\ $HOME"}},
+		{"double quoted comment", `FOO="bar" # comment`, map[string]string{"FOO": "bar"}},
+		{"multiline", "KEY=\"line1\nline2\"\nNEXT=1", map[string]string{"KEY": "line1\nline2", "NEXT": "1"}},
+		{"no expansion", "A=$HOME\nB=$(whoami)\nC=\"`id`\"", map[string]string{"A": "$HOME", "B": "$(whoami)", "C": "`id`"}},
+		{"later overrides", "FOO=1\nFOO=2", map[string]string{"FOO": "2"}},
+		{"dotted key", "app.name=x", map[string]string{"app.name": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(tt.content)
+			if err != nil {
+				t.Fatalf("ParseDotenv() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotenv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"missing equals", "FOO\n", `line 1: expected KEY=VALUE, got "FOO"`},
+		{"invalid key", "\n1FOO=bar", `line 2: invalid variable name "1FOO"`},
+		{"key with space", "FOO BAR=1", `line 1: invalid variable name "FOO BAR"`},
+		{"unterminated double", "A=1\nFOO=\"bar\nbaz", "line 2: unterminated double-quoted value"},
+		{"unterminated single", "FOO='bar", "line 1: unterminated single-quoted value"},
+		{"junk after quote", `FOO="bar" baz`, `line 1: unexpected 'b' after quoted value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotenv(tt.content)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseDotenv() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadDotenvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("FOO=bar\nBAD\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadDotenvFile(path)
+	if err == nil || !strings.HasPrefix(err.Error(), path+": line 2:") {
+		t.Errorf("ReadDotenvFile() error = %v, want it to name the file and line", err)
+	}
+
+	_, err = ReadDotenvFile(filepath.Join(t.TempDir(), "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("ReadDotenvFile() error = %v, want a not-exist error", err)
+	}
+}
@@ -50,6 +50,19 @@ func RunV(cmd string, args ...string) error {
 	return ish.RunV(st.ActiveContext(), nil, "", cmd, args...)
 }
 
+// RunWithPrefix is like RunV, but starts every line the command writes to
+// stdout or stderr with "[prefix] ", so that the output of commands run by
+// concurrent targets (e.g. from st.Deps) can be told apart:
+//
+//	func Lint() error {
+//		return sh.RunWithPrefix("lint", "golangci-lint", "run")
+//	}
+//
+// In dry-run mode, it prints the prefixed command instead of running it.
+func RunWithPrefix(prefix, cmd string, args ...string) error {
+	return ish.RunWithPrefix(st.ActiveContext(), nil, "", prefix, cmd, args...)
+}
+
 // RunWith runs the given command, directing stderr to this program's stderr and
 // printing stdout to stdout if stave was run with -v.  It adds env to the
 // environment variables for the command being run. Environment variables should
@@ -68,6 +81,43 @@ func Output(cmd string, args ...string) (string, error) {
 	return ish.Output(st.ActiveContext(), nil, "", cmd, args...)
 }
 
+// MustRun is like Run, but panics with the command's error if it fails. Stave
+// recovers panics in targets and reports them the same way as returned errors
+// (including the exit status), so this lets targets that don't return an error
+// still abort cleanly.
+func MustRun(cmd string, args ...string) {
+	if err := Run(cmd, args...); err != nil {
+		panic(err)
+	}
+}
+
+// MustOutput is like Output, but panics with the command's error if it fails.
+// See MustRun.
+func MustOutput(cmd string, args ...string) string {
+	out, err := Output(cmd, args...)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Pipeline runs cmds, each a command and its args, with the stdout of each
+// piped to the stdin of the next, like a shell pipeline, and returns the stdout
+// of the last:
+//
+//	count, err := sh.Pipeline([][]string{
+//		{"git", "log", "--oneline"},
+//		{"grep", "fix"},
+//		{"wc", "-l"},
+//	})
+//
+// The commands run at the same time. If one fails, the others are stopped and
+// its error is returned, which makes stave exit with its exit status like
+// Exec. In dry-run mode, it prints the pipeline instead of running it.
+func Pipeline(cmds [][]string) (string, error) {
+	return ish.Pipeline(st.ActiveContext(), cmds)
+}
+
 // OutputWith is like RunWith, but returns what is written to stdout.
 func OutputWith(env map[string]string, wd, cmd string, args ...string) (string, error) {
 	return ish.Output(st.ActiveContext(), env, wd, cmd, args...)
@@ -41,6 +41,26 @@ func TestExitCode(t *testing.T) {
 	}
 }
 
+func TestMustRunPanicsOnFailure(t *testing.T) {
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		require.True(t, ok, "expected MustRun to panic with an error, got %#v", r)
+		assert.Equal(t, 99, ExitStatus(err))
+	}()
+	MustRun(os.Args[0], "-helper", "-exit", "99")
+	t.Fatal("MustRun should have panicked")
+}
+
+func TestMustOutput(t *testing.T) {
+	out := MustOutput(os.Args[0], "-printArgs", "foo")
+	assert.Equal(t, "[foo]", out)
+
+	assert.Panics(t, func() {
+		MustOutput(os.Args[0], "-helper", "-exit", "3")
+	})
+}
+
 func TestEnv(t *testing.T) {
 	theEnv := "SOME_REALLY_LONG_STAVEFILE_SPECIFIC_THING"
 	out := &bytes.Buffer{}
@@ -91,6 +111,114 @@ func TestDryRunOutput(t *testing.T) {
 	assert.Contains(t, got, want)
 }
 
+func TestDryRunFileHelpers(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		env    []string
+		dryRun bool
+	}{
+		{name: "dry run", env: []string{"STAVEFILE_DRYRUN_POSSIBLE=1", "STAVEFILE_DRYRUN=1"}, dryRun: true},
+		// without STAVEFILE_DRYRUN_POSSIBLE this is not a compiled stavefile,
+		// so the request is ignored.
+		{name: "requested only", env: []string{"STAVEFILE_DRYRUN=1"}},
+		{name: "not requested", env: []string{"STAVEFILE_DRYRUN_POSSIBLE=1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rmPath := filepath.Join(dir, "dist")
+			src := filepath.Join(dir, "a")
+			dst := filepath.Join(dir, "b")
+			require.NoError(t, os.Mkdir(rmPath, 0o755))
+			require.NoError(t, os.WriteFile(src, []byte("hello"), 0o644))
+
+			cmd := exec.Command(os.Args[0], "-fileHelpers", rmPath, dst, src)
+			cmd.Env = append(os.Environ(), tt.env...)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			got := string(out)
+			assert.NotContains(t, got, "ERR:")
+
+			if tt.dryRun {
+				assert.Contains(t, got, "dry run: true")
+				assert.Contains(t, got, "DRYRUN: rm -rf "+rmPath)
+				assert.Contains(t, got, "DRYRUN: cp "+src+" "+dst)
+				assert.DirExists(t, rmPath)
+				assert.NoFileExists(t, dst)
+				return
+			}
+			assert.Contains(t, got, "dry run: false")
+			assert.NotContains(t, got, "DRYRUN")
+			assert.NoDirExists(t, rmPath)
+			assert.FileExists(t, dst)
+		})
+	}
+}
+
+func TestRunWithPrefix(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-prefixOutput")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), stderr.String())
+	assert.Equal(t, "[build] one\n[build] two\n", stdout.String())
+	assert.Equal(t, "[build] three\n[build] four\n", stderr.String())
+}
+
+func TestRunWithPrefixDryRun(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-prefixOutput")
+	cmd.Env = append(os.Environ(), "STAVEFILE_DRYRUN_POSSIBLE=1", "STAVEFILE_DRYRUN=1")
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	got := string(out)
+	assert.True(t, strings.HasPrefix(got, "[build] DRYRUN: "+os.Args[0]+" -helper -stdout one\n"), got)
+	for line := range strings.Lines(got) {
+		assert.True(t, strings.HasPrefix(line, "[build] "), "line %q isn't prefixed", line)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	out, err := Pipeline([][]string{
+		{os.Args[0], "-helper", "-stdout", "one\ntwo"},
+		{os.Args[0], "-upperStdin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ONE\nTWO", out)
+}
+
+func TestPipelineFailingStage(t *testing.T) {
+	out, err := Pipeline([][]string{
+		{os.Args[0], "-helper", "-stdout", "one"},
+		{os.Args[0], "-helper", "-exit", "7"},
+		{os.Args[0], "-upperStdin"},
+	})
+	require.Error(t, err)
+	assert.Empty(t, out)
+	assert.Equal(t, 7, ExitStatus(err))
+	assert.Contains(t, err.Error(), `running "`+os.Args[0]+` -helper -exit 7" in pipeline`)
+	assert.Contains(t, err.Error(), "failed with exit code 7")
+}
+
+func TestPipelineEmpty(t *testing.T) {
+	_, err := Pipeline(nil)
+	require.EqualError(t, err, "empty pipeline")
+
+	_, err = Pipeline([][]string{{os.Args[0], "-printArgs"}, {}})
+	require.EqualError(t, err, "empty command at stage 2 of pipeline")
+}
+
+func TestPipelineDryRun(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-pipeline")
+	cmd.Env = append(os.Environ(), "STAVEFILE_DRYRUN_POSSIBLE=1", "STAVEFILE_DRYRUN=1")
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "DRYRUN: "+os.Args[0]+" -helper -stdout one | "+os.Args[0]+" -upperStdin\n\n", string(out))
+
+	cmd = exec.Command(os.Args[0], "-pipeline")
+	out, err = cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "ONE\n", string(out))
+}
+
 func TestPiper(t *testing.T) {
 	t.Run("pipes stdin to stdout", func(t *testing.T) {
 		if runtime.GOOS == "windows" {
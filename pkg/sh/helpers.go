@@ -1,16 +1,26 @@
 package sh
 
 import (
+	"github.com/yaklabco/stave/internal/dryrun"
 	"github.com/yaklabco/stave/internal/ish"
 )
 
 // Rm removes the given file or directory even if non-empty. It will not return
 // an error if the target doesn't exist, only if the target cannot be removed.
+// In dry-run mode it only prints "DRYRUN: rm -rf path".
 func Rm(path string) error {
 	return ish.Rm(path)
 }
 
 // Copy robustly copies the source file to the destination, overwriting the destination if necessary.
+// In dry-run mode it only prints "DRYRUN: cp src dst".
 func Copy(dst string, src string) error {
 	return ish.Copy(dst, src)
 }
+
+// IsDryRun reports whether stave is running in dry-run mode, in which the
+// commands and file changes made with this package are printed rather than
+// carried out. Targets can use it to skip changes of their own.
+func IsDryRun() bool {
+	return dryrun.IsDryRun()
+}
@@ -3,7 +3,9 @@ package sh
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -16,6 +18,10 @@ var (
 	printVar     string
 	printWd      bool
 	dryRunOutput bool
+	fileHelpers  bool
+	prefixOutput bool
+	upperStdin   bool
+	pipeline     bool
 )
 
 func init() {
@@ -27,6 +33,10 @@ func init() {
 	flag.StringVar(&printVar, "printVar", "", "")
 	flag.BoolVar(&printWd, "printWd", false, "")
 	flag.BoolVar(&dryRunOutput, "dryRunOutput", false, "")
+	flag.BoolVar(&fileHelpers, "fileHelpers", false, "")
+	flag.BoolVar(&prefixOutput, "prefixOutput", false, "")
+	flag.BoolVar(&upperStdin, "upperStdin", false, "")
+	flag.BoolVar(&pipeline, "pipeline", false, "")
 }
 
 func TestMain(m *testing.M) {
@@ -64,6 +74,50 @@ func TestMain(m *testing.M) {
 		return
 	}
 
+	if fileHelpers {
+		// Remove the first arg and copy the third to the second, in whatever
+		// dry-run mode the environment sets.
+		_, _ = fmt.Fprintln(os.Stdout, "dry run:", IsDryRun())
+		if err := Rm(flag.Arg(0)); err != nil {
+			_, _ = fmt.Fprintln(os.Stdout, "ERR:", err)
+		}
+		if err := Copy(flag.Arg(1), flag.Arg(2)); err != nil {
+			_, _ = fmt.Fprintln(os.Stdout, "ERR:", err)
+		}
+		return
+	}
+
+	if prefixOutput {
+		// Run the helper through RunWithPrefix, in whatever dry-run mode the
+		// environment sets.
+		if err := RunWithPrefix("build", os.Args[0], "-helper", "-stdout", "one\ntwo", "-stderr", "three\nfour"); err != nil {
+			_, _ = fmt.Fprintln(os.Stdout, "ERR:", err)
+		}
+		return
+	}
+
+	if upperStdin {
+		in, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		_, _ = fmt.Fprint(os.Stdout, strings.ToUpper(string(in)))
+		return
+	}
+
+	if pipeline {
+		// Run a two-stage pipeline of the helper, in whatever dry-run mode the
+		// environment sets.
+		out, err := Pipeline([][]string{{os.Args[0], "-helper", "-stdout", "one"}, {os.Args[0], "-upperStdin"}})
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stdout, "ERR:", err)
+			return
+		}
+		_, _ = fmt.Fprintln(os.Stdout, out)
+		return
+	}
+
 	if helperCmd {
 		_, _ = fmt.Fprintln(os.Stderr, stderr)
 		_, _ = fmt.Fprintln(os.Stdout, stdout)
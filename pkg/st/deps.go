@@ -2,13 +2,18 @@ package st
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/yaklabco/stave/internal/dryrun"
 	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parallelism"
 	"github.com/yaklabco/stave/pkg/watch/mode"
 	"github.com/yaklabco/stave/pkg/watch/wctx"
 	"github.com/yaklabco/stave/pkg/watch/wtarget"
@@ -87,23 +92,28 @@ func (o *onceMap) LoadOrStore(theFunc Fn) *onceFun {
 
 // SerialDeps is like Deps except it runs each dependency serially, instead of
 // in parallel. This can be useful for resource intensive dependencies that
-// shouldn't be run at the same time.
+// shouldn't be run at the same time, or for dependencies that rely on the ones
+// before them. Each dependency starts once the one before it has finished,
+// and if one fails, the ones after it don't run.
 func SerialDeps(fns ...any) {
-	funcs := checkFns(fns)
-	ctx := wctx.GetActive()
-	for i := range fns {
-		runDeps(ctx, funcs[i:i+1])
-	}
+	runSerialDeps(wctx.GetActive(), checkFns(fns))
 }
 
 // SerialCtxDeps is like CtxDeps except it runs each dependency serially,
-// instead of in parallel. This can be useful for resource intensive
-// dependencies that shouldn't be run at the same time.
+// instead of in parallel, with the same guarantees as SerialDeps.
 func SerialCtxDeps(ctx context.Context, fns ...any) {
-	funcs := checkFns(fns)
-	for i := range fns {
-		runDeps(ctx, funcs[i:i+1])
+	runSerialDeps(ctx, checkFns(fns))
+}
+
+// DepsLimit is like Deps except that at most n of the given dependencies run
+// at once, on top of the limit on all dependencies set by -p. This can be
+// useful for a fan-out that would overwhelm the machine. It panics if n is
+// less than 1.
+func DepsLimit(n int, fns ...any) {
+	if n < 1 {
+		panic(fmt.Errorf("st.DepsLimit needs a limit of at least 1, got %d", n))
 	}
+	runDeps(wctx.GetActive(), checkFns(fns), n)
 }
 
 // CtxDeps runs the given functions as dependencies of the calling function.
@@ -124,44 +134,75 @@ func SerialCtxDeps(ctx context.Context, fns ...any) {
 // prototype allows for it.
 func CtxDeps(ctx context.Context, fns ...any) {
 	funcs := checkFns(fns)
-	runDeps(ctx, funcs)
+	runDeps(ctx, funcs, 0)
 }
 
-// runDeps assumes you've already called checkFns.
-func runDeps(ctx context.Context, fns []Fn) {
-	errMutex := &sync.Mutex{}
-	var errs []string
-	var exit int
+// runSerialDeps runs fns one after the other, stopping at the first that
+// fails. It assumes you've already called checkFns.
+func runSerialDeps(ctx context.Context, fns []Fn) {
+	for i := range fns {
+		runDeps(ctx, fns[i:i+1], 0)
+	}
+}
+
+// runDeps runs fns in parallel, at most limit of them at once if limit is
+// above 0, and panics with the errors of those that failed, each annotated
+// with its name. A dependency that panics fails with the panic value. It
+// assumes you've already called checkFns.
+func runDeps(ctx context.Context, fns []Fn, limit int) {
+	if dryrun.IsDepsDryRun() {
+		planDeps(fns)
+		return
+	}
+
+	// A dependency waiting for its own dependencies gives up its slot, so they
+	// can run even if every slot is held by a waiting dependency.
+	if slot := depSlotFrom(ctx); slot != nil {
+		slot.beginWait()
+		defer slot.endWait()
+	}
+	// Nor does it count as running for the exclusive lock, so an exclusive
+	// dependency can run.
+	if run := exclusiveRunFrom(ctx); run != nil {
+		exclusive.beginWait(run)
+		defer exclusive.endWait(run)
+	}
+
+	var limiter chan struct{}
+	if limit > 0 {
+		limiter = make(chan struct{}, limit)
+	}
+
+	// each dependency's goroutine only writes its own entries.
+	names := make([]string, len(fns))
+	errs := make([]error, len(fns))
 	waitGroup := &sync.WaitGroup{}
-	for _, depFn := range fns {
+	for i, depFn := range fns {
 		depFunc := onces.LoadOrStore(depFn)
+		names[i] = depFunc.displayName
 		waitGroup.Add(1)
 		go func() {
 			defer func() {
 				if panicValue := recover(); panicValue != nil {
-					errMutex.Lock()
-					if err, ok := panicValue.(error); ok {
-						exit = changeExit(exit, ExitStatus(err))
-					} else {
-						exit = changeExit(exit, 1)
+					err, ok := panicValue.(error)
+					if !ok {
+						err = errors.New(fmt.Sprint(panicValue))
 					}
-					errs = append(errs, fmt.Sprint(panicValue))
-					errMutex.Unlock()
+					errs[i] = err
 				}
 				waitGroup.Done()
 			}()
-			if err := depFunc.run(ctx); err != nil {
-				errMutex.Lock()
-				errs = append(errs, fmt.Sprint(err))
-				exit = changeExit(exit, ExitStatus(err))
-				errMutex.Unlock()
+			if limiter != nil {
+				limiter <- struct{}{}
+				defer func() { <-limiter }()
 			}
+			errs[i] = depFunc.run(ctx)
 		}()
 	}
 
 	waitGroup.Wait()
-	if len(errs) > 0 {
-		panic(Fatal(exit, strings.Join(errs, "\n")))
+	if err := newDepsError(names, errs); err != nil {
+		panic(err)
 	}
 }
 
@@ -176,7 +217,7 @@ func checkFns(fns []any) []Fn {
 		// Check if the target provided is a not function so we can give a clear warning
 		t := reflect.TypeOf(theFunc)
 		if t == nil || t.Kind() != reflect.Func {
-			panic(fmt.Errorf("non-function used as a target dependency: %T. The st.Deps, st.SerialDeps and st.CtxDeps functions accept function names, such as st.Deps(TargetA, TargetB)", theFunc)) //nolint:lll // Long string-literal.
+			panic(fmt.Errorf("non-function used as a target dependency: %T. st.Deps and its variants accept function names, such as st.Deps(TargetA, TargetB)", theFunc)) //nolint:lll // Long string-literal.
 		}
 
 		funcs[iFunc] = F(theFunc)
@@ -207,21 +248,6 @@ func Deps(fns ...any) {
 	CtxDeps(wctx.GetActive(), fns...)
 }
 
-func changeExit(oldExitCode, newExitCode int) int {
-	if newExitCode == 0 {
-		return oldExitCode
-	}
-	if oldExitCode == 0 {
-		return newExitCode
-	}
-	if oldExitCode == newExitCode {
-		return oldExitCode
-	}
-	// both different and both non-zero, just set
-	// exit to 1. Nothing more we can do.
-	return 1
-}
-
 // funcName returns the unique name for the function.
 func funcName(i any) string {
 	return funcObj(i).Name()
@@ -247,10 +273,26 @@ type onceFun struct {
 // run will run the function exactly once and capture the error output. Further runs simply return
 // the same error output.
 func (o *onceFun) run(ctx context.Context) error {
-	ctx = ContextWithTarget(ctx, o.displayName)
+	slot := &depSlot{}
+	run := &exclusiveRun{parent: exclusiveRunFrom(ctx)}
+	ctx = context.WithValue(ContextWithTarget(ctx, o.displayName), depSlotKey{}, slot)
+	ctx = context.WithValue(ctx, exclusiveRunKey{}, run)
 	wctx.Register(o.displayName, ctx)
 	defer wctx.Unregister(o.displayName)
 	o.once.Do(func() {
+		// a serial target waits for its earlier run before anything else, so
+		// that it doesn't count as running for the exclusive lock, or hold a
+		// slot, while it waits.
+		if lock := serialLock(o.fn); lock != nil {
+			lock.Lock()
+			defer lock.Unlock()
+		}
+		// wait for the exclusive lock before taking a slot, so that targets
+		// waiting for it don't hold slots the running targets need.
+		exclusive.start(run, isExclusive(o.fn))
+		defer exclusive.finish(run)
+		slot.acquire()
+		defer slot.release()
 		defer func() {
 			if r := recover(); r != nil {
 				o.panicVal = r
@@ -268,6 +310,19 @@ func (o *onceFun) run(ctx context.Context) error {
 	return o.err
 }
 
+// planDeps prints the dependencies that would run, in order, instead of
+// running them. Like a real run, each is only reported once. Only the given
+// dependencies are listed, not theirs: those are declared by calling Deps from
+// the dependency's body, which a dry run doesn't run.
+func planDeps(fns []Fn) {
+	for _, depFn := range fns {
+		depFunc := onces.LoadOrStore(depFn)
+		depFunc.once.Do(func() {
+			_, _ = fmt.Fprintln(os.Stdout, "DRYRUN: would run target:", depFunc.displayName)
+		})
+	}
+}
+
 // RunFn runs the given function as a Stave target.
 func RunFn(ctx context.Context, theFunc any) error {
 	var fn Fn
@@ -282,3 +337,98 @@ func RunFn(ctx context.Context, theFunc any) error {
 	defer wctx.Unregister(displayName)
 	return fn.Run(ctx)
 }
+
+var (
+	depsSemOnce sync.Once     //nolint:gochecknoglobals // Part of a lazily initialized pattern.
+	depsSem     chan struct{} //nolint:gochecknoglobals // Part of a lazily initialized pattern.
+)
+
+// depsSemaphore returns the semaphore that bounds how many dependencies run
+// at once, across all calls to Deps and its variants.
+func depsSemaphore() chan struct{} {
+	depsSemOnce.Do(func() {
+		depsSem = make(chan struct{}, depsLimit())
+	})
+	return depsSem
+}
+
+// depsLimit returns how many dependencies may run at once: the parallelism
+// stave resolved and passed in STAVE_NUM_PROCESSORS (from -p, the variable
+// itself, or the CPU count), or GOMAXPROCS outside stave.
+func depsLimit() int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(parallelism.StaveNumProcessorsEnvVar)))
+	if err != nil || n < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return n
+}
+
+type depSlotKey struct{}
+
+// depSlot is a running dependency's place in the deps semaphore. It is stored
+// in the dependency's context, so that Deps calls made from the dependency can
+// give it up while they wait.
+type depSlot struct {
+	mu      sync.Mutex
+	held    bool
+	waiting int
+	gaveUp  bool // the slot was given up by beginWait, so endWait takes it back
+}
+
+func depSlotFrom(ctx context.Context) *depSlot {
+	if ctx == nil {
+		return nil
+	}
+	slot, _ := ctx.Value(depSlotKey{}).(*depSlot)
+	return slot
+}
+
+func (s *depSlot) acquire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acquireLocked()
+}
+
+// acquireLocked waits for a place in the semaphore. It is called with s.mu
+// held, so that the slot is never seen as free while it is being taken back.
+func (s *depSlot) acquireLocked() {
+	depsSemaphore() <- struct{}{}
+	s.held = true
+}
+
+func (s *depSlot) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *depSlot) releaseLocked() bool {
+	if !s.held {
+		return false
+	}
+	s.held = false
+	<-depsSemaphore()
+	return true
+}
+
+// beginWait gives up the slot while the dependency waits for its own
+// dependencies. Calls may overlap if the dependency calls Deps from several
+// goroutines; the slot is taken back once the last of them ends.
+func (s *depSlot) beginWait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waiting++
+	if s.releaseLocked() {
+		s.gaveUp = true
+	}
+}
+
+func (s *depSlot) endWait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waiting--
+	if s.waiting == 0 && s.gaveUp {
+		s.gaveUp = false
+		s.acquireLocked()
+	}
+}
@@ -2,11 +2,19 @@ package st
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	stdlog "log"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/yaklabco/stave/internal/dryrun"
 	"github.com/yaklabco/stave/internal/log"
 )
 
@@ -46,7 +54,7 @@ func TestDepWasNotInvoked(t *testing.T) {
 		}
 		gotErr := fmt.Sprint(err)
 		wantErr := "non-function used as a target dependency: <nil>. " +
-			"The st.Deps, st.SerialDeps and st.CtxDeps functions accept function names, " +
+			"st.Deps and its variants accept function names, " +
 			"such as st.Deps(TargetA, TargetB)"
 		if !strings.Contains(gotErr, wantErr) {
 			t.Fatalf(`expected to get "%s" but got "%s"`, wantErr, gotErr)
@@ -56,3 +64,326 @@ func TestDepWasNotInvoked(t *testing.T) {
 		checkFns(fns)
 	}(fn1())
 }
+
+// setDepsLimit replaces the deps semaphore with one of size n for the rest of
+// the test.
+func setDepsLimit(t *testing.T, n int) {
+	t.Helper()
+	old := depsSemaphore()
+	depsSem = make(chan struct{}, n)
+	t.Cleanup(func() { depsSem = old })
+}
+
+func TestDepsLimit(t *testing.T) {
+	const limit = 2
+	setDepsLimit(t, limit)
+
+	var running, peak, runs atomic.Int32
+	dep := func(int) error {
+		runs.Add(1)
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	fns := make([]any, 0, 4*limit)
+	for i := range 4 * limit {
+		fns = append(fns, F(dep, i))
+	}
+	CtxDeps(t.Context(), fns...)
+	// once-caching still applies
+	CtxDeps(t.Context(), fns...)
+
+	if got := peak.Load(); got != limit {
+		t.Fatalf("expected at most %d deps to run at once, but %d did", limit, got)
+	}
+	if got := runs.Load(); got != 4*limit {
+		t.Fatalf("expected each of the %d deps to run once, but there were %d runs", 4*limit, got)
+	}
+	if n := len(depsSemaphore()); n != 0 {
+		t.Fatalf("expected all slots to be released, but %d are held", n)
+	}
+}
+
+func TestDepsLimitNested(t *testing.T) {
+	setDepsLimit(t, 1)
+
+	var leaves atomic.Int32
+	leaf := func(int) { leaves.Add(1) }
+	parent := func(ctx context.Context, i int) {
+		// Waiting for these must not keep the only slot.
+		CtxDeps(ctx, F(leaf, i), F(leaf, i+100))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		CtxDeps(t.Context(), F(parent, 1), F(parent, 2), F(parent, 3))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested deps deadlocked with a limit of 1")
+	}
+	if got := leaves.Load(); got != 6 {
+		t.Fatalf("expected 6 leaf deps to run, got %d", got)
+	}
+}
+
+func TestDepsLimitOverlappingWaits(t *testing.T) {
+	const limit = 2
+	setDepsLimit(t, limit)
+
+	var running, peak atomic.Int32
+	leaf := func(int) {
+		n := running.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+	}
+	// each parent waits for its leaves from several goroutines at once, so its
+	// slot is given up and taken back while other parents do the same.
+	parent := func(ctx context.Context, i int) {
+		var wg sync.WaitGroup
+		for j := range 3 {
+			wg.Go(func() { CtxDeps(ctx, F(leaf, i*10+j)) })
+		}
+		wg.Wait()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		CtxDeps(t.Context(), F(parent, 1), F(parent, 2), F(parent, 3), F(parent, 4))
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("overlapping waits deadlocked")
+	}
+	if got := peak.Load(); got > limit {
+		t.Fatalf("expected at most %d leaves to run at once, but %d did", limit, got)
+	}
+	if n := len(depsSemaphore()); n != 0 {
+		t.Fatalf("expected all slots to be released, but %d are held", n)
+	}
+}
+
+func TestDepsLimitErrors(t *testing.T) {
+	setDepsLimit(t, 1)
+
+	dep := func(i int) error {
+		if i%2 == 1 {
+			return fmt.Errorf("dep %d failed", i)
+		}
+		return nil
+	}
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok {
+			t.Fatalf("expected an error panic, got %v", r)
+		}
+		if ExitStatus(err) != 1 {
+			t.Fatalf("expected exit status 1, got %d", ExitStatus(err))
+		}
+		for _, want := range []string{"dep 1 failed", "dep 3 failed"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("expected %q in %q", want, err.Error())
+			}
+		}
+		if n := len(depsSemaphore()); n != 0 {
+			t.Fatalf("expected all slots to be released, but %d are held", n)
+		}
+	}()
+	CtxDeps(t.Context(), F(dep, 0), F(dep, 1), F(dep, 2), F(dep, 3))
+}
+
+func TestDepsLimitCall(t *testing.T) {
+	setDepsLimit(t, 8)
+
+	var running, peak, runs atomic.Int32
+	dep := func(int) {
+		runs.Add(1)
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	fns := make([]any, 0, 6)
+	for i := range 6 {
+		fns = append(fns, F(dep, i))
+	}
+	DepsLimit(2, fns...)
+
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected at most 2 deps to run at once, but %d did", got)
+	}
+	if got := runs.Load(); got != 6 {
+		t.Fatalf("expected each of the 6 deps to run once, but there were %d runs", got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "at least 1") {
+			t.Fatalf("expected a panic about the limit, got %v", r)
+		}
+	}()
+	DepsLimit(0, fns...)
+}
+
+// TestDepsVariants checks that the variants of Deps agree on running each
+// dependency once, and on the errors they fail with.
+func TestDepsVariants(t *testing.T) {
+	variants := map[string]func(fns ...any){
+		"Deps":          Deps,
+		"CtxDeps":       func(fns ...any) { CtxDeps(t.Context(), fns...) },
+		"SerialDeps":    SerialDeps,
+		"SerialCtxDeps": func(fns ...any) { SerialCtxDeps(t.Context(), fns...) },
+		"DepsLimit":     func(fns ...any) { DepsLimit(1, fns...) },
+	}
+	for variant, deps := range variants {
+		t.Run(variant, func(t *testing.T) {
+			var runs atomic.Int32
+			succeeds := func(string) { runs.Add(1) }
+			fails := func(string) error { return Fatal(4, "ouch") }
+			panics := func(string) { panic("boom") }
+
+			deps(F(succeeds, variant), F(succeeds, variant))
+			deps(F(succeeds, variant))
+			if got := runs.Load(); got != 1 {
+				t.Fatalf("expected the dep to run once, but it ran %d times", got)
+			}
+
+			for _, tc := range []struct {
+				fn   Fn
+				msg  string
+				code int
+			}{
+				{F(fails, variant), "ouch", 4},
+				{F(panics, variant), "boom", 1},
+			} {
+				// the second call fails the same way, without running it again.
+				for range 2 {
+					err := depsPanic(func() { deps(tc.fn) })
+					if want := DisplayName(tc.fn.Name()) + ": " + tc.msg; err == nil || err.Error() != want {
+						t.Fatalf("expected %q, got %v", want, err)
+					}
+					if got := ExitStatus(err); got != tc.code {
+						t.Fatalf("expected exit status %d, got %d", tc.code, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSerialDepsStopAtFailure(t *testing.T) {
+	var ran []string
+	fails := func() error {
+		ran = append(ran, "fails")
+		return errors.New("ouch")
+	}
+	after := func() { ran = append(ran, "after") }
+
+	if err := depsPanic(func() { SerialDeps(fails, after) }); err == nil {
+		t.Fatal("expected SerialDeps to fail")
+	}
+	if got := strings.Join(ran, ","); got != "fails" {
+		t.Fatalf("expected SerialDeps to stop at the failure, but ran %s", got)
+	}
+}
+
+// depsPanic calls run and returns the error it panicked with, if any.
+func depsPanic(run func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, _ = r.(error)
+		}
+	}()
+	run()
+	return nil
+}
+
+func TestDepsLimitFromEnv(t *testing.T) {
+	t.Setenv("STAVE_NUM_PROCESSORS", "3")
+	if got := depsLimit(); got != 3 {
+		t.Fatalf("expected a limit of 3, got %d", got)
+	}
+	t.Setenv("STAVE_NUM_PROCESSORS", "nope")
+	if got := depsLimit(); got < 1 {
+		t.Fatalf("expected a positive fallback limit, got %d", got)
+	}
+}
+
+func TestDepsDryRun(t *testing.T) {
+	dryrun.SetPossible(true)
+	dryrun.SetRequested(true)
+	dryrun.SetDepsRequested(true)
+	t.Cleanup(func() {
+		dryrun.SetPossible(false)
+		dryrun.SetRequested(false)
+		dryrun.SetDepsRequested(false)
+	})
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	dryRunBodies.Store(0)
+	CtxDeps(t.Context(), dryRunGenerate, dryRunBuild)
+	SerialCtxDeps(t.Context(), dryRunBuild, dryRunTest)
+
+	os.Stdout = stdout
+	_ = writer.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := dryRunBodies.Load(); got != 0 {
+		t.Fatalf("expected no deps to run, but %d did", got)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 planned targets, got\n%s", out)
+	}
+	for i, want := range []string{"dryRunGenerate", "dryRunBuild", "dryRunTest"} {
+		if !strings.HasPrefix(lines[i], "DRYRUN: would run target: ") || !strings.HasSuffix(lines[i], want) {
+			t.Fatalf("expected line %d to plan %s, got %q", i, want, lines[i])
+		}
+	}
+}
+
+var dryRunBodies atomic.Int32 //nolint:gochecknoglobals // Counts the dependency bodies below.
+
+func dryRunGenerate() { dryRunBodies.Add(1) }
+
+func dryRunBuild() { dryRunBodies.Add(1) }
+
+func dryRunTest() error {
+	dryRunBodies.Add(1)
+	return nil
+}
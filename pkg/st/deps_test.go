@@ -87,8 +87,8 @@ func TestDepError(t *testing.T) {
 			t.Fatal("expected panic, but didn't get one")
 		}
 		actual := fmt.Sprint(err)
-		if actual != "ouch" {
-			t.Fatalf(`expected to get "ouch" but got "%s"`, actual)
+		if want := DisplayName(F(theFunc).Name()) + ": ouch"; actual != want {
+			t.Fatalf(`expected to get "%s" but got "%s"`, want, actual)
 		}
 	}()
 	Deps(theFunc)
@@ -104,8 +104,8 @@ func TestDepFatal(t *testing.T) {
 			t.Fatal("expected panic, but didn't get one")
 		}
 		actual := fmt.Sprint(panicValue)
-		if actual != "ouch!" {
-			t.Fatalf(`expected to get "ouch!" but got "%s"`, actual)
+		if want := DisplayName(F(theFunc).Name()) + ": ouch!"; actual != want {
+			t.Fatalf(`expected to get "%s" but got "%s"`, want, actual)
 		}
 		err, ok := panicValue.(error)
 		if !ok {
@@ -132,19 +132,19 @@ func TestDepTwoFatal(t *testing.T) {
 			t.Fatal("expected panic, but didn't get one")
 		}
 		actual := fmt.Sprint(panicValue)
-		// order is non-deterministic, so check for both orders
-		if actual != "ouch!\nbang!" && actual != "bang!\nouch!" {
-			t.Fatalf(`expected to get "ouch!" and "bang!" but got "%s"`, actual)
+		// the errors are listed in the order the dependencies were given.
+		want := DisplayName(F(funcF).Name()) + ": ouch!\n" + DisplayName(F(funcG).Name()) + ": bang!"
+		if actual != want {
+			t.Fatalf(`expected to get "%s" but got "%s"`, want, actual)
 		}
 		err, ok := panicValue.(error)
 		if !ok {
 			t.Fatalf("expected recovered val to be error but was %T", panicValue)
 		}
 		code := ExitStatus(err)
-		// two different error codes returns, so we give up and just use error
-		// code 1.
-		if code != 1 {
-			t.Fatalf("Expected exit status 1, but got %v", code)
+		// the exit status is that of the first error.
+		if code != 99 {
+			t.Fatalf("Expected exit status 99, but got %v", code)
 		}
 	}()
 	Deps(funcF, funcG)
@@ -209,8 +209,8 @@ func TestDepPanicNonError(t *testing.T) {
 			t.Fatal("expected panic, but didn't get one")
 		}
 		actual := fmt.Sprint(panicValue)
-		if actual != "string panic value" {
-			t.Fatalf(`expected "string panic value" but got %q`, actual)
+		if want := DisplayName(F(theFunc).Name()) + ": string panic value"; actual != want {
+			t.Fatalf(`expected %q but got %q`, want, actual)
 		}
 		err, ok := panicValue.(error)
 		if !ok {
@@ -241,27 +241,85 @@ func TestCtxDepsPassesContext(t *testing.T) {
 	}
 }
 
-func TestChangeExit(t *testing.T) {
-	tests := []struct {
-		name string
-		old  int
-		nw   int
-		want int
-	}{
-		{"both zero", 0, 0, 0},
-		{"old zero new nonzero", 0, 5, 5},
-		{"old nonzero new zero", 5, 0, 5},
-		{"same nonzero", 5, 5, 5},
-		{"different nonzero", 5, 3, 1},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := changeExit(tt.old, tt.nw)
-			if got != tt.want {
-				t.Errorf("changeExit(%d, %d) = %d, want %d", tt.old, tt.nw, got, tt.want)
+func TestDepsErrorsAggregated(t *testing.T) {
+	lint := func() error { return errors.New("lint failed") }
+	build := func() {}
+	test := func() error { return Fatal(3, "tests failed") }
+	vet := func() error { return Fatal(4, "vet failed") }
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok {
+			t.Fatal("expected Deps to panic with an error")
+		}
+		want := DisplayName(F(lint).Name()) + ": lint failed\n" +
+			DisplayName(F(test).Name()) + ": tests failed\n" +
+			DisplayName(F(vet).Name()) + ": vet failed"
+		if err.Error() != want {
+			t.Fatalf("expected error %q, got %q", want, err.Error())
+		}
+		if code := ExitStatus(err); code != 3 {
+			t.Fatalf("expected the exit status of the first error that has one, 3, got %d", code)
+		}
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) || len(joined.Unwrap()) != 3 {
+			t.Fatalf("expected 3 joined errors, got %#v", err)
+		}
+	}()
+	Deps(lint, build, test, vet)
+}
+
+func TestDepsErrorsNested(t *testing.T) {
+	leafA := func() error { return errors.New("a failed") }
+	leafB := func() error { return errors.New("b failed") }
+	middle := func() { Deps(leafA, leafB) }
+	other := func() error { return errors.New("other failed") }
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok {
+			t.Fatal("expected Deps to panic with an error")
+		}
+		// the failures are listed under the targets they happened in, not
+		// under middle, which only failed because of them.
+		want := DisplayName(F(leafA).Name()) + ": a failed\n" +
+			DisplayName(F(leafB).Name()) + ": b failed\n" +
+			DisplayName(F(other).Name()) + ": other failed"
+		if err.Error() != want {
+			t.Fatalf("expected error %q, got %q", want, err.Error())
+		}
+	}()
+	Deps(middle, other)
+}
+
+func TestDepsErrorsDropCancellations(t *testing.T) {
+	failing := func() error { return errors.New("real failure") }
+	canceled := func() error { return fmt.Errorf("waiting for lock: %w", context.Canceled) }
+
+	func() {
+		defer func() {
+			err, ok := recover().(error)
+			if !ok {
+				t.Fatal("expected Deps to panic with an error")
 			}
-		})
-	}
+			if want := DisplayName(F(failing).Name()) + ": real failure"; err.Error() != want {
+				t.Fatalf("expected error %q, got %q", want, err.Error())
+			}
+		}()
+		Deps(failing, canceled)
+	}()
+
+	onlyCanceled := func() error { return context.Canceled }
+	defer func() {
+		err, ok := recover().(error)
+		if !ok {
+			t.Fatal("expected Deps to panic with an error")
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancellation when nothing else failed, got %v", err)
+		}
+	}()
+	Deps(onlyCanceled)
 }
 
 func TestSerialCtxDeps(t *testing.T) {
@@ -0,0 +1,82 @@
+package st
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/pkg/env"
+)
+
+// EnvString returns the value of the environment variable name, or
+// defaultValue if it is unset or empty.
+func EnvString(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// EnvBool returns the value of the environment variable name parsed as a
+// boolean ("true", "yes", "1", "false", "no" or "0", in any case), or
+// defaultValue if it is unset or empty. If the value can't be parsed, it
+// returns defaultValue and an error naming the variable.
+func EnvBool(name string, defaultValue bool) (bool, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	b, err := env.ParseBool(v)
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// EnvInt returns the value of the environment variable name parsed as an int,
+// or defaultValue if it is unset or empty. If the value can't be parsed, it
+// returns defaultValue and an error naming the variable.
+func EnvInt(name string, defaultValue int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	return i, nil
+}
+
+// EnvDuration returns the value of the environment variable name parsed as a
+// duration like "1m30s", or defaultValue if it is unset or empty. If the value
+// can't be parsed, it returns defaultValue and an error naming the variable.
+func EnvDuration(name string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// RequireEnv returns an error listing every one of the given environment
+// variables that is unset or empty, or nil if they are all set. The error has
+// exit status 2, like a missing `stave:requires-env` variable.
+func RequireEnv(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return Fatalf(2, "missing required environment variables: %s", strings.Join(missing, ", "))
+}
@@ -0,0 +1,94 @@
+package st
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvString(t *testing.T) {
+	t.Setenv("STAVE_TEST_ENV", "")
+	if got := EnvString("STAVE_TEST_ENV", "fallback"); got != "fallback" {
+		t.Fatalf("expected the default for an empty variable, got %q", got)
+	}
+	t.Setenv("STAVE_TEST_ENV", "value")
+	if got := EnvString("STAVE_TEST_ENV", "fallback"); got != "value" {
+		t.Fatalf("expected %q, got %q", "value", got)
+	}
+}
+
+func TestEnvBool(t *testing.T) {
+	t.Setenv("STAVE_TEST_ENV", "")
+	if got, err := EnvBool("STAVE_TEST_ENV", true); err != nil || !got {
+		t.Fatalf("expected the default for an empty variable, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", "No")
+	if got, err := EnvBool("STAVE_TEST_ENV", true); err != nil || got {
+		t.Fatalf("expected false, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", "maybe")
+	got, err := EnvBool("STAVE_TEST_ENV", true)
+	if err == nil || !strings.Contains(err.Error(), "STAVE_TEST_ENV") {
+		t.Fatalf("expected an error naming the variable, got %v", err)
+	}
+	if !got {
+		t.Fatal("expected the default for an invalid value")
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	t.Setenv("STAVE_TEST_ENV", "")
+	if got, err := EnvInt("STAVE_TEST_ENV", 3); err != nil || got != 3 {
+		t.Fatalf("expected the default for an empty variable, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", " 42 ")
+	if got, err := EnvInt("STAVE_TEST_ENV", 3); err != nil || got != 42 {
+		t.Fatalf("expected 42, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", "forty")
+	got, err := EnvInt("STAVE_TEST_ENV", 3)
+	if err == nil || !strings.Contains(err.Error(), "STAVE_TEST_ENV") {
+		t.Fatalf("expected an error naming the variable, got %v", err)
+	}
+	if got != 3 {
+		t.Fatalf("expected the default for an invalid value, got %v", got)
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	t.Setenv("STAVE_TEST_ENV", "")
+	if got, err := EnvDuration("STAVE_TEST_ENV", time.Second); err != nil || got != time.Second {
+		t.Fatalf("expected the default for an empty variable, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", "1m30s")
+	if got, err := EnvDuration("STAVE_TEST_ENV", time.Second); err != nil || got != 90*time.Second {
+		t.Fatalf("expected 1m30s, got %v, %v", got, err)
+	}
+	t.Setenv("STAVE_TEST_ENV", "soon")
+	got, err := EnvDuration("STAVE_TEST_ENV", time.Second)
+	if err == nil || !strings.Contains(err.Error(), "STAVE_TEST_ENV") {
+		t.Fatalf("expected an error naming the variable, got %v", err)
+	}
+	if got != time.Second {
+		t.Fatalf("expected the default for an invalid value, got %v", got)
+	}
+}
+
+func TestRequireEnv(t *testing.T) {
+	t.Setenv("STAVE_TEST_SET", "1")
+	t.Setenv("STAVE_TEST_EMPTY", "")
+	if err := RequireEnv("STAVE_TEST_SET"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err := RequireEnv("STAVE_TEST_EMPTY", "STAVE_TEST_SET", "STAVE_TEST_UNSET_FOR_SURE")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := "missing required environment variables: STAVE_TEST_EMPTY, STAVE_TEST_UNSET_FOR_SURE"
+	if err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+	if code := ExitStatus(err); code != 2 {
+		t.Fatalf("expected exit status 2, got %d", code)
+	}
+}
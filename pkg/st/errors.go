@@ -1,8 +1,10 @@
 package st
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type fatalError struct {
@@ -53,3 +55,84 @@ func ExitStatus(err error) int {
 	}
 	return 1
 }
+
+// depError is the error a dependency failed with, annotated with its name.
+type depError struct {
+	name string
+	err  error
+}
+
+func (d depError) Error() string {
+	return d.name + ": " + d.err.Error()
+}
+
+func (d depError) Unwrap() error {
+	return d.err
+}
+
+// depsError is the error a Deps call fails with: that of each dependency that
+// failed, in the order they were given. Its exit status is that of the first
+// of them that has one.
+type depsError struct {
+	errs []error
+	code int
+}
+
+func (d *depsError) Error() string {
+	msgs := make([]string, len(d.errs))
+	for i, err := range d.errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (d *depsError) Unwrap() []error {
+	return d.errs
+}
+
+func (d *depsError) ExitStatus() int {
+	return d.code
+}
+
+// newDepsError returns the error for the dependencies called names failing
+// with errs, or nil if none of them failed. A dependency that failed because
+// its own dependencies did contributes their errors rather than its own, so
+// each failure is listed once, under the name of the target it happened in.
+// Cancellations are left out if anything else failed, since they are usually
+// the fallout.
+func newDepsError(names []string, errs []error) error {
+	var failed []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if nested, ok := err.(*depsError); ok { //nolint:errorlint // only a dependency's own Deps failing is flattened.
+			failed = append(failed, nested.errs...)
+			continue
+		}
+		failed = append(failed, depError{name: names[i], err: err})
+	}
+
+	uncanceled := make([]error, 0, len(failed))
+	for _, err := range failed {
+		if !errors.Is(err, context.Canceled) {
+			uncanceled = append(uncanceled, err)
+		}
+	}
+	if len(uncanceled) > 0 {
+		failed = uncanceled
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	code := 1
+	for _, err := range failed {
+		var exit exitStatus
+		if errors.As(err, &exit) {
+			code = exit.ExitStatus()
+			break
+		}
+	}
+	return &depsError{errs: failed, code: code}
+}
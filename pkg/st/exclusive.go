@@ -0,0 +1,148 @@
+package st
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	exclusiveTargetsMu sync.RWMutex        //nolint:gochecknoglobals // Set once by the generated mainfile.
+	exclusiveTargets   map[string]struct{} //nolint:gochecknoglobals // Set once by the generated mainfile.
+)
+
+// SetExclusive marks targets as exclusive: while one of them runs as a
+// dependency, or through RunTarget, no other target does, except for its own
+// dependencies. It waits for the targets already running to finish, and new
+// ones wait for it. The generated mainfile of a stavefile that imports st calls
+// it with the targets marked stave:exclusive; stavefiles should not.
+func SetExclusive(targets ...any) {
+	names := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		names[funcName(target)] = struct{}{}
+	}
+	exclusiveTargetsMu.Lock()
+	defer exclusiveTargetsMu.Unlock()
+	exclusiveTargets = names
+}
+
+// isExclusive reports whether theFunc was marked exclusive with SetExclusive.
+func isExclusive(theFunc Fn) bool {
+	exclusiveTargetsMu.RLock()
+	defer exclusiveTargetsMu.RUnlock()
+	_, ok := exclusiveTargets[theFunc.Name()]
+	return ok
+}
+
+// exclusiveLock keeps exclusive targets from running alongside other targets.
+// It works like a readers-writer lock over the running targets, except that a
+// target waiting for its dependencies doesn't count as running, so that an
+// exclusive dependency isn't kept waiting by the targets that depend on it.
+type exclusiveLock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int           // active is how many targets are running outside the exclusive target.
+	holder *exclusiveRun // holder is the exclusive target that is running, or waiting for active to reach zero.
+}
+
+var exclusive = newExclusiveLock() //nolint:gochecknoglobals // Shared by all calls to Deps and RunTarget.
+
+func newExclusiveLock() *exclusiveLock {
+	lock := &exclusiveLock{}
+	lock.cond = sync.NewCond(&lock.mu)
+	return lock
+}
+
+type exclusiveRunKey struct{}
+
+// exclusiveRun is a running target's place in the exclusive lock. It is
+// stored in the target's context, so that its dependencies inherit its
+// exclusivity, and so that Deps calls made from it can stop counting it as
+// running while they wait.
+type exclusiveRun struct {
+	parent  *exclusiveRun
+	holder  *exclusiveRun // holder is the exclusive target the run is part of: itself, or one it is a dependency of.
+	waiting int
+}
+
+func exclusiveRunFrom(ctx context.Context) *exclusiveRun {
+	if ctx == nil {
+		return nil
+	}
+	run, _ := ctx.Value(exclusiveRunKey{}).(*exclusiveRun)
+	return run
+}
+
+// start waits until run may start: at once for the dependencies of the
+// exclusive target that is running, after any exclusive target for other
+// targets, and, for exclusive targets, also until no other target is running.
+func (l *exclusiveLock) start(run *exclusiveRun, isExclusive bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != nil && run.parent != nil && run.parent.holder == l.holder {
+		run.holder = l.holder
+		return
+	}
+	for l.holder != nil {
+		l.cond.Wait()
+	}
+	if isExclusive {
+		// claim the lock first, so that no new targets start while the
+		// running ones finish.
+		run.holder = run
+		l.holder = run
+		for l.active > 0 {
+			l.cond.Wait()
+		}
+		return
+	}
+	l.active++
+}
+
+// finish lets the targets waiting for run go ahead.
+func (l *exclusiveLock) finish(run *exclusiveRun) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch run.holder {
+	case run:
+		l.holder = nil
+	case nil:
+		l.active--
+	default:
+		return
+	}
+	l.cond.Broadcast()
+}
+
+// beginWait stops counting run as running while it waits for its
+// dependencies. Calls may overlap if the target calls Deps from several
+// goroutines; it counts as running again once the last of them ends.
+func (l *exclusiveLock) beginWait(run *exclusiveRun) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if run.holder != nil {
+		return
+	}
+	run.waiting++
+	if run.waiting == 1 {
+		l.active--
+		l.cond.Broadcast()
+	}
+}
+
+// endWait waits for any exclusive target that started while run was waiting
+// to finish, before run carries on.
+func (l *exclusiveLock) endWait(run *exclusiveRun) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if run.holder != nil {
+		return
+	}
+	run.waiting--
+	if run.waiting > 0 {
+		return
+	}
+	for l.holder != nil {
+		l.cond.Wait()
+	}
+	l.active++
+}
@@ -0,0 +1,91 @@
+package st
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type span struct {
+	start, end time.Time
+}
+
+func (s span) overlaps(other span) bool {
+	return s.start.Before(other.end) && other.start.Before(s.end)
+}
+
+var (
+	exclusiveSpansMu sync.Mutex
+	exclusiveSpans   map[string]span
+)
+
+func recordSpan(name string, d time.Duration) {
+	start := time.Now()
+	time.Sleep(d)
+	end := time.Now()
+	exclusiveSpansMu.Lock()
+	defer exclusiveSpansMu.Unlock()
+	exclusiveSpans[name] = span{start: start, end: end}
+}
+
+func exclusiveTestAll() {
+	Deps(exclusiveTestBuild, exclusiveTestMigrate, exclusiveTestLint)
+}
+
+func exclusiveTestBuild() { recordSpan("build", 50*time.Millisecond) }
+
+func exclusiveTestLint() { recordSpan("lint", 50*time.Millisecond) }
+
+func exclusiveTestMigrate() {
+	Deps(exclusiveTestSeed)
+	recordSpan("migrate", 50*time.Millisecond)
+}
+
+func exclusiveTestSeed() { recordSpan("seed", 20*time.Millisecond) }
+
+func TestExclusiveTargets(t *testing.T) {
+	setDepsLimit(t, 4)
+	exclusiveSpans = make(map[string]span)
+	// seed is exclusive too, and must inherit migrate's exclusivity rather
+	// than wait for migrate to finish.
+	SetExclusive(exclusiveTestMigrate, exclusiveTestSeed)
+	t.Cleanup(func() {
+		SetExclusive()
+		ResetSpecificOnces(exclusiveTestBuild, exclusiveTestLint, exclusiveTestMigrate, exclusiveTestSeed)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exclusiveTestAll()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exclusive targets deadlocked")
+	}
+
+	for _, exclusiveName := range []string{"migrate", "seed"} {
+		for _, name := range []string{"build", "lint"} {
+			if exclusiveSpans[exclusiveName].overlaps(exclusiveSpans[name]) {
+				t.Errorf("exclusive target %s ran alongside %s: %+v and %+v",
+					exclusiveName, name, exclusiveSpans[exclusiveName], exclusiveSpans[name])
+			}
+		}
+	}
+	if !exclusiveSpans["seed"].end.Before(exclusiveSpans["migrate"].start) {
+		t.Errorf("seed should have finished before migrate started: %+v", exclusiveSpans)
+	}
+}
+
+func TestIsExclusive(t *testing.T) {
+	SetExclusive(exclusiveTestMigrate)
+	t.Cleanup(func() { SetExclusive() })
+
+	if !isExclusive(F(exclusiveTestMigrate)) {
+		t.Error("exclusiveTestMigrate should be exclusive")
+	}
+	if isExclusive(F(exclusiveTestBuild)) {
+		t.Error("exclusiveTestBuild should not be exclusive")
+	}
+}
@@ -0,0 +1,33 @@
+package st
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/yaklabco/stave/pkg/target"
+)
+
+// Glob returns the names of the files matching pattern, as filepath.Glob
+// does, so that targets can collect their sources for NewerThan. It returns
+// no names and no error if nothing matches.
+func Glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("globbing %q: %w", pattern, err)
+	}
+	return matches, nil
+}
+
+// NewerThan reports whether dst needs rebuilding from srcs: whether dst is
+// missing, or any of srcs was modified more recently than it. It's an error if
+// any of srcs doesn't exist. Paths are expanded and checked as target.Path
+// does.
+//
+//	srcs, err := st.Glob("*.go")
+//	if err != nil {
+//		return err
+//	}
+//	rebuild, err := st.NewerThan("bin/app", srcs...)
+func NewerThan(dst string, srcs ...string) (bool, error) {
+	return target.Path(dst, srcs...)
+}
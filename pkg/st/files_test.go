@@ -0,0 +1,113 @@
+package st
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFileAt writes a file at path with its modification time set to
+// modTime.
+func writeFileAt(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(filepath.Base(path)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Now()
+	writeFileAt(t, filepath.Join(dir, "a.go"), now)
+	writeFileAt(t, filepath.Join(dir, "b.go"), now)
+	writeFileAt(t, filepath.Join(dir, "c.txt"), now)
+
+	matches, err := Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+	if len(matches) != len(want) || matches[0] != want[0] || matches[1] != want[1] {
+		t.Fatalf("expected %q, got %q", want, matches)
+	}
+
+	matches, err = Glob(filepath.Join(dir, "*.rs"))
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("expected no matches and no error, got %q, %v", matches, err)
+	}
+
+	if _, err := Glob("["); err == nil {
+		t.Fatal("expected an error for a malformed pattern")
+	}
+}
+
+func TestNewerThanMissingDst(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	writeFileAt(t, src, time.Now())
+
+	newer, err := NewerThan(filepath.Join(dir, "app"), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newer {
+		t.Fatal("expected a missing dst to need rebuilding")
+	}
+}
+
+func TestNewerThanStaleDst(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Now()
+	dst := filepath.Join(dir, "app")
+	writeFileAt(t, dst, now.Add(-time.Hour))
+	writeFileAt(t, filepath.Join(dir, "a.go"), now.Add(-2*time.Hour))
+	writeFileAt(t, filepath.Join(dir, "b.go"), now)
+
+	srcs, err := Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := NewerThan(dst, srcs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newer {
+		t.Fatal("expected dst older than a source to need rebuilding")
+	}
+}
+
+func TestNewerThanUpToDateDst(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Now()
+	dst := filepath.Join(dir, "app")
+	writeFileAt(t, dst, now)
+	writeFileAt(t, filepath.Join(dir, "a.go"), now.Add(-2*time.Hour))
+	writeFileAt(t, filepath.Join(dir, "b.go"), now.Add(-time.Hour))
+
+	newer, err := NewerThan(dst, filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newer {
+		t.Fatal("expected dst newer than all its sources to be up to date")
+	}
+}
+
+func TestNewerThanMissingSrc(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "app")
+	writeFileAt(t, dst, time.Now())
+
+	if _, err := NewerThan(dst, filepath.Join(dir, "missing.go")); err == nil {
+		t.Fatal("expected an error for a missing source")
+	}
+}
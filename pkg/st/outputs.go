@@ -0,0 +1,80 @@
+package st
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OutputsManifestEnv is the environment variable through which stave tells a
+// running stavefile where to record the files declared with Output, so they
+// can be collected into .stave/outputs once the run succeeds.
+const OutputsManifestEnv = "STAVEFILE_OUTPUTS_MANIFEST"
+
+// OutputsKeepEnv is the environment variable that sets how many sets of
+// outputs stave keeps per target in .stave/outputs; the default is 5.
+const OutputsKeepEnv = "STAVEFILE_OUTPUTS_KEEP"
+
+// TargetEnv is the environment variable that holds the name of the top-level
+// target currently being run by a stavefile.
+const TargetEnv = "STAVEFILE_TARGET"
+
+// OutputRecord is a single entry in the outputs manifest: a file declared by
+// a target with Output.
+type OutputRecord struct {
+	Target string `json:"target"`
+	Path   string `json:"path"`
+}
+
+var outputsMu sync.Mutex //nolint:gochecknoglobals // serializes writes to the outputs manifest
+
+// Output declares that the current target produced the file at path. After a
+// successful run, stave copies declared files into
+// .stave/outputs/<target>/<hash>/ and points .stave/outputs/<target>/latest at
+// them. Relative paths are resolved against the current working directory.
+//
+// Output does nothing if the stavefile was not run by stave (e.g. a binary
+// built with --compile).
+func Output(path string) error {
+	return Outputs(path)
+}
+
+// Outputs declares several files produced by the current target. See Output.
+func Outputs(paths ...string) error {
+	manifest := os.Getenv(OutputsManifestEnv)
+	if manifest == "" {
+		return nil
+	}
+	target := os.Getenv(TargetEnv)
+
+	var buf []byte
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("resolving output %s: %w", path, err)
+		}
+		line, err := json.Marshal(OutputRecord{Target: target, Path: absPath})
+		if err != nil {
+			return fmt.Errorf("encoding output %s: %w", path, err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	f, err := os.OpenFile(manifest, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening outputs manifest: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("recording outputs: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("recording outputs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package st
+
+import (
+	"fmt"
+	"os"
+)
+
+// Outputf writes a message formatted as by fmt.Printf to stdout, unless stave
+// was run with -q (see Quiet). It's the sanctioned way for targets to print,
+// for linters like forbidigo that ban fmt.Print*. Not to be confused with
+// Output, which declares a file a target produced.
+func Outputf(format string, args ...any) {
+	if Quiet() {
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stdout, format, args...)
+}
+
+// Outputln writes s and a newline to stdout, unless stave was run with -q
+// (see Quiet).
+func Outputln(s string) {
+	if Quiet() {
+		return
+	}
+	_, _ = fmt.Fprintln(os.Stdout, s)
+}
@@ -0,0 +1,50 @@
+package st
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	_ = writer.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestOutput(t *testing.T) {
+	t.Setenv(QuietEnv, "")
+	out := captureStdout(t, func() {
+		Outputf("built %d of %s\n", 3, "targets")
+		Outputln("done")
+	})
+	if want := "built 3 of targets\ndone\n"; out != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
+
+func TestOutputQuiet(t *testing.T) {
+	t.Setenv(QuietEnv, "1")
+	out := captureStdout(t, func() {
+		Outputf("built %d of %s\n", 3, "targets")
+		Outputln("done")
+	})
+	if out != "" {
+		t.Fatalf("expected no output under quiet, got %q", out)
+	}
+}
@@ -0,0 +1,42 @@
+package st
+
+import (
+	"context"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// Retry calls fn until it succeeds, at most attempts times in all, waiting
+// delay between calls, and returns the error of the last call, or nil. Where
+// the stave:retries directive re-runs a whole target, Retry re-runs one step of
+// it, like a request to a flaky service. An attempts below 1 counts as 1.
+func Retry(attempts int, delay time.Duration, fn func() error) error {
+	return RetryCtx(context.Background(), attempts, delay, func(context.Context) error {
+		return fn()
+	})
+}
+
+// RetryCtx is like Retry, but passes ctx to fn, and stops retrying once ctx is
+// done, returning the error of the last call. If ctx is done before the first
+// call, fn isn't called, and ctx's error is returned.
+func RetryCtx(ctx context.Context, attempts int, delay time.Duration, fn func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	attempts = max(attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt == attempts || ctx.Err() != nil {
+			return err
+		}
+		if Verbose() {
+			log.SimpleConsoleLogger.Printf("attempt %d of %d failed, retrying in %s: %v\n", attempt, attempts, delay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package st
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetrySucceedsOnNthAttempt(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	err := Retry(5, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("attempt %d failed", calls)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success on the third attempt, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryExhaustsAttempts(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	err := Retry(3, time.Millisecond, func() error {
+		calls++
+		return fmt.Errorf("attempt %d failed", calls)
+	})
+	if err == nil || err.Error() != "attempt 3 failed" {
+		t.Fatalf("expected the last attempt's error, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+
+	calls = 0
+	_ = Retry(0, time.Millisecond, func() error {
+		calls++
+		return errors.New("failed")
+	})
+	if calls != 1 {
+		t.Fatalf("expected attempts below 1 to make 1 call, got %d", calls)
+	}
+}
+
+func TestRetryCtxCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(t.Context())
+	calls := 0
+	start := time.Now()
+	err := RetryCtx(ctx, 10, time.Hour, func(fnCtx context.Context) error {
+		if fnCtx != ctx {
+			t.Error("expected fn to be given ctx")
+		}
+		calls++
+		if calls == 2 {
+			t.Error("expected no retry after cancellation")
+		}
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("failed")
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Fatalf("expected the last attempt's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected cancellation to cut the delay short, but it took %s", elapsed)
+	}
+
+	calls = 0
+	err = RetryCtx(ctx, 3, time.Millisecond, func(context.Context) error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Fatalf("expected a done ctx to fail without calling fn, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,128 @@
+package st
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/yaklabco/stave/internal/dryrun"
+)
+
+// ResolvedTarget is a target of the running stavefile, resolved from its name
+// and command-line style args.
+type ResolvedTarget struct {
+	Func any                             // Func is the target function, as it would be passed to Deps.
+	Args []any                           // Args are the converted args, as they would be passed to F.
+	Run  func(ctx context.Context) error // Run runs the target with its args, retrying it if it should be.
+}
+
+// TargetResolver resolves the target of the running stavefile called name,
+// with command-line style args.
+type TargetResolver func(name string, args []string) (ResolvedTarget, error)
+
+var (
+	targetResolverMu sync.RWMutex   //nolint:gochecknoglobals // Set once by the generated mainfile.
+	targetResolver   TargetResolver //nolint:gochecknoglobals // Set once by the generated mainfile.
+)
+
+// SetTargetResolver sets the function RunTarget uses to resolve targets. The
+// generated mainfile of a stavefile that imports st calls it; stavefiles
+// should not.
+func SetTargetResolver(resolver TargetResolver) {
+	targetResolverMu.Lock()
+	defer targetResolverMu.Unlock()
+	targetResolver = resolver
+}
+
+// RunTarget runs the target called name, with the given args, from within a
+// running stavefile, as if it had been given on the command line: aliases and
+// namespaces are resolved, and args are converted and checked against the
+// target's `stave:arg` constraints. Unknown targets, args that can't be
+// converted and missing required environment variables are returned as
+// errors.
+//
+// The target is a dependency like any other: it runs once for each distinct
+// function and args, whether it is reached through RunTarget, an alias or
+// Deps, and later calls return the error of the first. It runs in the calling
+// goroutine with ctx.
+func RunTarget(ctx context.Context, name string, args ...string) error {
+	targetResolverMu.RLock()
+	resolver := targetResolver
+	targetResolverMu.RUnlock()
+	if resolver == nil {
+		return errors.New("st.RunTarget can only be used from a running stavefile")
+	}
+
+	resolved, err := resolver(name, args)
+	if err != nil {
+		return err
+	}
+	theFn, err := newTargetFn(resolved)
+	if err != nil {
+		return err
+	}
+	if dryrun.IsDepsDryRun() {
+		planDeps([]Fn{theFn})
+		return nil
+	}
+	// like runDeps, give up the caller's slot while the target runs.
+	if slot := depSlotFrom(ctx); slot != nil {
+		slot.beginWait()
+		defer slot.endWait()
+	}
+	if run := exclusiveRunFrom(ctx); run != nil {
+		exclusive.beginWait(run)
+		defer exclusive.endWait(run)
+	}
+	return onces.LoadOrStore(theFn).run(ctx)
+}
+
+// targetFn is an Fn for a target run by name with RunTarget. Its name and ID
+// are those F gives the same function and args, so that it shares its once
+// with Deps.
+type targetFn struct {
+	name       string
+	id         string
+	run        func(ctx context.Context) error
+	underlying *runtime.Func
+}
+
+func newTargetFn(resolved ResolvedTarget) (targetFn, error) {
+	args := resolved.Args
+	if len(args) == 0 {
+		args = nil // as F(target) with no args
+	}
+	id, err := json.Marshal(args)
+	if err != nil {
+		return targetFn{}, fmt.Errorf("can't convert args into a stave-compatible id for st.RunTarget: %w", err)
+	}
+	return targetFn{
+		name:       funcName(resolved.Func),
+		id:         string(id),
+		run:        resolved.Run,
+		underlying: funcObj(resolved.Func),
+	}, nil
+}
+
+// Name returns the fully qualified name of the target function.
+func (t targetFn) Name() string {
+	return t.name
+}
+
+// ID returns the args, json-encoded.
+func (t targetFn) ID() string {
+	return t.id
+}
+
+// Run runs the target.
+func (t targetFn) Run(ctx context.Context) error {
+	return t.run(ctx)
+}
+
+// Underlying returns the target function object.
+func (t targetFn) Underlying() *runtime.Func {
+	return t.underlying
+}
@@ -0,0 +1,66 @@
+package st
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunTargetWithoutResolver(t *testing.T) {
+	SetTargetResolver(nil)
+	if err := RunTarget(t.Context(), "build"); err == nil {
+		t.Fatal("expected an error outside a running stavefile")
+	}
+}
+
+var greetCalls []string //nolint:gochecknoglobals // Records calls to greet.
+
+func greet(name string) error {
+	greetCalls = append(greetCalls, name)
+	if name == "bad" {
+		return errFailedGreet
+	}
+	return nil
+}
+
+var errFailedGreet = errors.New("failed") //nolint:gochecknoglobals // Returned by greet.
+
+func TestRunTargetOnce(t *testing.T) {
+	ResetOnces()
+	t.Cleanup(ResetOnces)
+	greetCalls = nil
+
+	errUnknown := errors.New("unknown target")
+	SetTargetResolver(func(name string, args []string) (ResolvedTarget, error) {
+		// "hi" is an alias of greet
+		if name != "greet" && name != "hi" {
+			return ResolvedTarget{}, errUnknown
+		}
+		return ResolvedTarget{
+			Func: greet,
+			Args: []any{args[0]},
+			Run:  func(context.Context) error { return greet(args[0]) },
+		}, nil
+	})
+	t.Cleanup(func() { SetTargetResolver(nil) })
+
+	for range 2 {
+		if err := RunTarget(t.Context(), "greet", "alice"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := RunTarget(t.Context(), "hi", "alice"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := RunTarget(t.Context(), "greet", "bad"); !errors.Is(err, errFailedGreet) {
+			t.Fatalf("expected the target's error, got %v", err)
+		}
+	}
+	// shares its once with Deps
+	Deps(F(greet, "alice"), F(greet, "bob"))
+	if len(greetCalls) != 3 || greetCalls[0] != "alice" || greetCalls[1] != "bad" || greetCalls[2] != "bob" {
+		t.Fatalf("expected each function and args to run once, got %q", greetCalls)
+	}
+	if err := RunTarget(t.Context(), "nope"); !errors.Is(err, errUnknown) {
+		t.Fatalf("expected the resolver's error, got %v", err)
+	}
+}
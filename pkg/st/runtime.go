@@ -17,6 +17,11 @@ const CacheEnv = "STAVEFILE_CACHE"
 // verbose mode when running a stavefile.
 const VerboseEnv = "STAVEFILE_VERBOSE"
 
+// QuietEnv is the environment variable through which stave tells a running
+// stavefile that it was run with -q, so that Outputf and Outputln print
+// nothing.
+const QuietEnv = "STAVEFILE_QUIET"
+
 // DebugEnv is the environment variable that indicates the user requested
 // debug mode when running stave.
 const DebugEnv = "STAVEFILE_DEBUG"
@@ -31,6 +36,10 @@ const DryRunRequestedEnv = dryrun.RequestedEnv
 // DryRunPossibleEnv is the environment variable that indicates we are in a context where a dry run is possible.
 const DryRunPossibleEnv = dryrun.PossibleEnv
 
+// DryRunDepsRequestedEnv is the environment variable that indicates the user requested that st.Deps print,
+// but not run, targets in dryrun mode.
+const DryRunDepsRequestedEnv = dryrun.DepsRequestedEnv
+
 // GoCmdEnv is the environment variable that indicates the go binary the user
 // desires to utilize for Stavefile compilation.
 const GoCmdEnv = "STAVEFILE_GOCMD"
@@ -39,6 +48,92 @@ const GoCmdEnv = "STAVEFILE_GOCMD"
 // to ignore the default target specified in the stavefile.
 const IgnoreDefaultEnv = "STAVEFILE_IGNOREDEFAULT"
 
+// OnNoTargetEnv is the environment variable that sets what a stavefile run
+// without a target does: "default" runs the default target, and "help" or
+// "list" show the usage instead. (stave lists the targets itself for "list",
+// so the stavefile only sees it when it's run directly.)
+const OnNoTargetEnv = "STAVEFILE_ON_NO_TARGET"
+
+// FuzzyTargetsEnv is the environment variable that lets targets be run by a
+// unique prefix or abbreviation of their name.
+const FuzzyTargetsEnv = "STAVEFILE_FUZZY_TARGETS"
+
+// PerTargetTimeoutEnv is the environment variable that gives each target run
+// from the command line its own timeout, like "30s". It applies within the
+// whole-run timeout, STAVEFILE_TIMEOUT, so whichever ends first wins.
+const PerTargetTimeoutEnv = "STAVEFILE_TIMEOUT_PER_TARGET"
+
+// LogFormatEnv is the environment variable that sets how a stavefile writes
+// its messages about running targets and the errors they fail with: "text"
+// (the default), or one "json" or "logfmt" record per line, with the fields
+// ts, level, target, msg and duration. What targets print is unchanged.
+const LogFormatEnv = "STAVEFILE_LOG_FORMAT"
+
+// ErrorFormatEnv is the environment variable that sets how a stavefile
+// reports the error a run fails with: "text" (the default), or "json", a
+// single object with the fields target, error and exitCode on stderr, for CI
+// to parse.
+const ErrorFormatEnv = "STAVEFILE_ERROR_FORMAT"
+
+// YesEnv is the environment variable that answers yes to the questions of
+// targets marked stave:confirm, as --yes does, so that they run without
+// asking.
+const YesEnv = "STAVE_YES"
+
+// InteractiveEnv is the environment variable that says whether targets marked
+// stave:confirm may ask their question on stdin, overriding the check for a
+// terminal: "1" to read the answer from a pipe, "0" to never ask.
+const InteractiveEnv = "STAVEFILE_INTERACTIVE"
+
+// PrefixOutputEnv is the environment variable that makes the lines that each
+// target given on the command line, and the commands it runs, write to stdout
+// and stderr start with "[target] ". -v does the same when more than one target
+// runs.
+const PrefixOutputEnv = "STAVEFILE_PREFIX_OUTPUT"
+
+// LdflagsEnv is the environment variable through which stave tells a running
+// stavefile the -ldflags it was given, so that targets that build Go code can
+// apply them too (see Ldflags).
+const LdflagsEnv = "STAVEFILE_LDFLAGS"
+
+// NoContainerEnv is the environment variable that makes targets marked
+// stave:container run where stave runs, rather than in their container.
+const NoContainerEnv = "STAVEFILE_NO_CONTAINER"
+
+// ContainerPullEnv is the environment variable that sets when the images of
+// targets marked stave:container are pulled: "missing" (docker's default),
+// "always" or "never".
+const ContainerPullEnv = "STAVEFILE_CONTAINER_PULL"
+
+// ContainerEnvEnv is the environment variable that lists, comma-separated,
+// the environment variables passed in to targets marked stave:container,
+// besides the STAVEFILE_ ones.
+const ContainerEnvEnv = "STAVEFILE_CONTAINER_ENV"
+
+// InContainerEnv is the environment variable that stave sets in the
+// container a target marked stave:container runs in (see InContainer).
+const InContainerEnv = "STAVEFILE_IN_CONTAINER"
+
+// CleanupGraceEnv is the environment variable that sets how long targets are
+// given to clean up after they are cancelled (by SIGINT or a timeout) before
+// stave gives up on them. It takes a duration like "30s"; the default is 5
+// seconds.
+const CleanupGraceEnv = "STAVEFILE_CLEANUP_GRACE"
+
+// StaveDirEnv is the environment variable through which stave tells a running
+// stavefile the directory it read the stavefiles from (the -d flag).
+const StaveDirEnv = "STAVEFILE_DIR"
+
+// WorkDirEnv is the environment variable through which stave tells a running
+// stavefile the directory its targets run in (the -w flag).
+const WorkDirEnv = "STAVEFILE_WORKDIR"
+
+// WatchDirsEnv is the environment variable through which stave tells a
+// running stavefile the directories, separated by os.PathListSeparator, that
+// watch mode is limited to (the --watch-dir flag). Relative ones are relative
+// to the directory targets run in.
+const WatchDirsEnv = "STAVEFILE_WATCH_DIRS"
+
 // HashFastEnv is the environment variable that indicates the user requested to
 // use a quick hash of stavefiles to determine whether or not the stavefile binary
 // needs to be rebuilt. This results in faster runtimes, but means that stave
@@ -46,6 +141,11 @@ const IgnoreDefaultEnv = "STAVEFILE_IGNOREDEFAULT"
 // stave with the -f flag.
 const HashFastEnv = "STAVEFILE_HASHFAST"
 
+// NoCacheEnv is the environment variable that makes stave recompile the
+// stavefiles on every run, as the -f flag does, rather than run a cached
+// binary, for CI setups that never want one.
+const NoCacheEnv = "STAVE_NO_CACHE"
+
 // EnableColorEnv is the environment variable that indicates the user is using
 // a terminal which supports a color output. The default is false for backwards
 // compatibility. When the value is true and the detected terminal does support colors
@@ -93,6 +193,11 @@ func Verbose() bool {
 	return env.FailsafeParseBoolEnv(VerboseEnv, false)
 }
 
+// Quiet reports whether a stavefile was run with the quiet flag.
+func Quiet() bool {
+	return env.FailsafeParseBoolEnv(QuietEnv, false)
+}
+
 // Multiline reports whether a stavefile was run with the multiline flag.
 func Multiline() bool {
 	return env.FailsafeParseBoolEnv(MultilineEnv, false)
@@ -117,18 +222,60 @@ func GoCmd() string {
 	return "go"
 }
 
+// Ldflags returns the -ldflags stave was run with, for targets to pass to the
+// go builds they run, or "" if there were none. Stave itself only applies them
+// to the stavefile binary.
+func Ldflags() string {
+	return os.Getenv(LdflagsEnv)
+}
+
+// InContainer reports whether the running target was marked stave:container
+// and is running in its container.
+func InContainer() bool {
+	return env.FailsafeParseBoolEnv(InContainerEnv, false)
+}
+
 // HashFast reports whether the user has requested to use the fast hashing
 // mechanism rather than rely on go's rebuilding mechanism.
 func HashFast() bool {
 	return env.FailsafeParseBoolEnv(HashFastEnv, false)
 }
 
+// NoCache reports whether the user has requested that stave never run a
+// cached stavefile binary.
+func NoCache() bool {
+	return env.FailsafeParseBoolEnv(NoCacheEnv, false)
+}
+
 // IgnoreDefault reports whether the user has requested to ignore the default target
 // in the stavefile.
 func IgnoreDefault() bool {
 	return env.FailsafeParseBoolEnv(IgnoreDefaultEnv, false)
 }
 
+// StaveDir returns the absolute path of the directory stave read the
+// stavefiles from, which may differ from the directory targets run in (see
+// WorkingDir). It returns "." if the stavefile was not run by stave.
+func StaveDir() string {
+	if d := os.Getenv(StaveDirEnv); d != "" {
+		return d
+	}
+	return "."
+}
+
+// WorkingDir returns the absolute path of the directory targets run in. It
+// falls back to the current working directory if the stavefile was not run by
+// stave.
+func WorkingDir() string {
+	if d := os.Getenv(WorkDirEnv); d != "" {
+		return d
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
+
 // CacheDir returns the directory where stave caches compiled binaries.  It
 // defaults to $HOME/.stavefile, but may be overridden by the STAVEFILE_CACHE
 // environment variable.
@@ -0,0 +1,32 @@
+package st
+
+import "sync"
+
+var (
+	serialTargetsMu sync.RWMutex           //nolint:gochecknoglobals // Set once by the generated mainfile.
+	serialTargets   map[string]*sync.Mutex //nolint:gochecknoglobals // Set once by the generated mainfile.
+)
+
+// SetSerial marks targets as serial: runs of one of them as a dependency, or
+// through RunTarget, never overlap, even with different arguments, or when
+// ResetOnces lets it run again while an earlier run is still going. Unlike
+// SetExclusive, other targets still run alongside it. Stavefiles should not
+// call it: the generated mainfile of a stavefile that imports st does, with
+// the targets marked stave:serial.
+func SetSerial(targets ...any) {
+	locks := make(map[string]*sync.Mutex, len(targets))
+	for _, target := range targets {
+		locks[funcName(target)] = &sync.Mutex{}
+	}
+	serialTargetsMu.Lock()
+	defer serialTargetsMu.Unlock()
+	serialTargets = locks
+}
+
+// serialLock returns the mutex that keeps runs of theFunc from overlapping,
+// or nil if it wasn't marked serial with SetSerial.
+func serialLock(theFunc Fn) *sync.Mutex {
+	serialTargetsMu.RLock()
+	defer serialTargetsMu.RUnlock()
+	return serialTargets[theFunc.Name()]
+}
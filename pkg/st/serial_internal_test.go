@@ -0,0 +1,83 @@
+package st
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// overlapCounter tracks how many runs of a target overlap.
+type overlapCounter struct {
+	running, most atomic.Int32
+}
+
+func (c *overlapCounter) run() {
+	n := c.running.Add(1)
+	for {
+		most := c.most.Load()
+		if n <= most || c.most.CompareAndSwap(most, n) {
+			break
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	c.running.Add(-1)
+}
+
+var serialGenerate, parallelFormat overlapCounter
+
+func serialTestGenerate(int) { serialGenerate.run() }
+
+func serialTestFormat(int) { parallelFormat.run() }
+
+func serialTestBuild() { Deps(F(serialTestGenerate, 1), F(serialTestFormat, 1)) }
+
+func serialTestLint() { Deps(F(serialTestGenerate, 2), F(serialTestFormat, 2)) }
+
+func serialTestDocs() { Deps(F(serialTestGenerate, 3), F(serialTestFormat, 3)) }
+
+func TestSerialTargets(t *testing.T) {
+	setDepsLimit(t, 8)
+	SetSerial(serialTestGenerate)
+	deps := []any{
+		serialTestBuild, serialTestLint, serialTestDocs,
+		F(serialTestGenerate, 1), F(serialTestGenerate, 2), F(serialTestGenerate, 3),
+		F(serialTestFormat, 1), F(serialTestFormat, 2), F(serialTestFormat, 3),
+	}
+	t.Cleanup(func() {
+		SetSerial()
+		ResetSpecificOnces(deps...)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Deps(serialTestBuild, serialTestLint, serialTestDocs)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serial targets deadlocked")
+	}
+
+	if most := serialGenerate.most.Load(); most != 1 {
+		t.Errorf("expected the serial target never to overlap itself, but %d runs did", most)
+	}
+	if most := parallelFormat.most.Load(); most < 2 {
+		t.Errorf("expected runs of the target that isn't serial to overlap, but at most %d did", most)
+	}
+}
+
+func TestSerialLock(t *testing.T) {
+	SetSerial(serialTestGenerate)
+	t.Cleanup(func() { SetSerial() })
+
+	if serialLock(F(serialTestGenerate, 1)) == nil {
+		t.Error("serialTestGenerate should be serial")
+	}
+	if serialLock(F(serialTestGenerate, 1)) != serialLock(F(serialTestGenerate, 2)) {
+		t.Error("runs of serialTestGenerate with different args should share a lock")
+	}
+	if serialLock(F(serialTestFormat, 1)) != nil {
+		t.Error("serialTestFormat should not be serial")
+	}
+}
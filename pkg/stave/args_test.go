@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
 )
 
 const testDataDir = "testdata"
@@ -73,7 +74,7 @@ func TestBadIntArg(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "can't convert argument \"abc123\" to int\n"
+	expected := "target \"count\": argument \"i\" (position 1): can't convert \"abc123\" to int\n"
 	assert.Equal(t, expected, stderr.String())
 }
 
@@ -100,7 +101,7 @@ func TestBadBoolArg(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "can't convert argument \"abc123\" to bool\n"
+	expected := "target \"cough\": argument \"b\" (position 1): can't convert \"abc123\" to bool\n"
 	assert.Contains(t, stderr.String(), expected)
 }
 
@@ -129,7 +130,7 @@ func TestBadDurationArg(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "can't convert argument \"abc123\" to time.Duration\n"
+	expected := "target \"wait\": argument \"d\" (position 1): can't convert \"abc123\" to time.Duration\n"
 	assert.Equal(t, expected, stderr.String())
 }
 
@@ -158,10 +159,107 @@ func TestBadFloat64Arg(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "can't convert argument \"abc123\" to float64\n"
+	expected := "target \"doubleit\": argument \"f\" (position 1): can't convert \"abc123\" to float64\n"
 	assert.Equal(t, expected, stderr.String())
 }
 
+func TestTimeArg(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataArgsDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stderr := &bytes.Buffer{}
+	stdout := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stderr:  stderr,
+		Stdout:  stdout,
+		Args:    []string{"backup", "2026-03-04T05:06:07+02:00", "backup", "2026-03-04"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	expected := `backing up as of 2026-03-04T03:06:07Z
+backing up as of 2026-03-04T00:00:00Z
+`
+	assert.Equal(t, expected, stdout.String())
+}
+
+func TestBadTimeArg(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataArgsDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stderr := &bytes.Buffer{}
+	stdout := &bytes.Buffer{}
+	logOutput := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:         t.Context(),
+		Dir:             dataDirForThisTest,
+		Stderr:          stderr,
+		Stdout:          stdout,
+		WriterForLogger: logOutput, // Isolate slog from stderr
+		Args:            []string{"backup", "yesterday"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Equal(t, 2, sh.ExitStatus(err))
+
+	expected := "target \"backup\": argument \"at\" (position 1): can't convert \"yesterday\" to time.Time\n"
+	assert.Equal(t, expected, stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestBadArgAmongSeveral(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataArgsDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	ctx := t.Context()
+
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"repeat", "hi", "twice", "true"},
+			expected: "target \"repeat\": argument \"times\" (position 2): can't convert \"twice\" to int\n",
+		},
+		{
+			args:     []string{"repeat", "hi", "2", "very"},
+			expected: "target \"repeat\": argument \"loud\" (position 3): can't convert \"very\" to bool\n",
+		},
+	} {
+		stderr := &bytes.Buffer{}
+		stdout := &bytes.Buffer{}
+
+		runParams := RunParams{
+			BaseCtx:         ctx,
+			Dir:             dataDirForThisTest,
+			Stderr:          stderr,
+			Stdout:          stdout,
+			WriterForLogger: &bytes.Buffer{}, // Isolate slog from stderr
+			Args:            tc.args,
+		}
+
+		err := Run(runParams)
+		require.Error(t, err)
+		assert.Equal(t, 2, sh.ExitStatus(err))
+		assert.Equal(t, tc.expected, stderr.String())
+		assert.Empty(t, stdout.String())
+	}
+}
+
 func TestMissingArgs(t *testing.T) {
 	t.Parallel()
 	dataDirForThisTest := testDataArgsDir
@@ -252,3 +350,70 @@ func TestMgF(t *testing.T) {
 	expected := "saying hi Susan\n"
 	assert.Equal(t, expected, stdout.String())
 }
+
+func TestArgConstraints(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "argconstraints")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	run := func(args ...string) (string, string, error) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:         t.Context(),
+			Dir:             dataDirForThisTest,
+			Stdout:          stdout,
+			Stderr:          stderr,
+			WriterForLogger: &bytes.Buffer{}, // Isolate slog from stderr
+			Args:            args,
+		})
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("deploy", "prod", "3", "30s")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "deploying 3 replicas to prod, waiting 30s\n", stdout)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"deploy", "staging", "3", "30s"},
+			want: `invalid value "staging" for argument env of target deploy: must match ^(dev|prod)$` + "\n",
+		},
+		{
+			args: []string{"deploy", "dev", "0", "30s"},
+			want: `invalid value "0" for argument replicas of target deploy: must be at least 1` + "\n",
+		},
+		{
+			args: []string{"deploy", "dev", "51", "30s"},
+			want: `invalid value "51" for argument replicas of target deploy: must be at most 50` + "\n",
+		},
+		{
+			args: []string{"deploy", "dev", "1", "2m"},
+			want: `invalid value "2m" for argument wait of target deploy: must be at most 1m` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		stdout, stderr, err := run(tt.args...)
+		require.Error(t, err, "args: %v", tt.args)
+		assert.Equal(t, 2, sh.ExitStatus(err))
+		assert.Empty(t, stdout)
+		assert.Equal(t, tt.want, stderr)
+	}
+
+	// The invalid constraint on scale was ignored.
+	stdout, stderr, err = run("scale", "5")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "scaling by 5\n", stdout)
+
+	stdout, _, err = run("-i", "deploy")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Arguments:\n\n"+
+		"\tenv       must match ^(dev|prod)$\n"+
+		"\treplicas  between 1 and 50\n"+
+		"\twait      at most 1m\n")
+}
@@ -0,0 +1,134 @@
+package stave
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// fallbackModuleName is the module name used by --auto-mod when the stavefiles
+// directory's name can't be turned into a valid module path.
+const fallbackModuleName = "stavefiles"
+
+// missingModuleMarkers are the messages go build prints when the files it was
+// given need a go.mod that doesn't exist.
+var missingModuleMarkers = []string{
+	"cannot find main module",
+	"go.mod file not found",
+	"no required module provides package",
+	"cannot find module providing package", // with -mod=mod
+}
+
+// isMissingModuleError reports whether go build's output says the build failed
+// for want of a go.mod.
+func isMissingModuleError(output string) bool {
+	for _, marker := range missingModuleMarkers {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleNameChars matches the runs of characters that aren't allowed in a
+// module path element inferred from a directory name.
+var moduleNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// inferModuleName returns the module name that --auto-mod gives the stavefiles
+// in dir, based on the name of the project directory.
+func inferModuleName(dir string) string {
+	name := strings.ToLower(filepath.Base(dir))
+	if name == StavefilesDirName {
+		name = strings.ToLower(filepath.Base(filepath.Dir(dir)))
+	}
+	name = strings.Trim(moduleNameChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" || module.CheckImportPath(name) != nil {
+		return fallbackModuleName
+	}
+	return name
+}
+
+// autoModEnabled reports whether stave may create a go.mod for the stavefiles,
+// either because of --auto-mod or the auto_mod config key.
+func autoModEnabled(params RunParams, cfg *config.Config) bool {
+	return params.AutoMod || cfg.AutoMod
+}
+
+// compileStavefiles compiles the stavefiles, and if that fails because they are
+// not in a Go module, bootstraps one in the stavefiles dir and tries again,
+// when --auto-mod allows it.
+func compileStavefiles(ctx context.Context, params RunParams, cfg *config.Config, compileParams CompileParams) error {
+	stderr := compileParams.Stderr
+	var output bytes.Buffer
+	compileParams.Stderr = &output
+	err := Compile(ctx, compileParams)
+	_, _ = stderr.Write(output.Bytes())
+	if err == nil || !isMissingModuleError(output.String()) || inModule(params.Dir) {
+		return err
+	}
+
+	if !autoModEnabled(params, cfg) {
+		return fmt.Errorf(
+			"%w: %s is not in a Go module; run \"go mod init\" and \"go mod tidy\" there, or rerun with --auto-mod",
+			err, params.Dir,
+		)
+	}
+	if err := initStavefilesModule(ctx, params); err != nil {
+		return err
+	}
+	compileParams.Stderr = stderr
+	return Compile(ctx, compileParams)
+}
+
+// inModule reports whether dir is inside a Go module. A go.mod that can't be
+// read counts, since stave must not paper over it with a new one.
+func inModule(dir string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return true
+	}
+	root, _, err := parse.FindModuleRoot(absDir)
+	return err != nil || root != ""
+}
+
+// initStavefilesModule runs "go mod init" and "go mod tidy" in the stavefiles
+// dir. It only ever writes go.mod and go.sum there.
+func initStavefilesModule(ctx context.Context, params RunParams) error {
+	name := inferModuleName(params.Dir)
+	for _, args := range [][]string{{"init", name}, {"tidy"}} {
+		if err := runGoMod(ctx, params, params.Dir, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runGoMod runs "go mod" with args in dir, the stavefiles dir.
+func runGoMod(ctx context.Context, params RunParams, dir string, args ...string) error {
+	args = append([]string{"mod"}, args...)
+	slog.Info(
+		"bootstrapping module for stavefiles",
+		slog.String(log.Cmd, params.GoCmd),
+		slog.Any(log.Args, args),
+		slog.String(log.Dir, dir),
+	)
+	theCmd := dryrun.Wrap(ctx, nil, params.GoCmd, args...)
+	theCmd.Dir = dir
+	theCmd.Stdout = params.Stderr
+	theCmd.Stderr = params.Stderr
+	if err := theCmd.Run(); err != nil {
+		return fmt.Errorf("running %s %s in %s: %w", params.GoCmd, strings.Join(args, " "), dir, err)
+	}
+	return nil
+}
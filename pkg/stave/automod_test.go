@@ -0,0 +1,120 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// moduleLessStavefile imports a package from outside the standard library, so
+// it can't compile without a go.mod.
+const moduleLessStavefile = `//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Hello says hello.
+func Hello() {
+	fmt.Println("hello, verbose:", st.Verbose())
+}
+`
+
+// writeModuleLessProject writes a project outside any Go module, with its
+// stavefile in a stavefiles dir, and returns the project and stavefiles dirs.
+func writeModuleLessProject(t *testing.T) (string, string) {
+	t.Helper()
+	projectDir := filepath.Join(t.TempDir(), "My Project")
+	staveDir := filepath.Join(projectDir, StavefilesDirName)
+	require.NoError(t, os.MkdirAll(staveDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(staveDir, "stavefile.go"), []byte(moduleLessStavefile), 0o644))
+	require.False(t, inModule(staveDir), "temp dir is inside a Go module")
+	return projectDir, staveDir
+}
+
+func TestIsMissingModuleError(t *testing.T) {
+	t.Parallel()
+	for output, want := range map[string]bool{
+		"s.go:5:8: no required module provides package example.com/x: go.mod file not found in current directory or any parent directory": true,
+		"s.go:5:8: cannot find module providing package example.com/x":                                                                    true,
+		"go: cannot find main module, but found .git/config in /src":                                                                      true,
+		"s.go:7:2: undefined: foo": false,
+	} {
+		assert.Equal(t, want, isMissingModuleError(output), output)
+	}
+}
+
+func TestInferModuleName(t *testing.T) {
+	t.Parallel()
+	for dir, want := range map[string]string{
+		filepath.Join("src", "tools"):                         "tools",
+		filepath.Join("src", "My Project", StavefilesDirName): "my-project",
+		filepath.Join("src", "--"):                            fallbackModuleName,
+		filepath.Join("src", ".hidden"):                       "hidden",
+	} {
+		assert.Equal(t, want, inferModuleName(dir), dir)
+	}
+}
+
+func TestAutoModOff(t *testing.T) {
+	t.Parallel()
+	projectDir, staveDir := writeModuleLessProject(t)
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     staveDir,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  stderr,
+		Args:    []string{"hello"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not in a Go module")
+	assert.Contains(t, err.Error(), "--auto-mod")
+	assert.Contains(t, stderr.String(), "github.com/yaklabco/stave/pkg/st")
+	assert.NoFileExists(t, filepath.Join(staveDir, "go.mod"))
+	assert.NoFileExists(t, filepath.Join(projectDir, "go.mod"))
+}
+
+func TestAutoMod(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping auto-mod test in short mode, go mod tidy needs the network")
+	}
+	t.Parallel()
+	projectDir, staveDir := writeModuleLessProject(t)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      staveDir,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		CacheDir: t.TempDir(),
+		AutoMod:  true,
+		Args:     []string{"hello"},
+	})
+	require.NoError(t, err, stderr.String())
+	assert.Equal(t, "hello, verbose: false\n", stdout.String())
+	assert.Contains(t, stderr.String(), "bootstrapping module for stavefiles")
+
+	goMod, err := os.ReadFile(filepath.Join(staveDir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "module my-project\n")
+	assert.Contains(t, string(goMod), "github.com/yaklabco/stave")
+	assert.FileExists(t, filepath.Join(staveDir, "go.sum"))
+
+	// nothing was written outside the stavefiles dir
+	entries, err := os.ReadDir(projectDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, StavefilesDirName, entries[0].Name())
+}
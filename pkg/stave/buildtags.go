@@ -0,0 +1,331 @@
+package stave
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// constraintLikeRE matches a comment that looks like an attempt at a build
+// constraint, whether or not Go reads it as one, like "// go:build stave".
+var constraintLikeRE = regexp.MustCompile(`^//\s*(go\s*:\s*build|\+\s*build)\b\s*(.*)$`)
+
+// tagCheck is what checkBuildTags found in the build constraint of a Go file
+// that mentions the stave tag.
+type tagCheck struct {
+	Problems []string        // Problems describes what's wrong with the constraint, if anything.
+	Broken   bool            // Broken is set if Go ignores or misreads the constraint, rather than it only lacking one of the two styles.
+	Expr     constraint.Expr // Expr is the constraint the file is meant to have.
+
+	lines    []constraintLine // lines are the stave constraint lines, to be replaced.
+	insertAt int              // insertAt is the byte offset the fixed constraint goes at.
+}
+
+// constraintLine is a comment in a file's header that is, or looks like, a
+// build constraint.
+type constraintLine struct {
+	start, end int // byte offsets of the comment, without its newline
+}
+
+// checkBuildTags checks the build constraint of the Go file src, which must
+// have //go:build and // +build lines that agree, both above the package
+// clause and set apart from its doc comment. It returns nil if no constraint
+// in the header mentions the stave tag, as those files are left alone.
+func checkBuildTags(src []byte) (*tagCheck, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	packageLine := fset.Position(file.Package).Line
+
+	// all the constraint lines are checked if any of them mentions stave, as
+	// the // +build lines of a constraint like "stave && linux" needn't.
+	type candidate struct {
+		line      constraintLine
+		groupPos  int
+		expr      constraint.Expr
+		isGoBuild bool
+		isDoc     bool
+		text      string
+		problem   string
+	}
+	var candidates []candidate
+	mentionsStave := false
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		// Go reads a comment group that ends right above the package clause
+		// as package documentation, not as build constraints.
+		isDoc := fset.Position(group.End()).Line+1 >= packageLine
+		for _, comment := range group.List {
+			match := constraintLikeRE.FindStringSubmatch(comment.Text)
+			if match == nil {
+				continue
+			}
+			expr, problem := parseConstraintLine(comment.Text, match[2])
+			if expr == nil {
+				if strings.Contains(comment.Text, "stave") {
+					return nil, fmt.Errorf("can't make sense of the build constraint %q", comment.Text)
+				}
+				continue
+			}
+			mentionsStave = mentionsStave || mentionsTag(expr, "stave")
+			candidates = append(candidates, candidate{
+				line: constraintLine{
+					start: fset.Position(comment.Pos()).Offset,
+					end:   fset.Position(comment.End()).Offset,
+				},
+				groupPos:  fset.Position(group.Pos()).Offset,
+				expr:      expr,
+				isGoBuild: strings.Contains(strings.ReplaceAll(match[1], " ", ""), "go:build"),
+				isDoc:     isDoc,
+				text:      comment.Text,
+				problem:   problem,
+			})
+		}
+	}
+	if !mentionsStave {
+		return nil, nil //nolint:nilnil // No stave constraint is not an error.
+	}
+
+	check := &tagCheck{}
+	var goBuild, plusBuild []constraint.Expr
+	for i, c := range candidates {
+		if c.problem != "" {
+			check.Problems = append(check.Problems, c.problem)
+			check.Broken = true
+		}
+		if c.isDoc {
+			check.Problems = append(check.Problems, fmt.Sprintf("%q is right above the package clause, so Go reads it as documentation", c.text))
+			check.Broken = true
+		}
+		if c.isGoBuild {
+			goBuild = append(goBuild, c.expr)
+		} else {
+			plusBuild = append(plusBuild, c.expr)
+		}
+		if i == 0 {
+			// the fixed constraint goes above the doc comment, rather than in
+			// it.
+			check.insertAt = c.line.start
+			if c.isDoc {
+				check.insertAt = c.groupPos
+			}
+		}
+		check.lines = append(check.lines, c.line)
+	}
+
+	var plusExpr constraint.Expr
+	for _, expr := range plusBuild {
+		if plusExpr == nil {
+			plusExpr = expr
+		} else {
+			plusExpr = &constraint.AndExpr{X: plusExpr, Y: expr}
+		}
+	}
+	switch {
+	case len(goBuild) > 1:
+		check.Problems = append(check.Problems, "more than one //go:build line")
+		check.Broken = true
+		check.Expr = goBuild[0]
+	case len(goBuild) == 1:
+		check.Expr = goBuild[0]
+	default:
+		check.Expr = plusExpr
+		check.Problems = append(check.Problems, "no //go:build line")
+	}
+	switch {
+	case plusExpr == nil:
+		check.Problems = append(check.Problems, "no // +build line")
+	case len(goBuild) > 0 && !sameConstraint(goBuild[0], plusExpr):
+		check.Problems = append(check.Problems, fmt.Sprintf("//go:build %s and // +build lines disagree", goBuild[0]))
+		check.Broken = true
+	}
+	return check, nil
+}
+
+// parseConstraintLine parses text, a comment that looks like a //go:build or
+// // +build line, with args the part after the keyword. If Go wouldn't read
+// it as it was presumably meant, problem says why, and expr is what it was
+// meant to say.
+func parseConstraintLine(text, args string) (constraint.Expr, string) {
+	if expr, err := constraint.Parse(text); err == nil {
+		return expr, ""
+	}
+	problem := fmt.Sprintf("%q isn't a valid build constraint", text)
+	// the args are often in the other style, like "//go:build stave,linux".
+	for _, line := range []string{"//go:build " + args, "// +build " + args} {
+		if expr, err := constraint.Parse(line); err == nil {
+			return expr, problem
+		}
+	}
+	return nil, problem
+}
+
+// sameConstraint reports whether x and y are satisfied by the same tags.
+func sameConstraint(x, y constraint.Expr) bool {
+	var tags []string
+	collect := func(expr constraint.Expr) {
+		expr.Eval(func(tag string) bool {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+			return true
+		})
+	}
+	collect(x)
+	collect(y)
+	if len(tags) > 16 {
+		return x.String() == y.String()
+	}
+	for set := range 1 << len(tags) {
+		has := func(tag string) bool { return set&(1<<slices.Index(tags, tag)) != 0 }
+		if x.Eval(has) != y.Eval(has) {
+			return false
+		}
+	}
+	return true
+}
+
+// fixBuildTags returns src with its stave build constraint rewritten as the
+// pair of //go:build and // +build lines for check.Expr, where the first
+// constraint line was, or above the doc comment it was in, with a blank line
+// after them.
+func fixBuildTags(src []byte, check *tagCheck) ([]byte, error) {
+	plusLines, err := constraint.PlusBuildLines(check.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("writing // +build lines for %s: %w", check.Expr, err)
+	}
+	block := "//go:build " + check.Expr.String() + "\n" + strings.Join(plusLines, "\n") + "\n\n"
+
+	var out bytes.Buffer
+	out.Write(src[:check.insertAt])
+	out.WriteString(block)
+	last := check.insertAt
+	for _, line := range check.lines {
+		out.Write(src[last:line.start])
+		last = line.end
+		// drop the line's newline too.
+		if last < len(src) && src[last] == '\n' {
+			last++
+		}
+	}
+	out.Write(src[last:])
+
+	fixed, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting fixed file: %w", err)
+	}
+	return fixed, nil
+}
+
+// warnBuildTagProblems warns about the .go files in dir whose stave build
+// constraint Go ignores or misreads, which would otherwise silently not be
+// stavefiles.
+func warnBuildTagProblems(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return
+	}
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		check, err := checkBuildTags(src)
+		if err != nil {
+			slog.Debug("could not check build constraint", slog.String(log.Path, path), slog.Any(log.Error, err))
+			continue
+		}
+		if check == nil || !check.Broken {
+			continue
+		}
+		slog.Warn(
+			"malformed stave build constraint, run stave --fix-tags to fix it",
+			slog.String(log.Path, path),
+			slog.String(log.Reason, strings.Join(check.Problems, "; ")),
+		)
+	}
+}
+
+// runFixTagsMode handles the --fix-tags flag by rewriting the stave build
+// constraint of each .go file in the stavefiles dir that needs it as a
+// //go:build and // +build pair, and saying what it fixed.
+func runFixTagsMode(params RunParams) error {
+	files, err := filepath.Glob(filepath.Join(params.Dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(files)
+	fixed := 0
+	var errs []error
+	for _, path := range files {
+		changed, problems, err := fixBuildTagsFile(path, params.DryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if !changed {
+			continue
+		}
+		fixed++
+		verb := "fixed"
+		if params.DryRun {
+			verb = "would fix"
+		}
+		_, _ = fmt.Fprintf(params.Stdout, "%s %s: %s\n", verb, path, strings.Join(problems, "; "))
+	}
+	if fixed == 0 && len(errs) == 0 {
+		_, _ = io.WriteString(params.Stdout, "no build constraints to fix\n")
+	}
+	if len(errs) > 0 {
+		return newError(KindParse, errors.Join(errs...))
+	}
+	return nil
+}
+
+// fixBuildTagsFile fixes the stave build constraint of the Go file at path,
+// unless dryRun is set, and reports whether it needed fixing and why.
+func fixBuildTagsFile(path string, dryRun bool) (bool, []string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, nil, err
+	}
+	check, err := checkBuildTags(src)
+	if err != nil || check == nil || len(check.Problems) == 0 {
+		return false, nil, err
+	}
+	fixed, err := fixBuildTags(src, check)
+	if err != nil {
+		return false, nil, err
+	}
+	if bytes.Equal(fixed, src) {
+		return false, nil, nil
+	}
+	if dryRun {
+		return true, check.Problems, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, nil, err
+	}
+	if err := os.WriteFile(path, fixed, info.Mode().Perm()); err != nil {
+		return false, nil, err
+	}
+	return true, check.Problems, nil
+}
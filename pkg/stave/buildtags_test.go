@@ -0,0 +1,126 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixBuildTags(t *testing.T) {
+	t.Parallel()
+	const canonical = "//go:build stave\n// +build stave\n\npackage main\n"
+	for _, tc := range []struct {
+		name   string
+		src    string
+		want   string
+		broken bool
+	}{
+		{
+			name: "go:build only",
+			src:  "//go:build stave\n\npackage main\n",
+			want: canonical,
+		},
+		{
+			name: "+build only",
+			src:  "// +build stave\n\npackage main\n",
+			want: canonical,
+		},
+		{
+			name:   "space after slashes",
+			src:    "// go:build stave\n\npackage main\n",
+			want:   canonical,
+			broken: true,
+		},
+		{
+			name:   "+build syntax in go:build line",
+			src:    "//go:build stave,linux\n\npackage main\n",
+			want:   "//go:build stave && linux\n// +build stave,linux\n\npackage main\n",
+			broken: true,
+		},
+		{
+			name:   "no blank line before package",
+			src:    "// Build tools.\n// +build stave\npackage main\n",
+			want:   "//go:build stave\n// +build stave\n\n// Build tools.\npackage main\n",
+			broken: true,
+		},
+		{
+			name:   "mismatched",
+			src:    "//go:build stave\n// +build stave,!windows\n\npackage main\n",
+			want:   canonical,
+			broken: true,
+		},
+		{
+			name: "other tags and a copyright header kept",
+			src:  "// Copyright 2026 Acme.\n\n//go:build stave && (linux || darwin)\n\n// Package main builds things.\npackage main\n\nfunc Build() {}\n",
+			want: "// Copyright 2026 Acme.\n\n//go:build stave && (linux || darwin)\n// +build stave\n// +build linux darwin\n\n// Package main builds things.\npackage main\n\nfunc Build() {}\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			check, err := checkBuildTags([]byte(tc.src))
+			require.NoError(t, err)
+			require.NotNil(t, check)
+			assert.NotEmpty(t, check.Problems)
+			assert.Equal(t, tc.broken, check.Broken, "problems: %q", check.Problems)
+
+			fixed, err := fixBuildTags([]byte(tc.src), check)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(fixed))
+
+			// fixing is idempotent.
+			check, err = checkBuildTags(fixed)
+			require.NoError(t, err)
+			require.NotNil(t, check)
+			assert.Empty(t, check.Problems)
+		})
+	}
+}
+
+func TestCheckBuildTagsLeavesOtherFilesAlone(t *testing.T) {
+	t.Parallel()
+	for _, src := range []string{
+		"package main\n",
+		"//go:build linux\n\npackage main\n",
+		"// Package main mentions stave, but not in a constraint.\npackage main\n",
+	} {
+		check, err := checkBuildTags([]byte(src))
+		require.NoError(t, err)
+		assert.Nil(t, check, src)
+	}
+}
+
+func TestRunFixTagsMode(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	fixable := filepath.Join(dir, "stavefile.go")
+	fine := filepath.Join(dir, "tools.go")
+	other := filepath.Join(dir, "lib.go")
+	require.NoError(t, os.WriteFile(fixable, []byte("// +build stave\n\npackage main\n"), 0o644))
+	require.NoError(t, os.WriteFile(fine, []byte("//go:build stave\n// +build stave\n\npackage main\n"), 0o644))
+	require.NoError(t, os.WriteFile(other, []byte("package main\n"), 0o644))
+
+	stdout := &bytes.Buffer{}
+	require.NoError(t, runFixTagsMode(RunParams{Dir: dir, Stdout: stdout, DryRun: true}))
+	assert.Equal(t, "would fix "+fixable+": no //go:build line\n", stdout.String())
+	data, err := os.ReadFile(fixable)
+	require.NoError(t, err)
+	assert.Equal(t, "// +build stave\n\npackage main\n", string(data))
+
+	stdout.Reset()
+	require.NoError(t, runFixTagsMode(RunParams{Dir: dir, Stdout: stdout}))
+	assert.Equal(t, "fixed "+fixable+": no //go:build line\n", stdout.String())
+	data, err = os.ReadFile(fixable)
+	require.NoError(t, err)
+	assert.Equal(t, "//go:build stave\n// +build stave\n\npackage main\n", string(data))
+	data, err = os.ReadFile(other)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(data))
+
+	stdout.Reset()
+	require.NoError(t, runFixTagsMode(RunParams{Dir: dir, Stdout: stdout}))
+	assert.Equal(t, "no build constraints to fix\n", stdout.String())
+}
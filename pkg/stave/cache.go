@@ -0,0 +1,141 @@
+package stave
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// cacheMetaSuffix is appended to the name of a compiled stavefile in the cache
+// dir to get the name of its metadata sidecar.
+const cacheMetaSuffix = ".meta.json"
+
+// cacheMeta is the sidecar written next to each compiled stavefile in the
+// cache dir, so that `stave --clean --dryrun` can say where it came from, and
+// a rebuild can say which files changed since.
+type cacheMeta struct {
+	Dir     string            `json:"dir"`
+	Created time.Time         `json:"created"`
+	Files   map[string]string `json:"files,omitempty"` // Files maps each hashed file, relative to Dir, to its hash.
+}
+
+// writeCacheMeta records the stavefiles dir that exePath was compiled from,
+// and the hashes of the files it was compiled from. The sidecar is optional,
+// so failures are only logged.
+func writeCacheMeta(exePath, dir string, files map[string]string) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+	data, err := json.Marshal(cacheMeta{Dir: absDir, Created: time.Now(), Files: files})
+	if err == nil {
+		err = os.WriteFile(exePath+cacheMetaSuffix, data, 0o644)
+	}
+	if err != nil {
+		slog.Debug("could not write cache metadata", slog.String(log.Path, exePath), slog.Any(log.Error, err))
+	}
+}
+
+// cacheEntry is a file in the cache dir, as listed by `stave --clean --dryrun`.
+type cacheEntry struct {
+	name    string
+	size    int64 // including the metadata sidecar, if any
+	modTime time.Time
+	project string
+}
+
+// listCache returns the files that `stave --clean` would remove from dir,
+// newest first, with each binary's metadata sidecar folded into its entry.
+func listCache(dir string) ([]cacheEntry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := make(map[string]os.FileInfo, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files[dirEntry.Name()] = info
+	}
+
+	var entries []cacheEntry
+	for name, info := range files {
+		if binary, isMeta := strings.CutSuffix(name, cacheMetaSuffix); isMeta && files[binary] != nil {
+			continue // listed with its binary
+		}
+		entry := cacheEntry{name: name, size: info.Size(), modTime: info.ModTime()}
+		if meta, ok := files[name+cacheMetaSuffix]; ok {
+			entry.size += meta.Size()
+			entry.project = readCacheMeta(filepath.Join(dir, name+cacheMetaSuffix)).Dir
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b cacheEntry) int {
+		return cmp.Or(b.modTime.Compare(a.modTime), strings.Compare(a.name, b.name))
+	})
+	return entries, nil
+}
+
+// readCacheMeta reads a metadata sidecar, returning the zero value if it
+// can't.
+func readCacheMeta(path string) cacheMeta {
+	var meta cacheMeta
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &meta)
+	}
+	if err != nil {
+		slog.Debug("could not read cache metadata", slog.String(log.Path, path), slog.Any(log.Error, err))
+	}
+	return meta
+}
+
+// renderCacheDryRun renders the output of `stave --clean --dryrun`.
+func renderCacheDryRun(out io.Writer, dir string, entries []cacheEntry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintf(out, "Nothing to remove from %s\n", dir)
+		return err
+	}
+
+	var total int64
+	var b strings.Builder
+	fmt.Fprintf(&b, "Would remove from %s:\n\n", dir)
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tSIZE\tMODIFIED\tPROJECT")
+	for _, entry := range entries {
+		total += entry.size
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			entry.name,
+			formatBytes(uint64(entry.size)), //nolint:gosec // file sizes are non-negative
+			entry.modTime.Format(time.DateTime),
+			orDash(entry.project),
+		)
+	}
+	_ = tw.Flush()
+	noun := "entries"
+	if len(entries) == 1 {
+		noun = "entry"
+	}
+	fmt.Fprintf(&b, "\n%d %s, %s reclaimable\n", len(entries), noun, formatBytes(uint64(total))) //nolint:gosec // file sizes are non-negative
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
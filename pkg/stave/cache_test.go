@@ -0,0 +1,101 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanDryRun(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	seed := func(name string, size int, modTime time.Time) {
+		path := filepath.Join(cacheDir, name)
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o755))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	older := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	newer := older.Add(time.Hour)
+
+	seed("aaaa", 2048, older)
+	writeCacheMeta(filepath.Join(cacheDir, "aaaa"), projectDir, nil)
+	seed("bbbb", 1000, newer) // from before sidecars were written
+	require.NoError(t, os.Mkdir(filepath.Join(cacheDir, "subdir"), 0o755))
+
+	metaInfo, err := os.Stat(filepath.Join(cacheDir, "aaaa"+cacheMetaSuffix))
+	require.NoError(t, err)
+
+	stdout := &bytes.Buffer{}
+	err = Run(RunParams{
+		BaseCtx:  t.Context(),
+		Stdout:   stdout,
+		Stderr:   &bytes.Buffer{},
+		CacheDir: cacheDir,
+		Clean:    true,
+		DryRun:   true,
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(stdout.String(), "\n")
+	require.GreaterOrEqual(t, len(lines), 7, stdout.String())
+	assert.Equal(t, "Would remove from "+cacheDir+":", lines[0])
+	assert.Equal(t, []string{"NAME", "SIZE", "MODIFIED", "PROJECT"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"bbbb", "1000B", "2026-01-02", "04:04:05", "-"}, strings.Fields(lines[3]))
+	assert.Equal(t,
+		[]string{"aaaa", formatBytes(uint64(2048 + metaInfo.Size())), "2026-01-02", "03:04:05", projectDir},
+		strings.Fields(lines[4]),
+	)
+	assert.Equal(t,
+		"2 entries, "+formatBytes(uint64(3048+metaInfo.Size()))+" reclaimable",
+		lines[6],
+	)
+
+	// nothing was deleted
+	for _, name := range []string{"aaaa", "aaaa" + cacheMetaSuffix, "bbbb"} {
+		assert.FileExists(t, filepath.Join(cacheDir, name))
+	}
+	assert.DirExists(t, filepath.Join(cacheDir, "subdir"))
+}
+
+func TestCleanRemovesCacheMeta(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	exe := filepath.Join(cacheDir, "aaaa")
+	require.NoError(t, os.WriteFile(exe, []byte("binary"), 0o755))
+	writeCacheMeta(exe, t.TempDir(), nil)
+
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &bytes.Buffer{},
+		CacheDir: cacheDir,
+		Clean:    true,
+	})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, exe)
+	assert.NoFileExists(t, exe+cacheMetaSuffix)
+}
+
+func TestListCacheOrphanMeta(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "gone"+cacheMetaSuffix), []byte("{}"), 0o644))
+
+	entries, err := listCache(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "gone"+cacheMetaSuffix, entries[0].name)
+
+	entries, err = listCache(filepath.Join(cacheDir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -2,13 +2,39 @@ package stave
 
 import (
 	"context"
+	"io"
 	"path/filepath"
 
+	"github.com/yaklabco/stave/config"
 	"github.com/yaklabco/stave/internal/parse"
 )
 
-// TargetNames returns a list of all targets in the current directory or stavefiles/ directory.
+// TargetNames returns a list of all targets in the current directory or
+// stavefiles/ directory, and the external targets configured for it.
 func TargetNames(ctx context.Context, dir string) ([]string, error) {
+	info, cfg, err := parseTargetsIn(ctx, dir)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	targets := make([]string, 0, len(info.Funcs)+len(info.Aliases)+len(cfg.ExternalTargets))
+	for _, f := range info.Funcs {
+		targets = append(targets, lowerFirstTargetName(f.TargetName()))
+	}
+	for alias := range info.Aliases {
+		targets = append(targets, lowerFirstTargetName(alias))
+	}
+	for name := range cfg.ExternalTargets {
+		targets = append(targets, name)
+	}
+
+	return targets, nil
+}
+
+// parseTargetsIn parses the stavefiles in dir, or in its stavefiles/
+// directory, and returns them with the config they are run with. It returns
+// nil if there are no stavefiles.
+func parseTargetsIn(ctx context.Context, dir string) (*parse.PkgInfo, *config.Config, error) {
 	params := RunParams{
 		Dir: dir,
 	}
@@ -16,10 +42,10 @@ func TargetNames(ctx context.Context, dir string) ([]string, error) {
 
 	files, err := Stavefiles(params.Dir, params.GOOS, params.GOARCH, params.UsesStavefiles())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(files) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	filenames := make([]string, 0, len(files))
@@ -27,18 +53,16 @@ func TargetNames(ctx context.Context, dir string) ([]string, error) {
 		filenames = append(filenames, filepath.Base(files[i]))
 	}
 
-	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, filenames, params.Multiline)
+	// Config warnings would garble the completions.
+	opts := configLoadOptions(params)
+	opts.Stderr = io.Discard
+	cfg, err := config.Load(opts)
 	if err != nil {
-		return nil, err
-	}
-
-	targets := make([]string, 0, len(info.Funcs)+len(info.Aliases))
-	for _, f := range info.Funcs {
-		targets = append(targets, lowerFirstTargetName(f.TargetName()))
+		return nil, nil, err
 	}
-	for alias := range info.Aliases {
-		targets = append(targets, lowerFirstTargetName(alias))
+	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, filenames, params.Multiline, cfg.ImportAliases, params.GoEnv)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return targets, nil
+	return info, cfg, nil
 }
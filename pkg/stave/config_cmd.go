@@ -1,11 +1,16 @@
 package stave
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/yaklabco/stave/config"
@@ -35,7 +40,15 @@ func RunConfigCommand(stdout, stderr io.Writer, args []string) int {
 
 // RunConfigCommandContext handles the `stave --config` subcommand with context.
 // It returns the exit code.
-func RunConfigCommandContext(_ context.Context, stdout, stderr io.Writer, args []string) int {
+func RunConfigCommandContext(ctx context.Context, stdout, stderr io.Writer, args []string) int {
+	return runConfigCommand(ctx, stdout, stderr, args, nil, false)
+}
+
+// runConfigCommand handles the `stave --config` subcommand, loading the
+// config with opts, so that show and path report on the same config as the
+// rest of the run. With origin, show annotates each value with where it came
+// from, as with `show --origin`.
+func runConfigCommand(_ context.Context, stdout, stderr io.Writer, args []string, opts *config.LoadOptions, origin bool) int {
 	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
 	flagSet.SetOutput(stdout)
 	flagSet.Usage = func() {
@@ -53,7 +66,7 @@ func RunConfigCommandContext(_ context.Context, stdout, stderr io.Writer, args [
 	subArgs := flagSet.Args()
 	if len(subArgs) == 0 {
 		// No subcommand, show effective config
-		return runConfigShow(stdout, stderr)
+		return runConfigShow(stdout, stderr, opts, origin)
 	}
 
 	subcmd := ConfigSubcommand(strings.ToLower(subArgs[0]))
@@ -61,9 +74,22 @@ func RunConfigCommandContext(_ context.Context, stdout, stderr io.Writer, args [
 	case ConfigInit:
 		return runConfigInit(stdout, stderr)
 	case ConfigShow:
-		return runConfigShow(stdout, stderr)
+		showFlags := flag.NewFlagSet("show", flag.ContinueOnError)
+		showFlags.SetOutput(stdout)
+		showFlags.Usage = func() {
+			configUsage(stdout)
+		}
+		showFlags.BoolVar(&origin, "origin", origin, "show where each value comes from")
+		if err := showFlags.Parse(subArgs[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitCodeOK
+			}
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitCodeUsageError
+		}
+		return runConfigShow(stdout, stderr, opts, origin)
 	case ConfigPath:
-		return runConfigPath(stdout, stderr)
+		return runConfigPath(stdout, stderr, opts)
 	default:
 		_, _ = fmt.Fprintf(stderr, "Error: unknown config subcommand %q\n", subArgs[0])
 		configUsage(stderr)
@@ -82,9 +108,10 @@ func runConfigInit(stdout, stderr io.Writer) int {
 	return 0
 }
 
-// runConfigShow displays the effective configuration.
-func runConfigShow(stdout, stderr io.Writer) int {
-	cfg, err := config.Load(nil)
+// runConfigShow displays the effective configuration, with origin annotating
+// each value with where it came from.
+func runConfigShow(stdout, stderr io.Writer, opts *config.LoadOptions, origin bool) int {
+	cfg, err := config.Load(opts)
 	if err != nil {
 		_, _ = fmt.Fprintf(stderr, "Error loading config: %v\n", err)
 		return 1
@@ -93,34 +120,141 @@ func runConfigShow(stdout, stderr io.Writer) int {
 	_, _ = fmt.Fprintln(stdout, "# Effective Stave Configuration")
 	if cfg.ConfigFile() != "" {
 		_, _ = fmt.Fprintf(stdout, "# Loaded from: %s\n", cfg.ConfigFile())
+		if user := cfg.UserConfigFile(); user != "" && user != cfg.ConfigFile() {
+			_, _ = fmt.Fprintf(stdout, "# Inherits from: %s\n", user)
+		}
 	} else {
 		_, _ = fmt.Fprintln(stdout, "# (using defaults, no config file found)")
 	}
 	_, _ = fmt.Fprintln(stdout)
-	_, _ = fmt.Fprintf(stdout, "cache_dir: %s\n", cfg.CacheDir)
-	_, _ = fmt.Fprintf(stdout, "go_cmd: %s\n", cfg.GoCmd)
-	_, _ = fmt.Fprintf(stdout, "verbose: %v\n", cfg.Verbose)
-	_, _ = fmt.Fprintf(stdout, "debug: %v\n", cfg.Debug)
-	_, _ = fmt.Fprintf(stdout, "hash_fast: %v\n", cfg.HashFast)
-	_, _ = fmt.Fprintf(stdout, "ignore_default: %v\n", cfg.IgnoreDefault)
-	_, _ = fmt.Fprintf(stdout, "enable_color: %v\n", cfg.EnableColor)
-	_, _ = fmt.Fprintf(stdout, "target_color: %s\n", cfg.TargetColor)
+
+	annotate := func(line, key string) string {
+		if origin {
+			line += "  # " + string(cfg.Origin(key))
+		}
+		return line
+	}
+	show := func(indent, key, name string, value any) {
+		_, _ = fmt.Fprintln(stdout, annotate(indent+yamlScalar(name)+": "+yamlValue(value), key))
+	}
+	show("", "cache_dir", "cache_dir", cfg.CacheDir)
+	show("", "go_cmd", "go_cmd", cfg.GoCmd)
+	show("", "verbose", "verbose", cfg.Verbose)
+	show("", "multiline", "multiline", cfg.Multiline)
+	show("", "debug", "debug", cfg.Debug)
+	show("", "hash_fast", "hash_fast", cfg.HashFast)
+	show("", "ignore_default", "ignore_default", cfg.IgnoreDefault)
+	show("", "on_no_target", "on_no_target", cfg.OnNoTarget)
+	show("", "fuzzy_targets", "fuzzy_targets", cfg.FuzzyTargets)
+	show("", "enable_color", "enable_color", cfg.EnableColor)
+	show("", "target_color", "target_color", cfg.TargetColor)
+	show("", "min_free_disk", "min_free_disk", cfg.MinFreeDisk)
+	show("", "auto_mod", "auto_mod", cfg.AutoMod)
+	show("", "verify", "verify", cfg.Verify)
+	if cfg.MainfileName != "" {
+		show("", "mainfile_name", "mainfile_name", cfg.MainfileName)
+	}
+	if len(cfg.EnvFiles) > 0 {
+		show("", "env_files", "env_files", cfg.EnvFiles)
+	}
+	if cfg.Ldflags != "" {
+		show("", "ldflags", "ldflags", string(cfg.Ldflags))
+	}
+	if cfg.Gcflags != "" {
+		show("", "gcflags", "gcflags", string(cfg.Gcflags))
+	}
+	if cfg.Asmflags != "" {
+		show("", "asmflags", "asmflags", string(cfg.Asmflags))
+	}
+	if len(cfg.ImportAliases) > 0 {
+		_, _ = fmt.Fprintln(stdout, "import_aliases:")
+		for _, importPath := range slices.Sorted(maps.Keys(cfg.ImportAliases)) {
+			show("  ", "import_aliases."+importPath, importPath, cfg.ImportAliases[importPath])
+		}
+	}
+	if cfg.BinaryCache.Enabled() {
+		_, _ = fmt.Fprintln(stdout, "binary_cache:")
+		show("  ", "binary_cache.type", "type", cmp.Or(cfg.BinaryCache.Type, config.BinaryCacheTypeHTTP))
+		show("  ", "binary_cache.url", "url", cfg.BinaryCache.URL)
+		show("  ", "binary_cache.write", "write", cfg.BinaryCache.Write)
+	}
+	if len(cfg.Hooks) > 0 {
+		_, _ = fmt.Fprintln(stdout, annotate("hooks:", "hooks"))
+		for _, hookName := range slices.Sorted(maps.Keys(cfg.Hooks)) {
+			_, _ = fmt.Fprintf(stdout, "  %s:\n", yamlScalar(hookName))
+			for _, step := range cfg.Hooks[hookName] {
+				fields := []string{"target", step.Target, "run", step.Run, "workdir", step.WorkDir}
+				prefix := "    - "
+				for i := 0; i < len(fields); i += 2 {
+					if fields[i+1] == "" {
+						continue
+					}
+					_, _ = fmt.Fprintf(stdout, "%s%s: %s\n", prefix, fields[i], yamlScalar(fields[i+1]))
+					prefix = "      "
+				}
+				if len(step.Args) > 0 {
+					_, _ = fmt.Fprintf(stdout, "%sargs: %s\n", prefix, yamlValue(step.Args))
+				}
+			}
+		}
+	}
 
 	return 0
 }
 
+// yamlPlainRE matches the strings that YAML can hold unquoted, leaving aside
+// those it would read as another type. Others are quoted, which is always
+// valid, if not always needed.
+var yamlPlainRE = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./~@+-]*$`)
+
+// yamlValue formats value, a config value, as YAML: strings as by yamlScalar,
+// and lists of them as flow sequences.
+func yamlValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return yamlScalar(value)
+	case []string:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = yamlScalar(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// yamlScalar formats s as a YAML string, quoted if YAML would otherwise read
+// it as something else, like "" or "true", or couldn't read it.
+func yamlScalar(s string) string {
+	if !yamlPlainRE.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", ".inf", ".nan":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
 // runConfigPath displays the configuration file paths.
-func runConfigPath(stdout, _ io.Writer) int {
+func runConfigPath(stdout, _ io.Writer, opts *config.LoadOptions) int {
 	paths := config.ResolveXDGPaths()
 
 	_, _ = fmt.Fprintln(stdout, "Configuration Paths:")
-	_, _ = fmt.Fprintf(stdout, "  User config:    %s\n", paths.ConfigFilePath())
+	_, _ = fmt.Fprintf(stdout, "  User config:    %s\n", config.UserConfigPath())
 	_, _ = fmt.Fprintf(stdout, "  Config dir:     %s\n", paths.ConfigDir())
 	_, _ = fmt.Fprintf(stdout, "  Cache dir:      %s\n", paths.CacheDir())
 	_, _ = fmt.Fprintf(stdout, "  Data dir:       %s\n", paths.DataDir())
 
 	// Check if user config exists
-	cfg, err := config.Load(nil)
+	cfg, err := config.Load(opts)
 	if err == nil && cfg.ConfigFile() != "" {
 		_, _ = fmt.Fprintf(stdout, "\nActive config file: %s\n", cfg.ConfigFile())
 	} else {
@@ -139,13 +273,16 @@ Manage Stave configuration.
 
 Subcommands:
   init    Create a default configuration file
-  show    Display effective configuration (default)
+  show    Display effective configuration as YAML (default); with --origin,
+          show where each value comes from: default, user, project, env
+          or flag
   path    Show configuration file paths
 
 Examples:
   stave --config           # Show effective configuration
   stave --config init      # Create ~/.config/stave/config.yaml
   stave --config show      # Same as 'stave --config'
+  stave --config show --origin  # Annotate values with their source
   stave --config path      # Show config file locations
 `[1:])
 }
@@ -176,3 +176,122 @@ func TestRunConfigCommand_Help(t *testing.T) {
 		t.Errorf("Expected help output, got: %s", output)
 	}
 }
+
+func TestRunConfigCommand_ConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(configFile, []byte("auto_mod: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{nil, {"show"}, {"path"}} {
+		var stdout, stderr bytes.Buffer
+		err := Run(RunParams{
+			BaseCtx:    t.Context(),
+			Dir:        t.TempDir(),
+			Stdout:     &stdout,
+			Stderr:     &stderr,
+			Config:     true,
+			ConfigFile: configFile,
+			Args:       args,
+		})
+		if err != nil {
+			t.Fatalf("stave --config %v: %v; stderr: %s", args, err, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), configFile) {
+			t.Errorf("stave --config %v: expected output to name %s, got: %s", args, configFile, stdout.String())
+		}
+	}
+}
+
+func TestRunConfigCommand_ShowOrigin(t *testing.T) {
+	userConfig := filepath.Join(t.TempDir(), "user.yaml")
+	if err := os.WriteFile(userConfig, []byte("hash_fast: true\ntarget_color: Red\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.UserConfigEnv, userConfig)
+	t.Setenv("STAVEFILE_GOCMD", "")
+	t.Setenv("STAVEFILE_VERBOSE", "")
+	t.Setenv("STAVEFILE_TARGET_COLOR", "")
+	configFile := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(configFile, []byte("target_color: Blue\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []RunParams{
+		{Args: []string{"show", "--origin"}},
+		{Args: nil, ConfigOrigin: true},
+	} {
+		var stdout, stderr bytes.Buffer
+		params.BaseCtx = t.Context()
+		params.Dir = t.TempDir()
+		params.Stdout = &stdout
+		params.Stderr = &stderr
+		params.Config = true
+		params.ConfigFile = configFile
+		params.Verbose = true
+		if err := Run(params); err != nil {
+			t.Fatalf("stave --config %v: %v; stderr: %s", params.Args, err, stderr.String())
+		}
+
+		output := stdout.String()
+		for _, want := range []string{
+			"# Inherits from: " + userConfig,
+			"hash_fast: true  # user",
+			"target_color: Blue  # project",
+			"verbose: true  # flag",
+			"auto_mod: false  # default",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("stave --config %v: expected output to contain %q, got: %s", params.Args, want, output)
+			}
+		}
+	}
+}
+
+func TestRunConfigCommand_ShowFlagOverridesFile(t *testing.T) {
+	// an empty user config, so that the user's own doesn't show up.
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(userConfig, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.UserConfigEnv, userConfig)
+	t.Setenv("STAVEFILE_GOCMD", "")
+	t.Setenv("STAVEFILE_TARGET_COLOR", "")
+	dir := t.TempDir()
+	projectConfig := "go_cmd: go1.24\n" +
+		"target_color: Blue\n" +
+		"min_free_disk: \"0\"\n" +
+		"env_files: [.env, \"!ci env\"]\n" +
+		"hooks:\n  pre-commit:\n    - target: lint\n      args: [--fix]\n"
+	if err := os.WriteFile(filepath.Join(dir, "stave.yaml"), []byte(projectConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Config:  true,
+		GoCmd:   "go1.25",
+		Args:    []string{"show", "--origin"},
+	})
+	if err != nil {
+		t.Fatalf("stave --config show --origin: %v; stderr: %s", err, stderr.String())
+	}
+
+	output := stdout.String()
+	for _, want := range []string{
+		"\ngo_cmd: go1.25  # flag\n",
+		"\ntarget_color: Blue  # project\n",
+		"\nmin_free_disk: \"0\"  # project\n",
+		"\nenv_files: [.env, \"!ci env\"]  # project\n",
+		"\nmultiline: false  # default\n",
+		"\nhooks:  # project\n  pre-commit:\n    - target: lint\n      args: [\"--fix\"]\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got: %s", want, output)
+		}
+	}
+}
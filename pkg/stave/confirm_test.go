@@ -0,0 +1,113 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// runConfirm runs args in testdata/confirm with stdin as the answer to any
+// question, and returns stdout, stderr and the error. The tests that call it
+// can't be parallel, as they set the environment the compiled stavefile
+// inherits.
+func runConfirm(t *testing.T, stdin string, yes bool, args ...string) (string, string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "confirm")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdin:   strings.NewReader(stdin),
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Yes:     yes,
+		Args:    args,
+	})
+	return stdout.String(), stderr.String(), err
+}
+
+func TestConfirmAnsweredYes(t *testing.T) {
+	t.Setenv(st.YesEnv, "")
+	t.Setenv(st.InteractiveEnv, "1")
+
+	stdout, stderr, err := runConfirm(t, "y\n", false, "clean", "build")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Contains(t, stderr, "This will delete the dist directory. Continue? [y/N] ")
+	assert.Equal(t, "cleaned\nbuilt\n", stdout)
+}
+
+func TestConfirmAnsweredNo(t *testing.T) {
+	t.Setenv(st.YesEnv, "")
+	t.Setenv(st.InteractiveEnv, "1")
+
+	for _, answer := range []string{"n\n", "\n", "whatever\n", ""} {
+		stdout, stderr, err := runConfirm(t, answer, false, "clean")
+		require.Error(t, err, "answer %q", answer)
+		assert.Contains(t, stderr, "not running target clean: not confirmed", "answer %q", answer)
+		assert.Empty(t, stdout, "answer %q", answer)
+	}
+}
+
+func TestConfirmNotInteractive(t *testing.T) {
+	t.Setenv(st.YesEnv, "")
+	t.Setenv(st.InteractiveEnv, "")
+
+	// stdin isn't a terminal, so the question isn't asked.
+	stdout, stderr, err := runConfirm(t, "y\n", false, "clean")
+	require.Error(t, err)
+	assert.Contains(t, stderr, "target clean requires confirmation; pass --yes or set STAVE_YES=1")
+	assert.NotContains(t, stderr, "Continue?")
+	assert.Empty(t, stdout)
+
+	// targets that don't ask still run.
+	stdout, stderr, err = runConfirm(t, "", false, "build")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "built\n", stdout)
+}
+
+func TestConfirmYes(t *testing.T) {
+	t.Setenv(st.YesEnv, "")
+	t.Setenv(st.InteractiveEnv, "1")
+
+	stdout, stderr, err := runConfirm(t, "n\n", true, "clean")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.NotContains(t, stderr, "Continue?")
+	assert.Equal(t, "cleaned\n", stdout)
+
+	t.Setenv(st.YesEnv, "1")
+	stdout, stderr, err = runConfirm(t, "n\n", false, "clean")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "cleaned\n", stdout)
+}
+
+func TestConfirmInfo(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "confirm")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Info:    true,
+		Args:    []string{"clean"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Contains(t, stdout.String(), "Asks to confirm: This will delete the dist directory. Continue?\n")
+	assert.NotContains(t, stdout.String(), "stave:confirm")
+}
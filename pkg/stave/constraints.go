@@ -0,0 +1,129 @@
+package stave
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// unixOS are the GOOS values that satisfy the "unix" build tag.
+var unixOS = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos",
+	"ios", "linux", "netbsd", "openbsd", "solaris",
+}
+
+// explainExcludedStavefiles logs, at debug level, why each of the given files
+// that go/build ignored was excluded, if it has the stave build tag. Users
+// otherwise have no way to tell why the targets in such a file are missing.
+func explainExcludedStavefiles(bctx build.Context, dir string, ignored []string) {
+	for _, name := range ignored {
+		path := filepath.Join(dir, name)
+		expr, err := readBuildConstraint(path)
+		if err != nil {
+			slog.Debug("could not read build constraint", slog.String(log.Path, path), slog.Any(log.Error, err))
+			continue
+		}
+		if expr == nil || !mentionsTag(expr, "stave") {
+			continue
+		}
+		slog.Debug(
+			"stavefile excluded by build constraints",
+			slog.String(log.Path, path),
+			slog.String(log.Constraint, expr.String()),
+			slog.String(log.Reason, exclusionReason(bctx, name, expr)),
+		)
+	}
+}
+
+// readBuildConstraint returns the //go:build expression of the Go file at
+// path, or nil if it has none.
+func readBuildConstraint(path string) (constraint.Expr, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				return constraint.Parse(comment.Text)
+			}
+		}
+	}
+	return nil, nil //nolint:nilnil // No constraint is not an error.
+}
+
+// mentionsTag reports whether tag appears anywhere in expr.
+func mentionsTag(expr constraint.Expr, tag string) bool {
+	found := false
+	expr.Eval(func(t string) bool {
+		found = found || t == tag
+		return true
+	})
+	return found
+}
+
+// exclusionReason explains why bctx excludes the file called name, whose
+// build constraint is expr. If expr is satisfied, the file's _GOOS or _GOARCH
+// name suffix must be what excludes it.
+func exclusionReason(bctx build.Context, name string, expr constraint.Expr) string {
+	target := fmt.Sprintf("GOOS=%s GOARCH=%s", bctx.GOOS, bctx.GOARCH)
+	if expr.Eval(matchTag(bctx)) {
+		return fmt.Sprintf("the GOOS or GOARCH suffix of the file name %s doesn't match %s", name, target)
+	}
+	failed := failingTerms(expr, matchTag(bctx))
+	return fmt.Sprintf("false for %s: %s", target, strings.Join(failed, ", "))
+}
+
+// failingTerms returns the smallest parts of expr, which is false, that make
+// it false.
+func failingTerms(expr constraint.Expr, ok func(string) bool) []string {
+	switch e := expr.(type) {
+	case *constraint.AndExpr:
+		var terms []string
+		for _, x := range []constraint.Expr{e.X, e.Y} {
+			if !x.Eval(ok) {
+				terms = append(terms, failingTerms(x, ok)...)
+			}
+		}
+		return terms
+	case *constraint.OrExpr:
+		return append(failingTerms(e.X, ok), failingTerms(e.Y, ok)...)
+	default:
+		return []string{expr.String()}
+	}
+}
+
+// matchTag returns a function reporting whether a build tag is satisfied in
+// bctx, following the rules of go/build.
+func matchTag(bctx build.Context) func(string) bool {
+	return func(tag string) bool {
+		switch {
+		case tag == bctx.GOOS, tag == bctx.GOARCH, tag == bctx.Compiler:
+			return true
+		case tag == "cgo":
+			return bctx.CgoEnabled
+		case tag == "unix":
+			return slices.Contains(unixOS, bctx.GOOS)
+		case tag == "linux":
+			return bctx.GOOS == "android"
+		case tag == "solaris":
+			return bctx.GOOS == "illumos"
+		case tag == "darwin":
+			return bctx.GOOS == "ios"
+		}
+		return slices.Contains(bctx.BuildTags, tag) ||
+			slices.Contains(bctx.ToolTags, tag) ||
+			slices.Contains(bctx.ReleaseTags, tag)
+	}
+}
@@ -0,0 +1,60 @@
+package stave
+
+import (
+	"bytes"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusionReason(t *testing.T) {
+	t.Parallel()
+	bctx := build.Default
+	bctx.GOOS = windows
+	bctx.GOARCH = amd64
+	bctx.BuildTags = []string{"stave"}
+
+	for line, want := range map[string]string{
+		"//go:build stave && !windows":               "false for GOOS=windows GOARCH=amd64: !windows",
+		"//go:build stave && (linux || darwin)":      "false for GOOS=windows GOARCH=amd64: linux, darwin",
+		"//go:build stave && unix && !arm64":         "false for GOOS=windows GOARCH=amd64: unix",
+		"//go:build stave":                           "the GOOS or GOARCH suffix of the file name stavefile_linux.go doesn't match GOOS=windows GOARCH=amd64",
+		"//go:build (stave && linux) || integration": "false for GOOS=windows GOARCH=amd64: linux, integration",
+	} {
+		expr, err := constraint.Parse(line)
+		require.NoError(t, err)
+		assert.Equal(t, want, exclusionReason(bctx, "stavefile_linux.go", expr), line)
+	}
+}
+
+func TestExcludedStavefilesExplained(t *testing.T) {
+	dataDirForThisTest := testDataGOOSStaveFilesDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  stderr,
+		List:    true,
+		Debug:   true,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	assert.Contains(t, stderr.String(), "stavefile excluded by build constraints")
+	if runtime.GOOS == windows {
+		assert.Contains(t, stderr.String(), filepath.Join(dataDirForThisTest, "stavefile_nonwindows.go"))
+		assert.Contains(t, stderr.String(), "false for GOOS=windows GOARCH="+runtime.GOARCH+": !windows")
+	} else {
+		assert.Contains(t, stderr.String(), filepath.Join(dataDirForThisTest, "stavefile_windows.go"))
+		assert.Contains(t, stderr.String(), "the GOOS or GOARCH suffix of the file name stavefile_windows.go doesn't match GOOS="+runtime.GOOS)
+	}
+}
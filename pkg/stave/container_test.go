@@ -0,0 +1,84 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+func runContainerTarget(t *testing.T, noContainer bool, target string) (string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "container")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dataDirForThisTest,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		CacheDir:    t.TempDir(),
+		NoContainer: noContainer,
+		Args:        []string{target},
+	})
+	if err != nil {
+		t.Logf("stderr was: %s", stderr.String())
+	}
+	return stdout.String(), err
+}
+
+func TestContainerTargetNoContainer(t *testing.T) {
+	out, err := runContainerTarget(t, true, "where")
+	require.NoError(t, err)
+	assert.Equal(t, "in container: false\n", out)
+}
+
+func TestContainerTarget(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+	if err := exec.CommandContext(t.Context(), "docker", "info").Run(); err != nil {
+		t.Skip("docker daemon is not available")
+	}
+
+	out, err := runContainerTarget(t, false, "where")
+	require.NoError(t, err)
+	assert.Equal(t, "in container: true\n", out)
+
+	_, err = runContainerTarget(t, false, "fail")
+	require.Error(t, err)
+	assert.Equal(t, 3, sh.ExitStatus(err))
+}
+
+func TestContainerQuotedImageCompiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	stavefile := "//go:build stave\n\npackage main\n\nimport \"fmt\"\n\n" +
+		"// Where prints.\n//\n//stave:container=image\"quoted\nfunc Where() { fmt.Println(\"ran\") }\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/proj\n\ngo 1.25\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(stavefile), 0o644))
+
+	// the image is written into the generated mainfile for stave -i.
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dir,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		CacheDir:    t.TempDir(),
+		NoContainer: true,
+		Args:        []string{"where"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "ran\n", stdout.String())
+}
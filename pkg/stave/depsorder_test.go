@@ -0,0 +1,95 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runDepsOrder runs the targets of testdata/depsorder, and returns the lines
+// its targets recorded, in the order they did, stderr and the error. The tests
+// that call it can't be parallel, as they set the environment the compiled
+// stavefile inherits.
+func runDepsOrder(t *testing.T, args ...string) ([]string, string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "depsorder")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	record := filepath.Join(t.TempDir(), "order")
+	t.Setenv("DEPS_ORDER_FILE", record)
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  stderr,
+		Args:    args,
+	})
+	data, readErr := os.ReadFile(record)
+	require.NoError(t, readErr)
+	return strings.Split(strings.TrimSpace(string(data)), "\n"), stderr.String(), err
+}
+
+func TestDepsOrderSerial(t *testing.T) {
+	lines, stderr, err := runDepsOrder(t, "serial", "serialctx")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	// the targets on the command line run in order, and serialctx's
+	// dependencies already ran for serial.
+	assert.Equal(t, []string{
+		"start one", "end one",
+		"start two", "end two",
+		"start three", "end three",
+		"serial",
+		"serialctx",
+	}, lines)
+
+	lines, stderr, err = runDepsOrder(t, "serialctx")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, []string{
+		"start three", "end three",
+		"start two", "end two",
+		"start one", "end one",
+		"serialctx",
+	}, lines)
+}
+
+func TestDepsOrderParallel(t *testing.T) {
+	lines, stderr, err := runDepsOrder(t, "parallel")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	// the steps run in any order, but all of them before the target.
+	require.Len(t, lines, 7)
+	assert.Equal(t, "parallel", lines[6])
+	assert.ElementsMatch(t, []string{
+		"start one", "end one",
+		"start two", "end two",
+		"start three", "end three",
+	}, lines[:6])
+}
+
+func TestDepsOrderLimited(t *testing.T) {
+	lines, stderr, err := runDepsOrder(t, "limited")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	require.Len(t, lines, 7)
+	assert.Equal(t, "limited", lines[6])
+	for i := 0; i < 6; i += 2 {
+		step, ok := strings.CutPrefix(lines[i], "start ")
+		require.True(t, ok, "expected a step to start: %q", lines)
+		assert.Equal(t, "end "+step, lines[i+1], "steps overlapped: %q", lines)
+	}
+}
+
+func TestDepsOrderSerialFailure(t *testing.T) {
+	lines, stderr, err := runDepsOrder(t, "fail")
+	require.Error(t, err)
+	// the step after the failing one never runs.
+	assert.Equal(t, []string{"start one", "end one", "broken"}, lines)
+	assert.Contains(t, stderr, "Error: Broken: broken\n")
+}
@@ -10,7 +10,11 @@ import (
 )
 
 func delegateToDirEnv(ctx context.Context, params RunParams) error {
-	env, err := setupEnv(params)
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
+	}
+	env, err := setupEnv(params, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to setup environment: %w", err)
 	}
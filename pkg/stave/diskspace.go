@@ -0,0 +1,104 @@
+package stave
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/env"
+)
+
+// SkipDiskCheckEnv is the environment variable that turns off the free disk
+// space check stave makes before compiling a stavefile.
+const SkipDiskCheckEnv = "STAVEFILE_SKIP_DISK_CHECK"
+
+// errDiskSpaceUnsupported is returned by freeDiskSpace on platforms where we
+// don't know how to ask for free space.
+var errDiskSpaceUnsupported = errors.New("free disk space check not supported on this platform")
+
+// freeDiskFunc returns the bytes available to unprivileged users on the
+// filesystem holding path.
+type freeDiskFunc func(path string) (uint64, error)
+
+// checkDiskSpace makes sure the cache dir and the go build temp dir have at
+// least min_free_disk free, so that a full disk fails with a clear message
+// rather than a confusing compile error.
+func checkDiskSpace(params RunParams, cfg *config.Config) error {
+	if env.FailsafeParseBoolEnv(SkipDiskCheckEnv, false) {
+		return nil
+	}
+	dirs := []diskCheckDir{
+		{desc: "cache dir", path: params.CacheDir},
+		{desc: "build temp dir", path: cmp.Or(os.Getenv("GOTMPDIR"), os.TempDir())},
+	}
+	return checkFreeDisk(dirs, cfg.MinFreeDiskBytes(), freeDiskSpace)
+}
+
+// diskCheckDir is a directory whose filesystem needs free space, with a
+// description for error messages.
+type diskCheckDir struct {
+	desc string
+	path string
+}
+
+// checkFreeDisk returns an error naming the first of dirs with less than
+// minFree bytes available. Directories that don't exist yet are checked via
+// their nearest existing parent. Failures to get the free space are logged
+// and otherwise ignored.
+func checkFreeDisk(dirs []diskCheckDir, minFree uint64, free freeDiskFunc) error {
+	if minFree == 0 {
+		return nil
+	}
+	for _, dir := range dirs {
+		if dir.path == "" {
+			continue
+		}
+		existing := nearestExistingDir(dir.path)
+		avail, err := free(existing)
+		if err != nil {
+			slog.Debug("could not check free disk space", slog.String(log.Path, existing), slog.Any(log.Error, err))
+			continue
+		}
+		if avail < minFree {
+			return fmt.Errorf(
+				"only %s free on the disk holding the %s %s, but min_free_disk is %s; "+
+					"free up some space, lower min_free_disk, or set %s=1 to skip this check",
+				formatBytes(avail), dir.desc, dir.path, formatBytes(minFree), SkipDiskCheckEnv,
+			)
+		}
+	}
+	return nil
+}
+
+// nearestExistingDir returns dir, or its closest ancestor that exists.
+func nearestExistingDir(dir string) string {
+	for cur := dir; ; {
+		if _, err := os.Stat(cur); err == nil {
+			return cur
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return dir
+		}
+		cur = parent
+	}
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "42.0MB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,7 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || windows)
+
+package stave
+
+func freeDiskSpace(string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
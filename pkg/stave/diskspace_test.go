@@ -0,0 +1,59 @@
+package stave
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFreeDisk(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	tmpDir := t.TempDir()
+	dirs := []diskCheckDir{
+		{desc: "cache dir", path: filepath.Join(cacheDir, "not", "created", "yet")},
+		{desc: "build temp dir", path: tmpDir},
+	}
+	const mb = 1 << 20
+
+	freeSpace := map[string]uint64{cacheDir: 600 * mb, tmpDir: 40 * mb}
+	statfs := func(path string) (uint64, error) {
+		free, ok := freeSpace[path]
+		if !ok {
+			return 0, errors.New("unexpected path " + path)
+		}
+		return free, nil
+	}
+
+	// plenty of space
+	require.NoError(t, checkFreeDisk(dirs, 10*mb, statfs))
+
+	// the temp dir is short; the missing cache dir is checked via its parent
+	err := checkFreeDisk(dirs, 100*mb, statfs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only 40.0MB free on the disk holding the build temp dir "+tmpDir)
+	assert.Contains(t, err.Error(), "min_free_disk is 100.0MB")
+	assert.Contains(t, err.Error(), SkipDiskCheckEnv+"=1")
+
+	err = checkFreeDisk(dirs, 1<<30, statfs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache dir")
+
+	// zero disables the check
+	require.NoError(t, checkFreeDisk(dirs, 0, statfs))
+
+	// failures to read free space don't block the build
+	failing := func(string) (uint64, error) { return 0, errDiskSpaceUnsupported }
+	require.NoError(t, checkFreeDisk(dirs, 100*mb, failing))
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "512B", formatBytes(512))
+	assert.Equal(t, "1.5KB", formatBytes(1536))
+	assert.Equal(t, "500.0MB", formatBytes(500<<20))
+	assert.Equal(t, "2.0GB", formatBytes(2<<30))
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package stave
+
+import "golang.org/x/sys/windows"
+
+func freeDiskSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var avail uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &avail, nil, nil); err != nil {
+		return 0, err
+	}
+	return avail, nil
+}
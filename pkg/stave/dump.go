@@ -0,0 +1,148 @@
+package stave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// runDumpTargetsMode handles the --dump-targets flag by parsing stavefiles and
+// printing everything the parser resolved about them, without compiling. It is
+// meant for diagnosing why a function does or doesn't show up as a target.
+func runDumpTargetsMode(ctx context.Context, params RunParams) error {
+	files, err := Stavefiles(params.Dir, params.GOOS, params.GOARCH, params.UsesStavefiles())
+	if err != nil {
+		return newError(KindParse, fmt.Errorf("determining list of stavefiles: %w", err))
+	}
+
+	if len(files) == 0 {
+		return newError(KindParse, errors.New("no .go files marked with the stave build tag in this directory"))
+	}
+
+	fnames := make([]string, 0, len(files))
+	for _, f := range files {
+		fnames = append(fnames, filepath.Base(f))
+	}
+
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
+	}
+	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline, cfg.ImportAliases, params.GoEnv)
+	if err != nil {
+		return newError(KindParse, fmt.Errorf("parsing stavefiles: %w", err))
+	}
+
+	sort.Sort(info.Funcs)
+	sort.Sort(info.Imports)
+
+	return renderTargetDump(params.Stdout, info)
+}
+
+// renderTargetDump renders the output of `stave --dump-targets`.
+func renderTargetDump(out io.Writer, info *parse.PkgInfo) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "package %s\n", info.PkgName)
+	if info.DefaultFunc != nil {
+		fmt.Fprintf(&b, "  default: %s\n", strings.ToLower(info.DefaultFunc.TargetName()))
+	}
+	aliases := make([]string, 0, len(info.Aliases))
+	for alias := range info.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		fmt.Fprintf(&b, "  alias:   %s -> %s\n", alias, strings.ToLower(info.Aliases[alias].TargetName()))
+	}
+	if len(info.RequiredEnv) > 0 {
+		fmt.Fprintf(&b, "  requires: %s\n", strings.Join(info.RequiredEnv, ", "))
+	}
+
+	for _, fn := range info.Funcs {
+		dumpFunction(&b, fn)
+	}
+	for _, imp := range info.Imports {
+		fmt.Fprintf(&b, "\nimport %s\n", imp.Path)
+		fmt.Fprintf(&b, "  package: %s\n", imp.Name)
+		if imp.Alias != "" {
+			fmt.Fprintf(&b, "  alias:   %s\n", imp.Alias)
+		}
+		for _, fn := range imp.Info.Funcs {
+			dumpFunction(&b, fn)
+		}
+	}
+
+	if len(info.InvalidFuncs) > 0 {
+		b.WriteString("\nskipped functions\n")
+		for _, invalid := range info.InvalidFuncs {
+			fmt.Fprintf(&b, "  %s (%s): %s\n", invalid.Name, invalid.Pos, invalid.Reason)
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func dumpFunction(b *strings.Builder, fn *parse.Function) {
+	importPath := fn.ImportPath
+	if importPath == "" {
+		importPath = "<current>"
+	}
+	args := make([]string, 0, len(fn.Args))
+	for _, arg := range fn.Args {
+		args = append(args, arg.Name+" "+arg.Type)
+	}
+
+	fmt.Fprintf(b, "\ntarget %s\n", strings.ToLower(fn.TargetName()))
+	fmt.Fprintf(b, "  func:     %s\n", fn.Name)
+	fmt.Fprintf(b, "  receiver: %s\n", orDash(fn.Receiver))
+	fmt.Fprintf(b, "  import:   %s\n", importPath)
+	fmt.Fprintf(b, "  args:     %s\n", orDash(strings.Join(args, ", ")))
+	fmt.Fprintf(b, "  error:    %s\n", strconv.FormatBool(fn.IsError))
+	fmt.Fprintf(b, "  context:  %s\n", strconv.FormatBool(fn.IsContext))
+	fmt.Fprintf(b, "  watch:    %s\n", strconv.FormatBool(fn.IsWatch))
+	if len(fn.WatchGlobs) > 0 {
+		fmt.Fprintf(b, "  globs:    %s\n", strings.Join(fn.WatchGlobs, ", "))
+	}
+	if fn.Hidden {
+		b.WriteString("  hidden:   true\n")
+	}
+	if fn.Exclusive {
+		b.WriteString("  exclusive: true\n")
+	}
+	if fn.Serial {
+		b.WriteString("  serial:   true\n")
+	}
+	if fn.Retries > 0 {
+		fmt.Fprintf(b, "  retries:  %d (%s between attempts)\n", fn.Retries, fn.RetryDelay)
+	}
+	if len(fn.RequiresEnv) > 0 {
+		fmt.Fprintf(b, "  requires: %s\n", strings.Join(fn.RequiresEnv, ", "))
+	}
+	if len(fn.Requires) > 0 {
+		fmt.Fprintf(b, "  precond:  %s\n", strings.Join(fn.Requires, ", "))
+	}
+	if fn.Container != "" {
+		fmt.Fprintf(b, "  image:    %s\n", fn.Container)
+	}
+	for _, arg := range fn.Args {
+		if arg.HasConstraints() {
+			fmt.Fprintf(b, "  arg:      %s %s\n", arg.Name, arg.Constraints())
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
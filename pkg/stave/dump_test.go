@@ -0,0 +1,108 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+func TestDumpTargets(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "context")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dataDirForThisTest,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		DumpTargets: true,
+	}
+
+	require.NoError(t, Run(runParams), "stderr was: %s", stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "package main\n")
+	assert.Contains(t, out, `target takescontextnoerror
+  func:     TakesContextNoError
+  receiver: -
+  import:   <current>
+  args:     -
+  error:    false
+  context:  true
+  watch:    false
+`)
+	assert.Contains(t, out, `target takescontextwitherror
+  func:     TakesContextWithError
+  receiver: -
+  import:   <current>
+  args:     -
+  error:    true
+  context:  true
+`)
+}
+
+func TestDumpTargetsSkippedFunctions(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "signatures")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dataDirForThisTest,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		DumpTargets: true,
+	}
+
+	require.NoError(t, Run(runParams), "stderr was: %s", stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "target build\n")
+	assert.Contains(t, out, "skipped functions\n")
+	assert.Contains(t, out, "Scale (")
+	assert.Contains(t, out, "complex128")
+	assert.NotContains(t, out, "helper")
+}
+
+func TestDumpFunctionContainer(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	dumpFunction(&b, &parse.Function{Name: "Lint", Container: "golangci/golangci-lint:v2"})
+	assert.Contains(t, b.String(), "  image:    golangci/golangci-lint:v2\n")
+}
+
+func TestDumpFunctionWatchGlobs(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	dumpFunction(&b, &parse.Function{Name: "Serve", IsWatch: true, WatchGlobs: []string{"*.go", parse.DynamicWatchGlob}})
+	assert.Contains(t, b.String(), "  watch:    true\n  globs:    *.go, <dynamic>\n")
+
+	b.Reset()
+	dumpFunction(&b, &parse.Function{Name: "Build"})
+	assert.NotContains(t, b.String(), "globs")
+}
+
+func TestDumpFunctionExclusive(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	dumpFunction(&b, &parse.Function{Name: "Migrate", Exclusive: true})
+	assert.Contains(t, b.String(), "  exclusive: true\n")
+
+	b.Reset()
+	dumpFunction(&b, &parse.Function{Name: "Build"})
+	assert.NotContains(t, b.String(), "exclusive")
+}
@@ -0,0 +1,64 @@
+package stave
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/env"
+)
+
+// requiredEnvFilePrefix marks an env_files entry that must exist.
+const requiredEnvFilePrefix = "!"
+
+// envFile is a dotenv file to load into the stavefile's environment.
+type envFile struct {
+	path     string
+	required bool
+}
+
+// loadEnvFiles reads the dotenv files listed under env_files in stave.yaml,
+// then those given with --env-file, and merges them in that order, so later
+// files override earlier ones. Config entries are relative to the project
+// directory and are skipped if missing, unless prefixed with "!"; files given
+// on the command line are relative to the current directory and must exist.
+func loadEnvFiles(params RunParams, cfg *config.Config) (map[string]string, error) {
+	projectDir := configDir(params)
+
+	var files []envFile
+	for _, entry := range cfg.EnvFiles {
+		path, required := strings.CutPrefix(entry, requiredEnvFilePrefix)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		files = append(files, envFile{path: path, required: required})
+	}
+	for _, path := range params.EnvFiles {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving env file %s: %w", path, err)
+		}
+		files = append(files, envFile{path: absPath, required: true})
+	}
+
+	vars := make(map[string]string)
+	for _, f := range files {
+		fileVars, err := env.ReadDotenvFile(f.path)
+		if errors.Is(err, os.ErrNotExist) && !f.required {
+			slog.Debug("optional env file not found", slog.String(log.Path, f.path))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("loading env file: %w", err)
+		}
+		slog.Debug("loaded env file", slog.String(log.Path, f.path), slog.Int("count", len(fileVars)))
+		maps.Copy(vars, fileVars)
+	}
+	return vars, nil
+}
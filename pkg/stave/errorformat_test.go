@@ -0,0 +1,75 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runErrorFormatJSON runs args in the testdata dir named dir with
+// --error-format json, and returns the object the compiled stavefile reported
+// the failure with, the last line it wrote to stderr.
+func runErrorFormatJSON(t *testing.T, dir string, args ...string) map[string]any {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, dir)
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dataDirForThisTest,
+		Stdout:      &bytes.Buffer{},
+		Stderr:      stderr,
+		ErrorFormat: "json",
+		Args:        args,
+	})
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+	last := lines[len(lines)-1]
+	var report map[string]any
+	require.NoError(t, json.Unmarshal([]byte(last), &report), "stderr was: %s", stderr.String())
+	return report
+}
+
+func TestErrorFormatJSON(t *testing.T) {
+	t.Parallel()
+	report := runErrorFormatJSON(t, "logformat", "build", "fail")
+	assert.Equal(t, map[string]any{
+		"target":   "Fail",
+		"error":    `broken: "x" = 1`,
+		"exitCode": float64(1),
+	}, report)
+}
+
+func TestErrorFormatJSONKeepsExitStatus(t *testing.T) {
+	t.Parallel()
+	report := runErrorFormatJSON(t, "exitstatus", "fail", "3")
+	assert.Equal(t, "Fail", report["target"])
+	assert.Equal(t, "failing with status 3", report["error"])
+	assert.InDelta(t, 3, report["exitCode"], 0)
+}
+
+func TestErrorFormatInvalid(t *testing.T) {
+	t.Parallel()
+	err := Run(RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         testDataDir,
+		Stdout:      &bytes.Buffer{},
+		Stderr:      &bytes.Buffer{},
+		ErrorFormat: "xml",
+		Args:        []string{"ReturnsNilError"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `--error-format must be text or json, not "xml"`)
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindUsage, stErr.Kind)
+}
@@ -0,0 +1,136 @@
+package stave
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+// usageErrorFileEnv is the environment variable through which stave tells the
+// compiled stavefile where to record that it exited because of a mistake on
+// the command line, such as an unknown target, rather than a failed target.
+const usageErrorFileEnv = "STAVEFILE_USAGE_ERROR_FILE"
+
+// usageExitStatus is the exit status for KindUsage errors.
+const usageExitStatus = 2
+
+// ErrorKind classifies the errors returned by Run.
+type ErrorKind int
+
+const (
+	// KindInternal is a failure of stave itself or of its environment, such as
+	// a cache dir that can't be written. Errors not covered by another kind
+	// have this kind.
+	KindInternal ErrorKind = iota
+
+	// KindUsage is a mistake in how stave was invoked: flags that can't be
+	// used together, an unknown target, or target arguments that are missing
+	// or can't be converted.
+	KindUsage
+
+	// KindParse means the stavefiles couldn't be found or parsed.
+	KindParse
+
+	// KindCompile means the stavefiles didn't compile.
+	KindCompile
+
+	// KindTarget means the compiled stavefile ran and a target failed.
+	KindTarget
+)
+
+// String returns the lowercase name of the kind, e.g. "usage".
+func (k ErrorKind) String() string {
+	switch k {
+	case KindInternal:
+		return "internal"
+	case KindUsage:
+		return "usage"
+	case KindParse:
+		return "parse"
+	case KindCompile:
+		return "compile"
+	case KindTarget:
+		return "target"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// Error is the type of the errors returned by Run, so programs embedding stave
+// can tell failures apart without matching messages:
+//
+//	var stErr *stave.Error
+//	if errors.As(err, &stErr) && stErr.Kind == stave.KindTarget {
+//		os.Exit(stErr.Code)
+//	}
+type Error struct {
+	Kind ErrorKind
+
+	// Code is the exit status for the error: 2 for KindUsage, the stavefile's
+	// exit status for KindTarget, and otherwise 1, unless Err carries a status
+	// of its own.
+	Code int
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitStatus returns Code, so that st.ExitStatus and sh.ExitStatus report it.
+func (e *Error) ExitStatus() int {
+	return e.Code
+}
+
+// newError returns err as an *Error of the given kind, or nil if err is nil.
+// An err that already wraps an *Error is returned as is, so the kind given
+// where a failure happens wins over those given further up.
+func newError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var stErr *Error
+	if errors.As(err, &stErr) {
+		return err
+	}
+	code := sh.ExitStatus(err)
+	if kind == KindUsage {
+		code = usageExitStatus
+	}
+	return &Error{Kind: kind, Code: code, Err: err}
+}
+
+// newUsageErrorFile creates the empty file the compiled stavefile writes to
+// when it exits with a usage error, and returns its path and a func to remove
+// it.
+func newUsageErrorFile() (string, func(), error) {
+	f, err := os.CreateTemp("", "stave-usage-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("creating usage error file: %w", err)
+	}
+	name := f.Name()
+	cleanup := func() { _ = os.Remove(name) }
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("creating usage error file: %w", err)
+	}
+	return name, cleanup, nil
+}
+
+// stavefileError classifies the error from running the compiled stavefile,
+// which recorded in the file at usagePath whether it was a usage error.
+func stavefileError(err error, usagePath string) error {
+	if !sh.CmdRan(err) {
+		return newError(KindInternal, err)
+	}
+	if info, statErr := os.Stat(usagePath); statErr == nil && info.Size() > 0 {
+		return newError(KindUsage, err)
+	}
+	return newError(KindTarget, err)
+}
@@ -0,0 +1,195 @@
+package stave
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+func TestRunErrorKinds(t *testing.T) {
+	brokenDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "stavefile.go"), []byte(`//go:build stave
+
+package main
+
+// Build doesn't compile.
+func Build() error {
+	return 1
+}
+`), 0o644))
+
+	tests := []struct {
+		name     string
+		params   RunParams
+		wantKind ErrorKind
+		wantCode int
+	}{
+		{
+			name:     "conflicting flags",
+			params:   RunParams{Dir: testDataDir, GOOS: "windows", Args: []string{"ReturnsNilError"}},
+			wantKind: KindUsage,
+			wantCode: 2,
+		},
+		{
+			name:     "unknown target",
+			params:   RunParams{Dir: testDataDir, Args: []string{"NotGonnaWork"}},
+			wantKind: KindUsage,
+			wantCode: 2,
+		},
+		{
+			name:     "bad argument",
+			params:   RunParams{Dir: filepath.Join(testDataDir, "exitstatus"), Args: []string{"fail", "x"}},
+			wantKind: KindUsage,
+			wantCode: 2,
+		},
+		{
+			name:     "no stavefiles",
+			params:   RunParams{Dir: t.TempDir(), Args: []string{"build"}},
+			wantKind: KindParse,
+			wantCode: 1,
+		},
+		{
+			name:     "invalid target signature",
+			params:   RunParams{Dir: filepath.Join(testDataDir, "signatures"), StrictSignatures: true, Args: []string{"build"}},
+			wantKind: KindParse,
+			wantCode: 1,
+		},
+		{
+			name:     "compile error",
+			params:   RunParams{Dir: brokenDir, CacheDir: t.TempDir(), Args: []string{"build"}},
+			wantKind: KindCompile,
+			wantCode: 1,
+		},
+		{
+			name:     "target error",
+			params:   RunParams{Dir: testDataDir, Args: []string{"ReturnsNonNilError"}},
+			wantKind: KindTarget,
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			params := tt.params
+			params.BaseCtx = t.Context()
+			params.Stdout = &stdout
+			params.Stderr = &stderr
+
+			err := Run(params)
+			require.Error(t, err)
+
+			var stErr *Error
+			require.ErrorAs(t, err, &stErr)
+			assert.Equal(t, tt.wantKind, stErr.Kind, "error: %v\nstderr: %s", err, stderr.String())
+			assert.Equal(t, tt.wantCode, stErr.Code)
+			assert.Equal(t, tt.wantCode, sh.ExitStatus(err))
+		})
+	}
+}
+
+func TestRunTargetErrorKeepsExitStatus(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "exitstatus")
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Args:    []string{"fail", "3"},
+	})
+	require.Error(t, err)
+
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindTarget, stErr.Kind)
+	assert.Equal(t, 3, stErr.Code)
+}
+
+func TestRunDepsErrorsListed(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "deperrors")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Args:    []string{"all"},
+	})
+	require.Error(t, err)
+
+	assert.Contains(t, stderr.String(), "Error:\n  1. Lint: lint found 3 problems\n  2. Test: 2 tests failed\n")
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindTarget, stErr.Kind)
+	assert.Equal(t, 3, stErr.Code, "the exit status of the first failure that has one")
+}
+
+func TestNewError(t *testing.T) {
+	assert.NoError(t, newError(KindUsage, nil))
+
+	inner := &Error{Kind: KindCompile, Code: 1, Err: errors.New("error compiling stavefiles")}
+	err := newError(KindInternal, inner)
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindCompile, stErr.Kind, "the innermost kind wins")
+
+	assert.Equal(t, "usage", KindUsage.String())
+	assert.Equal(t, "ErrorKind(42)", ErrorKind(42).String())
+}
+
+func TestCompileErrorIncludesCompilerOutput(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(`//go:build stave
+
+package main
+
+// Build doesn't compile.
+func Build() error {
+	return 1
+}
+`), 0o644))
+
+	// go build's output goes to a buffer the user never sees, so the error
+	// has to carry it.
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dir,
+		CacheDir: t.TempDir(),
+		Stdout:   &stdout,
+		Stderr:   &stderr,
+		Args:     []string{"build"},
+	})
+	require.Error(t, err)
+
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindCompile, stErr.Kind)
+	assert.Contains(t, err.Error(), "error compiling stavefiles:\n")
+	assert.Contains(t, err.Error(), "stavefile.go:7:")
+	assert.Contains(t, err.Error(), "cannot use 1")
+	assert.Contains(t, stderr.String(), "cannot use 1", "the output still goes to Stderr too")
+}
+
+func TestOutputTail(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, outputTail("\n", 3))
+	assert.Equal(t, "  # main\n  ./stavefile.go:7:9: oops", outputTail("# main\r\n./stavefile.go:7:9: oops\n", 3))
+	assert.Equal(t, "  ...\n  c\n  d", outputTail("a\nb\nc\nd\n", 2))
+
+	assert.EqualError(t, compileError(""), "error compiling stavefiles")
+	assert.EqualError(t, compileError("boom\n"), "error compiling stavefiles:\n  boom")
+}
@@ -0,0 +1,45 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusiveTarget(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "exclusive")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		CacheDir: t.TempDir(),
+		Args:     []string{"all"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 6)
+	running := 0
+	for i, line := range lines {
+		switch {
+		case line == "start migrate":
+			assert.Zero(t, running, "migrate started alongside other targets: %q", lines)
+			assert.Equal(t, "end migrate", lines[i+1], "another target ran alongside migrate: %q", lines)
+		case strings.HasPrefix(line, "start "):
+			running++
+		case line != "end migrate":
+			running--
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package stave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parse"
+	"github.com/yaklabco/stave/pkg/env"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+// externalCommandName is $0 for the shell commands of external targets, which
+// get the arguments given after the target's name as "$@".
+const externalCommandName = "stave-external"
+
+// runExternalTarget runs the command of the external target name, configured
+// in external_targets, with args appended. It runs in the same environment
+// and working directory as the stavefile would, and its exit status is the
+// run's.
+func runExternalTarget(ctx context.Context, params RunParams, cfg *config.Config, name string, args []string) error {
+	target, _ := cfg.ExternalTarget(name)
+	theEnv, err := setupEnv(params, cfg)
+	if err != nil {
+		return fmt.Errorf("setting up environment for external target: %w", err)
+	}
+
+	slog.Debug("running external target", slog.String(log.Target, name), slog.String(log.Name, target.Command))
+	shArgs := append([]string{"-c", target.Command + ` "$@"`, externalCommandName}, args...)
+	theCmd := dryrun.Wrap(ctx, theEnv, "sh", shArgs...)
+	theCmd.Stderr = params.Stderr
+	theCmd.Stdout = params.Stdout
+	theCmd.Stdin = params.Stdin
+	theCmd.Dir = params.Dir
+	if params.WorkDir != params.Dir {
+		theCmd.Dir = params.WorkDir
+	}
+	theCmd.Env = env.ToAssignments(theEnv)
+
+	// pass signals on, as runCompiled does, so that the command can clean up.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	prepareSignalForwarding(theCmd)
+	if err := theCmd.Start(); err != nil {
+		return newError(KindTarget, fmt.Errorf("running external target %s: %w", name, err))
+	}
+	go func() {
+		for s := range sigCh {
+			if err := forwardSignal(theCmd.Process, s); err != nil {
+				slog.Error("failed to send signal to external target", slog.Any(log.Error, err))
+			}
+		}
+	}()
+
+	if err := theCmd.Wait(); err != nil {
+		if !sh.CmdRan(err) {
+			return newError(KindTarget, fmt.Errorf("running external target %s: %w", name, err))
+		}
+		return newError(KindTarget, fmt.Errorf("external target %s failed: %w", name, err))
+	}
+	return nil
+}
+
+// checkExternalTargets reports the targets and aliases of the stavefiles that
+// can't be run because an external target of the same name runs instead: as
+// warnings, or as an error if strict is set.
+func checkExternalTargets(info *parse.PkgInfo, cfg *config.Config, strict bool) error {
+	if len(cfg.ExternalTargets) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(info.Funcs)+len(info.Aliases))
+	for _, fn := range info.Funcs {
+		names = append(names, fn.TargetName())
+	}
+	for _, imp := range info.Imports {
+		for _, fn := range imp.Info.Funcs {
+			names = append(names, fn.TargetName())
+		}
+	}
+	names = slices.AppendSeq(names, maps.Keys(info.Aliases))
+	slices.Sort(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		if _, ok := cfg.ExternalTarget(name); !ok {
+			continue
+		}
+		const hint = "rename the target, or the external target in external_targets"
+		if strict {
+			fmt.Fprintf(&builder, "\n  %s: %s", name, hint)
+			continue
+		}
+		slog.Warn(
+			"target is hidden by an external target",
+			slog.String(log.Target, name),
+			slog.String(log.Hint, hint),
+		)
+	}
+	if builder.Len() == 0 {
+		return nil
+	}
+	return errors.New("targets hidden by external targets:" + builder.String())
+}
@@ -0,0 +1,119 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// writeExternalTargetsProject writes a project with a stavefile declaring
+// Build, and a stave.yaml configuring the external targets echo and fail, to
+// a temporary dir, which it returns.
+func writeExternalTargetsProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/proj\n\ngo 1.25\n",
+		"stavefile.go": "//go:build stave\n\npackage main\n\n// Build builds.\nfunc Build() {}\n",
+		"stave.yaml": `external_targets:
+  echo:
+    command: echo got
+    description: Echoes its arguments.
+  fail:
+    command: exit 4
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestExternalTargetRun(t *testing.T) {
+	t.Parallel()
+	dir := writeExternalTargetsProject(t)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"Echo", "one", "two words", "--flag"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "got one two words --flag\n", stdout.String())
+}
+
+func TestExternalTargetExitStatus(t *testing.T) {
+	t.Parallel()
+	dir := writeExternalTargetsProject(t)
+
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  &bytes.Buffer{},
+		Args:    []string{"fail"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "external target fail failed")
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindTarget, stErr.Kind)
+	assert.Equal(t, 4, stErr.Code)
+}
+
+func TestExternalTargetList(t *testing.T) {
+	t.Parallel()
+	dir := writeExternalTargetsProject(t)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dir,
+		CacheDir: t.TempDir(),
+		Stdout:   stdout,
+		Stderr:   stderr,
+		List:     true,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	out := stdout.String()
+	idx := strings.Index(out, "\nExternal\n")
+	require.NotEqual(t, -1, idx, "no External section in:\n%s", out)
+	assert.Less(t, strings.Index(out, "build"), idx, "stavefile targets are listed first")
+	external := out[idx:]
+	assert.Regexp(t, `echo\s.*Echoes its arguments\.`, external)
+	assert.Regexp(t, `fail\s.*runs exit 4`, external)
+}
+
+func TestCheckExternalTargets(t *testing.T) {
+	t.Parallel()
+	info := &parse.PkgInfo{
+		Funcs:   parse.Functions{{Name: "Build"}, {Name: "Deploy"}},
+		Aliases: map[string]*parse.Function{"ship": {Name: "Deploy"}},
+	}
+	cfg := &config.Config{ExternalTargets: map[string]config.ExternalTarget{
+		"deploy": {Command: "./deploy.sh"},
+		"ship":   {Command: "./ship.sh"},
+	}}
+
+	require.NoError(t, checkExternalTargets(info, cfg, false))
+	require.NoError(t, checkExternalTargets(info, &config.Config{}, true))
+
+	err := checkExternalTargets(info, cfg, true)
+	require.Error(t, err)
+	assert.Equal(t, "targets hidden by external targets:"+
+		"\n  Deploy: rename the target, or the external target in external_targets"+
+		"\n  ship: rename the target, or the external target in external_targets", err.Error())
+}
@@ -0,0 +1,132 @@
+package stave
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+const (
+	// githubActionsEnv is set by GitHub Actions in the jobs it runs.
+	githubActionsEnv = "GITHUB_ACTIONS"
+
+	// ghaEnv turns off the GitHub Actions output when set to 0, false or no.
+	ghaEnv = "STAVE_GHA"
+
+	// stepSummaryEnv is the path of the file GitHub Actions shows as the
+	// summary of the step.
+	stepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+	// ghaResultsFileEnv is the environment variable through which stave tells
+	// the compiled stavefile where to record how each target went, for the
+	// job summary.
+	ghaResultsFileEnv = "STAVEFILE_GHA_RESULTS"
+)
+
+// targetResult is how a target given on the command line went, as the
+// compiled stavefile records it in the file named by ghaResultsFileEnv.
+type targetResult struct {
+	Target   string        `json:"target"`
+	Duration time.Duration `json:"duration"`
+	OK       bool          `json:"ok"`
+}
+
+// githubActions reports whether theEnv is that of a GitHub Actions job, with
+// the workflow command output not turned off with STAVE_GHA.
+func githubActions(theEnv map[string]string) bool {
+	switch strings.ToLower(strings.TrimSpace(theEnv[ghaEnv])) {
+	case "0", "false", "no":
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(theEnv[githubActionsEnv])) {
+	case "true", "yes", "1":
+		return true
+	}
+	return false
+}
+
+// newResultsFile creates the empty file the compiled stavefile records target
+// results in, if the run needs a job summary, and returns its path and a func
+// to remove it. The path is empty outside GitHub Actions.
+func newResultsFile(theEnv map[string]string) (string, func(), error) {
+	if !githubActions(theEnv) || theEnv[stepSummaryEnv] == "" {
+		return "", func() {}, nil
+	}
+	f, err := os.CreateTemp("", "stave-results-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("creating target results file: %w", err)
+	}
+	name := f.Name()
+	cleanup := func() { _ = os.Remove(name) }
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("creating target results file: %w", err)
+	}
+	return name, cleanup, nil
+}
+
+// writeStepSummary appends a table of the target results recorded in
+// resultsPath to the job summary at summaryPath. It never fails the run:
+// problems are logged.
+func writeStepSummary(summaryPath, resultsPath string) {
+	results, err := readTargetResults(resultsPath)
+	if err != nil {
+		slog.Warn("could not read target results", slog.String(log.Path, resultsPath), slog.Any(log.Error, err))
+		return
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("### stave\n\n| Target | Duration | Status |\n| --- | --- | --- |\n")
+	for _, result := range results {
+		status := "passed"
+		if !result.OK {
+			status = "failed"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", result.Target, result.Duration.Round(time.Millisecond), status)
+	}
+	b.WriteString("\n")
+
+	f, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err == nil {
+		_, err = f.WriteString(b.String())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		slog.Warn("could not write job summary", slog.String(log.Path, summaryPath), slog.Any(log.Error, err))
+	}
+}
+
+// readTargetResults returns the target results recorded in path, one JSON
+// object per line.
+func readTargetResults(path string) ([]targetResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []targetResult
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var result targetResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
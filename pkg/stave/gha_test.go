@@ -0,0 +1,105 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+// runGHA runs the targets of testdata/gha with GITHUB_ACTIONS and
+// GITHUB_STEP_SUMMARY set, and STAVE_GHA set to gha, and returns stdout, the
+// job summary and the error. The tests that call it can't be parallel, as they
+// set the environment the compiled stavefile inherits.
+func runGHA(t *testing.T, gha string, args ...string) (string, string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "gha")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv(githubActionsEnv, "true")
+	t.Setenv(stepSummaryEnv, summary)
+	t.Setenv(ghaEnv, gha)
+
+	stdout := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  &bytes.Buffer{},
+		Args:    args,
+	})
+	data, readErr := os.ReadFile(summary)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		require.NoError(t, readErr)
+	}
+	return stdout.String(), string(data), err
+}
+
+func TestGitHubActionsOutput(t *testing.T) {
+	stdout, summary, err := runGHA(t, "", "build", "test")
+	require.Error(t, err)
+	assert.Equal(t, 3, sh.ExitStatus(err))
+
+	assert.Equal(t, ""+
+		"::group::Build\n"+
+		"building\n"+
+		"::endgroup::\n"+
+		"::group::Test\n"+
+		"testing\n"+
+		"::endgroup::\n"+
+		"::error title=stave target failed::Test: 2 tests failed%0Asee the log\n",
+		stdout,
+	)
+
+	assert.Contains(t, summary, "| Target | Duration | Status |\n")
+	assert.Regexp(t, `(?m)^\| Build \| \S+ \| passed \|$`, summary)
+	assert.Regexp(t, `(?m)^\| Test \| \S+ \| failed \|$`, summary)
+}
+
+func TestGitHubActionsOutputOff(t *testing.T) {
+	stdout, summary, err := runGHA(t, "0", "build")
+	require.NoError(t, err)
+	assert.Equal(t, "building\n", stdout)
+	assert.Empty(t, summary)
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results")
+	summary := filepath.Join(dir, "summary.md")
+	require.NoError(t, os.WriteFile(summary, []byte("earlier step\n"), 0o644))
+	require.NoError(t, os.WriteFile(results, []byte(
+		`{"target":"Build","duration":1234567890,"ok":true}`+"\n"+
+			`{"target":"ns:Lint","duration":2000000,"ok":false}`+"\n",
+	), 0o644))
+
+	writeStepSummary(summary, results)
+
+	data, err := os.ReadFile(summary)
+	require.NoError(t, err)
+	assert.Equal(t, "earlier step\n"+
+		"### stave\n\n"+
+		"| Target | Duration | Status |\n"+
+		"| --- | --- | --- |\n"+
+		"| Build | 1.235s | passed |\n"+
+		"| ns:Lint | 2ms | failed |\n\n",
+		string(data),
+	)
+}
+
+func TestGitHubActions(t *testing.T) {
+	t.Parallel()
+	assert.True(t, githubActions(map[string]string{githubActionsEnv: "true"}))
+	assert.True(t, githubActions(map[string]string{githubActionsEnv: "1", ghaEnv: "1"}))
+	assert.False(t, githubActions(map[string]string{githubActionsEnv: "true", ghaEnv: "0"}))
+	assert.False(t, githubActions(map[string]string{githubActionsEnv: "true", ghaEnv: "false"}))
+	assert.False(t, githubActions(map[string]string{}))
+}
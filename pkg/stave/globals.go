@@ -44,10 +44,35 @@ var mainfileTemplate = template.Must(template.New("").Funcs(map[string]any{
 
 var initOutput = template.Must(template.New("").Parse(staveTpl))
 
+// builtinFlags are stave's flags that do something other than run a target,
+// by the target name that is easily mistaken for them. "stave clean" runs a
+// Clean target; only "stave --clean" cleans the cache.
+var builtinFlags = map[string]string{
+	"clean":   "--clean",
+	"compile": "--compile",
+	"config":  "--config",
+	"direnv":  "--direnv",
+	"exec":    "--exec",
+	"help":    "--help",
+	"hooks":   "--hooks",
+	"info":    "--info",
+	"init":    "--init",
+	"list":    "--list",
+	"version": "--version",
+}
+
+// builtinCommands are stave's subcommands, which run instead of a target of the
+// same name.
+var builtinCommands = map[string]struct{}{
+	"completion": {},
+}
+
 const (
 	// mainFileBase is the base prefix used for generated mainfile names.
 	mainFileBase = "stave_output_file"
-	initFile     = "stavefile.go"
+	// generatedHeader is the first line of every generated mainfile.
+	generatedHeader = "// Code generated by stave. DO NOT EDIT."
+	initFile        = "stavefile.go"
 )
 
 // mainFilePathFromExePath derives a generated main filename from the
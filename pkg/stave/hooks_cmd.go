@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
@@ -14,7 +15,11 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/dryrun"
 	"github.com/yaklabco/stave/internal/hooks"
+	"github.com/yaklabco/stave/internal/ish"
+	"github.com/yaklabco/stave/internal/parse"
+	"github.com/yaklabco/stave/pkg/env"
 	"github.com/yaklabco/stave/pkg/st"
 )
 
@@ -54,8 +59,9 @@ type HooksParams struct {
 }
 
 // newStaveTargetRunner creates a TargetRunnerFunc that executes targets using stave.Run.
-// This wires the hooks runtime to the real Stave execution engine.
-func newStaveTargetRunner(cfg *config.Config, generalWorkDir string) hooks.TargetRunnerFunc {
+// This wires the hooks runtime to the real Stave execution engine. The hook
+// arguments are exposed to targets as STAVE_HOOK_ARG1 and so on.
+func newStaveTargetRunner(cfg *config.Config, generalWorkDir string, hookArgs []string) hooks.TargetRunnerFunc {
 	return func(
 		ctx context.Context,
 		targetWorkDir string,
@@ -88,6 +94,7 @@ func newStaveTargetRunner(cfg *config.Config, generalWorkDir string) hooks.Targe
 			Args: append([]string{target}, args...),
 
 			HooksAreRunning: true,
+			HookArgs:        hookArgs,
 		}
 
 		err = Run(runParams)
@@ -95,6 +102,49 @@ func newStaveTargetRunner(cfg *config.Config, generalWorkDir string) hooks.Targe
 	}
 }
 
+// hookCommandName is $0 for the shell commands of hook steps, which get their
+// arguments as $1, $2 and so on.
+const hookCommandName = "stave-hook"
+
+// newHookCommandRunner creates a CommandRunnerFunc that runs the shell command
+// of a hook step configured with run, through the dry-run aware command runner.
+// Like targets, the command runs with STAVEFILE_HOOKS_RUNNING set, and sees
+// the hook arguments as STAVE_HOOK_ARG1 and so on.
+func newHookCommandRunner(cfg *config.Config, generalWorkDir string, hookArgs []string) hooks.CommandRunnerFunc {
+	return func(
+		ctx context.Context,
+		workDir string,
+		command string,
+		args []string,
+		stdin io.Reader,
+		stdout, stderr io.Writer,
+	) (int, error) {
+		workDirForThisCommand, err := determineWorkDir(cfg, generalWorkDir, workDir)
+		if err != nil {
+			err = fmt.Errorf("error determining work dir for command: %w", err)
+			return st.ExitStatus(err), err
+		}
+
+		theEnv := env.GetMap()
+		theEnv[HooksAreRunningEnv] = "1"
+		maps.Copy(theEnv, hooks.HookArgsEnv(hookArgs))
+
+		shArgs := append([]string{"-c", command, hookCommandName}, args...)
+		theCmd := dryrun.Wrap(ctx, theEnv, "sh", shArgs...)
+		theCmd.Env = env.ToAssignments(theEnv)
+		theCmd.Dir = workDirForThisCommand
+		theCmd.Stdin = stdin
+		theCmd.Stdout = stdout
+		theCmd.Stderr = stderr
+
+		if err := theCmd.Run(); err != nil {
+			code := ish.ExitStatus(err)
+			return code, fmt.Errorf("running %q: %w", command, err)
+		}
+		return 0, nil
+	}
+}
+
 func determineWorkDir(cfg *config.Config, generalWorkDir string, targetWorkDir string) (string, error) {
 	workDir := strings.TrimSpace(targetWorkDir)
 	if workDir == "" {
@@ -163,6 +213,7 @@ const (
 	HooksUninstall HooksSubcommand = "uninstall"
 	HooksList      HooksSubcommand = "list"
 	HooksRun       HooksSubcommand = "run"
+	HooksValidate  HooksSubcommand = "validate"
 )
 
 func dispatchHooksSubcommand(ctx context.Context, params RunParams, subArgs []string) int {
@@ -182,6 +233,8 @@ func dispatchHooksSubcommand(ctx context.Context, params RunParams, subArgs []st
 		return runHooksList(ctx, params)
 	case HooksRun:
 		return runHooksRun(ctx, params, subArgs[1:])
+	case HooksValidate:
+		return runHooksValidate(ctx, params)
 	default:
 		slog.Debug("unknown hooks subcommand",
 			slog.String("subcommand", subArgs[0]))
@@ -196,7 +249,7 @@ func runHooksInit(ctx context.Context, params RunParams) int {
 	slog.Debug("loading hooks configuration")
 
 	// First ensure config exists
-	cfg, err := config.Load(&config.LoadOptions{ProjectDir: params.Dir})
+	cfg, err := config.Load(configLoadOptions(params))
 	if err != nil {
 		return printConfigErr(params.Stderr, err)
 	}
@@ -246,7 +299,7 @@ func runHooksInstall(ctx context.Context, params RunParams, args []string) int {
 
 	// Load configuration
 	slog.Debug("loading hooks configuration")
-	cfg, err := config.Load(&config.LoadOptions{ProjectDir: params.Dir})
+	cfg, err := config.Load(configLoadOptions(params))
 	if err != nil {
 		return printConfigErr(params.Stderr, err)
 	}
@@ -259,7 +312,141 @@ func runHooksInstall(ctx context.Context, params RunParams, args []string) int {
 		return exitError
 	}
 
-	return installHooks(repo, cfg, force, params)
+	if code := checkHookTargets(ctx, cfg, params, false); code != exitOK {
+		return code
+	}
+
+	if !params.HooksSubmodules {
+		return installHooks(repo, cfg, force, params)
+	}
+
+	submodules, err := repo.Submodules(ctx)
+	if err != nil {
+		return printErr(params.Stderr, err)
+	}
+	// a submodule that fails doesn't keep the hooks from the others.
+	code := exitOK
+	for _, r := range append([]*hooks.GitRepo{repo}, submodules...) {
+		_, _ = fmt.Fprintf(params.Stdout, "%s:\n", r.RootDir)
+		if repoCode := installHooks(r, cfg, force, params); repoCode != exitOK {
+			code = repoCode
+		}
+	}
+	return code
+}
+
+// runHooksValidate checks that every configured hook target exists.
+func runHooksValidate(ctx context.Context, params RunParams) int {
+	cfg, err := config.Load(configLoadOptions(params))
+	if err != nil {
+		return printConfigErr(params.Stderr, err)
+	}
+
+	if len(cfg.Hooks) == 0 {
+		_, _ = fmt.Fprintln(params.Stdout, "No hooks configured.")
+		return exitOK
+	}
+
+	if code := checkHookTargets(ctx, cfg, params, true); code != exitOK {
+		return code
+	}
+
+	_, _ = fmt.Fprintln(params.Stdout, "All hook targets are valid.")
+	return exitOK
+}
+
+// checkHookTargets reports configured hook targets that don't exist in the
+// stavefiles they would run against. Unless strict is set, directories without
+// stavefiles are skipped, so hooks can be installed before the stavefiles
+// are written.
+func checkHookTargets(ctx context.Context, cfg *config.Config, params RunParams, strict bool) int {
+	unknown, err := unknownHookTargets(ctx, cfg, params.Dir, strict)
+	if err != nil {
+		return printErr(params.Stderr, err)
+	}
+	if len(unknown) == 0 {
+		return exitOK
+	}
+
+	_, _ = fmt.Fprintln(params.Stderr, "Error: stave.yaml references unknown hook targets:")
+	for _, u := range unknown {
+		_, _ = fmt.Fprintf(params.Stderr, "  %s\n", u)
+	}
+	_, _ = fmt.Fprintln(params.Stderr, "Run 'stave -l' to see the available targets.")
+	return exitError
+}
+
+// unknownHookTargets returns a "<hook>: <target>" entry for each configured
+// hook target that doesn't name a target, alias or namespace in the
+// stavefiles of its working directory.
+func unknownHookTargets(ctx context.Context, cfg *config.Config, dir string, strict bool) ([]string, error) {
+	knownByDir := make(map[string]map[string]struct{})
+	var unknown []string
+	for _, hookName := range cfg.Hooks.HookNames() {
+		for _, target := range cfg.Hooks.Get(hookName) {
+			if target.IsCommand() {
+				continue
+			}
+			if _, ok := cfg.ExternalTarget(target.Target); ok {
+				continue
+			}
+			workDir, err := determineWorkDir(cfg, dir, target.WorkDir)
+			if err != nil {
+				return nil, fmt.Errorf("determining work dir for %s target %q: %w", hookName, target.Target, err)
+			}
+
+			known, seen := knownByDir[workDir]
+			if !seen {
+				info, _, err := parseTargetsIn(ctx, workDir)
+				if err != nil {
+					return nil, fmt.Errorf("parsing stavefiles in %s: %w", workDir, err)
+				}
+				if info == nil && strict {
+					return nil, fmt.Errorf("no stavefiles found in %s", workDir)
+				}
+				if info == nil {
+					slog.Debug("no stavefiles found, skipping hook target validation", slog.String("dir", workDir))
+				} else {
+					known = knownTargetNames(info)
+				}
+				knownByDir[workDir] = known
+			}
+			if known == nil {
+				continue
+			}
+
+			if !lo.HasKey(known, strings.ToLower(target.Target)) {
+				unknown = append(unknown, fmt.Sprintf("%s: %s", hookName, target.Target))
+			}
+		}
+	}
+	return unknown, nil
+}
+
+// knownTargetNames returns the lowercased names that can be passed to the
+// stavefile: targets, imported targets, aliases, and namespaces that have a
+// Default target.
+func knownTargetNames(info *parse.PkgInfo) map[string]struct{} {
+	known := make(map[string]struct{})
+	add := func(name string) {
+		name = strings.ToLower(name)
+		known[name] = struct{}{}
+		if ns, ok := strings.CutSuffix(name, nsDefaultSuffix); ok {
+			known[ns] = struct{}{}
+		}
+	}
+	for _, f := range info.Funcs {
+		add(f.TargetName())
+	}
+	for _, imp := range info.Imports {
+		for _, f := range imp.Info.Funcs {
+			add(f.TargetName())
+		}
+	}
+	for alias := range info.Aliases {
+		add(alias)
+	}
+	return known
 }
 
 func installHooks(repo *hooks.GitRepo, cfg *config.Config, force bool, params RunParams) int {
@@ -354,7 +541,7 @@ func runHooksUninstall(ctx context.Context, params RunParams, args []string) int
 	}
 
 	// Load configuration for hook names
-	cfg, err := config.Load(&config.LoadOptions{ProjectDir: params.Dir})
+	cfg, err := config.Load(configLoadOptions(params))
 	if err != nil {
 		return printConfigErr(params.Stderr, err)
 	}
@@ -411,7 +598,7 @@ func uninstallHooks(repo *hooks.GitRepo, hookNames []string, stdout, stderr io.W
 func runHooksList(ctx context.Context, params RunParams) int {
 	slog.Debug("loading hooks configuration for list")
 
-	cfg, err := config.Load(&config.LoadOptions{ProjectDir: params.Dir})
+	cfg, err := config.Load(configLoadOptions(params))
 	if err != nil {
 		return printConfigErr(params.Stderr, err)
 	}
@@ -446,7 +633,12 @@ func printConfiguredHooks(cfg *config.Config, out io.Writer) {
 		targets := cfg.Hooks.Get(hookName)
 		_, _ = fmt.Fprintf(out, "  %s:\n", hookName)
 		for _, target := range targets {
-			if len(target.Args) > 0 {
+			if target.IsCommand() {
+				_, _ = fmt.Fprintf(out, "    - run: %s\n", target.Run)
+				if len(target.Args) > 0 {
+					_, _ = fmt.Fprintf(out, "      args: %s\n", strings.Join(target.Args, " "))
+				}
+			} else if len(target.Args) > 0 {
 				_, _ = fmt.Fprintf(out, "    - %s %s\n", target.Target, strings.Join(target.Args, " "))
 			} else {
 				_, _ = fmt.Fprintf(out, "    - %s\n", target.Target)
@@ -517,18 +709,19 @@ func runHooksRun(ctx context.Context, params RunParams, args []string) int {
 
 	// Load configuration
 	slog.Debug("loading hooks configuration")
-	cfg, err := config.Load(&config.LoadOptions{ProjectDir: params.Dir})
+	cfg, err := config.Load(configLoadOptions(params))
 	if err != nil {
 		return printConfigErr(params.Stderr, err)
 	}
 
 	// Create runtime and execute with real target runner
 	runtime := &hooks.Runtime{
-		Config:       cfg,
-		Stdin:        params.Stdin,
-		Stdout:       params.Stdout,
-		Stderr:       params.Stderr,
-		TargetRunner: newStaveTargetRunner(cfg, params.Dir),
+		Config:        cfg,
+		Stdin:         params.Stdin,
+		Stdout:        params.Stdout,
+		Stderr:        params.Stderr,
+		TargetRunner:  newStaveTargetRunner(cfg, params.Dir, hookArgs),
+		CommandRunner: newHookCommandRunner(cfg, params.Dir, hookArgs),
 	}
 
 	result, err := runtime.Run(ctx, hookName, hookArgs)
@@ -556,9 +749,11 @@ Subcommands:
   uninstall   Remove Stave-managed hook scripts
   list        List configured hooks and their targets (default)
   run         Execute targets for a specific hook
+  validate    Check that configured hook targets exist in the stavefiles
 
 Flags for install:
-  --force     Overwrite existing non-Stave hooks
+  --force               Overwrite existing non-Stave hooks
+  --recurse-submodules  Also install the hooks in each initialized submodule
 
 Flags for uninstall:
   --all       Remove all Stave-managed hooks (not just configured ones)
@@ -567,11 +762,19 @@ Environment Variables:
   STAVE_HOOKS=0      Disable all hooks
   STAVE_HOOKS=debug  Enable debug output in hook scripts
 
+Hook Arguments:
+  The arguments git passes to a hook are appended to each target's arguments,
+  and are set in STAVE_HOOK_ARG1, STAVE_HOOK_ARG2, ... for the targets.
+  For commit-msg, a target with a single string parameter gets the path of
+  the commit message file; targets without parameters ignore the arguments.
+
 Examples:
   stave --hooks                    # List configured hooks
   stave --hooks init               # Show setup instructions
   stave --hooks install            # Install all configured hooks
   stave --hooks install --force    # Overwrite existing hooks
+  stave --hooks install --recurse-submodules  # Install in submodules too
+  stave --hooks validate           # Check hook targets for typos
   stave --hooks uninstall          # Remove configured hooks
   stave --hooks uninstall --all    # Remove all Stave hooks
   stave --hooks run pre-commit     # Execute pre-commit targets
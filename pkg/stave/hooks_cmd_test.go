@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/yaklabco/stave/config"
 	"github.com/yaklabco/stave/internal/hooks"
+	"github.com/yaklabco/stave/internal/parse"
 	"github.com/yaklabco/stave/pkg/fsutils"
 )
 
@@ -65,6 +66,33 @@ func testGitInit(t *testing.T, dir string) {
 	}
 }
 
+// testRunGit runs git in dir, isolated from the user's git config like
+// testGitInit.
+func testRunGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = testEnvForGit()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\nOutput: %s", args, err, out)
+	}
+}
+
+// testGitCommit creates a repository in dir, with a stave.yaml holding
+// configContent committed in it.
+func testGitCommit(t *testing.T, dir, configContent string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	testGitInit(t, dir)
+	if err := os.WriteFile(filepath.Join(dir, "stave.yaml"), []byte(configContent), testConfigPerm); err != nil {
+		t.Fatalf("WriteFile config failed: %v", err)
+	}
+	testRunGit(t, dir, "add", "stave.yaml")
+	testRunGit(t, dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-m", "initial commit")
+}
+
 // copyModFiles copies go.mod and go.sum from the repo root to the destination directory.
 // This is needed for tests that compile stavefiles in temp directories.
 func copyModFiles(t *testing.T, dstDir string) {
@@ -233,6 +261,33 @@ hooks:
 	}
 }
 
+func TestRunHooksCommand_List_WithCommand(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := t.TempDir()
+	configContent := `
+hooks:
+  pre-commit:
+    - target: lint
+    - run: gofmt -l .
+      args: ["-s"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), testConfigPerm))
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"list"},
+	})
+
+	require.Equalf(t, 0, code, "STDERR WAS:\n%s\n", stderr.String())
+	assert.Contains(t, stdout.String(), "    - lint\n    - run: gofmt -l .\n      args: -s\n")
+}
+
 func TestRunHooksCommand_Install_NotGitRepo(t *testing.T) {
 	t.Parallel()
 
@@ -362,6 +417,157 @@ hooks:
 	}
 }
 
+// setupHooksProject creates a git repository containing the hooks test
+// stavefile and a stave.yaml with the given content.
+func setupHooksProject(t *testing.T, configContent string) string {
+	t.Helper()
+
+	tmpDir, err := fsutils.TruePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("fsutils.TruePath failed: %v", err)
+	}
+
+	testGitInit(t, tmpDir)
+	copyModFiles(t, tmpDir)
+
+	srcContent, err := os.ReadFile(filepath.Join("testdata", "hooks", "stavefile.go"))
+	if err != nil {
+		t.Fatalf("ReadFile stavefile failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "stavefile.go"), srcContent, testConfigPerm); err != nil {
+		t.Fatalf("WriteFile stavefile failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), testConfigPerm); err != nil {
+		t.Fatalf("WriteFile config failed: %v", err)
+	}
+	return tmpDir
+}
+
+func TestRunHooksCommand_Install_ValidTargets(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := setupHooksProject(t, `
+hooks:
+  pre-commit:
+    - target: HookTest
+  pre-push:
+    - target: hookfail
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"install"},
+	})
+
+	assert.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+	assert.FileExists(t, filepath.Join(tmpDir, ".git", "hooks", "pre-commit"))
+	assert.FileExists(t, filepath.Join(tmpDir, ".git", "hooks", "pre-push"))
+}
+
+func TestRunHooksCommand_Install_UnknownTargets(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := setupHooksProject(t, `
+hooks:
+  pre-commit:
+    - target: HookTest
+    - target: hooktset
+  pre-push:
+    - target: lint
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"install"},
+	})
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Error: stave.yaml references unknown hook targets:\n  pre-commit: hooktset\n  pre-push: lint\n")
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".git", "hooks", "pre-commit"))
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".git", "hooks", "pre-push"))
+}
+
+func TestRunHooksCommand_Validate(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := setupHooksProject(t, `
+hooks:
+  pre-commit:
+    - target: hooktest
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"validate"},
+	})
+
+	assert.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+	assert.Contains(t, stdout.String(), "All hook targets are valid.")
+}
+
+func TestRunHooksCommand_Validate_NoStavefiles(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "stave.yaml")
+	if err := os.WriteFile(configPath, []byte(testPreCommitFmtConfig), testConfigPerm); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"validate"},
+	})
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "no stavefiles found in")
+}
+
+func TestKnownTargetNames(t *testing.T) {
+	t.Parallel()
+
+	build := &parse.Function{Name: "Default", Receiver: "Build"}
+	info := &parse.PkgInfo{
+		Funcs: parse.Functions{
+			{Name: "Lint"},
+			build,
+			{Name: "All", Receiver: "Build"},
+		},
+		Imports: parse.Imports{
+			{Info: parse.PkgInfo{Funcs: parse.Functions{{Name: "Up", PkgAlias: "deploy"}}}},
+		},
+		Aliases: map[string]*parse.Function{"b": build},
+	}
+
+	known := knownTargetNames(info)
+	for _, name := range []string{"lint", "build", "build:default", "build:all", "deploy:up", "b"} {
+		assert.Contains(t, known, name)
+	}
+	assert.NotContains(t, known, "deploy")
+	assert.Len(t, known, 6)
+}
+
 func TestRunHooksCommand_Install_ExistingNonStaveHook_Fails(t *testing.T) {
 	t.Parallel()
 
@@ -497,6 +703,79 @@ func TestRunHooksCommand_Install_UpdatesStaveHook(t *testing.T) {
 	assert.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
 }
 
+func TestRunHooksCommand_Install_Worktree(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := fsutils.TruePath(t.TempDir())
+	require.NoError(t, err)
+	mainRepoDir := filepath.Join(tmpDir, "main")
+	testGitCommit(t, mainRepoDir, testPreCommitFmtConfig)
+	worktreeDir := filepath.Join(tmpDir, "worktree")
+	testRunGit(t, mainRepoDir, "worktree", "add", worktreeDir)
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    worktreeDir,
+		Args:   []string{"install"},
+	})
+	require.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+
+	// git runs the hooks of a worktree from the main repository's hooks
+	// directory, not from .git/worktrees/<name>.
+	managed, err := hooks.IsStaveManaged(filepath.Join(mainRepoDir, ".git", "hooks", "pre-commit"))
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.NoFileExists(t, filepath.Join(mainRepoDir, ".git", "worktrees", "worktree", "hooks", "pre-commit"))
+}
+
+func TestRunHooksCommand_Install_RecurseSubmodules(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := fsutils.TruePath(t.TempDir())
+	require.NoError(t, err)
+	libDir := filepath.Join(tmpDir, "lib")
+	testGitCommit(t, libDir, testPreCommitFmtConfig)
+	mainRepoDir := filepath.Join(tmpDir, "main")
+	testGitCommit(t, mainRepoDir, testPreCommitFmtConfig)
+	testRunGit(t, mainRepoDir, "-c", "protocol.file.allow=always", "submodule", "add", libDir, "lib")
+
+	mainHook := filepath.Join(mainRepoDir, ".git", "hooks", "pre-commit")
+	libHook := filepath.Join(mainRepoDir, ".git", "modules", "lib", "hooks", "pre-commit")
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    mainRepoDir,
+		Args:   []string{"install"},
+	})
+	require.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+	assert.FileExists(t, mainHook)
+	assert.NoFileExists(t, libHook)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = RunHooksCommand(t.Context(), RunParams{
+		Stdout:          &stdout,
+		Stderr:          &stderr,
+		Dir:             mainRepoDir,
+		Args:            []string{"install"},
+		HooksSubmodules: true,
+	})
+	require.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+	for _, hookPath := range []string{mainHook, libHook} {
+		managed, err := hooks.IsStaveManaged(hookPath)
+		require.NoError(t, err)
+		assert.True(t, managed, hookPath)
+	}
+	// each repository gets its own report.
+	assert.Contains(t, stdout.String(), mainRepoDir+":\nInstalled pre-commit\n")
+	assert.Contains(t, stdout.String(), filepath.Join(mainRepoDir, "lib")+":\nInstalled pre-commit\n")
+	assert.Contains(t, stdout.String(), "Installed 1 hook(s) to "+filepath.Dir(libHook))
+}
+
 func TestRunHooksCommand_Uninstall(t *testing.T) {
 	t.Parallel()
 
@@ -718,6 +997,56 @@ hooks:
 	}
 }
 
+func TestRunHooksCommand_Run_PassesHookArgs(t *testing.T) {
+	for _, target := range []string{"HookCommitMsg", "HookCommitMsgEnv"} {
+		t.Run(target, func(t *testing.T) {
+			config.ResetGlobal()
+
+			tmpDir, err := fsutils.TruePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("fsutils.TruePath failed: %v", err)
+			}
+			copyModFiles(t, tmpDir)
+
+			srcContent, err := os.ReadFile(filepath.Join("testdata", "hooks", "stavefile.go"))
+			if err != nil {
+				t.Fatalf("ReadFile stavefile failed: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(tmpDir, "stavefile.go"), srcContent, testConfigPerm); err != nil {
+				t.Fatalf("WriteFile stavefile failed: %v", err)
+			}
+
+			configContent := "hooks:\n  commit-msg:\n    - target: " + target + "\n"
+			if err := os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), testConfigPerm); err != nil {
+				t.Fatalf("WriteFile config failed: %v", err)
+			}
+
+			// Simulate git running the hook script with the commit message file.
+			msgPath := filepath.Join(tmpDir, "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgPath, []byte("feat: add hook args\n"), testConfigPerm); err != nil {
+				t.Fatalf("WriteFile commit message failed: %v", err)
+			}
+			markerPath := filepath.Join(tmpDir, "marker.txt")
+			t.Setenv("HOOK_TEST_MARKER", markerPath)
+
+			var stdout, stderr bytes.Buffer
+			code := RunHooksCommand(t.Context(), RunParams{
+				Stdout: &stdout,
+				Stderr: &stderr,
+				Dir:    tmpDir,
+				Args:   []string{"run", "commit-msg", "--", msgPath},
+			})
+			assert.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+
+			markerContent, err := os.ReadFile(markerPath)
+			if err != nil {
+				t.Fatalf("Marker file not created: %v\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+			}
+			assert.Equal(t, "feat: add hook args\n", string(markerContent))
+		})
+	}
+}
+
 func TestRunHooksCommand_Run_TargetFailure(t *testing.T) {
 	t.Parallel()
 
@@ -773,6 +1102,65 @@ hooks:
 	}
 }
 
+func TestRunHooksCommand_Run_Command(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := t.TempDir()
+	tmpDir, err := fsutils.TruePath(tmpDir)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "sub"), 0o755))
+
+	configContent := `
+hooks:
+  commit-msg:
+    - run: 'echo "msg=$1 arg=$STAVE_HOOK_ARG1 running=$STAVEFILE_HOOKS_RUNNING dir=$(pwd)"'
+      workdir: sub
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), testConfigPerm))
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"run", "commit-msg", "--", ".git/COMMIT_EDITMSG"},
+	})
+
+	require.Equalf(t, 0, code, "STDOUT WAS:\n%s\n\nSTDERR WAS:\n%s\n\n", stdout.String(), stderr.String())
+	assert.Contains(t, stdout.String(),
+		"msg=.git/COMMIT_EDITMSG arg=.git/COMMIT_EDITMSG running=1 dir="+filepath.Join(tmpDir, "sub"))
+}
+
+func TestRunHooksCommand_Run_CommandFailure(t *testing.T) {
+	t.Parallel()
+
+	config.ResetGlobal()
+
+	tmpDir := t.TempDir()
+	configContent := `
+hooks:
+  pre-commit:
+    - run: exit 3
+    - run: echo should not run
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stave.yaml"), []byte(configContent), testConfigPerm))
+
+	var stdout, stderr bytes.Buffer
+	code := RunHooksCommand(t.Context(), RunParams{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Dir:    tmpDir,
+		Args:   []string{"run", "pre-commit"},
+	})
+
+	assert.Equal(t, 3, code)
+	// The banner names every step; only the command output has a line to itself.
+	assert.NotContains(t, strings.Split(stdout.String(), "\n"), "should not run")
+	assert.Contains(t, stderr.String(), "stave: hook pre-commit failed at command exit 3 (exit 3)")
+}
+
 func TestDetermineWorkDir(t *testing.T) {
 	tmpDir := t.TempDir()
 	// Resolve symlinks
@@ -3,6 +3,7 @@ package stave
 
 import (
 	"bytes"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -350,6 +351,31 @@ func TestStaveImportsOneLine(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+// Test that a package imported by several stavefiles, which also all import
+// st, is only imported once.
+func TestStaveImportsRepeated(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataStaveImportDir, "repeated")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"build", "test"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "build\nlint\n", stdout.String())
+}
+
 func TestStaveImportsTrailing(t *testing.T) {
 	t.Parallel()
 	dataDirForThisTest := filepath.Join(testDataStaveImportDir, "trailing")
@@ -458,7 +484,180 @@ func TestStaveImportsSameNamespaceDupTargets(t *testing.T) {
 
 	expected := `
 parsing stavefiles: "samenamespace:build" target has multiple definitions: github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package1.Build, github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package2.Build
+  hint: give the imports different aliases, in their import comments:
+    // stave:import package1
+    _ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package1"
+    // stave:import package2
+    _ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package2"
+  or in stave.yaml:
+    import_aliases:
+      github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package1: package1
+      github.com/yaklabco/stave/pkg/stave/testdata/staveimport/samenamespace/duptargets/package2: package2
 `[1:]
 
 	assert.Equal(t, expected, err.Error())
 }
+
+func TestStaveImportsConfigAliases(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataStaveImportDir, "configalias")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	run := func(configFile string, args ...string) (string, error) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:    t.Context(),
+			Dir:        dataDirForThisTest,
+			Stdout:     stdout,
+			Stderr:     stderr,
+			CacheDir:   t.TempDir(),
+			ConfigFile: configFile,
+			List:       len(args) == 0,
+			Args:       args,
+		})
+		return stdout.String(), err
+	}
+
+	// without aliases, the two Build targets clash
+	_, err := run("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"build" target has multiple definitions`)
+	assert.Contains(t, err.Error(), "import_aliases:")
+
+	configFile := filepath.Join(t.TempDir(), "stave.yaml")
+	configContent := `
+import_aliases:
+  github.com/yaklabco/stave/pkg/stave/testdata/staveimport/configalias/package2: two
+`
+	require.NoError(t, os.WriteFile(configFile, []byte(configContent), 0o644))
+
+	out, err := run(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "two:build")
+
+	out, err = run(configFile, "build")
+	require.NoError(t, err)
+	assert.Equal(t, "build 1\n", out)
+
+	out, err = run(configFile, "two:build")
+	require.NoError(t, err)
+	assert.Equal(t, "build 2\n", out)
+}
+
+func TestStaveImportsRelative(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "relimport")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	ctx := t.Context()
+
+	for _, tc := range []struct {
+		target   string
+		expected string
+	}{
+		{target: "local", expected: "local\n"},
+		{target: "lib", expected: "lib\n"},
+		{target: "tools:lint", expected: "lint\n"},
+		{target: "vet", expected: "vet\n"},
+	} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		runParams := RunParams{
+			BaseCtx: ctx,
+			Dir:     dataDirForThisTest,
+			Stdout:  stdout,
+			Stderr:  stderr,
+			Args:    []string{tc.target},
+		}
+
+		err := Run(runParams)
+		require.NoError(t, err, "stderr was: %s", stderr.String())
+		assert.Equal(t, tc.expected, stdout.String())
+	}
+}
+
+func TestStaveImportsRelativeOutsideModule(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	projDir := filepath.Join(tmpDir, "proj")
+	extDir := filepath.Join(tmpDir, "ext")
+	require.NoError(t, os.MkdirAll(projDir, 0o755))
+	require.NoError(t, os.MkdirAll(extDir, 0o755))
+
+	files := map[string]string{
+		filepath.Join(projDir, "go.mod"): "module example.com/proj\n\ngo 1.25\n",
+		filepath.Join(projDir, "stavefile.go"): `//go:build stave
+
+package main
+
+//stave:import ../ext
+`,
+		filepath.Join(extDir, "go.mod"): "module example.com/ext\n\ngo 1.25\n",
+		filepath.Join(extDir, "ext.go"): `package ext
+
+import "fmt"
+
+// Ext lives in a module of its own.
+func Ext() {
+	fmt.Println("ext")
+}
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     projDir,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"ext"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "ext\n", stdout.String())
+}
+
+func TestStaveImportsRelativeNoModule(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	projDir := filepath.Join(tmpDir, "proj")
+	libDir := filepath.Join(tmpDir, "lib")
+	require.NoError(t, os.MkdirAll(projDir, 0o755))
+	require.NoError(t, os.MkdirAll(libDir, 0o755))
+
+	files := map[string]string{
+		filepath.Join(projDir, "go.mod"):       "module example.com/proj\n\ngo 1.25\n",
+		filepath.Join(projDir, "stavefile.go"): "//go:build stave\n\npackage main\n\n//stave:import ../lib\n",
+		filepath.Join(libDir, "lib.go"):        "package lib\n\n// Lib has nowhere to live.\nfunc Lib() {}\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     projDir,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"lib"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is outside the module")
+}
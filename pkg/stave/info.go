@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/yaklabco/stave/internal/parse"
 )
@@ -16,16 +18,16 @@ import (
 // the doc string directly, without compiling a temporary binary.
 func runInfoMode(ctx context.Context, params RunParams) error {
 	if len(params.Args) < 1 {
-		return errors.New("no target specified for -i/--info flag")
+		return newError(KindUsage, errors.New("no target specified for -i/--info flag"))
 	}
 
 	files, err := Stavefiles(params.Dir, params.GOOS, params.GOARCH, params.UsesStavefiles())
 	if err != nil {
-		return fmt.Errorf("determining list of stavefiles: %w", err)
+		return newError(KindParse, fmt.Errorf("determining list of stavefiles: %w", err))
 	}
 
 	if len(files) == 0 {
-		return errors.New("no .go files marked with the stave build tag in this directory")
+		return newError(KindParse, errors.New("no .go files marked with the stave build tag in this directory"))
 	}
 
 	fnames := make([]string, 0, len(files))
@@ -33,9 +35,16 @@ func runInfoMode(ctx context.Context, params RunParams) error {
 		fnames = append(fnames, filepath.Base(f))
 	}
 
-	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline)
+	cfg, err := loadConfig(params)
 	if err != nil {
-		return fmt.Errorf("parsing stavefiles: %w", err)
+		return err
+	}
+	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline, cfg.ImportAliases, params.GoEnv)
+	if err != nil {
+		return newError(KindParse, fmt.Errorf("parsing stavefiles: %w", err))
+	}
+	if err := lintStavefiles(info, params, cfg); err != nil {
+		return newError(KindParse, err)
 	}
 
 	sort.Sort(info.Funcs)
@@ -70,7 +79,13 @@ func renderTargetInfo(writer io.Writer, targetName string, data *mainfileTemplat
 		}
 	}
 	if theTargetFunction == nil {
-		return fmt.Errorf("target %q not found in parsed functions", targetName)
+		if text, ok := data.NamespaceInfo[strings.ToLower(targetName)]; ok {
+			if _, err := fmt.Fprint(writer, text); err != nil {
+				return fmt.Errorf("writing namespace info to output: %w", err)
+			}
+			return nil
+		}
+		return newError(KindUsage, fmt.Errorf("target %q not found in parsed functions", targetName))
 	}
 
 	var builder strings.Builder
@@ -97,10 +112,49 @@ func renderTargetInfo(writer io.Writer, targetName string, data *mainfileTemplat
 		fmt.Fprintf(&builder, "Aliases: %s\n\n", strings.Join(aliases, ", "))
 	}
 
+	if theTargetFunction.Retries > 0 {
+		fmt.Fprintf(
+			&builder, "Retries: %d (%s between attempts)\n\n",
+			theTargetFunction.Retries, theTargetFunction.RetryDelay,
+		)
+	}
+
+	if len(theTargetFunction.RequiresEnv) > 0 {
+		fmt.Fprintf(&builder, "Requires environment: %s\n\n", strings.Join(theTargetFunction.RequiresEnv, ", "))
+	}
+
+	if theTargetFunction.Container != "" {
+		fmt.Fprintf(&builder, "Container: %s\n\n", theTargetFunction.Container)
+	}
+
+	if theTargetFunction.Exclusive {
+		builder.WriteString("Exclusive: no other targets run alongside it\n\n")
+	}
+
+	if theTargetFunction.Serial {
+		builder.WriteString("Serial: its runs never overlap\n\n")
+	}
+
+	if len(theTargetFunction.Requires) > 0 {
+		fmt.Fprintf(&builder, "Preconditions: %s\n\n", strings.Join(theTargetFunction.Requires, ", "))
+	}
+
+	if theTargetFunction.Confirm != "" {
+		fmt.Fprintf(&builder, "Asks to confirm: %s\n\n", theTargetFunction.Confirm)
+	}
+
+	if len(theTargetFunction.WatchGlobs) > 0 {
+		fmt.Fprintf(&builder, "Watches: %s\n\n", strings.Join(theTargetFunction.WatchGlobs, ", "))
+	}
+
 	if theTargetFunction.IsWatch {
 		builder.WriteString("This is a watch target, which means it will be re-run whenever any of its dependencies change.\n")
 	}
 
+	if note, ok := data.NamespaceNotes[strings.ToLower(theTargetFunction.TargetName())]; ok {
+		builder.WriteString(note)
+	}
+
 	_, err := fmt.Fprint(writer, builder.String())
 	if err != nil {
 		return fmt.Errorf("writing target info to output: %w", err)
@@ -108,3 +162,93 @@ func renderTargetInfo(writer io.Writer, targetName string, data *mainfileTemplat
 
 	return nil
 }
+
+// targetNamespace is a namespace as `stave -i` shows it.
+type targetNamespace struct {
+	name    string // name is the prefix of its targets' names, like "ns" or "pkg:ns", in lower case.
+	comment string
+	funcs   []*parse.Function
+}
+
+// namespaceInfo returns what `stave -i` prints for each namespace of info,
+// local or imported, keyed by its lower case name. A target by the same name
+// wins, so namespaces that share the name of a target are left out, and
+// instead notes says, by the target's lower case name, that the namespace
+// exists.
+func namespaceInfo(binaryName string, info *parse.PkgInfo) (map[string]string, map[string]string) {
+	targets := make(map[string]bool)
+	var namespaces []*targetNamespace
+	byName := make(map[string]*targetNamespace)
+	add := func(f *parse.Function, docs []parse.Namespace) {
+		targets[strings.ToLower(f.TargetName())] = true
+		if f.Receiver == "" || f.Hidden {
+			return
+		}
+		name := strings.ToLower(strings.TrimSuffix(f.TargetName(), ":"+f.Name))
+		ns, ok := byName[name]
+		if !ok {
+			ns = &targetNamespace{name: name}
+			for _, doc := range docs {
+				if doc.Name == f.Receiver {
+					ns.comment = doc.Comment
+				}
+			}
+			byName[name] = ns
+			namespaces = append(namespaces, ns)
+		}
+		ns.funcs = append(ns.funcs, f)
+	}
+	for _, f := range info.Funcs {
+		add(f, info.Namespaces)
+	}
+	for _, imp := range info.Imports {
+		for _, f := range imp.Info.Funcs {
+			add(f, imp.Info.Namespaces)
+		}
+	}
+
+	texts := make(map[string]string)
+	notes := make(map[string]string)
+	for _, ns := range namespaces {
+		slices.SortFunc(ns.funcs, func(a, b *parse.Function) int {
+			return strings.Compare(strings.ToLower(a.TargetName()), strings.ToLower(b.TargetName()))
+		})
+		if !targets[ns.name] {
+			texts[ns.name] = renderNamespaceInfo(binaryName, ns)
+			continue
+		}
+		names := make([]string, 0, len(ns.funcs))
+		for _, f := range ns.funcs {
+			names = append(names, strings.ToLower(f.TargetName()))
+		}
+		notes[ns.name] = fmt.Sprintf("%q is also a namespace, with the targets %s.\n\n", ns.name, strings.Join(names, ", "))
+	}
+	return texts, notes
+}
+
+// renderNamespaceInfo renders the doc comment of ns, followed by a table of
+// the usage lines and synopses of its targets.
+func renderNamespaceInfo(binaryName string, ns *targetNamespace) string {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	for _, f := range ns.funcs {
+		usage := binaryName + " " + strings.ToLower(f.TargetName())
+		for _, arg := range f.Args {
+			usage += " <" + arg.Name + ">"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", usage, f.Synopsis)
+	}
+	_ = tw.Flush()
+
+	var builder strings.Builder
+	if ns.comment != "" {
+		builder.WriteString(ns.comment)
+		builder.WriteString("\n\n")
+	}
+	fmt.Fprintf(&builder, "Targets in namespace %s:\n\n", ns.name)
+	for line := range strings.Lines(table.String()) {
+		builder.WriteString("\t" + strings.TrimRight(line, " \n") + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
@@ -0,0 +1,176 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitDirLayout(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           dir,
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		Init:          true,
+		InitDirLayout: true,
+	})
+	require.NoError(t, err, stderr.String())
+
+	stavefilesDir := filepath.Join(dir, StavefilesDirName)
+	assert.DirExists(t, stavefilesDir)
+	assert.NoFileExists(t, filepath.Join(dir, initFile))
+	content, err := os.ReadFile(filepath.Join(stavefilesDir, initFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "//go:build stave")
+	// the temp dir isn't in a module, so the stavefiles get their own.
+	assert.FileExists(t, filepath.Join(stavefilesDir, "go.mod"))
+	assert.Contains(t, stdout.String(), "Created "+filepath.Join(stavefilesDir, initFile))
+	assert.Contains(t, stdout.String(), "cd "+stavefilesDir+" && go mod tidy")
+
+	stdout.Reset()
+	err = Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		List:    true,
+	})
+	require.NoError(t, err, stderr.String())
+	assert.Contains(t, stdout.String(), "build")
+}
+
+func TestInitDirLayoutExisting(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	stavefilesDir := filepath.Join(dir, StavefilesDirName)
+	require.NoError(t, os.Mkdir(stavefilesDir, 0o755))
+	existing := filepath.Join(stavefilesDir, initFile)
+	require.NoError(t, os.WriteFile(existing, []byte("//go:build stave\n\npackage main\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           dir,
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		Init:          true,
+		InitDirLayout: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has .go files in it")
+
+	got, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "//go:build stave\n\npackage main\n", string(got))
+}
+
+func TestInitDirLayoutEmptyDir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	stavefilesDir := filepath.Join(dir, StavefilesDirName)
+	require.NoError(t, os.Mkdir(stavefilesDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(stavefilesDir, "README.md"), []byte("notes\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           dir,
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		Init:          true,
+		InitDirLayout: true,
+	})
+	require.NoError(t, err, stderr.String())
+	assert.FileExists(t, filepath.Join(stavefilesDir, initFile))
+	assert.FileExists(t, filepath.Join(stavefilesDir, "README.md"))
+}
+
+func TestInitDirLayoutInModule(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/proj\n\ngo 1.25\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           dir,
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		Init:          true,
+		InitDirLayout: true,
+	})
+	require.NoError(t, err, stderr.String())
+
+	stavefilesDir := filepath.Join(dir, StavefilesDirName)
+	assert.FileExists(t, filepath.Join(stavefilesDir, initFile))
+	assert.NoFileExists(t, filepath.Join(stavefilesDir, "go.mod"))
+	assert.NotContains(t, stdout.String(), "go mod tidy")
+
+	stdout.Reset()
+	err = Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		List:    true,
+	})
+	require.NoError(t, err, stderr.String())
+	assert.Contains(t, stdout.String(), "build")
+}
+
+func TestInitRootFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Init:    true,
+	})
+	require.NoError(t, err, stderr.String())
+
+	content, err := os.ReadFile(filepath.Join(dir, initFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "//go:build stave")
+	assert.NoDirExists(t, filepath.Join(dir, StavefilesDirName))
+	assert.Contains(t, stdout.String(), "Next steps:")
+
+	stdout.Reset()
+	err = Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		List:    true,
+	})
+	require.NoError(t, err, stderr.String())
+	assert.Contains(t, stdout.String(), "build")
+}
+
+func TestDirLayoutWithoutInit(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           t.TempDir(),
+		Stdout:        &stdout,
+		Stderr:        &stderr,
+		InitDirLayout: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--dir-layout only applies when running with --init")
+}
@@ -0,0 +1,138 @@
+package stave
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// exitCallHint is how to avoid ending the process from a target.
+const exitCallHint = "return an error instead, or st.Fatal to choose the exit status; " +
+	"mark helpers that must exit with stave:allow-exit"
+
+// lintStavefiles reports problems in the parsed stavefiles that don't stop
+// them compiling, given the config they are run with: as warnings, or as an
+// error under --strict.
+func lintStavefiles(info *parse.PkgInfo, params RunParams, cfg *config.Config) error {
+	if err := checkSignatures(info, params.StrictSignatures || params.Strict); err != nil {
+		return err
+	}
+	if err := checkExitCalls(info, params.Strict); err != nil {
+		return err
+	}
+	if err := checkExternalTargets(info, cfg, params.Strict); err != nil {
+		return err
+	}
+	return checkShadowedTargets(info, params.Strict)
+}
+
+// checkExitCalls reports the targets that end the process themselves, which
+// skips stave's cleanup, summary and exit status handling: as warnings, or as
+// an error if strict is set.
+func checkExitCalls(info *parse.PkgInfo, strict bool) error {
+	exits := slices.Clone(info.ExitCalls)
+	for _, imp := range info.Imports {
+		exits = append(exits, imp.Info.ExitCalls...)
+	}
+	if len(exits) == 0 {
+		return nil
+	}
+
+	if !strict {
+		for _, exit := range exits {
+			slog.Warn(
+				"target ends the process without returning to stave",
+				slog.String(log.Target, exit.Target),
+				slog.String(log.Func, cmp.Or(exit.Via, exit.Target)),
+				slog.String(log.Name, exit.Call),
+				slog.String(log.Position, exit.Pos.String()),
+				slog.String(log.Hint, exitCallHint),
+			)
+		}
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("targets that end the process without returning to stave:")
+	for _, exit := range exits {
+		fmt.Fprintf(&builder, "\n  %s: %s calls %s", exit.Pos, exit.Target, exit.Call)
+		if exit.Via != "" {
+			fmt.Fprintf(&builder, " in %s", exit.Via)
+		}
+	}
+	fmt.Fprintf(&builder, "\n    hint: %s", exitCallHint)
+	return errors.New(builder.String())
+}
+
+// builtinClash returns the stave flag or command that name, a target name or
+// alias, is easily confused with, and whether stave runs that command instead
+// of the target. It returns "" if there is none.
+func builtinClash(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if _, ok := builtinCommands[name]; ok {
+		return "stave " + name, true
+	}
+	return builtinFlags[name], false
+}
+
+// builtinClashNote is how `stave -l` annotates a target whose name or alias
+// clashes with builtin, or "" if there is no clash.
+func builtinClashNote(builtin string, shadowed bool) string {
+	switch {
+	case builtin == "":
+		return ""
+	case shadowed:
+		return "[hidden by " + builtin + "]"
+	default:
+		return "[not " + builtin + "]"
+	}
+}
+
+// checkShadowedTargets reports the targets and aliases that stave can't run
+// because a stave command of the same name runs instead: as warnings, or as an
+// error if strict is set. Targets named like a stave flag, such as Clean, are
+// only annotated in `stave -l`, since "stave clean" does run them.
+func checkShadowedTargets(info *parse.PkgInfo, strict bool) error {
+	names := make([]string, 0, len(info.Funcs)+len(info.Aliases))
+	for _, fn := range info.Funcs {
+		names = append(names, fn.TargetName())
+	}
+	for _, imp := range info.Imports {
+		for _, fn := range imp.Info.Funcs {
+			names = append(names, fn.TargetName())
+		}
+	}
+	names = slices.AppendSeq(names, maps.Keys(info.Aliases))
+	slices.Sort(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builtin, shadowed := builtinClash(name)
+		if !shadowed {
+			continue
+		}
+		hint := fmt.Sprintf("%q runs the command, not the target; rename the target or give it an alias", builtin)
+		if strict {
+			fmt.Fprintf(&builder, "\n  %s: %s", name, hint)
+			continue
+		}
+		slog.Warn(
+			"target is hidden by a stave command",
+			slog.String(log.Target, name),
+			slog.String(log.Name, builtin),
+			slog.String(log.Hint, hint),
+		)
+	}
+	if builder.Len() == 0 {
+		return nil
+	}
+	return errors.New("targets hidden by stave commands:" + builder.String())
+}
@@ -0,0 +1,163 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+func exitCallsInfo() *parse.PkgInfo {
+	return &parse.PkgInfo{
+		ExitCalls: []parse.ExitCall{{
+			Target: "Build",
+			Call:   "os.Exit",
+			Pos:    token.Position{Filename: "stavefile.go", Line: 12, Column: 2},
+		}},
+		Imports: []*parse.Import{{Info: parse.PkgInfo{ExitCalls: []parse.ExitCall{{
+			Target: "NS.Deploy",
+			Call:   "log.Fatal",
+			Via:    "mustLogin",
+			Pos:    token.Position{Filename: "deploy.go", Line: 30, Column: 3},
+		}}}}},
+	}
+}
+
+func TestCheckExitCallsLogsWarning(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	require.NoError(t, checkExitCalls(exitCallsInfo(), false))
+
+	var records []map[string]any
+	for line := range bytes.Lines(buf.Bytes()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "target ends the process without returning to stave", records[0]["msg"])
+	assert.Equal(t, "Build", records[0]["target"])
+	assert.Equal(t, "Build", records[0]["func"])
+	assert.Equal(t, "os.Exit", records[0]["name"])
+	assert.Equal(t, "stavefile.go:12:2", records[0]["position"])
+	assert.Equal(t, exitCallHint, records[0]["hint"])
+	assert.Equal(t, "NS.Deploy", records[1]["target"])
+	assert.Equal(t, "mustLogin", records[1]["func"])
+}
+
+func TestCheckExitCallsStrict(t *testing.T) {
+	t.Parallel()
+
+	err := checkExitCalls(exitCallsInfo(), true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "targets that end the process without returning to stave:")
+	assert.Contains(t, err.Error(), "stavefile.go:12:2: Build calls os.Exit\n")
+	assert.Contains(t, err.Error(), "deploy.go:30:3: NS.Deploy calls log.Fatal in mustLogin")
+	assert.Contains(t, err.Error(), "hint: return an error instead")
+
+	require.NoError(t, checkExitCalls(&parse.PkgInfo{}, true))
+}
+
+func TestLintStavefilesStrict(t *testing.T) {
+	t.Parallel()
+
+	info := &parse.PkgInfo{InvalidFuncs: []parse.InvalidFunc{{Name: "Deploy", Reason: "Deploy returns (string, error)"}}}
+	err := lintStavefiles(info, RunParams{Strict: true}, &config.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exported functions with invalid target signatures:")
+}
+
+func TestCheckShadowedTargets(t *testing.T) {
+	info := &parse.PkgInfo{
+		Funcs:   []*parse.Function{{Name: "Clean"}, {Name: "Help"}, {Name: "Build"}},
+		Aliases: map[string]*parse.Function{"completion": {Name: "Build"}},
+		Imports: []*parse.Import{{Info: parse.PkgInfo{Funcs: []*parse.Function{{Name: "Completion", PkgAlias: "docker"}}}}},
+	}
+
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	require.NoError(t, checkShadowedTargets(info, false))
+
+	var records []map[string]any
+	for line := range bytes.Lines(buf.Bytes()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "target is hidden by a stave command", records[0]["msg"])
+	assert.Equal(t, "completion", records[0]["target"])
+	assert.Equal(t, "stave completion", records[0]["name"])
+
+	err := checkShadowedTargets(info, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "targets hidden by stave commands:")
+	assert.Contains(t, err.Error(), `completion: "stave completion" runs the command, not the target`)
+	assert.NotContains(t, err.Error(), "Clean")
+	assert.NotContains(t, err.Error(), "Help")
+	assert.NotContains(t, err.Error(), "docker:Completion")
+}
+
+func TestBuiltinNameRunsTarget(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "builtinnames")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	cacheDir := t.TempDir()
+	marker := filepath.Join(cacheDir, "marker")
+	require.NoError(t, os.WriteFile(marker, nil, 0o644))
+
+	for target, want := range map[string]string{
+		"clean": "target clean ran",
+		"help":  "target help ran",
+		"init":  "target setup ran",
+	} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:  t.Context(),
+			Dir:      dataDirForThisTest,
+			CacheDir: cacheDir,
+			Stdout:   stdout,
+			Stderr:   stderr,
+			Args:     []string{target},
+		})
+		require.NoError(t, err, stderr.String())
+		assert.Equal(t, want+"\n", stdout.String())
+	}
+
+	// "stave clean" ran the target, not --clean.
+	assert.FileExists(t, marker)
+
+	stdout := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		CacheDir: cacheDir,
+		Stdout:   stdout,
+		Stderr:   &bytes.Buffer{},
+		List:     true,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), "build output. [not --clean]")
+	assert.Contains(t, stdout.String(), "workspace. [not --init]")
+	assert.Contains(t, stdout.String(), "contributor notes. [not --help]")
+}
@@ -8,15 +8,16 @@ import (
 	"io"
 	"maps"
 	"os"
-	"path/filepath"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/term"
 	"github.com/muesli/reflow/wordwrap"
+	"github.com/yaklabco/stave/config"
 	"github.com/yaklabco/stave/internal/parse"
 	"github.com/yaklabco/stave/pkg/st"
 	"github.com/yaklabco/stave/pkg/ui"
@@ -28,6 +29,7 @@ const (
 	targetGroupLocal targetGroupKind = iota
 	targetGroupNamespace
 	targetGroupImport
+	targetGroupExternal
 )
 
 const (
@@ -44,12 +46,16 @@ type targetKey struct {
 type targetItem struct {
 	key targetKey
 
+	targetName  string // as the mainfile knows it, e.g. "Ns:Build"
 	displayName string
 	args        []parse.Arg
 	synopsis    string
 	aliases     []string
 	isDefault   bool
 	isWatch     bool
+	watchGlobs  []string
+	isExclusive bool
+	clashNote   string // how the name or an alias clashes with a stave flag or command, if it does
 
 	groupKind targetGroupKind
 	groupName string // receiver name, import label, or empty for local
@@ -63,47 +69,127 @@ var nsDefaultSuffix = ":" + strings.ToLower(defaultLabel) //nolint:gochecknoglob
 func runListMode(ctx context.Context, params RunParams) error {
 	files, err := Stavefiles(params.Dir, params.GOOS, params.GOARCH, params.UsesStavefiles())
 	if err != nil {
-		return fmt.Errorf("determining list of stavefiles: %w", err)
+		return newError(KindParse, fmt.Errorf("determining list of stavefiles: %w", err))
 	}
 
 	if len(files) == 0 {
-		return errors.New("no .go files marked with the stave build tag in this directory")
+		return newError(KindParse, errors.New("no .go files marked with the stave build tag in this directory"))
 	}
 
-	fnames := make([]string, 0, len(files))
-	for _, f := range files {
-		fnames = append(fnames, filepath.Base(f))
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
 	}
-
-	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline)
+	info, err := cachedPrimaryPackage(ctx, params, cfg, files)
 	if err != nil {
-		return fmt.Errorf("parsing stavefiles: %w", err)
+		return newError(KindParse, fmt.Errorf("parsing stavefiles: %w", err))
+	}
+	if err := lintStavefiles(info, params, cfg); err != nil {
+		return newError(KindParse, err)
 	}
 
 	sort.Sort(info.Funcs)
 	sort.Sort(info.Imports)
 
+	var lastRun map[string]time.Duration
+	if !params.NoTimings {
+		lastRun = readTimings(params.CacheDir, params.Dir)
+	}
+
 	return renderTargetList(
 		params.Stdout,
 		info,
+		cfg.ExternalTargets,
 		params.Args,
+		listSections{
+			local:      params.ListLocal,
+			namespaces: params.ListNamespaces,
+			imports:    params.ListImports,
+			importName: params.ListImport,
+		},
+		lastRun,
+		params.ListArgs,
+		params.ListAll,
 	)
 }
 
-// renderTargetList renders the output of `stave -l`.
+// listSections restricts `stave -l` to some of its sections. The zero value
+// shows everything.
+type listSections struct {
+	local      bool
+	namespaces bool
+	imports    bool
+	importName string // show only the import with this label or import path
+}
+
+func (ls listSections) isSet() bool {
+	return ls.local || ls.namespaces || ls.imports || ls.importName != ""
+}
+
+// includes reports whether the item belongs to one of the selected sections.
+func (ls listSections) includes(it targetItem) bool {
+	if !ls.isSet() {
+		return true
+	}
+	switch it.groupKind {
+	case targetGroupLocal:
+		return ls.local
+	case targetGroupNamespace:
+		return ls.namespaces
+	case targetGroupImport:
+		if ls.importName != "" {
+			return strings.EqualFold(it.groupName, ls.importName) || it.groupMeta == ls.importName
+		}
+		return ls.imports
+	case targetGroupExternal:
+		return false
+	}
+	return false
+}
+
+func applySectionFilter(items []targetItem, sections listSections) []targetItem {
+	if !sections.isSet() {
+		return items
+	}
+	out := make([]targetItem, 0, len(items))
+	for _, it := range items {
+		if sections.includes(it) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// renderTargetList renders the output of `stave -l`, listing the external
+// targets after those of the stavefiles. If lastRun is not nil, a LAST column
+// shows the duration it gives for each target. If showArgs is set, an ARGS
+// column lists the name and type of each target's arguments. Targets marked
+// stave:hidden are only listed if showHidden is set.
 //
 // It is implemented in the Stave binary (not in the generated mainfile) so it can
 // use Charmbracelet styling without requiring additional dependencies in user projects.
-func renderTargetList(out io.Writer, info *parse.PkgInfo, filters []string) error {
-	items := buildTargetItems(info)
+func renderTargetList(
+	out io.Writer,
+	info *parse.PkgInfo,
+	externals map[string]config.ExternalTarget,
+	filters []string,
+	sections listSections,
+	lastRun map[string]time.Duration,
+	showArgs bool,
+	showHidden bool,
+) error {
+	items := append(buildTargetItems(info, showHidden), externalTargetItems(externals)...)
+	total := len(items)
+	if sections.importName != "" && !hasImport(info, sections.importName) {
+		return newError(KindUsage, fmt.Errorf("no imported package named %q", sections.importName))
+	}
+	items = applySectionFilter(items, sections)
 	items = applyTargetFilters(items, filters)
 
-	anyWatch := false
+	anyWatch, anyExclusive := false, false
 	for _, it := range items {
-		if it.isWatch {
-			anyWatch = true
-			break
-		}
+		anyWatch = anyWatch || it.isWatch
+		anyExclusive = anyExclusive || it.isExclusive
 	}
 
 	cs := ui.GetFangScheme()
@@ -131,7 +217,7 @@ func renderTargetList(out io.Writer, info *parse.PkgInfo, filters []string) erro
 		watchStyle = watchStyle.Foreground(cs.QuotedString).Reverse(true).Bold(true)
 	}
 
-	renderName := func(name string, isDefault, isWatch bool, args []parse.Arg) string {
+	renderName := func(name string, isDefault bool, marks []string, args []parse.Arg) string {
 		var sb strings.Builder
 		if !colorEnabled {
 			sb.WriteString(name)
@@ -143,8 +229,8 @@ func renderTargetList(out io.Writer, info *parse.PkgInfo, filters []string) erro
 				sb.WriteString(a.Name)
 				sb.WriteString(">")
 			}
-			if isWatch {
-				sb.WriteString(" [W]")
+			for _, mark := range marks {
+				sb.WriteString(" " + mark)
 			}
 			return sb.String()
 		}
@@ -166,9 +252,9 @@ func renderTargetList(out io.Writer, info *parse.PkgInfo, filters []string) erro
 			sb.WriteString(">")
 		}
 
-		if isWatch {
+		for _, mark := range marks {
 			sb.WriteString(" ")
-			sb.WriteString(watchStyle.Render("[W]"))
+			sb.WriteString(watchStyle.Render(mark))
 		}
 
 		return sb.String()
@@ -186,33 +272,67 @@ func renderTargetList(out io.Writer, info *parse.PkgInfo, filters []string) erro
 
 	_, _ = fmt.Fprintln(out, titleStyle.Render("Targets:"))
 
-	sections := groupTargets(items)
-	maxUsage := globalUsageWidth(sections)
+	groups := groupTargets(items)
+	maxUsage := globalUsageWidth(groups)
+	argCol := newArgsColumn(showArgs, groups)
+	last := newLastRunColumn(lastRun, groups)
 
-	writeSection := func(title string, groups []targetGroup) {
-		if len(groups) == 0 {
+	writeSection := func(title string, section []targetGroup) {
+		if len(section) == 0 {
 			return
 		}
 		_, _ = fmt.Fprintln(out)
 		_, _ = fmt.Fprintln(out, sectionStyle.Render(title))
-		for _, g := range groups {
-			writeTable(out, tableHeaderStyle, subsectionStyle, g, renderName, indent, maxUsage)
+		for _, g := range section {
+			writeTable(out, tableHeaderStyle, subsectionStyle, g, renderName, indent, maxUsage, argCol, last)
 		}
 	}
 
-	writeSection("Local", sections.local)
-	writeSection("Namespaces", sections.namespaces)
-	writeSection("Imports", sections.imports)
+	writeSection("Local", groups.local)
+	writeSection("Namespaces", groups.namespaces)
+	writeSection("Imports", groups.imports)
+	writeSection("External", groups.external)
 
+	if anyWatch || anyExclusive {
+		_, _ = fmt.Fprintln(out)
+	}
 	if anyWatch {
+		_, _ = fmt.Fprintln(out, watchStyle.Render(watchMark)+" = watch target")
+	}
+	if anyExclusive {
+		_, _ = fmt.Fprintln(out, watchStyle.Render(exclusiveMark)+" = exclusive target, never run alongside other targets")
+	}
+
+	if sections.isSet() || hasTextFilter(filters) {
 		_, _ = fmt.Fprintln(out)
-		_, _ = fmt.Fprintln(out, watchStyle.Render("[W]")+" = watch target")
+		_, _ = fmt.Fprintf(out, "showing %d of %d targets\n", len(items), total)
 	}
 
 	return nil
 }
 
-func buildTargetItems(info *parse.PkgInfo) []targetItem {
+// hasImport reports whether info imports a package with the given label (its
+// alias, or its name if it has none) or import path.
+func hasImport(info *parse.PkgInfo, name string) bool {
+	for _, imp := range info.Imports {
+		if imp == nil {
+			continue
+		}
+		if strings.EqualFold(cmp.Or(imp.Alias, imp.Name), name) || imp.Path == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTextFilter(filters []string) bool {
+	include, exclude := parseTargetFilters(filters)
+	return len(include) > 0 || len(exclude) > 0
+}
+
+// buildTargetItems returns an item for each target in info, leaving out those
+// marked stave:hidden unless showHidden is set.
+func buildTargetItems(info *parse.PkgInfo, showHidden bool) []targetItem {
 	aliasByKey := make(map[targetKey][]string)
 	for alias, fn := range info.Aliases {
 		if fn == nil {
@@ -241,19 +361,23 @@ func buildTargetItems(info *parse.PkgInfo) []targetItem {
 
 	// Local funcs
 	for _, fn := range info.Funcs {
-		if fn == nil {
+		if fn == nil || (fn.Hidden && !showHidden) {
 			continue
 		}
 		funcKey := targetKey{importPath: fn.ImportPath, receiver: fn.Receiver, name: fn.Name}
 		display := lowerFirstTargetName(fn.TargetName())
 		items = append(items, targetItem{
 			key:         funcKey,
+			targetName:  fn.TargetName(),
 			displayName: display,
 			args:        fn.Args,
 			synopsis:    fn.Synopsis,
 			aliases:     aliasByKey[funcKey],
 			isDefault:   funcKey == defaultKey && fn.Name != "",
 			isWatch:     fn.IsWatch,
+			watchGlobs:  fn.WatchGlobs,
+			isExclusive: fn.Exclusive,
+			clashNote:   targetClashNote(fn, aliasByKey[funcKey]),
 			groupKind:   localGroupKind(fn),
 			groupName:   localGroupName(fn),
 		})
@@ -269,19 +393,23 @@ func buildTargetItems(info *parse.PkgInfo) []targetItem {
 			label = imp.Alias
 		}
 		for _, fn := range imp.Info.Funcs {
-			if fn == nil {
+			if fn == nil || (fn.Hidden && !showHidden) {
 				continue
 			}
 			funcKey := targetKey{importPath: fn.ImportPath, receiver: fn.Receiver, name: fn.Name}
 			display := lowerFirstTargetName(fn.TargetName())
 			items = append(items, targetItem{
 				key:         funcKey,
+				targetName:  fn.TargetName(),
 				displayName: display,
 				args:        fn.Args,
 				synopsis:    fn.Synopsis,
 				aliases:     aliasByKey[funcKey],
 				isDefault:   funcKey == defaultKey && fn.Name != "",
 				isWatch:     fn.IsWatch,
+				watchGlobs:  fn.WatchGlobs,
+				isExclusive: fn.Exclusive,
+				clashNote:   targetClashNote(fn, aliasByKey[funcKey]),
 				groupKind:   targetGroupImport,
 				groupName:   label,
 				groupMeta:   imp.Path,
@@ -292,6 +420,35 @@ func buildTargetItems(info *parse.PkgInfo) []targetItem {
 	return items
 }
 
+// externalTargetItems returns an item for each of the external targets, whose
+// synopsis is their description, or else their command.
+func externalTargetItems(externals map[string]config.ExternalTarget) []targetItem {
+	items := make([]targetItem, 0, len(externals))
+	for _, name := range slices.Sorted(maps.Keys(externals)) {
+		target := externals[name]
+		items = append(items, targetItem{
+			key:         targetKey{name: name},
+			targetName:  name,
+			displayName: name,
+			synopsis:    cmp.Or(target.Description, "runs "+target.Command),
+			clashNote:   builtinClashNote(builtinClash(name)),
+			groupKind:   targetGroupExternal,
+		})
+	}
+	return items
+}
+
+// targetClashNote annotates a target whose name or one of whose aliases is
+// also a stave flag or command.
+func targetClashNote(fn *parse.Function, aliases []string) string {
+	for _, name := range append([]string{fn.TargetName()}, aliases...) {
+		if note := builtinClashNote(builtinClash(name)); note != "" {
+			return note
+		}
+	}
+	return ""
+}
+
 func localGroupKind(fn *parse.Function) targetGroupKind {
 	if fn.Receiver != "" {
 		return targetGroupNamespace
@@ -322,60 +479,95 @@ func usageFor(binaryName, display string, args []parse.Arg) string {
 	return sb.String()
 }
 
-func usageWidthFor(name string, args []parse.Arg, isWatch bool) int {
+// Marks that follow the usage of watch and exclusive targets in the list.
+const (
+	watchMark     = "[W]"
+	exclusiveMark = "[X]"
+)
+
+// marks returns the marks that follow the target's usage in the list.
+func (it targetItem) marks() []string {
+	var marks []string
+	if it.isWatch {
+		marks = append(marks, watchMark)
+	}
+	if it.isExclusive {
+		marks = append(marks, exclusiveMark)
+	}
+	return marks
+}
+
+func usageWidthFor(name string, args []parse.Arg, marks []string) int {
 	usage := usageFor("", name, args)
-	if isWatch {
-		usage += " [W]"
+	for _, mark := range marks {
+		usage += " " + mark
 	}
 	return lipgloss.Width(usage)
 }
 
 func globalUsageWidth(sections targetSections) int {
 	maxWidth := lipgloss.Width("USAGE")
-	for _, groups := range [][]targetGroup{sections.local, sections.namespaces, sections.imports} {
+	for _, groups := range [][]targetGroup{sections.local, sections.namespaces, sections.imports, sections.external} {
 		for _, g := range groups {
 			for _, it := range g.items {
 				name := it.displayName
 				if len(it.aliases) > 0 {
 					name = fmt.Sprintf("%s (%s)", name, strings.Join(it.aliases, ", "))
 				}
-				maxWidth = max(maxWidth, usageWidthFor(name, it.args, it.isWatch))
+				maxWidth = max(maxWidth, usageWidthFor(name, it.args, it.marks()))
 			}
 		}
 	}
 	return maxWidth
 }
 
-func applyTargetFilters(items []targetItem, filters []string) []targetItem {
-	if len(filters) == 0 {
-		return items
-	}
-
-	needles := make([]string, 0, len(filters))
+// parseTargetFilters splits the text filters given to `stave -l` into
+// lowercased needles that must match and, for filters starting with "!",
+// needles that must not.
+func parseTargetFilters(filters []string) ([]string, []string) {
+	var include, exclude []string
 	for _, f := range filters {
 		f = strings.TrimSpace(f)
-		if f != "" {
-			needles = append(needles, strings.ToLower(f))
+		negated := strings.HasPrefix(f, "!")
+		if negated {
+			f = strings.TrimSpace(f[1:])
+		}
+		switch {
+		case f == "":
+		case negated:
+			exclude = append(exclude, strings.ToLower(f))
+		default:
+			include = append(include, strings.ToLower(f))
 		}
 	}
-	if len(needles) == 0 {
+	return include, exclude
+}
+
+func applyTargetFilters(items []targetItem, filters []string) []targetItem {
+	include, exclude := parseTargetFilters(filters)
+	if len(include) == 0 && len(exclude) == 0 {
 		return items
 	}
 
-	matchAll := func(haystack string) bool {
+	matches := func(haystack string) bool {
 		haystack = strings.ToLower(haystack)
-		for _, n := range needles {
+		for _, n := range include {
 			if !strings.Contains(haystack, n) {
 				return false
 			}
 		}
+		for _, n := range exclude {
+			if strings.Contains(haystack, n) {
+				return false
+			}
+		}
 		return true
 	}
 
 	out := make([]targetItem, 0, len(items))
 	for _, it := range items {
 		aliases := strings.Join(it.aliases, ", ")
-		if matchAll(strings.Join([]string{it.displayName, it.synopsis, aliases, it.groupName, it.groupMeta}, " ")) {
+		if matches(strings.Join([]string{it.displayName, it.synopsis, aliases, it.groupName, it.groupMeta}, " ")) {
 			out = append(out, it)
 		}
 	}
@@ -392,6 +584,7 @@ type targetSections struct {
 	local      []targetGroup
 	namespaces []targetGroup
 	imports    []targetGroup
+	external   []targetGroup
 }
 
 // compareTargetItems returns a comparison function for sorting targetItems by display name.
@@ -422,7 +615,7 @@ func buildGroups(byLabel map[string][]targetItem, metaByLabel map[string]string)
 }
 
 func groupTargets(items []targetItem) targetSections {
-	var locals []targetItem
+	var locals, externals []targetItem
 	nsByName := make(map[string][]targetItem)
 	impByLabel := make(map[string][]targetItem)
 	impMetaByLabel := make(map[string]string)
@@ -446,30 +639,113 @@ func groupTargets(items []targetItem) targetSections {
 			if it.groupMeta != "" {
 				impMetaByLabel[it.groupName] = it.groupMeta
 			}
+		case targetGroupExternal:
+			externals = append(externals, it)
 		}
 	}
 
 	slices.SortFunc(locals, compareTargetItems)
 
-	var localGroups []targetGroup
+	var localGroups, externalGroups []targetGroup
 	if len(locals) > 0 {
 		localGroups = append(localGroups, targetGroup{header: "", items: locals})
 	}
+	if len(externals) > 0 {
+		slices.SortFunc(externals, compareTargetItems)
+		externalGroups = append(externalGroups, targetGroup{header: "", items: externals})
+	}
 
 	return targetSections{
 		local:      localGroups,
 		namespaces: buildGroups(nsByName, nil),
 		imports:    buildGroups(impByLabel, impMetaByLabel),
+		external:   externalGroups,
+	}
+}
+
+// argsColumn is the ARGS column of `stave -l --args`, listing the name and type
+// of each argument a target takes. The zero value is a hidden column.
+type argsColumn struct {
+	show  bool
+	width int
+}
+
+func newArgsColumn(show bool, sections targetSections) argsColumn {
+	if !show {
+		return argsColumn{}
+	}
+	col := argsColumn{show: true, width: lipgloss.Width("ARGS")}
+	for _, groups := range [][]targetGroup{sections.local, sections.namespaces, sections.imports, sections.external} {
+		for _, g := range groups {
+			for _, it := range g.items {
+				col.width = max(col.width, lipgloss.Width(col.text(it.args)))
+			}
+		}
 	}
+	return col
+}
+
+// text returns the column's entry for a target's arguments, e.g.
+// "env string, replicas int", or "-" if it takes none.
+func (c argsColumn) text(args []parse.Arg) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		if strings.TrimSpace(a.Name) == "" {
+			continue
+		}
+		parts = append(parts, a.Name+" "+a.Type)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lastRunColumn is the LAST column of `stave -l`, showing how long each target
+// took the last time it succeeded. The zero value is a hidden column.
+type lastRunColumn struct {
+	durations map[string]time.Duration
+	width     int
+}
+
+func newLastRunColumn(durations map[string]time.Duration, sections targetSections) lastRunColumn {
+	if durations == nil {
+		return lastRunColumn{}
+	}
+	col := lastRunColumn{durations: durations, width: lipgloss.Width("LAST")}
+	for _, groups := range [][]targetGroup{sections.local, sections.namespaces, sections.imports, sections.external} {
+		for _, g := range groups {
+			for _, it := range g.items {
+				col.width = max(col.width, lipgloss.Width(col.text(it.targetName)))
+			}
+		}
+	}
+	return col
+}
+
+func (c lastRunColumn) shown() bool {
+	return c.durations != nil
+}
+
+// text returns the column's entry for a target, "-" if it has no recorded
+// duration.
+func (c lastRunColumn) text(targetName string) string {
+	d, ok := c.durations[targetName]
+	if !ok {
+		return "-"
+	}
+	return humanizeDuration(d)
 }
 
 func writeTable(
 	out io.Writer,
 	headerStyle, subsectionStyle lipgloss.Style,
 	group targetGroup,
-	renderName func(name string, isDefault, isWatch bool, args []parse.Arg) string,
+	renderName func(name string, isDefault bool, marks []string, args []parse.Arg) string,
 	indent string,
 	maxUsage int,
+	argCol argsColumn,
+	last lastRunColumn,
 ) {
 	if len(group.items) == 0 {
 		return
@@ -487,19 +763,29 @@ func writeTable(
 	type row struct {
 		name      string
 		args      []parse.Arg
+		argsText  string
+		last      string
 		synopsis  string
 		isDefault bool
-		isWatch   bool
+		marks     []string
 	}
 
 	rows := make([]row, 0, len(group.items)+1)
 	rows = append(rows, row{
 		name:     "USAGE",
+		argsText: "ARGS",
+		last:     "LAST",
 		synopsis: "SYNOPSIS",
 	})
 
 	for _, it := range group.items {
 		syn := strings.TrimSpace(it.synopsis)
+		if it.clashNote != "" {
+			syn = strings.TrimSpace(syn + " " + it.clashNote)
+		}
+		if len(it.watchGlobs) > 0 {
+			syn = strings.TrimSpace(syn + " [watches " + strings.Join(it.watchGlobs, ", ") + "]")
+		}
 		if syn == "" {
 			syn = "-"
 		}
@@ -510,9 +796,11 @@ func writeTable(
 		rows = append(rows, row{
 			name:      name,
 			args:      it.args,
+			argsText:  argCol.text(it.args),
+			last:      last.text(it.targetName),
 			synopsis:  syn,
 			isDefault: it.isDefault,
-			isWatch:   it.isWatch,
+			marks:     it.marks(),
 		})
 	}
 
@@ -527,18 +815,33 @@ func writeTable(
 		return text + strings.Repeat(" ", width-textWidth)
 	}
 
+	// columns returns the cells of a row, without the synopsis.
+	columns := func(usage, argsText, lastText string) []string {
+		cells := []string{pad(usage, maxUsage)}
+		if argCol.show {
+			cells = append(cells, pad(argsText, argCol.width))
+		}
+		if last.shown() {
+			cells = append(cells, pad(lastText, last.width))
+		}
+		return cells
+	}
+
 	// Print header.
 	h := rows[0]
-	headerLine := strings.Join([]string{
-		pad(h.name, maxUsage),
-		h.synopsis,
-	}, "  ")
+	headerLine := strings.Join(append(columns(h.name, h.argsText, h.last), h.synopsis), "  ")
 	_, _ = fmt.Fprintln(out, indent+headerStyle.Render(headerLine))
 
 	// Compute terminal width and synopsis column width for wrapping.
 	termWidth := detectTermWidth(out)
 	const gap = 2
 	leftOffset := lipgloss.Width(indent) + maxUsage + gap
+	if argCol.show {
+		leftOffset += argCol.width + gap
+	}
+	if last.shown() {
+		leftOffset += last.width + gap
+	}
 	synWidth := termWidth - leftOffset
 	if synWidth < termWidthFloor {
 		synWidth = termWidthFloor
@@ -548,16 +851,13 @@ func writeTable(
 
 	// Print rows with word-wrapped synopsis using a hanging indent.
 	for _, theRow := range rows[1:] {
-		usage := renderName(theRow.name, theRow.isDefault, theRow.isWatch, theRow.args)
+		usage := renderName(theRow.name, theRow.isDefault, theRow.marks, theRow.args)
 
 		wrappedSyn := wordwrap.String(theRow.synopsis, synWidth)
 		// Align continuation lines under the start of the synopsis column.
 		wrappedSyn = strings.ReplaceAll(wrappedSyn, "\n", "\n"+spaceLeft)
 
-		line := strings.Join([]string{
-			pad(usage, maxUsage),
-			wrappedSyn,
-		}, strings.Repeat(" ", gap))
+		line := strings.Join(append(columns(usage, theRow.argsText, theRow.last), wrappedSyn), strings.Repeat(" ", gap))
 		_, _ = fmt.Fprintln(out, indent+line)
 	}
 }
@@ -41,7 +41,7 @@ func TestRenderTargetList_ColumnsAlignAcrossGroups(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := renderTargetList(&buf, info, nil)
+	err := renderTargetList(&buf, info, nil, nil, listSections{}, nil, false, false)
 	require.NoError(t, err)
 
 	output := buf.String()
@@ -116,7 +116,7 @@ func TestRenderTargetList_Watch(t *testing.T) {
 	}
 
 	buf := &bytes.Buffer{}
-	err := renderTargetList(buf, info, nil)
+	err := renderTargetList(buf, info, nil, nil, listSections{}, nil, false, false)
 	require.NoError(t, err)
 
 	output := buf.String()
@@ -129,3 +129,328 @@ func TestRenderTargetList_Watch(t *testing.T) {
 	// Check if legend is present
 	assert.Contains(t, output, "[W] = watch target")
 }
+
+func TestRenderTargetList_Exclusive(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	info := &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{Name: "Migrate", Exclusive: true, Synopsis: "Migrate the database"},
+			{Name: "Build", Synopsis: "Build the app"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := renderTargetList(buf, info, nil, nil, listSections{}, nil, false, false)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "migrate [X]")
+	assert.NotContains(t, output, "build [X]")
+	assert.Contains(t, output, "[X] = exclusive target, never run alongside other targets")
+	assert.NotContains(t, output, "[W] = watch target")
+}
+
+func TestRenderTargetList_WatchGlobs(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("COLUMNS", "200")
+
+	info := &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{
+				Name:       "Serve",
+				IsWatch:    true,
+				WatchGlobs: []string{"*.go", "web/**/*.html", parse.DynamicWatchGlob},
+				Synopsis:   "Serve the site",
+			},
+			{Name: "Test", IsWatch: true, Synopsis: "Test on changes"},
+			{Name: "Build", Synopsis: "Build the app"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := renderTargetList(buf, info, nil, nil, listSections{}, nil, false, false)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "Serve the site [watches *.go, web/**/*.html, <dynamic>]")
+	assert.Equal(t, 1, strings.Count(output, "[watches"), output)
+}
+
+func TestRenderTargetList_Args(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	info := &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{
+				Name:     "Deploy",
+				Synopsis: "Deploy the app",
+				Args:     []parse.Arg{{Name: "env", Type: "string"}, {Name: "replicas", Type: "int"}},
+			},
+			{Name: "Build", Synopsis: "Build the app"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderTargetList(&buf, info, nil, nil, listSections{}, nil, true, false))
+	output := buf.String()
+
+	lines := strings.Split(output, "\n")
+	var header, deploy, build string
+	for _, line := range lines {
+		switch trimmed := strings.TrimSpace(line); {
+		case strings.HasPrefix(trimmed, "USAGE"):
+			header = line
+		case strings.HasPrefix(trimmed, "deploy"):
+			deploy = line
+		case strings.HasPrefix(trimmed, "build"):
+			build = line
+		}
+	}
+	require.NotEmpty(t, header, output)
+	argsCol := strings.Index(header, "ARGS")
+	require.Positive(t, argsCol, output)
+
+	assert.Contains(t, deploy, "env string, replicas int")
+	assert.Equal(t, argsCol, strings.Index(deploy, "env string"), "ARGS column misaligned: %q", deploy)
+	assert.Equal(t, "-", strings.Fields(build[argsCol:])[0], "target without args: %q", build)
+
+	// Without --args there is no ARGS column.
+	buf.Reset()
+	require.NoError(t, renderTargetList(&buf, info, nil, nil, listSections{}, nil, false, false))
+	assert.NotContains(t, buf.String(), "ARGS")
+	assert.NotContains(t, buf.String(), "env string")
+}
+
+func TestRenderTargetList_Hidden(t *testing.T) {
+	info := &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{Name: "Build", Synopsis: "Build the app"},
+			{Name: "Plumbing", Synopsis: "Internal plumbing", Hidden: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderTargetList(&buf, info, nil, nil, listSections{}, nil, false, false))
+	assert.Contains(t, buf.String(), "build")
+	assert.NotContains(t, buf.String(), "plumbing")
+
+	// --all lists hidden targets too.
+	buf.Reset()
+	require.NoError(t, renderTargetList(&buf, info, nil, nil, listSections{}, nil, false, true))
+	assert.Contains(t, buf.String(), "build")
+	assert.Contains(t, buf.String(), "plumbing")
+	assert.Contains(t, buf.String(), "Internal plumbing")
+}
+
+func TestRenderTargetList_BuiltinClash(t *testing.T) {
+	setup := &parse.Function{Name: "Setup", Synopsis: "Prepare the workspace"}
+	info := &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{Name: "Clean", Synopsis: "Remove the build output"},
+			{Name: "Completion"},
+			setup,
+			{Name: "Build", Synopsis: "Build the app"},
+		},
+		Aliases: map[string]*parse.Function{"init": setup},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderTargetList(&buf, info, nil, nil, listSections{}, nil, false, false))
+	output := buf.String()
+	assert.Contains(t, output, "Remove the build output [not --clean]")
+	assert.Contains(t, output, "[hidden by stave completion]")
+	assert.Contains(t, output, "Prepare the workspace [not --init]")
+	assert.NotContains(t, output, "Build the app [")
+}
+
+func sectionsTestInfo() *parse.PkgInfo {
+	return &parse.PkgInfo{
+		PkgName: "main",
+		Funcs: []*parse.Function{
+			{Name: "Build", Synopsis: "Compile the project"},
+			{Name: "Test", Synopsis: "Run the tests"},
+			{Name: "Docker", Receiver: "Image", Synopsis: "Build the image"},
+		},
+		Imports: []*parse.Import{
+			{
+				Name: "ext",
+				Path: "example.com/ext",
+				Info: parse.PkgInfo{
+					PkgName: "ext",
+					Funcs: []*parse.Function{
+						{Name: "Run", Synopsis: "Run the external tool"},
+					},
+				},
+			},
+			{
+				Name:  "deploytasks",
+				Alias: "deploy",
+				Path:  "example.com/deploytasks",
+				Info: parse.PkgInfo{
+					PkgName: "deploytasks",
+					Funcs: []*parse.Function{
+						{Name: "Prod", Synopsis: "Deploy to production"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRenderTargetList_Sections(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	tests := []struct {
+		name     string
+		filters  []string
+		sections listSections
+		want     []string
+		notWant  []string
+		footer   string
+	}{
+		{
+			name:    "everything by default",
+			want:    []string{"Local", "Namespaces", "Imports", "build", "image:docker", "run", "prod"},
+			notWant: []string{"showing"},
+		},
+		{
+			name:     "local only",
+			sections: listSections{local: true},
+			want:     []string{"Local", "build", "test"},
+			notWant:  []string{"Namespaces", "Imports", "image:docker"},
+			footer:   "showing 2 of 5 targets",
+		},
+		{
+			name:     "namespaces and imports",
+			sections: listSections{namespaces: true, imports: true},
+			want:     []string{"Namespaces", "Imports", "image:docker", "run", "prod"},
+			notWant:  []string{"Local", "Compile the project"},
+			footer:   "showing 3 of 5 targets",
+		},
+		{
+			name:     "single import by alias",
+			sections: listSections{importName: "deploy"},
+			want:     []string{"Imports", "deploy (example.com/deploytasks)", "prod"},
+			notWant:  []string{"Local", "Namespaces", "ext (example.com/ext)"},
+			footer:   "showing 1 of 5 targets",
+		},
+		{
+			name:     "single import by path",
+			sections: listSections{importName: "example.com/ext"},
+			want:     []string{"ext (example.com/ext)"},
+			notWant:  []string{"deploytasks"},
+			footer:   "showing 1 of 5 targets",
+		},
+		{
+			name:     "sections compose with text filters",
+			filters:  []string{"compile"},
+			sections: listSections{local: true, imports: true},
+			want:     []string{"build"},
+			notWant:  []string{"Imports", "Run the tests"},
+			footer:   "showing 1 of 5 targets",
+		},
+		{
+			name:    "exclusion filters",
+			filters: []string{"!run"},
+			want:    []string{"build", "image:docker", "prod"},
+			notWant: []string{"Run the tests", "Run the external tool"},
+			footer:  "showing 3 of 5 targets",
+		},
+		{
+			name:    "text filters alone",
+			filters: []string{"run"},
+			want:    []string{"Run the tests", "Run the external tool"},
+			notWant: []string{"Compile the project"},
+			footer:  "showing 2 of 5 targets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, renderTargetList(&buf, sectionsTestInfo(), nil, tt.filters, tt.sections, nil, false, false))
+
+			output := buf.String()
+			for _, s := range tt.want {
+				assert.Contains(t, output, s)
+			}
+			for _, s := range tt.notWant {
+				assert.NotContains(t, output, s)
+			}
+			if tt.footer != "" {
+				assert.True(t, strings.HasSuffix(output, tt.footer+"\n"), "expected footer %q, got:\n%s", tt.footer, output)
+			}
+		})
+	}
+}
+
+func TestRenderTargetList_UnknownImport(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	var buf bytes.Buffer
+	err := renderTargetList(&buf, sectionsTestInfo(), nil, nil, listSections{importName: "nope"}, nil, false, false)
+	require.EqualError(t, err, `no imported package named "nope"`)
+}
+
+func TestApplyTargetFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{
+			name: "no filters",
+			want: []string{"build", "test", "image:docker", "run", "prod"},
+		},
+		{
+			name:    "inclusion only",
+			filters: []string{"build"},
+			want:    []string{"build", "image:docker"},
+		},
+		{
+			name:    "exclusion only",
+			filters: []string{"!run"},
+			want:    []string{"build", "image:docker", "prod"},
+		},
+		{
+			name:    "several exclusions",
+			filters: []string{"!RUN", "!deploy"},
+			want:    []string{"build", "image:docker"},
+		},
+		{
+			name:    "inclusion and exclusion",
+			filters: []string{"build", "!image"},
+			want:    []string{"build"},
+		},
+		{
+			name:    "exclusion matches imports",
+			filters: []string{"!example.com"},
+			want:    []string{"build", "test", "image:docker"},
+		},
+		{
+			name:    "bare negation is ignored",
+			filters: []string{"!", " "},
+			want:    []string{"build", "test", "image:docker", "run", "prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			items := applyTargetFilters(buildTargetItems(sectionsTestInfo(), false), tt.filters)
+			got := make([]string, 0, len(items))
+			for _, it := range items {
+				got = append(got, it.displayName)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
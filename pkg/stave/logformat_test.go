@@ -0,0 +1,117 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// runLogFormat runs the build and fail targets of testdata/logformat verbosely
+// with STAVEFILE_LOG_FORMAT set to format, and returns the lines the compiled
+// stavefile wrote to stderr. The tests that call it can't be parallel, as they
+// set the environment the compiled stavefile inherits.
+func runLogFormat(t *testing.T, format string) []string {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "logformat")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	t.Setenv(st.LogFormatEnv, format)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:         t.Context(),
+		Dir:             dataDirForThisTest,
+		Stdout:          stdout,
+		Stderr:          stderr,
+		WriterForLogger: &bytes.Buffer{},
+		Verbose:         true,
+		Args:            []string{"build", "fail"},
+	})
+	require.Error(t, err)
+	// what targets print is only prefixed, as -v does when several run.
+	assert.Equal(t, "[build] building\n", stdout.String())
+	return strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+}
+
+// checkLogRecords checks the records the run of runLogFormat logs.
+func checkLogRecords(t *testing.T, records []map[string]string) {
+	t.Helper()
+	require.Len(t, records, 5)
+	want := []struct{ level, target, msg string }{
+		{"info", "Build", "Running target"},
+		{"info", "Build", "Finished target"},
+		{"info", "Fail", "Running target"},
+		{"info", "Fail", "Finished target"},
+		{"error", "Fail", `broken: "x" = 1`},
+	}
+	for i, record := range records {
+		assert.Equal(t, want[i].level, record["level"], "record %d", i)
+		assert.Equal(t, want[i].target, record["target"], "record %d", i)
+		assert.Equal(t, want[i].msg, record["msg"], "record %d", i)
+		assert.NotEmpty(t, record["ts"], "record %d", i)
+		_, hasDuration := record["duration"]
+		assert.Equal(t, want[i].msg == "Finished target", hasDuration, "record %d", i)
+	}
+}
+
+func TestLogFormatJSON(t *testing.T) {
+	var records []map[string]string
+	for _, line := range runLogFormat(t, "json") {
+		var record map[string]string
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		records = append(records, record)
+	}
+	checkLogRecords(t, records)
+}
+
+func TestLogFormatLogfmt(t *testing.T) {
+	var records []map[string]string
+	for _, line := range runLogFormat(t, "logfmt") {
+		records = append(records, parseLogfmt(t, line))
+	}
+	checkLogRecords(t, records)
+}
+
+func TestLogFormatText(t *testing.T) {
+	lines := runLogFormat(t, "")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Running target: <Build>", lines[0])
+	assert.Regexp(t, `^Finished target: <Build> in \S+$`, lines[1])
+	assert.Equal(t, "Running target: <Fail>", lines[2])
+	assert.Regexp(t, `^Finished target: <Fail> in \S+$`, lines[3])
+	assert.Equal(t, `Error: broken: "x" = 1`, lines[4])
+}
+
+// parseLogfmt returns the fields of a logfmt line, whose values are quoted as
+// Go strings if they need to be.
+func parseLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+	record := make(map[string]string)
+	for line != "" {
+		key, rest, ok := strings.Cut(line, "=")
+		require.True(t, ok, "no = in %q", line)
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			require.NoError(t, err, rest)
+			value, err = strconv.Unquote(quoted)
+			require.NoError(t, err, quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+			rest = " " + rest
+		}
+		record[key] = value
+		line = strings.TrimPrefix(rest, " ")
+	}
+	return record
+}
@@ -0,0 +1,53 @@
+package stave
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	cblog "github.com/charmbracelet/log"
+	"github.com/yaklabco/stave/pkg/stave/prettylog"
+)
+
+// Log formats for RunParams.LogFormat.
+const (
+	LogFormatPretty = "pretty"
+	LogFormatJSON   = "json"
+)
+
+// setupLogger installs the default slog logger for the stave CLI: the
+// human-friendly one, or a JSON one for CI log processors. With debug it logs
+// debug messages too; otherwise, with quiet, it only logs errors.
+func setupLogger(writer io.Writer, format string, debug, quiet bool) error {
+	switch strings.ToLower(format) {
+	case "", LogFormatPretty:
+		logHandler := prettylog.SetupPrettyLogger(writer)
+		switch {
+		case debug:
+			logHandler.SetLevel(cblog.DebugLevel)
+		case quiet:
+			logHandler.SetLevel(cblog.ErrorLevel)
+		}
+		return nil
+
+	case LogFormatJSON:
+		level := slog.LevelInfo
+		switch {
+		case debug:
+			level = slog.LevelDebug
+		case quiet:
+			level = slog.LevelError
+		}
+		slog.SetDefault(slog.New(slog.NewJSONHandler(writer, &slog.HandlerOptions{
+			AddSource: true,
+			Level:     level,
+		})))
+		return nil
+
+	default:
+		// Still install a logger, so the error can be reported.
+		_ = setupLogger(writer, LogFormatPretty, debug, quiet)
+		return fmt.Errorf("unknown log format %q: must be %q or %q", format, LogFormatPretty, LogFormatJSON)
+	}
+}
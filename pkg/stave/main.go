@@ -1,6 +1,7 @@
 package stave
 
 import (
+	"bytes"
 	"cmp"
 	"context"
 	"crypto/sha256"
@@ -8,29 +9,35 @@ import (
 	"errors"
 	"fmt"
 	"go/build"
+	"go/format"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
-	cblog "github.com/charmbracelet/log"
 	"github.com/samber/lo"
 	"github.com/yaklabco/stave/cmd/stave/version"
+	"github.com/yaklabco/stave/config"
 	"github.com/yaklabco/stave/internal"
 	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/hooks"
 	"github.com/yaklabco/stave/internal/log"
 	"github.com/yaklabco/stave/internal/parallelism"
 	"github.com/yaklabco/stave/internal/parse"
 	"github.com/yaklabco/stave/pkg/env"
 	"github.com/yaklabco/stave/pkg/sh"
 	"github.com/yaklabco/stave/pkg/st"
-	"github.com/yaklabco/stave/pkg/stave/prettylog"
 )
 
 const (
@@ -55,33 +62,77 @@ type RunParams struct {
 
 	WriterForLogger io.Writer // writer for logger to write to
 
-	Clean      bool   // clean out old generated binaries from cache dir
-	CompileOut string // tells stave to compile a static binary to this path, but not execute
-	Config     bool   // triggers config management mode
-	DirEnv     bool   // triggers direnv delegation mode
-	Exec       bool   // tells the stavefile to treat the rest of the command-line as a command to execute
-	Hooks      bool   // triggers hooks management mode
-	Init       bool   // create an initial stavefile from template
-	List       bool   // tells the stavefile to print out a list of targets
-
-	Debug           bool          // turn on debug messages
-	Dir             string        // directory to read stavefiles from
-	WorkDir         string        // directory where stavefiles will run
-	Force           bool          // forces recreation of the compiled binary
-	Verbose         bool          // tells the stavefile to print out log statements
-	Info            bool          // tells the stavefile to print out docstring for a specific target
-	Keep            bool          // tells stave to keep the generated main file after compiling
-	DryRun          bool          // tells stave that all sh.Run* commands should print, but not execute
-	Timeout         time.Duration // tells stave to set a timeout to running the targets
-	GOOS            string        // sets the GOOS when producing a binary with -compileout
-	GOARCH          string        // sets the GOARCH when producing a binary with -compileout
-	Ldflags         string        // sets the ldflags when producing a binary with -compileout
-	Args            []string      // args to pass to the compiled binary
-	GoCmd           string        // the go binary command to run
-	CacheDir        string        // the directory where we should store compiled binaries
-	HashFast        bool          // don't rely on GOCACHE, just hash the stavefiles
-	Multiline       bool          // whether to retain line returns in help text for the generated main file
-	HooksAreRunning bool          // indicates whether hooks are currently being executed
+	Clean          bool   // clean out old generated binaries from cache dir
+	CompileOut     string // tells stave to compile a static binary to this path, but not execute
+	Config         bool   // triggers config management mode
+	DirEnv         bool   // triggers direnv delegation mode
+	DumpTargets    bool   // prints everything the parser resolved about the stavefiles, without compiling
+	EnsureCompiled bool   // compiles the stavefiles if needed and prints the binary's path, without running it
+	Exec           bool   // tells the stavefile to treat the rest of the command-line as a command to execute
+	FixTags        bool   // rewrites the stave build constraints of the stavefiles as //go:build and // +build pairs
+	Hooks          bool   // triggers hooks management mode
+	Init           bool   // create an initial stavefile from template
+	List           bool   // tells the stavefile to print out a list of targets
+
+	Debug            bool          // turn on debug messages
+	Dir              string        // directory to read stavefiles from
+	WorkDir          string        // directory where stavefiles will run
+	Force            bool          // forces recreation of the compiled binary
+	Verbose          bool          // tells the stavefile to print out log statements
+	Quiet            bool          // only log stave's own errors, not its info messages and warnings; wins over Verbose
+	Info             bool          // tells the stavefile to print out docstring for a specific target
+	Keep             bool          // tells stave to keep the generated main file after compiling
+	DryRun           bool          // tells stave that all sh.Run* commands should print, but not execute
+	DryRunDeps       bool          // like DryRun, and st.Deps prints the targets it would run instead of running them
+	Timeout          time.Duration // tells stave to set a timeout to running the targets
+	PerTargetTimeout time.Duration // tells stave to give each target its own timeout, within Timeout
+	CleanupGrace     time.Duration // how long cancelled targets get to clean up (default 5s)
+	NoContainer      bool          // runs targets marked stave:container here instead of in their container
+	ContainerPull    string        // when to pull the images of targets marked stave:container: "missing", "always" or "never"
+	ErrorFormat      string        // how the stavefile reports the error a run fails with: "text" (default) or "json"
+	Yes              bool          // answer yes to the questions of targets marked stave:confirm
+	GOOS             string        // sets the GOOS when producing a binary with -compileout
+	GOARCH           string        // sets the GOARCH when producing a binary with -compileout
+	Ldflags          string        // sets the ldflags when producing a binary, and tells targets them through st.Ldflags
+	Gcflags          string        // sets the gcflags when producing a binary
+	Asmflags         string        // sets the asmflags when producing a binary
+	BuildMode        string        // sets the -buildmode of the binary produced with CompileOut
+	BuildTags        []string      // build tags the binary produced with CompileOut is built with, besides stave
+	Args             []string      // args to pass to the compiled binary
+	GoCmd            string        // the go binary command to run
+	CacheDir         string        // the directory where we should store compiled binaries
+	HashFast         bool          // don't rely on GOCACHE, just hash the stavefiles
+	Multiline        bool          // whether to retain line returns in help text for the generated main file
+	HooksAreRunning  bool          // indicates whether hooks are currently being executed
+	StrictSignatures bool          // fail, rather than warn, on exported functions with invalid target signatures
+	Strict           bool          // fail, rather than warn, on all stavefile lint findings, like targets that call os.Exit
+	MainfileName     string        // fixed file name for the generated mainfile, instead of a per-run one (overrides mainfile_name in stave.yaml)
+	Vet              bool          // run go vet on the stavefiles before compiling them, and fail on its findings (like verify: vet in stave.yaml)
+	VerifyTargets    []string      // with CompileOut, targets the compiled binary must have
+	OutputsKeep      int           // how many sets of st.Output files to keep per target (default 5)
+	ListLocal        bool          // with List, shows the local targets section
+	ListNamespaces   bool          // with List, shows the namespaces section
+	ListImports      bool          // with List, shows the imports section
+	ListImport       string        // with List, shows only the targets of the import with this alias, name or path
+	NoTimings        bool          // with List, hides the column showing how long each target last took
+	ListArgs         bool          // with List, shows a column with the name and type of each target argument
+	ListAll          bool          // with List, also shows targets marked stave:hidden
+	InitDirLayout    bool          // with Init, creates the stavefile in a new stavefiles directory
+	ConfigOrigin     bool          // with Config, shows where each config value comes from
+	HooksSubmodules  bool          // with Hooks install, also installs the hooks in each initialized submodule
+	HookArgs         []string      // with HooksAreRunning, the arguments git passed to the hook
+	IgnoreFailures   []int         // indexes in Args of targets whose failure doesn't stop the run (from "-" lines in @task files)
+	PrintExpanded    bool          // print Args after expanding @task files, instead of running them
+	TimeTargets      int           // runs the targets this many times, each in a new process, and reports how long they took
+	TimeTargetsJSON  bool          // with TimeTargets, reports the timings as JSON
+	EnvFiles         []string      // dotenv files to load into the stavefile's environment, after those in stave.yaml
+	WatchDirs        []string      // directories, relative to WorkDir, that watch mode is limited to (default: WorkDir)
+	LogFormat        string        // format of stave's own log output: "pretty" (default) or "json"
+	Parallelism      int           // parallelism for the stavefile and its children, overriding STAVE_NUM_PROCESSORS (0 means auto)
+	AutoMod          bool          // run go mod init/tidy for stavefiles that aren't in a module and can't compile without one
+	ConfigFile       string        // project config file to load instead of searching for stave.yaml
+
+	GoEnv map[string]string // extra environment for go build and go list, like CC for cross-compiling with cgo
 }
 
 // UsesStavefiles returns true if we are getting our stave files from a stavefiles directory.
@@ -89,33 +140,113 @@ func (i RunParams) UsesStavefiles() bool {
 	return filepath.Base(i.Dir) == StavefilesDirName
 }
 
+// configDir returns the directory to load the project's stave.yaml from. That
+// is the parent of the stavefiles directory, when there is one.
+func configDir(params RunParams) string {
+	if params.UsesStavefiles() {
+		return filepath.Dir(params.Dir)
+	}
+	return params.Dir
+}
+
+// configLoadOptions returns the options for loading the project's config: the
+// --config-file if given, or else stave.yaml in configDir.
+func configLoadOptions(params RunParams) *config.LoadOptions {
+	return &config.LoadOptions{ProjectDir: configDir(params), ConfigPath: params.ConfigFile}
+}
+
+// configFlags returns the config settings given by command line flags, keyed
+// like stave.yaml, so that `stave --config show` reports them. Only flags that
+// differ from their environment default are included.
+func configFlags(params RunParams) map[string]any {
+	flags := make(map[string]any)
+	if params.Verbose && !st.Verbose() {
+		flags["verbose"] = true
+	}
+	if params.Debug && !st.Debug() {
+		flags["debug"] = true
+	}
+	if params.GoCmd != "" && params.GoCmd != st.GoCmd() {
+		flags["go_cmd"] = params.GoCmd
+	}
+	if params.AutoMod {
+		flags["auto_mod"] = true
+	}
+	if params.MainfileName != "" {
+		flags["mainfile_name"] = params.MainfileName
+	}
+	if params.Vet {
+		flags["verify"] = config.VerifyVet
+	}
+	if params.Ldflags != "" {
+		flags["ldflags"] = config.GoFlags(params.Ldflags)
+	}
+	if params.Gcflags != "" {
+		flags["gcflags"] = config.GoFlags(params.Gcflags)
+	}
+	if params.Asmflags != "" {
+		flags["asmflags"] = config.GoFlags(params.Asmflags)
+	}
+	return flags
+}
+
+// loadConfig loads the project's config for a run. It is loaded once, and
+// passed to everything that needs it, so that they all see the same config.
+func loadConfig(params RunParams) (*config.Config, error) {
+	opts := configLoadOptions(params)
+	opts.Stderr = params.Stderr
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, nil
+}
+
 // Run is the entrypoint for running stave.  It exists external to stave's main
 // function to allow it to be used from other programs, specifically so you can
 // go run a simple file that run's stave's Run.
+//
+// Any error it returns wraps an *Error, whose Kind says what failed and whose
+// Code is the status stave exits with.
 func Run(params RunParams) error {
+	return newError(KindInternal, run(params))
+}
+
+func run(params RunParams) error {
 	if params.WriterForLogger == nil {
 		params.WriterForLogger = params.Stderr
 	}
-	logHandler := prettylog.SetupPrettyLogger(params.WriterForLogger)
-
-	if params.Debug {
-		logHandler.SetLevel(cblog.DebugLevel)
+	if err := setupLogger(params.WriterForLogger, params.LogFormat, params.Debug, params.Quiet); err != nil {
+		return err
 	}
 	slog.Debug("logger initialized")
 
 	preprocessRunParams(&params)
+	if err := expandTaskFiles(&params); err != nil {
+		return newError(KindUsage, err)
+	}
+	if params.PrintExpanded {
+		return printExpanded(params.Stdout, params)
+	}
 
 	ctx := params.BaseCtx
 	err := applyBasicRunParams(params)
 	if err != nil {
-		return err
+		return newError(KindUsage, err)
 	}
 
 	if howManyThingsToDo(params) > 1 {
-		return errors.New("only one of --init, --clean, --list, --hooks, --config, or explicit targets may be specified")
+		return newError(KindUsage, errors.New("only one of --init, --clean, --list, --dump-targets, --ensure-compiled, --hooks, --config, or explicit targets may be specified"))
 	}
 
 	if params.Clean {
+		if params.DryRun {
+			entries, err := listCache(params.CacheDir)
+			if err != nil {
+				return fmt.Errorf("listing cache dir: %w", err)
+			}
+			return renderCacheDryRun(params.Stdout, params.CacheDir, entries)
+		}
 		if err := removeContents(params.CacheDir); err != nil {
 			return err
 		}
@@ -141,33 +272,44 @@ func Run(params RunParams) error {
 	}
 
 	if params.Init {
-		if err := generateInit(params.Dir); err != nil {
-			return err
-		}
-		slog.Info("created initial stavefile", slog.String(log.Filename, initFile))
-
-		return nil
+		return runInitMode(ctx, params)
 	}
 
 	if params.List {
 		return runListMode(ctx, params)
 	}
 
+	if params.DumpTargets {
+		return runDumpTargetsMode(ctx, params)
+	}
+
+	if params.FixTags {
+		return runFixTagsMode(params)
+	}
+
 	if params.Info {
 		return runInfoMode(ctx, params)
 	}
 
+	if params.EnsureCompiled {
+		return runEnsureCompiledMode(ctx, params)
+	}
+
 	return stave(ctx, params)
 }
 
 func execInStave(ctx context.Context, params RunParams) error {
 	if len(params.Args) < 1 {
-		return errors.New("--exec requires a command (and optionally, arguments) to run")
+		return newError(KindUsage, errors.New("--exec requires a command (and optionally, arguments) to run"))
 	}
 
 	dryrun.SetPossible(true)
 
-	theEnv, err := setupEnv(params)
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
+	}
+	theEnv, err := setupEnv(params, cfg)
 	if err != nil {
 		return fmt.Errorf("setting up environment for stavefile: %w", err)
 	}
@@ -196,7 +338,9 @@ func runHooksMode(ctx context.Context, params RunParams) error {
 }
 
 func runConfigMode(ctx context.Context, params RunParams) error {
-	exitCode := RunConfigCommandContext(ctx, params.Stdout, params.Stderr, params.Args)
+	opts := configLoadOptions(params)
+	opts.Flags = configFlags(params)
+	exitCode := runConfigCommand(ctx, params.Stdout, params.Stderr, params.Args, opts, params.ConfigOrigin)
 	if exitCode != 0 {
 		return st.Fatal(exitCode, "config command failed")
 	}
@@ -204,21 +348,119 @@ func runConfigMode(ctx context.Context, params RunParams) error {
 }
 
 func stave(ctx context.Context, params RunParams) error {
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
+	}
+	if len(params.Args) == 0 && cfg.OnNoTarget == config.OnNoTargetList {
+		return runListMode(ctx, params)
+	}
+	// external targets take all the arguments after them, and need no
+	// stavefiles compiled.
+	if len(params.Args) > 0 && params.CompileOut == "" {
+		if _, ok := cfg.ExternalTarget(params.Args[0]); ok {
+			return runExternalTarget(ctx, params, cfg, params.Args[0], params.Args[1:])
+		}
+	}
+
+	exePath, rebuilt, remote, err := ensureCompiled(ctx, params, cfg)
+	if err != nil {
+		return err
+	}
+	if rebuilt && params.CompileOut != "" {
+		return verifyTargets(ctx, params, exePath)
+	}
+
+	run := runCompiled
+	if params.TimeTargets > 0 {
+		run = timeTargets
+	}
+	if remote == nil {
+		return run(ctx, params, cfg, exePath)
+	}
+	uploaded := remote.upload(ctx, exePath)
+	err = run(ctx, params, cfg, exePath)
+	<-uploaded
+	return err
+}
+
+// EnsureCompiled does everything running a target does short of running it:
+// it finds, parses and compiles the stavefiles in params.Dir, unless an up to
+// date binary is already cached, and returns the binary's path. rebuilt
+// reports whether the binary was compiled by this call. With GOCACHE in use
+// (HashFast unset), the binary is always rebuilt, so that changes to
+// dependencies are picked up.
+func EnsureCompiled(ctx context.Context, params RunParams) (exePath string, rebuilt bool, err error) {
+	preprocessRunParams(&params)
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return "", false, err
+	}
+	exePath, rebuilt, remote, err := ensureCompiled(ctx, params, cfg)
+	if err != nil {
+		return "", false, err
+	}
+	if remote != nil {
+		<-remote.upload(ctx, exePath)
+	}
+	return exePath, rebuilt, nil
+}
+
+// runEnsureCompiledMode handles --ensure-compiled by printing the path of the
+// compiled stavefile binary.
+func runEnsureCompiledMode(ctx context.Context, params RunParams) error {
+	exePath, _, err := EnsureCompiled(ctx, params)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(params.Stdout, exePath)
+	return err
+}
+
+// ensureCompiled is EnsureCompiled with the config already loaded. remote is
+// set if the binary was compiled after missing the remote binary cache, so that
+// the caller can upload it.
+func ensureCompiled(
+	ctx context.Context,
+	params RunParams,
+	cfg *config.Config,
+) (exePath string, rebuilt bool, remote *remoteCache, err error) {
+	params = withConfigGoFlags(params, cfg)
+	// STAVE_NO_CACHE is checked here, rather than with the other defaults in
+	// preprocessRunParams, as Force also overwrites hooks with --hooks install.
+	if !params.Force && st.NoCache() {
+		slog.Debug("user has set STAVE_NO_CACHE, so we'll recompile")
+		params.Force = true
+	}
 	files, err := Stavefiles(params.Dir, params.GOOS, params.GOARCH, params.UsesStavefiles())
 	if err != nil {
-		return fmt.Errorf("determining list of stavefiles: %w", err)
+		return "", false, nil, newError(KindParse, fmt.Errorf("determining list of stavefiles: %w", err))
 	}
 
 	if len(files) == 0 {
-		return errors.New("no .go files marked with the stave build tag in this directory")
+		return "", false, nil, newError(KindParse, errors.New("no .go files marked with the stave build tag in this directory"))
 	}
 	slog.Debug("found stavefiles", slog.Any("files", files))
-	exePath := params.CompileOut
+
+	// packages pulled in by relative stave:import directives aren't covered by
+	// the go build cache check below, so they are hashed along with the stavefiles.
+	relFiles, err := parse.RelativeImportFiles(params.Dir, files)
+	if err != nil {
+		return "", false, nil, newError(KindParse, fmt.Errorf("determining relatively imported files: %w", err))
+	}
+	hashFiles := append(slices.Clone(files), relFiles...)
+	// settings like import_aliases change the generated mainfile, so they are
+	// hashed too, but not the rest of the config.
+	configHash := cfg.BuildHash()
+	flagsHash := compileFlagsHash(params)
+
+	exePath = params.CompileOut
 	if params.CompileOut == "" {
-		exePath, err = ExeName(ctx, params.GoCmd, params.CacheDir, files)
+		exePath, err = exeName(ctx, params.GoCmd, params.CacheDir, hashFiles, configHash, flagsHash)
 		if err != nil {
-			return fmt.Errorf("getting exe name: %w", err)
+			return "", false, nil, fmt.Errorf("getting exe name: %w", err)
 		}
+		exePath = crossExeName(exePath, params)
 	}
 	slog.Debug("executable path determined", slog.String("exePath", exePath))
 
@@ -228,7 +470,7 @@ func stave(ctx context.Context, params RunParams) error {
 	} else {
 		theGoCache, err := internal.OutputDebug(ctx, params.GoCmd, "env", "GOCACHE")
 		if err != nil {
-			return fmt.Errorf("failed to run %s env GOCACHE: %w", params.GoCmd, err)
+			return "", false, nil, fmt.Errorf("failed to run %s env GOCACHE: %w", params.GoCmd, err)
 		}
 
 		// if GOCACHE exists, always rebuild, so we catch transitive
@@ -239,26 +481,38 @@ func stave(ctx context.Context, params RunParams) error {
 		}
 	}
 
+	var statErr error
 	if !useCache {
-		_, err = os.Stat(exePath)
+		_, statErr = os.Stat(exePath)
 		switch {
-		case err == nil:
+		case statErr == nil:
 			if !params.Force {
-				slog.Debug("Running existing executable")
-				return RunCompiled(ctx, params, exePath)
+				slog.Debug("using existing executable")
+				return exePath, false, nil, nil
 			}
 			slog.Debug("ignoring existing executable")
-		case os.IsNotExist(err):
+		case os.IsNotExist(statErr):
+			if params.CompileOut == "" && !params.Force {
+				if remote = newRemoteCache(cfg); remote != nil && remote.fetch(ctx, exePath) {
+					writeCacheMeta(exePath, params.Dir, buildManifest(params.Dir, hashFiles, configHash))
+					return exePath, false, nil, nil
+				}
+			}
 			slog.Debug("no existing executable, creating new")
 		default:
 			slog.Debug(
 				"error reading existing executable",
 				slog.String(log.Path, exePath),
-				slog.Any(log.Error, err),
+				slog.Any(log.Error, statErr),
 			)
 			slog.Debug("creating new executable")
 		}
 	}
+	logRebuildReason(ctx, params, rebuildReason(params, useCache, statErr, exePath, hashFiles, configHash))
+
+	if err := checkDiskSpace(params, cfg); err != nil {
+		return "", false, nil, err
+	}
 
 	// parse wants dir + filenames... arg
 	fnames := make([]string, 0, len(files))
@@ -267,9 +521,12 @@ func stave(ctx context.Context, params RunParams) error {
 	}
 
 	slog.Debug("parsing stavefiles")
-	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline)
+	info, err := parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline, cfg.ImportAliases, params.GoEnv)
 	if err != nil {
-		return fmt.Errorf("parsing stavefiles: %w", err)
+		return "", false, nil, newError(KindParse, fmt.Errorf("parsing stavefiles: %w", err))
+	}
+	if err := lintStavefiles(info, params, cfg); err != nil {
+		return "", false, nil, newError(KindParse, err)
 	}
 
 	// reproducible output for deterministic builds
@@ -277,17 +534,22 @@ func stave(ctx context.Context, params RunParams) error {
 	sort.Sort(info.Imports)
 
 	// Use the content-based exe hash (not CompileOut) to derive the mainfile name.
-	hashPath, hashErr := ExeName(ctx, params.GoCmd, params.CacheDir, files)
+	hashPath, hashErr := exeName(ctx, params.GoCmd, params.CacheDir, hashFiles, configHash, flagsHash)
 	if hashErr != nil {
-		return fmt.Errorf("getting exe hash for mainfile: %w", hashErr)
+		return "", false, nil, fmt.Errorf("getting exe hash for mainfile: %w", hashErr)
 	}
 	main := mainFilePathFromExePath(params.Dir, hashPath)
+	if mainfileName := cmp.Or(params.MainfileName, cfg.MainfileName); mainfileName != "" {
+		if main, err = stableMainFilePath(params.Dir, mainfileName); err != nil {
+			return "", false, nil, err
+		}
+	}
 	binaryName := generateBinaryName(params)
 
 	createdByMe := false
 	if _, statErr := os.Stat(main); errors.Is(statErr, os.ErrNotExist) {
 		if genErr := GenerateMainFile(binaryName, main, info); genErr != nil {
-			return genErr
+			return "", false, nil, genErr
 		}
 		createdByMe = true
 	}
@@ -295,20 +557,36 @@ func stave(ctx context.Context, params RunParams) error {
 		defer func() { _ = os.RemoveAll(main) }()
 	}
 
+	modFile, cleanupModFile, err := replaceModFile(params.Dir, info.Imports)
+	if err != nil {
+		return "", false, nil, fmt.Errorf("wiring in relative imports: %w", err)
+	}
+	defer cleanupModFile()
+
 	files = append(files, main)
-	if err := Compile(ctx, CompileParams{
+	compileParams := CompileParams{
 		Goos:      params.GOOS,
 		Goarch:    params.GOARCH,
+		GoEnv:     params.GoEnv,
 		Ldflags:   params.Ldflags,
+		Gcflags:   params.Gcflags,
+		Asmflags:  params.Asmflags,
+		BuildMode: params.BuildMode,
+		BuildTags: params.BuildTags,
 		StavePath: params.Dir,
 		GoCmd:     params.GoCmd,
 		CompileTo: exePath,
+		ModFile:   modFile,
 		Gofiles:   files,
 		Debug:     params.Debug,
 		Stderr:    params.Stderr,
 		Stdout:    params.Stdout,
-	}); err != nil {
-		return err
+	}
+	if err := vetStavefiles(ctx, verifyMode(params, cfg), main, compileParams); err != nil {
+		return "", false, nil, err
+	}
+	if err := compileStavefiles(ctx, params, cfg, compileParams); err != nil {
+		return "", false, nil, err
 	}
 	if !params.Keep && createdByMe {
 		// move aside this file before we run the compiled version, in case the
@@ -316,14 +594,15 @@ func stave(ctx context.Context, params RunParams) error {
 		// defer, that's ok.
 		_ = os.RemoveAll(main)
 	} else if params.Keep {
-		slog.Debug("keeping mainfile")
+		slog.Debug("keeping mainfile", slog.String(log.Path, main))
 	}
 
 	if params.CompileOut != "" {
-		return nil
+		return exePath, true, nil, nil
 	}
+	writeCacheMeta(exePath, params.Dir, buildManifest(params.Dir, hashFiles, configHash))
 
-	return RunCompiled(ctx, params, exePath)
+	return exePath, true, remote, nil
 }
 
 func generateBinaryName(params RunParams) string {
@@ -343,7 +622,10 @@ func howManyThingsToDo(params RunParams) int {
 		params.Clean,
 		params.Config,
 		params.DirEnv,
+		params.DumpTargets,
+		params.EnsureCompiled,
 		params.Exec,
+		params.FixTags,
 		params.Hooks,
 		params.Init,
 		params.List:
@@ -373,6 +655,11 @@ func preprocessRunParams(params *RunParams) {
 
 	params.CacheDir = cmp.Or(params.CacheDir, st.CacheDir())
 
+	params.DryRun = params.DryRun || params.DryRunDeps
+
+	// --quiet wins over --verbose, and over STAVEFILE_VERBOSE.
+	params.Verbose = params.Verbose && !params.Quiet
+
 	// . will be default unless we find a stave folder.
 	stavefilesDir := filepath.Join(params.Dir, StavefilesDirName)
 
@@ -402,22 +689,93 @@ func applyBasicRunParams(params RunParams) error {
 		dryrun.SetRequested(true)
 	}
 
-	if lo.IsEmpty(params.CompileOut) && (params.GOARCH != "" || params.GOOS != "") {
-		return errors.New("-goos and -goarch only apply when running with -compile")
+	if lo.IsEmpty(params.CompileOut) && !params.EnsureCompiled && (params.GOARCH != "" || params.GOOS != "") {
+		return errors.New("-goos and -goarch only apply when running with -compile or --ensure-compiled")
+	}
+
+	if params.CompileOut == "" && (params.BuildMode != "" || len(params.BuildTags) > 0) {
+		return errors.New("--buildmode and --build-tags only apply when running with --compile")
+	}
+
+	if params.CompileOut == "" && len(params.VerifyTargets) > 0 {
+		return errors.New("--verify-targets only applies when running with --compile")
+	}
+
+	if len(params.VerifyTargets) > 0 && crossCompiling(params) {
+		return errors.New("--verify-targets can't run a binary built for another platform with --goos or --goarch")
+	}
+
+	if !params.Init && params.InitDirLayout {
+		return errors.New("--dir-layout only applies when running with --init")
+	}
+
+	if !params.List && (params.ListLocal || params.ListNamespaces || params.ListImports || params.ListImport != "" || params.ListAll) {
+		return errors.New("--local, --namespaces, --imports, --import and --all only apply when running with --list")
+	}
+
+	if params.TimeTargets < 0 {
+		return errors.New("--time-targets must be a number of runs")
+	}
+
+	if params.TimeTargets == 0 && params.TimeTargetsJSON {
+		return errors.New("--time-targets-json only applies when running with --time-targets")
+	}
+
+	switch params.ContainerPull {
+	case "", "missing", "always", "never":
+	default:
+		return fmt.Errorf("--container-pull must be missing, always or never, not %q", params.ContainerPull)
+	}
+
+	switch params.ErrorFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("--error-format must be text or json, not %q", params.ErrorFormat)
+	}
+
+	if !params.Config && params.ConfigOrigin {
+		return errors.New("--origin only applies when running with --config")
+	}
+
+	for key := range params.GoEnv {
+		if key == "" || key == internal.GoOSEnvVar || key == internal.GoArchEnvVar {
+			return fmt.Errorf("--goenv can't set %q, use --goos and --goarch for GOOS and GOARCH", key)
+		}
+	}
+
+	if !params.Hooks && params.HooksSubmodules {
+		return errors.New("--recurse-submodules only applies when running with --hooks")
+	}
+
+	if params.ConfigFile != "" {
+		if _, err := os.Stat(params.ConfigFile); err != nil {
+			return fmt.Errorf("--config-file: %w", err)
+		}
 	}
 
 	return nil
 }
 
 type mainfileTemplateData struct {
-	Description  string
-	Funcs        []*parse.Function
-	DefaultFunc  parse.Function
-	Aliases      map[string]*parse.Function
-	Imports      []*parse.Import
-	Namespaces   map[string]string
-	BinaryName   string
-	NoColorTERMs []string
+	Description    string
+	Funcs          []*parse.Function
+	DefaultFunc    parse.Function
+	Aliases        map[string]*parse.Function
+	RequiredEnv    []string
+	Imports        []*parse.Import
+	Namespaces     map[string]string
+	NamespaceInfo  map[string]string // NamespaceInfo is what -i prints for each namespace that no target shares the name of, by its lower case name.
+	NamespaceNotes map[string]string // NamespaceNotes is what -i adds for each target that shares the name of a namespace, by its lower case name.
+	BinaryName     string
+	NoColorTERMs   []string
+	UsesRegexp     bool              // UsesRegexp is whether any target has a stave:arg pattern, so the mainfile imports regexp.
+	UsesContainers bool              // UsesContainers is whether any target is marked stave:container, so the mainfile can run docker.
+	UsesRequires   bool              // UsesRequires is whether any target has a stave:require precondition, so the mainfile can run git.
+	UsesConfirm    bool              // UsesConfirm is whether any target is marked stave:confirm, so the mainfile can ask its question.
+	ExclusiveFuncs []*parse.Function // ExclusiveFuncs are the targets marked stave:exclusive, for the mainfile to pass to st.SetExclusive.
+	SerialFuncs    []*parse.Function // SerialFuncs are the targets marked stave:serial, for the mainfile to pass to st.SetSerial.
+	TargetNames    []string          // TargetNames are the names targets and namespaces can be run by, for fuzzy matching.
+	StaveVersion   string            // StaveVersion is the version of stave that generated the mainfile, for crash reports.
 }
 
 // listGoFiles returns a list of all .go files in a given directory,
@@ -461,6 +819,10 @@ func listGoFiles(stavePath, tag string, theEnv map[string]string) ([]string, err
 		return []string{}, errors.New("unexpected nil return-value from bctx.Import")
 	}
 
+	if tag != "" {
+		explainExcludedStavefiles(bctx, stavePath, pkg.IgnoredGoFiles)
+	}
+
 	goFiles := make([]string, len(pkg.GoFiles))
 	for i := range pkg.GoFiles {
 		goFiles[i] = filepath.Join(origStavePath, pkg.GoFiles[i])
@@ -490,6 +852,7 @@ func Stavefiles(stavePath, goos, goarch string, isStavefilesDirectory bool) ([]s
 	if err != nil {
 		return nil, fmt.Errorf("listing stave files: %w", err)
 	}
+	warnBuildTagProblems(stavePath)
 
 	if isStavefilesDirectory {
 		// For the stavefiles directory, we always use all go files, both with
@@ -532,16 +895,36 @@ func Stavefiles(stavePath, goos, goarch string, isStavefilesDirectory bool) ([]s
 type CompileParams struct {
 	Goos      string
 	Goarch    string
+	GoEnv     map[string]string // GoEnv is set in go build's environment, on top of the current one.
 	Ldflags   string
+	Gcflags   string   // Gcflags is passed to go build as -gcflags, if set.
+	Asmflags  string   // Asmflags is passed to go build as -asmflags, if set.
+	BuildMode string   // BuildMode is passed to go build as -buildmode, if set.
+	BuildTags []string // BuildTags are build tags to use besides stave.
 	StavePath string
 	GoCmd     string
 	CompileTo string
+	ModFile   string
 	Gofiles   []string
 	Debug     bool
 	Stderr    io.Writer
 	Stdout    io.Writer
 }
 
+// tags returns the -tags argument for go build and vet: stave and
+// params.BuildTags.
+func (params CompileParams) tags() string {
+	return strings.Join(append([]string{"stave"}, params.BuildTags...), ",")
+}
+
+// env returns the environment for go build and vet: the current one, with
+// GOOS, GOARCH and params.GoEnv set.
+func (params CompileParams) env() map[string]string {
+	theEnv := internal.EnvWithGOOS(params.Goos, params.Goarch)
+	maps.Copy(theEnv, params.GoEnv)
+	return theEnv
+}
+
 // Compile uses the go tool to compile the files into an executable at path.
 func Compile(ctx context.Context, params CompileParams) error {
 	slog.Debug(
@@ -558,39 +941,114 @@ func Compile(ctx context.Context, params CompileParams) error {
 		}
 	}
 
-	theEnv := internal.EnvWithGOOS(params.Goos, params.Goarch)
+	theEnv := params.env()
 
 	// strip off the path since we're setting the path in the build command
 	for i := range params.Gofiles {
 		params.Gofiles[i] = filepath.Base(params.Gofiles[i])
 	}
 
-	buildArgs := []string{"build", "-tags", "stave", "-o", params.CompileTo}
+	buildArgs := []string{"build", "-tags", params.tags(), "-o", params.CompileTo}
+	// each flag's value is passed as one argument, which go build splits
+	// itself, so that quoted arguments in it stay whole.
 	if params.Ldflags != "" {
 		buildArgs = append(buildArgs, "-ldflags", params.Ldflags)
 	}
+	if params.Gcflags != "" {
+		buildArgs = append(buildArgs, "-gcflags", params.Gcflags)
+	}
+	if params.Asmflags != "" {
+		buildArgs = append(buildArgs, "-asmflags", params.Asmflags)
+	}
+	if params.BuildMode != "" {
+		buildArgs = append(buildArgs, "-buildmode", params.BuildMode)
+	}
+	if params.ModFile != "" {
+		buildArgs = append(buildArgs, "-modfile", params.ModFile, "-mod=mod")
+	}
 
 	args := make([]string, len(buildArgs), len(buildArgs)+len(params.Gofiles))
 	copy(args, buildArgs)
 	args = append(args, params.Gofiles...)
 
 	slog.Debug("running go", slog.String(log.Cmd, params.GoCmd), slog.Any(log.Args, args))
+	// the output is also kept for the error, since the caller's Stderr may
+	// not be where the user is looking.
+	output := &lockedBuffer{}
 	theCmd := dryrun.Wrap(ctx, theEnv, params.GoCmd, args...)
 	theCmd.Env = env.ToAssignments(theEnv)
-	theCmd.Stderr = params.Stderr
-	theCmd.Stdout = params.Stdout
+	theCmd.Stderr = teeTo(params.Stderr, output)
+	theCmd.Stdout = teeTo(params.Stdout, output)
 	theCmd.Dir = params.StavePath
 
 	start := time.Now()
 	err := theCmd.Run()
 	slog.Debug("finished compiling", slog.Duration(log.Duration, time.Since(start)))
 	if err != nil {
-		return errors.New("error compiling stavefiles")
+		return compileError(output.String())
 	}
 
 	return nil
 }
 
+// compileErrorTailLines is how many lines from the end of go build's output
+// a compile error includes.
+const compileErrorTailLines = 20
+
+// compileError returns the error for a failed compile, with the tail of go
+// build's output.
+func compileError(output string) error {
+	tail := outputTail(output, compileErrorTailLines)
+	if tail == "" {
+		return newError(KindCompile, errors.New("error compiling stavefiles"))
+	}
+	return newError(KindCompile, errors.New("error compiling stavefiles:\n"+tail))
+}
+
+// outputTail returns the last n lines of output, indented, after a "..."
+// line if there were more.
+func outputTail(output string, n int) string {
+	output = strings.TrimRight(output, " \r\n")
+	if output == "" {
+		return ""
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) > n {
+		lines = append([]string{"..."}, lines[len(lines)-n:]...)
+	}
+	for i, line := range lines {
+		lines[i] = "  " + strings.TrimRight(line, "\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lockedBuffer is a bytes.Buffer that a command's stdout and stderr can both
+// write to.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// teeTo returns a writer that writes to both w, if it isn't nil, and output.
+func teeTo(w, output io.Writer) io.Writer {
+	if w == nil {
+		return output
+	}
+	return io.MultiWriter(w, output)
+}
+
 // GenerateMainFile generates the stave mainfile at path.
 func GenerateMainFile(binaryName, path string, info *parse.PkgInfo) error {
 	slog.Debug("generating mainfile", slog.String(log.Path, path))
@@ -606,10 +1064,28 @@ func GenerateMainFile(binaryName, path string, info *parse.PkgInfo) error {
 	defer func() { _ = outputFile.Close() }()
 	data := buildTemplateData(binaryName, info)
 
-	slog.Debug("writing new file", slog.String(log.Path, path))
-	if err := mainfileTemplate.Execute(outputFile, data); err != nil {
+	var buf bytes.Buffer
+	if err := mainfileTemplate.Execute(&buf, data); err != nil {
 		return fmt.Errorf("can't execute mainfile template: %w", err)
 	}
+	// gofmt the output so kept mainfiles are readable and diff cleanly. This is
+	// deterministic, so it doesn't affect reproducible builds. A formatting
+	// failure means the template produced something odd, but the compiler gets
+	// the final say on that, so we write the raw output instead.
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		slog.Warn(
+			"could not format generated mainfile, writing it unformatted",
+			slog.String(log.Path, path),
+			slog.Any(log.Error, err),
+		)
+		out = buf.Bytes()
+	}
+
+	slog.Debug("writing new file", slog.String(log.Path, path))
+	if _, err := outputFile.Write(out); err != nil {
+		return fmt.Errorf("error writing generated mainfile: %w", err)
+	}
 	if err := outputFile.Close(); err != nil {
 		return fmt.Errorf("error closing generated mainfile: %w", err)
 	}
@@ -622,15 +1098,67 @@ func GenerateMainFile(binaryName, path string, info *parse.PkgInfo) error {
 	return nil
 }
 
+// stableMainFilePath returns the path of the generated mainfile when the user
+// has given it a fixed name. A previously kept mainfile by that name is removed
+// so it gets regenerated, but we refuse to clobber a file stave didn't write.
+func stableMainFilePath(dir, name string) (string, error) {
+	if name != filepath.Base(name) || filepath.Ext(name) != ".go" {
+		return "", fmt.Errorf("invalid mainfile name %q: must be a plain file name ending in .go", name)
+	}
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return path, nil
+	case err != nil:
+		return "", fmt.Errorf("reading existing mainfile: %w", err)
+	case !bytes.HasPrefix(existing, []byte(generatedHeader)):
+		return "", fmt.Errorf("refusing to overwrite %s: it was not generated by stave", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("removing previously generated mainfile: %w", err)
+	}
+	return path, nil
+}
+
 func buildTemplateData(binaryName string, info *parse.PkgInfo) *mainfileTemplateData {
 	data := &mainfileTemplateData{
 		Description:  info.Description,
 		Funcs:        info.Funcs,
 		Aliases:      info.Aliases,
+		RequiredEnv:  info.RequiredEnv,
 		Imports:      info.Imports,
 		BinaryName:   binaryName,
 		NoColorTERMs: st.NoColorTERMs(),
 		Namespaces:   make(map[string]string),
+		StaveVersion: version.EffectiveVersion(context.Background()),
+	}
+
+	funcs := info.Funcs
+	for _, imp := range info.Imports {
+		funcs = append(funcs[:len(funcs):len(funcs)], imp.Info.Funcs...)
+	}
+	for _, f := range funcs {
+		if f.Container != "" {
+			data.UsesContainers = true
+		}
+		if f.Exclusive {
+			data.ExclusiveFuncs = append(data.ExclusiveFuncs, f)
+		}
+		if f.Serial {
+			data.SerialFuncs = append(data.SerialFuncs, f)
+		}
+		if len(f.Requires) > 0 {
+			data.UsesRequires = true
+		}
+		if f.Confirm != "" {
+			data.UsesConfirm = true
+		}
+		for _, arg := range f.Args {
+			if arg.Pattern != "" {
+				data.UsesRegexp = true
+			}
+		}
 	}
 
 	for _, f := range info.Funcs {
@@ -663,6 +1191,15 @@ func buildTemplateData(binaryName string, info *parse.PkgInfo) *mainfileTemplate
 		}
 	}
 
+	for _, f := range funcs {
+		data.TargetNames = append(data.TargetNames, f.TargetName())
+	}
+	data.TargetNames = slices.AppendSeq(data.TargetNames, maps.Keys(info.Aliases))
+	data.TargetNames = slices.AppendSeq(data.TargetNames, maps.Keys(data.Namespaces))
+	slices.Sort(data.TargetNames)
+
+	data.NamespaceInfo, data.NamespaceNotes = namespaceInfo(binaryName, info)
+
 	if info.DefaultFunc != nil {
 		data.DefaultFunc = *info.DefaultFunc
 	}
@@ -673,7 +1210,15 @@ func buildTemplateData(binaryName string, info *parse.PkgInfo) *mainfileTemplate
 // ExeName reports the executable filename that this version of Stave would
 // create for the given stavefiles.
 func ExeName(ctx context.Context, goCmd, cacheDir string, files []string) (string, error) {
-	hashes := make([]string, 0, len(files)+1)
+	return exeName(ctx, goCmd, cacheDir, files)
+}
+
+// exeName is ExeName for stavefiles compiled with build settings that
+// buildHashes are the hashes of, like config.Config.BuildHash for those in
+// stave.yaml and compileFlagsHash for those on the command line. Empty ones
+// are ignored.
+func exeName(ctx context.Context, goCmd, cacheDir string, files []string, buildHashes ...string) (string, error) {
+	hashes := make([]string, 0, len(files)+len(buildHashes)+1)
 	for _, s := range files {
 		h, err := hashFile(s)
 		if err != nil {
@@ -681,6 +1226,11 @@ func ExeName(ctx context.Context, goCmd, cacheDir string, files []string) (strin
 		}
 		hashes = append(hashes, h)
 	}
+	for _, h := range buildHashes {
+		if h != "" {
+			hashes = append(hashes, h)
+		}
+	}
 	// hash the mainfile template to ensure if it gets updated, we make a new
 	// binary.
 	hashes = append(hashes, fmt.Sprintf("%x", sha256.Sum256([]byte(staveMainfileTplString))))
@@ -699,6 +1249,52 @@ func ExeName(ctx context.Context, goCmd, cacheDir string, files []string) (strin
 	return out, nil
 }
 
+// compileFlagsHash returns a hash of the --buildmode, --build-tags,
+// --ldflags, --gcflags, --asmflags and --goenv settings, which change the
+// binary, or "" if none is set.
+func compileFlagsHash(params RunParams) string {
+	if params.BuildMode == "" && len(params.BuildTags) == 0 &&
+		params.Ldflags == "" && params.Gcflags == "" && params.Asmflags == "" && len(params.GoEnv) == 0 {
+		return ""
+	}
+	var goEnv strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(params.GoEnv)) {
+		goEnv.WriteString("\x00env:" + key + "=" + params.GoEnv[key])
+	}
+	hash := sha256.Sum256([]byte("buildmode=" + params.BuildMode +
+		"\x00tags=" + strings.Join(params.BuildTags, ",") +
+		"\x00ldflags=" + params.Ldflags +
+		"\x00gcflags=" + params.Gcflags +
+		"\x00asmflags=" + params.Asmflags +
+		goEnv.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+// withConfigGoFlags returns params with the ldflags, gcflags and asmflags
+// from cfg where the command line didn't set them.
+func withConfigGoFlags(params RunParams, cfg *config.Config) RunParams {
+	params.Ldflags = cmp.Or(params.Ldflags, string(cfg.Ldflags))
+	params.Gcflags = cmp.Or(params.Gcflags, string(cfg.Gcflags))
+	params.Asmflags = cmp.Or(params.Asmflags, string(cfg.Asmflags))
+	return params
+}
+
+// crossExeName returns the path in the cache for a binary built for
+// params.GOOS and params.GOARCH, so that it doesn't take the place of the
+// native binary at exePath.
+func crossExeName(exePath string, params RunParams) string {
+	if params.GOOS == "" && params.GOARCH == "" {
+		return exePath
+	}
+	goos := cmp.Or(params.GOOS, runtime.GOOS)
+	goarch := cmp.Or(params.GOARCH, runtime.GOARCH)
+	name := strings.TrimSuffix(exePath, ".exe") + "-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
 func hashFile(filename string) (string, error) {
 	inputFile, err := os.Open(filename)
 	if err != nil {
@@ -713,28 +1309,129 @@ func hashFile(filename string) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-func generateInit(dir string) error {
-	slog.Debug("generating default stavefile", slog.String(log.Dir, dir))
-	outputFile, err := os.Create(filepath.Join(dir, initFile))
-	if err != nil {
-		return fmt.Errorf("could not create stave template: %w", err)
+// runInitMode writes the initial stavefile, in a new stavefiles directory
+// with --dir-layout, and prints what to do next.
+func runInitMode(ctx context.Context, params RunParams) error {
+	dir := params.Dir
+	modDir := ""
+	if params.InitDirLayout {
+		var err error
+		if dir, err = createStavefilesDir(params); err != nil {
+			return err
+		}
+		// the initial stavefile imports stave, so outside a module the
+		// stavefiles get one of their own.
+		if !inModule(dir) {
+			if err := runGoMod(ctx, params, dir, "init", inferModuleName(dir)); err != nil {
+				return err
+			}
+			modDir = dir
+		}
 	}
-	defer func() { _ = outputFile.Close() }()
+	// all the .go files in a stavefiles directory are stavefiles, so the
+	// stave build tag is left out there.
+	if err := generateInit(dir, !params.InitDirLayout); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, initFile)
+	slog.Debug("created initial stavefile", slog.String(log.Filename, file))
+
+	return printInitNextSteps(params.Stdout, file, modDir)
+}
 
-	if err := initOutput.Execute(outputFile, nil); err != nil {
+// createStavefilesDir creates the stavefiles directory for `stave --init
+// --dir-layout`, if it doesn't exist yet, and returns its path. It refuses to
+// touch one that already has .go files in it.
+func createStavefilesDir(params RunParams) (string, error) {
+	// preprocessRunParams already moved Dir into an existing stavefiles dir.
+	dir := params.Dir
+	if !params.UsesStavefiles() {
+		dir = filepath.Join(params.Dir, StavefilesDirName)
+	}
+	if goFiles, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(goFiles) > 0 {
+		return "", newError(KindUsage, fmt.Errorf("%s already has .go files in it", dir))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating stavefiles directory: %w", err)
+	}
+	return dir, nil
+}
+
+// generateInit writes the initial stavefile to dir, with the stave build tag
+// if buildTag is set.
+func generateInit(dir string, buildTag bool) error {
+	slog.Debug("generating default stavefile", slog.String(log.Dir, dir))
+	var buf bytes.Buffer
+	if err := initOutput.Execute(&buf, nil); err != nil {
 		return fmt.Errorf("can't execute stavefile template: %w", err)
 	}
+	content := buf.Bytes()
+	if !buildTag {
+		content = bytes.TrimPrefix(content, []byte("//go:build stave\n\n"))
+	}
+	if err := os.WriteFile(filepath.Join(dir, initFile), content, 0o644); err != nil {
+		return fmt.Errorf("could not create stave template: %w", err)
+	}
 
 	return nil
 }
 
+// printInitNextSteps tells the user what to do with the initial stavefile
+// at file. modDir is the stavefiles directory if --init gave it a go.mod.
+func printInitNextSteps(out io.Writer, file, modDir string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Created %s\n\nNext steps:\n", file)
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	if modDir != "" {
+		_, _ = fmt.Fprintf(tw, "  cd %s && go mod tidy\tadd Stave to the new go.mod\n", modDir)
+	}
+	_, _ = fmt.Fprintln(tw, "  stave -l\tlist the targets")
+	_, _ = fmt.Fprintln(tw, "  stave build\trun the build target")
+	_ = tw.Flush()
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
 // RunCompiled runs an already-compiled stave command with the given args,.
 func RunCompiled(ctx context.Context, params RunParams, exePath string) error {
-	theEnv, err := setupEnv(params)
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return err
+	}
+	return runCompiled(ctx, params, cfg, exePath)
+}
+
+// runCompiled is RunCompiled with the config already loaded.
+func runCompiled(ctx context.Context, params RunParams, cfg *config.Config, exePath string) error {
+	theEnv, err := setupEnv(params, cfg)
 	if err != nil {
 		return fmt.Errorf("setting up environment for stavefile: %w", err)
 	}
 
+	manifest, cleanupManifest, err := newOutputsManifest()
+	if err != nil {
+		return err
+	}
+	defer cleanupManifest()
+	theEnv[st.OutputsManifestEnv] = manifest
+
+	usageFile, cleanupUsageFile, err := newUsageErrorFile()
+	if err != nil {
+		return err
+	}
+	defer cleanupUsageFile()
+	theEnv[usageErrorFileEnv] = usageFile
+
+	resultsFile, cleanupResultsFile, err := newResultsFile(theEnv)
+	if err != nil {
+		return err
+	}
+	defer cleanupResultsFile()
+	if resultsFile != "" {
+		theEnv[ghaResultsFileEnv] = resultsFile
+	}
+
 	slog.Debug("running binary", slog.String(log.Path, exePath))
 	theCmd := dryrun.Wrap(ctx, theEnv, exePath, params.Args...)
 	theCmd.Stderr = params.Stderr
@@ -754,18 +1451,22 @@ func RunCompiled(ctx context.Context, params RunParams, exePath string) error {
 		})),
 	)
 
-	// catch signals to allow stavefile to handle them
+	// Catch signals, including the SIGTERM sent by CI systems and process
+	// managers, and pass them on so the stavefile can cancel its targets and
+	// clean up. Stave keeps running until the stavefile exits, and returns its
+	// status.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
+	prepareSignalForwarding(theCmd)
 	if err := theCmd.Start(); err != nil {
 		return err
 	}
 
 	go func() {
 		for s := range sigCh {
-			sigProcessErr := theCmd.Process.Signal(s)
+			sigProcessErr := forwardSignal(theCmd.Process, s)
 			if sigProcessErr != nil {
 				slog.Error("failed to send signal to stavefile", slog.Any(log.Error, sigProcessErr))
 			}
@@ -773,15 +1474,32 @@ func RunCompiled(ctx context.Context, params RunParams, exePath string) error {
 	}()
 
 	err = theCmd.Wait()
+	if resultsFile != "" {
+		writeStepSummary(theEnv[stepSummaryEnv], resultsFile)
+	}
 	if !sh.CmdRan(err) {
 		slog.Error("failed to run compiled stavefile", slog.Any(log.Error, err))
 	}
-	return err
+	if err != nil {
+		return stavefileError(err, usageFile)
+	}
+	return collectOutputs(params, manifest)
 }
 
-func setupEnv(params RunParams) (map[string]string, error) {
+func setupEnv(params RunParams, cfg *config.Config) (map[string]string, error) {
 	theEnv := env.GetMap()
 
+	// Variables from env files only fill in what the shell doesn't set.
+	envFileVars, err := loadEnvFiles(params, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range envFileVars {
+		if _, isSet := theEnv[key]; !isSet {
+			theEnv[key] = value
+		}
+	}
+
 	// We don't want to actually allow dryrun in the outermost invocation of
 	// stave, since that will inhibit the very compilation of the stavefile & the
 	// use of the resulting binary.
@@ -790,8 +1508,12 @@ func setupEnv(params RunParams) (map[string]string, error) {
 	// over throughout all such situations.
 	theEnv["STAVEFILE_DRYRUN_POSSIBLE"] = "1"
 
-	if params.Verbose {
+	switch {
+	case params.Verbose:
 		theEnv["STAVEFILE_VERBOSE"] = "1"
+	case params.Quiet:
+		theEnv["STAVEFILE_VERBOSE"] = "0"
+		theEnv[st.QuietEnv] = "1"
 	}
 	if params.Debug {
 		theEnv["STAVEFILE_DEBUG"] = "1"
@@ -802,21 +1524,119 @@ func setupEnv(params RunParams) (map[string]string, error) {
 	if params.Timeout > 0 {
 		theEnv["STAVEFILE_TIMEOUT"] = params.Timeout.String()
 	}
+	if params.PerTargetTimeout > 0 {
+		theEnv[st.PerTargetTimeoutEnv] = params.PerTargetTimeout.String()
+	}
+	if ldflags := cmp.Or(params.Ldflags, string(cfg.Ldflags)); ldflags != "" {
+		theEnv[st.LdflagsEnv] = ldflags
+	}
+	if params.NoContainer {
+		theEnv[st.NoContainerEnv] = "1"
+	}
+	if params.ContainerPull != "" {
+		theEnv[st.ContainerPullEnv] = params.ContainerPull
+	}
+	if params.ErrorFormat != "" {
+		theEnv[st.ErrorFormatEnv] = params.ErrorFormat
+	}
+	if params.Yes {
+		theEnv[st.YesEnv] = "1"
+	}
+	if params.CleanupGrace > 0 {
+		theEnv[st.CleanupGraceEnv] = params.CleanupGrace.String()
+	}
 	if params.DryRun {
 		theEnv["STAVEFILE_DRYRUN"] = "1"
 	}
+	if params.DryRunDeps {
+		theEnv[st.DryRunDepsRequestedEnv] = "1"
+	}
+	if cfg.OnNoTarget != "" {
+		theEnv[st.OnNoTargetEnv] = cfg.OnNoTarget
+	}
+	if cfg.FuzzyTargets {
+		theEnv[st.FuzzyTargetsEnv] = "1"
+	}
+
+	// Targets run in WorkDir, so pass both as absolute paths.
+	if params.Dir != "" {
+		dir, err := filepath.Abs(params.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("resolving stavefiles directory: %w", err)
+		}
+		theEnv[st.StaveDirEnv] = dir
+	}
+	if workDir := cmp.Or(params.WorkDir, params.Dir); workDir != "" {
+		dir, err := filepath.Abs(workDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolving working directory: %w", err)
+		}
+		theEnv[st.WorkDirEnv] = dir
+	}
+
+	if len(params.WatchDirs) > 0 {
+		dirs, err := watchDirs(params)
+		if err != nil {
+			return nil, err
+		}
+		theEnv[st.WatchDirsEnv] = strings.Join(dirs, string(os.PathListSeparator))
+	}
+
+	// Dry runs don't do the work, so their durations would be misleading.
+	if params.CacheDir != "" && !params.DryRun {
+		cacheDir, err := filepath.Abs(params.CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolving cache directory: %w", err)
+		}
+		theEnv[timingsFileEnv] = timingsFile(cacheDir)
+	}
+
+	if len(params.IgnoreFailures) > 0 {
+		indexes := make([]string, 0, len(params.IgnoreFailures))
+		for _, i := range params.IgnoreFailures {
+			indexes = append(indexes, strconv.Itoa(i))
+		}
+		theEnv[ignoreFailuresEnv] = strings.Join(indexes, ",")
+	}
 
 	if params.HooksAreRunning {
 		theEnv[HooksAreRunningEnv] = "1"
+		maps.Copy(theEnv, hooks.HookArgsEnv(params.HookArgs))
 	}
 
-	if err := parallelism.Apply(theEnv); err != nil {
+	if err := parallelism.Apply(theEnv, params.Parallelism); err != nil {
 		return nil, err
 	}
 
 	return theEnv, nil
 }
 
+// watchDirs returns the absolute paths of params.WatchDirs, which are relative
+// to the directory targets run in, and checks that they're directories.
+func watchDirs(params RunParams) ([]string, error) {
+	workDir := cmp.Or(params.WorkDir, params.Dir)
+	dirs := make([]string, 0, len(params.WatchDirs))
+	for _, dir := range params.WatchDirs {
+		path := dir
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving --watch-dir %s: %w", dir, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, newError(KindUsage, fmt.Errorf("--watch-dir: %w", err))
+		}
+		if !info.IsDir() {
+			return nil, newError(KindUsage, fmt.Errorf("--watch-dir %s: not a directory", dir))
+		}
+		dirs = append(dirs, path)
+	}
+	return dirs, nil
+}
+
 // removeContents removes all files but not any subdirectories in the given
 // directory.
 func removeContents(dir string) error {
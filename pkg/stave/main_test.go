@@ -1,14 +1,17 @@
 package stave
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/sha256"
 	"debug/macho"
 	"debug/pe"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/format"
 	"go/parser"
 	"go/token"
 	"io"
@@ -17,6 +20,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -26,8 +30,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/config"
 	"github.com/yaklabco/stave/internal"
+	"github.com/yaklabco/stave/internal/parse"
 	"github.com/yaklabco/stave/pkg/fsutils"
+	"github.com/yaklabco/stave/pkg/sh"
 	"github.com/yaklabco/stave/pkg/st"
 )
 
@@ -36,6 +43,10 @@ const (
 
 	testExeEnv = "STAVE_TEST_STRING"
 
+	// testSignalsTargetEnv makes the test binary act as a stave process
+	// running the given target from testdata/signals.
+	testSignalsTargetEnv = "STAVE_TEST_SIGNALS_TARGET"
+
 	hiExclam           = "hi!"
 	hiExclamAndNewline = hiExclam + "\n"
 
@@ -80,6 +91,16 @@ func TestMain(m *testing.M) {
 		_, _ = fmt.Fprint(os.Stdout, s)
 		os.Exit(0)
 	}
+	if target := os.Getenv(testSignalsTargetEnv); target != "" {
+		err := Run(RunParams{
+			BaseCtx: context.Background(),
+			Dir:     filepath.Join(testDataDir, "signals"),
+			Stdout:  os.Stdout,
+			Stderr:  os.Stderr,
+			Args:    []string{target},
+		})
+		os.Exit(sh.ExitStatus(err))
+	}
 	os.Exit(actualTestMain(m))
 }
 
@@ -108,6 +129,12 @@ func actualTestMain(m *testing.M) int {
 		slog.Error(err.Error())
 		return 1
 	}
+	// the tests check stave's plain output, even when they run in GitHub
+	// Actions; those of the workflow command output turn it back on.
+	if err := os.Setenv(ghaEnv, "0"); err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
 	if err := os.Unsetenv(st.VerboseEnv); err != nil {
 		slog.Error(err.Error())
 		return 1
@@ -313,6 +340,111 @@ func TestTransitiveHashFast(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+func TestEnsureCompiled(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "ensurecompiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	params := RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		CacheDir: t.TempDir(),
+		HashFast: true,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &bytes.Buffer{},
+	}
+
+	exePath, rebuilt, err := EnsureCompiled(t.Context(), params)
+	require.NoError(t, err)
+	assert.True(t, rebuilt)
+	assert.Equal(t, params.CacheDir, filepath.Dir(exePath))
+	info, err := os.Stat(exePath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.NotZero(t, info.Mode()&0o111, "binary is not executable")
+	}
+	_, err = os.Stat(mainFilePathFromExePath(dataDirForThisTest, exePath))
+	require.ErrorIs(t, err, os.ErrNotExist, "mainfile was not cleaned up")
+
+	again, rebuilt, err := EnsureCompiled(t.Context(), params)
+	require.NoError(t, err)
+	assert.False(t, rebuilt)
+	assert.Equal(t, exePath, again)
+
+	// The binary runs targets as usual.
+	out, err := exec.CommandContext(t.Context(), exePath, "returnsnilerror").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "stuff")
+}
+
+func TestNoCacheEnv(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "nocache")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	params := RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		CacheDir: t.TempDir(),
+		HashFast: true,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &bytes.Buffer{},
+	}
+	ensure := func() bool {
+		t.Helper()
+		_, rebuilt, err := EnsureCompiled(t.Context(), params)
+		require.NoError(t, err)
+		return rebuilt
+	}
+
+	t.Setenv(st.NoCacheEnv, "")
+	assert.True(t, ensure(), "first build")
+	assert.False(t, ensure(), "cached binary is used")
+
+	t.Setenv(st.NoCacheEnv, "yes")
+	assert.True(t, ensure(), "recompiled despite the cached binary")
+
+	t.Setenv(st.NoCacheEnv, "0")
+	assert.False(t, ensure(), "cached binary is used again")
+}
+
+func TestEnsureCompiledFlag(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "ensurecompiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cacheDir := t.TempDir()
+	err := Run(RunParams{
+		BaseCtx:        t.Context(),
+		Dir:            dataDirForThisTest,
+		CacheDir:       cacheDir,
+		Stdout:         stdout,
+		Stderr:         stderr,
+		EnsureCompiled: true,
+	})
+	require.NoError(t, err, stderr.String())
+
+	exePath := strings.TrimSpace(stdout.String())
+	assert.Equal(t, cacheDir, filepath.Dir(exePath))
+	assert.FileExists(t, exePath)
+}
+
+func TestCrossExeName(t *testing.T) {
+	t.Parallel()
+
+	exePath := filepath.Join("cache", "abc123")
+	assert.Equal(t, exePath, crossExeName(exePath, RunParams{}))
+	assert.Equal(t, exePath+"-linux-arm64", crossExeName(exePath, RunParams{GOOS: "linux", GOARCH: "arm64"}))
+	assert.Equal(t, exePath+"-windows-"+runtime.GOARCH+".exe", crossExeName(exePath+".exe", RunParams{GOOS: "windows"}))
+}
+
 func TestListStavefilesMain(t *testing.T) {
 	t.Parallel()
 
@@ -374,6 +506,52 @@ func TestListStavefilesIgnoresRespectsGOOSArg(t *testing.T) {
 	assert.Equal(t, expected, files)
 }
 
+func TestParseAndCompileAgreeForOtherGOOS(t *testing.T) {
+	mu := mutexByDir(testDataGOOSStaveFilesDir)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	ctx := t.Context()
+	goos, want := windows, "WindowsTarget"
+	if runtime.GOOS == windows {
+		goos, want = "linux", "NonWindowsTarget"
+	}
+
+	files, err := Stavefiles(testDataGOOSStaveFilesDir, goos, amd64, false)
+	require.NoError(t, err)
+	fnames := make([]string, 0, len(files))
+	for _, file := range files {
+		fnames = append(fnames, filepath.Base(file))
+	}
+	info, err := parse.PrimaryPackage(ctx, "go", testDataGOOSStaveFilesDir, fnames, false, nil, nil)
+	require.NoError(t, err)
+	names := make([]string, 0, len(info.Funcs))
+	for _, f := range info.Funcs {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{want}, names)
+
+	// the same files compile for that GOOS, so the parsed targets are the
+	// compiled ones.
+	target := t.TempDir()
+	stderr := &bytes.Buffer{}
+	err = Run(RunParams{
+		BaseCtx:    ctx,
+		Stderr:     stderr,
+		Stdout:     &bytes.Buffer{},
+		Dir:        testDataGOOSStaveFilesDir,
+		CompileOut: filepath.Join(target, "output"),
+		GOOS:       goos,
+		GOARCH:     amd64,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	if goos == windows {
+		theOS, _, err := fileData(filepath.Join(target, "output"))
+		require.NoError(t, err)
+		assert.Equal(t, winExe, theOS)
+	}
+}
+
 func TestCompileDiffGoosGoarch(t *testing.T) {
 	dataDirForThisTest := testDataDir
 
@@ -638,8 +816,10 @@ func TestGoRun(t *testing.T) {
 	c := exec.Command("go", "run", "main.go")
 	c.Dir = testDataDir
 	c.Env = os.Environ()
-	b, err := c.CombinedOutput()
-	require.NoError(t, err, "stderr was: %s", string(b))
+	stderr := &bytes.Buffer{}
+	c.Stderr = stderr
+	b, err := c.Output()
+	require.NoError(t, err, "stderr was: %s", stderr.String())
 
 	expected := "stuff\n"
 	assert.Equal(t, expected, string(b))
@@ -1018,6 +1198,191 @@ func TestHelpMatchesCompiledBinary(t *testing.T) {
 	}
 }
 
+func TestCompileVerifyTargets(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "compiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	name := filepath.Join(t.TempDir(), "stave_verify_test")
+	if runtime.GOOS == windows {
+		name += ".exe"
+	}
+	compile := func(targets ...string) error {
+		return Run(RunParams{
+			BaseCtx:       t.Context(),
+			Dir:           dataDirForThisTest,
+			Stdout:        &bytes.Buffer{},
+			Stderr:        &bytes.Buffer{},
+			CompileOut:    name,
+			VerifyTargets: targets,
+		})
+	}
+
+	require.NoError(t, compile("deploy", "EchoArg", "testverbose"))
+
+	err := compile("deploy", "build", "test")
+	require.Error(t, err)
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindCompile, stErr.Kind)
+	assert.Contains(t, err.Error(), "is missing targets: build, test")
+}
+
+func TestCompileVerifyTargetsNeedsCompile(t *testing.T) {
+	t.Parallel()
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           filepath.Join(testDataDir, "compiled"),
+		Stdout:        &bytes.Buffer{},
+		Stderr:        &bytes.Buffer{},
+		VerifyTargets: []string{"deploy"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--verify-targets only applies when running with --compile")
+}
+
+func TestCompileBuildModeAndTags(t *testing.T) {
+	t.Parallel()
+	if !slices.Contains([]string{"linux", "darwin", "windows"}, runtime.GOOS) ||
+		!slices.Contains([]string{"amd64", "arm64"}, runtime.GOARCH) {
+		t.Skipf("-buildmode=pie isn't supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	dataDirForThisTest := filepath.Join(testDataDir, "compiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	name := filepath.Join(t.TempDir(), "stave_pie_test")
+	if runtime.GOOS == windows {
+		name += ".exe"
+	}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     &bytes.Buffer{},
+		Stderr:     stderr,
+		CompileOut: name,
+		BuildMode:  "pie",
+		BuildTags:  []string{"extra"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	// go records the flags it built the binary with.
+	out, err := exec.CommandContext(t.Context(), "go", "version", "-m", name).Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "-buildmode=pie")
+	assert.Contains(t, string(out), "-tags=stave,extra")
+}
+
+func TestCompileBuildModeNeedsCompile(t *testing.T) {
+	t.Parallel()
+	err := Run(RunParams{
+		BaseCtx:   t.Context(),
+		Dir:       filepath.Join(testDataDir, "compiled"),
+		Stdout:    &bytes.Buffer{},
+		Stderr:    &bytes.Buffer{},
+		BuildMode: "pie",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--buildmode and --build-tags only apply when running with --compile")
+}
+
+func TestCompileFlagsHash(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, compileFlagsHash(RunParams{}))
+	pie := compileFlagsHash(RunParams{BuildMode: "pie"})
+	tagged := compileFlagsHash(RunParams{BuildMode: "pie", BuildTags: []string{"extra"}})
+	assert.NotEmpty(t, pie)
+	assert.NotEqual(t, pie, tagged)
+	assert.Equal(t, tagged, compileFlagsHash(RunParams{BuildMode: "pie", BuildTags: []string{"extra"}}))
+	stamped := compileFlagsHash(RunParams{Ldflags: "-X main.Version=1"})
+	assert.NotEmpty(t, stamped)
+	assert.NotEqual(t, stamped, compileFlagsHash(RunParams{Ldflags: "-X main.Version=2"}))
+	assert.NotEqual(t, stamped, compileFlagsHash(RunParams{Gcflags: "-X main.Version=1"}))
+	clang := compileFlagsHash(RunParams{GoEnv: map[string]string{"CC": "clang"}})
+	assert.NotEmpty(t, clang)
+	assert.NotEqual(t, clang, compileFlagsHash(RunParams{GoEnv: map[string]string{"CC": "gcc"}}))
+	assert.Equal(t, clang, compileFlagsHash(RunParams{GoEnv: map[string]string{"CC": "clang"}}))
+}
+
+func TestCompileGoEnv(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "compiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	name := filepath.Join(t.TempDir(), "stave_goenv_test")
+	if runtime.GOOS == windows {
+		name += ".exe"
+	}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     &bytes.Buffer{},
+		Stderr:     stderr,
+		CompileOut: name,
+		GoEnv:      map[string]string{"GOFLAGS": "-trimpath"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	// go build records -trimpath, which it only got from GOFLAGS.
+	out, err := exec.CommandContext(t.Context(), "go", "version", "-m", name).Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "-trimpath=true")
+}
+
+func TestGoEnvCantSetGOOS(t *testing.T) {
+	t.Parallel()
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     filepath.Join(testDataDir, "compiled"),
+		Stdout:  &bytes.Buffer{},
+		Stderr:  &bytes.Buffer{},
+		GoEnv:   map[string]string{"GOOS": "windows"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use --goos and --goarch")
+}
+
+func TestCompileGcflags(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "compiled")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	name := filepath.Join(t.TempDir(), "stave_gcflags_test")
+	if runtime.GOOS == windows {
+		name += ".exe"
+	}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     &bytes.Buffer{},
+		Stderr:     stderr,
+		CompileOut: name,
+		Gcflags:    "all=-N -l",
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	out, err := exec.CommandContext(t.Context(), "go", "version", "-m", name).Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `-gcflags="all=-N -l"`)
+}
+
+func TestMissingTargets(t *testing.T) {
+	t.Parallel()
+	list := "build\nns:lint\ntest\n"
+	assert.Empty(t, missingTargets(list, []string{"Build", "NS:Lint", " test"}))
+	assert.Equal(t, []string{"deploy", "ns:vet"}, missingTargets(list, []string{"build", "deploy", "ns:vet"}))
+}
+
 func TestList(t *testing.T) {
 	t.Parallel()
 	dataDirForThisTest := testDataListDir
@@ -1209,6 +1574,21 @@ func TestListFiltering(t *testing.T) {
 	assert.NotContains(t, out, "testVerbose", "testVerbose should not match filter 'pig'")
 }
 
+func TestListSectionFlagsRequireList(t *testing.T) {
+	t.Parallel()
+
+	runParams := RunParams{
+		BaseCtx:   t.Context(),
+		Dir:       testDataListDir,
+		Stdout:    &bytes.Buffer{},
+		Stderr:    &bytes.Buffer{},
+		ListLocal: true,
+	}
+
+	err := Run(runParams)
+	require.EqualError(t, err, "--local, --namespaces, --imports, --import and --all only apply when running with --list")
+}
+
 func TestNoArgNoDefaultList(t *testing.T) {
 	t.Parallel()
 	dataDirForThisTest := testDataNoDefaultDir
@@ -1234,6 +1614,107 @@ func TestNoArgNoDefaultList(t *testing.T) {
 	assert.Regexp(t, `(?m)no targets specified and no .*Default.* defined`, stderr.String())
 }
 
+func TestOnNoTarget(t *testing.T) {
+	t.Parallel()
+
+	dataDirForThisTest := filepath.Join(testDataDir, "onnotarget")
+
+	tests := []struct {
+		mode      string
+		dir       string
+		wantErr   string
+		wantOut   string
+		unwantOut string
+	}{
+		{mode: config.OnNoTargetDefault, dir: dataDirForThisTest, wantOut: "stuff"},
+		{mode: config.OnNoTargetDefault, dir: testDataNoDefaultDir, wantErr: "no targets specified and no `Default` defined"},
+		{mode: config.OnNoTargetList, dir: dataDirForThisTest, wantOut: "Targets:", unwantOut: "stuff"},
+		{mode: config.OnNoTargetList, dir: testDataNoDefaultDir, wantOut: "fooBar"},
+		{mode: config.OnNoTargetHelp, dir: dataDirForThisTest, wantOut: "[options] [target]", unwantOut: "stuff"},
+		{mode: config.OnNoTargetHelp, dir: testDataNoDefaultDir, wantOut: "[options] [target]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+filepath.Base(tt.dir), func(t *testing.T) {
+			t.Parallel()
+			mu := mutexByDir(tt.dir)
+			mu.Lock()
+			t.Cleanup(mu.Unlock)
+
+			configFile := filepath.Join(t.TempDir(), "stave.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte("on_no_target: "+tt.mode+"\n"), 0o644))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			err := Run(RunParams{
+				BaseCtx:    t.Context(),
+				Dir:        tt.dir,
+				Stdout:     stdout,
+				Stderr:     stderr,
+				ConfigFile: configFile,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, stderr.String(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err, "stderr was: %s", stderr.String())
+			assert.Contains(t, stdout.String(), tt.wantOut)
+			if tt.unwantOut != "" {
+				assert.NotContains(t, stdout.String(), tt.unwantOut)
+			}
+		})
+	}
+}
+
+func TestFuzzyTargets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fuzzy   bool
+		target  string
+		wantErr string
+		wantOut string
+	}{
+		{name: "unique prefix", fuzzy: true, target: "returnsnil", wantOut: "stuff"},
+		{name: "ambiguous abbreviation", fuzzy: true, target: "rnilerr", wantErr: `Ambiguous target "rnilerr" matches: ReturnsNilError, ReturnsNonNilError`},
+		{name: "ambiguous prefix", fuzzy: true, target: "returnsn", wantErr: `Ambiguous target "returnsn" matches: ReturnsNilError, ReturnsNonNilError`},
+		{name: "unique abbreviation", fuzzy: true, target: "tverb"},
+		{name: "off", target: "returnsnil", wantErr: `Unknown target specified: "returnsnil"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dataDirForThisTest := filepath.Join(testDataDir, "fuzzy")
+			mu := mutexByDir(dataDirForThisTest)
+			mu.Lock()
+			t.Cleanup(mu.Unlock)
+
+			configFile := filepath.Join(t.TempDir(), "stave.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf("fuzzy_targets: %v\n", tt.fuzzy)), 0o644))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			err := Run(RunParams{
+				BaseCtx:    t.Context(),
+				Dir:        dataDirForThisTest,
+				Stdout:     stdout,
+				Stderr:     stderr,
+				ConfigFile: configFile,
+				Args:       []string{tt.target},
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, 2, sh.ExitStatus(err))
+				assert.Contains(t, stderr.String(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err, stderr.String())
+			assert.Contains(t, stdout.String(), tt.wantOut)
+		})
+	}
+}
+
 func TestIgnoreDefault(t *testing.T) {
 	ctx := t.Context()
 
@@ -1319,9 +1800,51 @@ func TestTargetPanics(t *testing.T) {
 
 	err := Run(runParams)
 	require.Error(t, err)
+	assert.Equal(t, 1, sh.ExitStatus(err))
 
-	expected := "Error: boom!\n"
+	expected := "Error: panic: boom!\n"
 	assert.Contains(t, stderr.String(), expected)
+
+	crashFile := crashReportPath(t, stderr.String(), "Panics")
+	report, err := os.ReadFile(crashFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "target: Panics\n")
+	assert.Contains(t, string(report), `args: ["panics"]`)
+	assert.Contains(t, string(report), "panic: boom!\n")
+	assert.Regexp(t, `main\.Panics\(`, string(report))
+}
+
+// crashReportPath returns the crash report file the stavefile said it wrote
+// for target, and removes it at the end of the test.
+func crashReportPath(t *testing.T, stderr, target string) string {
+	t.Helper()
+	prefix := "target " + target + " panicked; crash report written to "
+	for line := range strings.Lines(stderr) {
+		if path, ok := strings.CutPrefix(line, prefix); ok {
+			path = strings.TrimSpace(path)
+			t.Cleanup(func() { _ = os.Remove(path) })
+			return path
+		}
+	}
+	t.Fatalf("no crash report message in stderr: %s", stderr)
+	return ""
+}
+
+func TestTargetPanicsDebug(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     testDataDir,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  stderr,
+		Debug:   true,
+		Args:    []string{"panics"},
+	})
+	require.Error(t, err)
+
+	// The first frames of the stack start at the panicking target.
+	assert.Regexp(t, `target Panics panicked: boom!\nmain\.Panics\(.*\n\t.*panic\.go:13`, stderr.String())
+	assert.FileExists(t, crashReportPath(t, stderr.String(), "Panics"))
 }
 
 func TestPanicsErr(t *testing.T) {
@@ -1343,8 +1866,34 @@ func TestPanicsErr(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "Error: kaboom!\n"
+	expected := "Error: panic: kaboom!\n"
+	assert.Contains(t, stderr.String(), expected)
+	assert.FileExists(t, crashReportPath(t, stderr.String(), "PanicsErr"))
+}
+
+func TestMustRunAbortsTarget(t *testing.T) {
+	dataDirForThisTest := testDataDir
+
+	ctx := t.Context()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: ctx,
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"mustrunfails"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+
+	expected := `Error: running "go bogus-subcommand" failed with exit code 2`
 	assert.Contains(t, stderr.String(), expected)
+	assert.Equal(t, 2, sh.ExitStatus(err))
+	assert.NotContains(t, stderr.String(), "crash report")
 }
 
 // ensure we include the hash of the mainfile template in determining the
@@ -1367,6 +1916,34 @@ func TestHashTemplate(t *testing.T) {
 	assert.NotEqual(t, name, changed)
 }
 
+// TestExeNameBuildConfig checks that the build settings in stave.yaml go into
+// the exe name, and the rest of the config doesn't. It isn't parallel, as
+// TestHashTemplate changes the template that goes into the name.
+func TestExeNameBuildConfig(t *testing.T) {
+	ctx := t.Context()
+	files := []string{filepath.Join(testDataDir, "func.go"), filepath.Join(testDataDir, "command.go")}
+	exeNameFor := func(configContent string) string {
+		t.Helper()
+		projectDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "stave.yaml"), []byte(configContent), 0o644))
+		cfg, err := config.Load(&config.LoadOptions{ProjectDir: projectDir, SkipUserConfig: true, SkipEnv: true})
+		require.NoError(t, err)
+		name, err := exeName(ctx, "go", st.CacheDir(), files, cfg.BuildHash())
+		require.NoError(t, err)
+		return name
+	}
+
+	plain, err := ExeName(ctx, "go", st.CacheDir(), files)
+	require.NoError(t, err)
+	assert.Equal(t, plain, exeNameFor("verbose: false\n"))
+	assert.Equal(t, plain, exeNameFor("verbose: true\nverify: warn\n"), "settings that don't change the build don't change the name")
+
+	aliased := exeNameFor("import_aliases:\n  example.com/tools: tools\n")
+	assert.NotEqual(t, plain, aliased)
+	assert.Equal(t, aliased, exeNameFor("verbose: true\nimport_aliases:\n  example.com/tools: tools\n"))
+	assert.NotEqual(t, aliased, exeNameFor("import_aliases:\n  example.com/tools: tls\n"))
+}
+
 // Test if the -keep flag does keep the mainfile around after running.
 func TestKeepFlag(t *testing.T) {
 	t.Parallel()
@@ -1405,9 +1982,108 @@ func TestKeepFlag(t *testing.T) {
 	require.NoError(t, err)
 }
 
-type tLogWriter struct {
-	*testing.T
-}
+// Test that --mainfile-name gives the kept mainfile a stable name, that it is
+// gofmt'd, and that a later run regenerates it in place.
+func TestMainfileName(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataKeepFlagDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	const mainfileName = "stave_main_gen.go"
+	buildFile := filepath.Join(dataDirForThisTest, mainfileName)
+	_ = os.Remove(buildFile)
+	defer func() {
+		_ = os.Remove(buildFile)
+	}()
+
+	logWriter := tLogWriter{t}
+
+	runParams := RunParams{
+		BaseCtx:      t.Context(),
+		Dir:          dataDirForThisTest,
+		Stdout:       logWriter,
+		Stderr:       logWriter,
+		Args:         []string{"noop"},
+		Keep:         true,
+		Force:        true,
+		MainfileName: mainfileName,
+	}
+
+	for range 2 {
+		require.NoError(t, Run(runParams))
+		out, err := os.ReadFile(buildFile)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(out), generatedHeader))
+		formatted, err := format.Source(out)
+		require.NoError(t, err)
+		assert.Equal(t, string(formatted), string(out))
+	}
+
+	runParams.Keep = false
+	require.NoError(t, Run(runParams))
+	_, err := os.Stat(buildFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// Test that mainfile_name in the config names the generated mainfile, and that
+// it is removed after the run unless --keep is given.
+func TestMainfileNameFromConfig(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataKeepFlagDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	const mainfileName = "stave_main_cfg.go"
+	buildFile := filepath.Join(dataDirForThisTest, mainfileName)
+	_ = os.Remove(buildFile)
+	defer func() {
+		_ = os.Remove(buildFile)
+	}()
+
+	configFile := filepath.Join(t.TempDir(), "stave.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("mainfile_name: "+mainfileName+"\n"), 0o600))
+
+	logWriter := tLogWriter{t}
+	runParams := RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     logWriter,
+		Stderr:     logWriter,
+		Args:       []string{"noop"},
+		Keep:       true,
+		Force:      true,
+		ConfigFile: configFile,
+	}
+	require.NoError(t, Run(runParams))
+	out, err := os.ReadFile(buildFile)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), generatedHeader))
+
+	runParams.Keep = false
+	require.NoError(t, Run(runParams))
+	_, err = os.Stat(buildFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMainfileNameRefusesToOverwrite(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	_, err := stableMainFilePath(dir, "main.go")
+	require.ErrorContains(t, err, "not generated by stave")
+
+	_, err = stableMainFilePath(dir, filepath.Join("sub", "main.go"))
+	require.ErrorContains(t, err, "invalid mainfile name")
+}
+
+type tLogWriter struct {
+	*testing.T
+}
 
 func (t tLogWriter) Write(b []byte) (int, error) {
 	t.Log(string(b))
@@ -1511,7 +2187,7 @@ func TestFirstTargetFails(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expectedErrRegexp := `.*Running target: .*ReturnsNonNilError.*\nError: bang!\n`
+	expectedErrRegexp := `.*Running target: .*ReturnsNonNilError.*\nFinished target: <ReturnsNonNilError> in \S+\nError: bang!\n`
 	assert.Regexp(t, expectedErrRegexp, stderr.String())
 	assert.Empty(t, stdout.String())
 }
@@ -1564,6 +2240,85 @@ func TestSetDir(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+func TestLdflags(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "ldflags")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		CacheDir: t.TempDir(),
+		Ldflags:  "-X main.Version=1.2.3",
+		Args:     []string{"printLdflags"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "-X main.Version=1.2.3\n1.2.3\n", stdout.String())
+}
+
+func TestLdflagsQuoted(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "ldflags")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	listConfig := filepath.Join(t.TempDir(), "stave.yaml")
+	require.NoError(t, os.WriteFile(listConfig, []byte("ldflags: [-X, \"main.Version=1.2.3, from config\"]\n"), 0o600))
+	stringConfig := filepath.Join(t.TempDir(), "stave.yaml")
+	require.NoError(t, os.WriteFile(stringConfig, []byte("ldflags: -X 'main.Version=1.2.3, from config'\n"), 0o600))
+
+	for _, tc := range []struct {
+		name       string
+		ldflags    string
+		configFile string
+		want       string
+	}{
+		{
+			name:    "flag",
+			ldflags: `-X "main.Version=1.2.3 (built by ci)"`,
+			want:    "-X \"main.Version=1.2.3 (built by ci)\"\n1.2.3 (built by ci)\n",
+		},
+		{
+			name:       "config list",
+			configFile: listConfig,
+			want:       "-X 'main.Version=1.2.3, from config'\n1.2.3, from config\n",
+		},
+		{
+			name:       "config string",
+			configFile: stringConfig,
+			want:       "-X 'main.Version=1.2.3, from config'\n1.2.3, from config\n",
+		},
+		{
+			name:       "flag over config",
+			ldflags:    "-X main.Version=flag",
+			configFile: listConfig,
+			want:       "-X main.Version=flag\nflag\n",
+		},
+	} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:    t.Context(),
+			Dir:        dataDirForThisTest,
+			Stdout:     stdout,
+			Stderr:     stderr,
+			CacheDir:   t.TempDir(),
+			ConfigFile: tc.configFile,
+			Ldflags:    tc.ldflags,
+			Args:       []string{"printLdflags"},
+		})
+		require.NoError(t, err, "%s: stderr was: %s", tc.name, stderr.String())
+		assert.Equal(t, tc.want, stdout.String(), tc.name)
+	}
+}
+
 func TestSetWorkingDir(t *testing.T) {
 	dataDirForThisTest := filepath.Join(testDataDir, "setworkdir")
 
@@ -1586,6 +2341,248 @@ func TestSetWorkingDir(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+func TestStaveDirAndWorkingDir(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "setworkdir")
+
+	ctx := t.Context()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	err := Run(RunParams{
+		BaseCtx: ctx,
+		Dir:     dataDirForThisTest,
+		WorkDir: filepath.Join(dataDirForThisTest, "data"),
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"PrintDirs"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	staveDir, err := filepath.Abs(dataDirForThisTest)
+	require.NoError(t, err)
+	expected := staveDir + "\n" + filepath.Join(staveDir, "data") + "\n"
+	assert.Equal(t, expected, stdout.String())
+
+	// Without -w, targets run in the stavefiles directory.
+	stdout.Reset()
+	stderr.Reset()
+	err = Run(RunParams{
+		BaseCtx: ctx,
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"PrintDirs"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, staveDir+"\n"+staveDir+"\n", stdout.String())
+}
+
+func TestParallelism(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "parallelism")
+	t.Setenv("STAVE_NUM_PROCESSORS", "3")
+
+	run := func(parallelism int) string {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:     t.Context(),
+			Dir:         dataDirForThisTest,
+			Stdout:      stdout,
+			Stderr:      stderr,
+			Parallelism: parallelism,
+			Args:        []string{"report"},
+		})
+		require.NoError(t, err, "stderr was: %s", stderr.String())
+		return stdout.String()
+	}
+
+	// -p wins over STAVE_NUM_PROCESSORS
+	assert.Equal(t, "STAVE_NUM_PROCESSORS=2\nGOMAXPROCS=2\nruntime.GOMAXPROCS=2\n", run(2))
+	// which is used without it
+	assert.Equal(t, "STAVE_NUM_PROCESSORS=3\nGOMAXPROCS=3\nruntime.GOMAXPROCS=3\n", run(0))
+
+	err := Run(RunParams{
+		BaseCtx:     t.Context(),
+		Dir:         dataDirForThisTest,
+		Stdout:      &bytes.Buffer{},
+		Stderr:      &bytes.Buffer{},
+		Parallelism: -1,
+		Args:        []string{"report"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parallelism must be at least 1")
+}
+
+func TestDryRunDeps(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "dryrundeps")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	run := func(params RunParams) string {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		params.BaseCtx = t.Context()
+		params.Dir = dataDirForThisTest
+		params.Stdout = stdout
+		params.Stderr = stderr
+		params.Args = []string{"deploy"}
+		require.NoError(t, Run(params), "stderr was: %s", stderr.String())
+		return stdout.String()
+	}
+
+	// --dryrun still runs the dependencies
+	out := run(RunParams{DryRun: true})
+	assert.Contains(t, out, "building\n")
+	assert.Contains(t, out, "testing\n")
+	assert.Contains(t, out, "DRYRUN: echo deployed\n")
+	assert.NotContains(t, out, "would run target")
+
+	// --dryrun-deps prints them in order, once, instead
+	expected := "DRYRUN: would run target: Build\n" +
+		"DRYRUN: would run target: Test\n" +
+		"DRYRUN: echo deployed\n"
+	assert.Equal(t, expected, run(RunParams{DryRunDeps: true}))
+}
+
+func TestConfigFile(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "envfiles")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	// Only the given file is read, not the stave.yaml next to the stavefiles.
+	configFile := filepath.Join(t.TempDir(), "ci.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("env_files: [cli.env]\n"), 0o600))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     stdout,
+		Stderr:     stderr,
+		Args:       []string{"echo"},
+		ConfigFile: configFile,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "FROM_ENV=\nOVERRIDDEN=\nFROM_SHELL=\nFROM_CLI=cli value\n", stdout.String())
+
+	err = Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dataDirForThisTest,
+		Stdout:     &bytes.Buffer{},
+		Stderr:     &bytes.Buffer{},
+		Args:       []string{"echo"},
+		ConfigFile: filepath.Join(t.TempDir(), "missing.yaml"),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--config-file")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEnvFiles(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "envfiles")
+	t.Setenv("FROM_SHELL", "shell")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		Args:     []string{"echo"},
+		EnvFiles: []string{filepath.Join(dataDirForThisTest, "cli.env")},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	expected := "FROM_ENV=base\nOVERRIDDEN=local\nFROM_SHELL=shell\nFROM_CLI=cli value\n"
+	assert.Equal(t, expected, stdout.String())
+
+	// Files given with --env-file must exist.
+	stderr.Reset()
+	err = Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   stderr,
+		Args:     []string{"echo"},
+		EnvFiles: []string{filepath.Join(dataDirForThisTest, "missing.env")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "loading env file")
+
+	// A config that fails to load fails the run, rather than running without
+	// its env files.
+	brokenDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "go.mod"), []byte("module example.com/broken\n\ngo 1.25\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "stavefile.go"),
+		[]byte("//go:build stave\n\npackage main\n\nfunc Echo() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "stave.yaml"), []byte("env_files: [\"!.env\"\n"), 0o644))
+	err = Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      brokenDir,
+		CacheDir: t.TempDir(),
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &bytes.Buffer{},
+		Args:     []string{"echo"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "loading config")
+}
+
+// TestJSONLogFormat isn't parallel, since it replaces the default logger.
+func TestJSONLogFormat(t *testing.T) {
+	dataDirForThisTest := testDataListDir
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	err := Run(RunParams{
+		BaseCtx:   t.Context(),
+		Dir:       dataDirForThisTest,
+		Stdout:    stdout,
+		Stderr:    stderr,
+		List:      true,
+		Debug:     true,
+		LogFormat: LogFormatJSON,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	require.NotEmpty(t, lines)
+	initialized := false
+	for _, line := range lines {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record), "not a JSON record: %s", line)
+		assert.Contains(t, record, "level")
+		assert.Contains(t, record, "msg")
+		if record["level"] == "DEBUG" && record["msg"] == "logger initialized" {
+			initialized = true
+		}
+	}
+	assert.True(t, initialized, "no logger initialized record in: %s", stderr.String())
+}
+
+func TestUnknownLogFormat(t *testing.T) {
+	err := Run(RunParams{
+		BaseCtx:   t.Context(),
+		Dir:       testDataListDir,
+		Stdout:    &bytes.Buffer{},
+		Stderr:    &bytes.Buffer{},
+		List:      true,
+		LogFormat: "xml",
+	})
+	require.EqualError(t, err, `unknown log format "xml": must be "pretty" or "json"`)
+}
+
 // Test the timeout option.
 func TestTimeout(t *testing.T) {
 	t.Parallel()
@@ -1615,6 +2612,51 @@ func TestTimeout(t *testing.T) {
 	assert.Contains(t, stderr.String(), expected)
 }
 
+// Test that each target gets a fresh deadline with the per-target timeout, and
+// that the whole-run timeout still applies.
+func TestPerTargetTimeout(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "context")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	testCases := []struct {
+		name             string
+		timeout          time.Duration
+		perTargetTimeout time.Duration
+		wantErr          bool
+	}{
+		// each target takes 300ms, so the two together take longer than -t.
+		{name: "whole run", timeout: 500 * time.Millisecond, wantErr: true},
+		{name: "per target", perTargetTimeout: 500 * time.Millisecond},
+		{name: "per target too short", perTargetTimeout: 100 * time.Millisecond, wantErr: true},
+		{name: "tighter wins", timeout: 500 * time.Millisecond, perTargetTimeout: 2 * time.Second, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			stdout := &bytes.Buffer{}
+
+			err := Run(RunParams{
+				BaseCtx:          t.Context(),
+				Dir:              dataDirForThisTest,
+				Stdout:           stdout,
+				Stderr:           stderr,
+				Args:             []string{"sleep", "sleep"},
+				Timeout:          tc.timeout,
+				PerTargetTimeout: tc.perTargetTimeout,
+			})
+			if !tc.wantErr {
+				require.NoError(t, err, "stderr was: %s", stderr.String())
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, stderr.String(), "Error: context deadline exceeded\n")
+		})
+	}
+}
+
 func TestInfoTarget(t *testing.T) {
 	dataDirForThisTest := testDataDir
 
@@ -1745,6 +2787,71 @@ func TestInvalidAlias(t *testing.T) {
 	assert.Contains(t, stderr.String(), expected)
 }
 
+func TestNamespacedAlias(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "namespaced_alias")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	ctx := t.Context()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: ctx,
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"build:a"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "built all\n", stdout.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	runParams.Args = []string{"Build:A"}
+	err = Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "built all\n", stdout.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	runParams.Args = []string{"build:all"}
+	runParams.Info = true
+	err = Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Contains(t, stdout.String(), "Aliases: build:a\n")
+}
+
+func TestNamespacedAliasConflict(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "namespaced_alias_conflict")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	ctx := t.Context()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: ctx,
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"build:a"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `alias "build:a" duplicates existing target(s): <current>.Build.A`)
+}
+
 func TestRunCompiledPrintsError(t *testing.T) {
 	// Not parallel - this test modifies the global slog handler and would
 	// cause race conditions with other tests that also use/modify slog.
@@ -1827,6 +2934,41 @@ func TestCompiledFlags(t *testing.T) {
 	require.Error(t, err)
 	want = "context deadline exceeded"
 	assert.Contains(t, err.Error(), want)
+
+	// flags may also follow the target name
+	err = run(stdout, stderr, name, "deploy", "-i")
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Contains(t, stdout.String(), "This is the synopsis for Deploy.")
+
+	err = run(stdout, stderr, name, "testverbose", "-v")
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Contains(t, stderr.String(), hiExclam)
+
+	err = run(stdout, stderr, name, "printverboseflag", "--verbose")
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "st.Verbose()==true", stdout.String())
+
+	err = run(stdout, stderr, name, "sleep", "-t", "1ms")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context deadline exceeded")
+
+	err = run(stdout, stderr, name, "sleep", "--timeout=1ms")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context deadline exceeded")
+
+	// but not after "--"
+	err = run(stdout, stderr, name, "testverbose", "--", "-v")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Unknown target specified: "-v"`)
+
+	// arguments after "--" are passed to the target verbatim
+	err = run(stdout, stderr, name, "echoArg", "--", "-v")
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "-v\n", stdout.String())
+
+	err = run(stdout, stderr, name, "-v", "echoArg", "--", "--timeout=1ms")
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "--timeout=1ms\n", stdout.String())
 }
 
 func TestCompiledEnvironmentVars(t *testing.T) {
@@ -2075,6 +3217,62 @@ func TestSignals(t *testing.T) {
 	assert.Contains(t, stderr.String(), want)
 }
 
+// TestSigtermToParent sends SIGTERM to the stave process rather than to the
+// compiled stavefile, as CI systems do.
+func TestSigtermToParent(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.CommandContext(t.Context(), os.Args[0])
+	cmd.Env = append(os.Environ(), testSignalsTargetEnv+"=readyThenExitsAfterCancel")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdoutPipe, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	// Wait until the stavefile is running before signalling stave.
+	stdout := &strings.Builder{}
+	scanner := bufio.NewScanner(stdoutPipe)
+	for scanner.Scan() {
+		stdout.WriteString(scanner.Text() + "\n")
+		if scanner.Text() == "ready" {
+			require.NoError(t, syscall.Kill(cmd.Process.Pid, syscall.SIGTERM))
+			break
+		}
+	}
+	rest, err := io.ReadAll(stdoutPipe)
+	require.NoError(t, err)
+	stdout.Write(rest)
+
+	// stave must wait for the stavefile's cleanup, and exit with its status.
+	require.NoError(t, cmd.Wait(), "stdout was: %s\nstderr was: %s", stdout, stderr)
+	assert.Contains(t, stdout.String(), "ready\nexiting...done\n")
+	assert.Contains(t, stderr.String(), "cancelling stave targets, waiting up to 5 seconds for cleanup...\n")
+}
+
+func TestCleanupGrace(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:      t.Context(),
+		Dir:          filepath.Join(testDataDir, "signals"),
+		Stdout:       stdout,
+		Stderr:       stderr,
+		Timeout:      500 * time.Millisecond,
+		CleanupGrace: 8 * time.Second,
+		Args:         []string{"slowCleanup"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, stdout.String(), "slow cleanup done\n")
+	assert.Contains(t, stderr.String(), "cancelling stave targets, waiting up to 8 seconds for cleanup...\n")
+	assert.NotContains(t, stderr.String(), "cleanup timeout exceeded")
+}
+
 func TestCompiledDeterministic(t *testing.T) {
 	dir := testDataCompiled
 	compileDir, err := os.MkdirTemp(dir, "")
@@ -2255,6 +3453,54 @@ func TestNamespaceDep(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+func TestTargetsNamedLikeMainfileIdentifiers(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "reservednames")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stderr:  stderr,
+		Stdout:  stdout,
+		Args:    []string{"logger", "ctx"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "ran logger\nran ctx\n", stdout.String())
+}
+
+func TestReservedNameInStavefile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(`//go:build stave
+
+package main
+
+// Build builds.
+func Build() {}
+
+func len(s string) int { return 0 }
+`), 0o644))
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dir,
+		Stderr:  stderr,
+		Stdout:  &bytes.Buffer{},
+		Args:    []string{"build"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stavefile.go:8:6: len can't be declared in a stavefile")
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindParse, stErr.Kind)
+}
+
 func TestNamespace(t *testing.T) {
 	t.Parallel()
 	dataDirForThisTest := testDataNamespaces
@@ -2308,6 +3554,32 @@ func TestNamespaceDefault(t *testing.T) {
 	assert.Equal(t, expected, stdout.String())
 }
 
+func TestBuildTemplateDataUsesRegexp(t *testing.T) {
+	info := &parse.PkgInfo{
+		Funcs: []*parse.Function{{Name: "Build", Args: []parse.Arg{{Name: "n", Type: "int", Min: "1"}}}},
+	}
+	assert.False(t, buildTemplateData("stave", info).UsesRegexp)
+
+	info.Imports = []*parse.Import{{
+		Name: "ext",
+		Info: parse.PkgInfo{Funcs: []*parse.Function{
+			{Name: "Deploy", Args: []parse.Arg{{Name: "env", Type: "string", Pattern: "^dev$"}}},
+		}},
+	}}
+	assert.True(t, buildTemplateData("stave", info).UsesRegexp)
+	assert.Len(t, info.Funcs, 1)
+}
+
+func TestBuildTemplateDataUsesContainers(t *testing.T) {
+	info := &parse.PkgInfo{
+		Funcs: []*parse.Function{{Name: "Build"}},
+	}
+	assert.False(t, buildTemplateData("stave", info).UsesContainers)
+
+	info.Funcs = append(info.Funcs, &parse.Function{Name: "Lint", Container: "golangci/golangci-lint:v2"})
+	assert.True(t, buildTemplateData("stave", info).UsesContainers)
+}
+
 func TestAliasToImport(_ *testing.T) {
 }
 
@@ -2333,7 +3605,7 @@ func TestWrongDependency(t *testing.T) {
 	err := Run(runParams)
 	require.Error(t, err)
 
-	expected := "Error: argument 0 (complex128), is not a supported argument type\n"
+	expected := "Error: panic: argument 0 (complex128), is not a supported argument type\n"
 	assert.Contains(t, stderr.String(), expected)
 }
 
@@ -2418,3 +3690,25 @@ func fileData(file string) (exeType, archSize, error) {
 	}
 	return -1, -1, errors.New("unrecognized executable format")
 }
+
+func TestSetupEnvWatchDirs(t *testing.T) {
+	t.Parallel()
+	workDir := t.TempDir()
+	src := filepath.Join(workDir, "src")
+	require.NoError(t, os.Mkdir(src, 0o755))
+	other := t.TempDir()
+
+	theEnv, err := setupEnv(RunParams{WorkDir: workDir, WatchDirs: []string{"src", other}}, config.DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, src+string(os.PathListSeparator)+other, theEnv[st.WatchDirsEnv])
+
+	theEnv, err = setupEnv(RunParams{WorkDir: workDir}, config.DefaultConfig())
+	require.NoError(t, err)
+	assert.NotContains(t, theEnv, st.WatchDirsEnv)
+
+	_, err = setupEnv(RunParams{WorkDir: workDir, WatchDirs: []string{"missing"}}, config.DefaultConfig())
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindUsage, stErr.Kind)
+	assert.Contains(t, err.Error(), "--watch-dir")
+}
@@ -0,0 +1,87 @@
+package stave
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yaklabco/stave/internal/parse"
+	"golang.org/x/mod/modfile"
+)
+
+// replaceModFile writes a temporary copy of the main module's go.mod (and
+// go.sum) that requires and replaces every relatively stave:import'ed package
+// living in a module of its own, for use with go build -modfile. It returns an
+// empty path if there are no such imports.
+func replaceModFile(stavePath string, imports parse.Imports) (string, func(), error) {
+	noop := func() {}
+	replaced := make(map[string]string)
+	for _, imp := range imports {
+		if imp.ModuleDir != "" {
+			replaced[imp.ModulePath] = imp.ModuleDir
+		}
+	}
+	if len(replaced) == 0 {
+		return "", noop, nil
+	}
+
+	absStavePath, err := filepath.Abs(stavePath)
+	if err != nil {
+		return "", noop, fmt.Errorf("resolving stavefiles dir: %w", err)
+	}
+	modDir, _, err := parse.FindModuleRoot(absStavePath)
+	if err != nil {
+		return "", noop, err
+	}
+	if modDir == "" {
+		return "", noop, fmt.Errorf("no go.mod found for stavefiles in %s", absStavePath)
+	}
+	goModPath := filepath.Join(modDir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return "", noop, fmt.Errorf("reading %s: %w", goModPath, err)
+	}
+	modFile, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return "", noop, fmt.Errorf("parsing %s: %w", goModPath, err)
+	}
+	for modPath, dir := range replaced {
+		if err := modFile.AddRequire(modPath, "v0.0.0"); err != nil {
+			return "", noop, fmt.Errorf("adding require for %s: %w", modPath, err)
+		}
+		if err := modFile.AddReplace(modPath, "", dir, ""); err != nil {
+			return "", noop, fmt.Errorf("adding replace for %s: %w", modPath, err)
+		}
+	}
+	modFile.Cleanup()
+	out, err := modFile.Format()
+	if err != nil {
+		return "", noop, fmt.Errorf("formatting synthesized go.mod: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "stave-modfile-")
+	if err != nil {
+		return "", noop, fmt.Errorf("creating dir for synthesized go.mod: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+	tmpMod := filepath.Join(tmpDir, "go.mod")
+	if err := os.WriteFile(tmpMod, out, 0o644); err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("writing synthesized go.mod: %w", err)
+	}
+	// go build -modfile looks for go.sum next to the given go.mod.
+	sum, err := os.ReadFile(filepath.Join(modDir, "go.sum"))
+	switch {
+	case err == nil:
+		if err := os.WriteFile(filepath.Join(tmpDir, "go.sum"), sum, 0o644); err != nil {
+			cleanup()
+			return "", noop, fmt.Errorf("writing synthesized go.sum: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		cleanup()
+		return "", noop, fmt.Errorf("reading go.sum: %w", err)
+	}
+	return tmpMod, cleanup, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/internal/parse"
 )
 
 func TestNamespaceFallback(t *testing.T) {
@@ -87,3 +88,82 @@ func TestNamespaceFallback(t *testing.T) {
 		assert.Equal(t, "lib:NS:Default\n", stdout.String())
 	})
 }
+
+func TestNamespaceInfo(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := testDataNamespaces
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	const expected = "NS has the namespace targets.\n\n" +
+		"Targets in namespace ns:\n\n" +
+		"\tstave ns:bare     does nothing.\n" +
+		"\tstave ns:barectx\n" +
+		"\tstave ns:ctxerr\n" +
+		"\tstave ns:error    says hi.\n\n"
+
+	for _, tt := range []struct {
+		name   string
+		params RunParams
+	}{
+		{
+			name:   "stave",
+			params: RunParams{Info: true, Args: []string{"NS"}},
+		},
+		{
+			name:   "compiled",
+			params: RunParams{Args: []string{"-i", "ns"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			stdout := &bytes.Buffer{}
+			runParams := tt.params
+			runParams.BaseCtx = t.Context()
+			runParams.Dir = dataDirForThisTest
+			runParams.Stderr = stderr
+			runParams.Stdout = stdout
+
+			err := Run(runParams)
+			require.NoError(t, err, "stderr was: %s", stderr.String())
+			assert.Equal(t, expected, stdout.String())
+		})
+	}
+}
+
+func TestNamespaceInfoPrefersTarget(t *testing.T) {
+	t.Parallel()
+	info := &parse.PkgInfo{
+		Funcs: []*parse.Function{
+			{Name: "Deploy", Synopsis: "deploys everything."},
+			{Name: "Prod", Receiver: "Deploy", Synopsis: "deploys to prod."},
+			{Name: "Lint", Receiver: "Check", Args: []parse.Arg{{Name: "dir", Type: "string"}}},
+		},
+		Namespaces: []parse.Namespace{
+			{Name: "Deploy", Comment: "Deploy has the deploy targets."},
+			{Name: "Check"},
+		},
+	}
+	info.Imports = []*parse.Import{{
+		Name: "ext",
+		Info: parse.PkgInfo{
+			Funcs:      []*parse.Function{{PkgAlias: "ext", Name: "Up", Receiver: "Infra", Synopsis: "brings it up."}},
+			Namespaces: []parse.Namespace{{Name: "Infra", Comment: "Infra manages servers."}},
+		},
+	}}
+
+	texts, notes := namespaceInfo("stave", info)
+	assert.Equal(t, map[string]string{
+		"check":     "Targets in namespace check:\n\n\tstave check:lint <dir>\n\n",
+		"ext:infra": "Infra manages servers.\n\nTargets in namespace ext:infra:\n\n\tstave ext:infra:up  brings it up.\n\n",
+	}, texts)
+	assert.Equal(t, map[string]string{
+		"deploy": "\"deploy\" is also a namespace, with the targets deploy:prod.\n\n",
+	}, notes)
+
+	var out bytes.Buffer
+	data := buildTemplateData("stave", info)
+	require.NoError(t, renderTargetInfo(&out, "deploy", data))
+	assert.Equal(t, "Usage:\n\n\tstave deploy\n\n\"deploy\" is also a namespace, with the targets deploy:prod.\n\n", out.String())
+}
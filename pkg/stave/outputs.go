@@ -0,0 +1,287 @@
+package stave
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/internal/ish"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+const (
+	// outputsDir is where declared target outputs are collected, relative to
+	// the working directory.
+	outputsDir         = ".stave/outputs"
+	outputsLatestLink  = "latest"
+	outputsExternalDir = "external" // holds outputs from outside the working directory
+	defaultOutputsKeep = 5
+)
+
+// newOutputsManifest creates the empty file that the stavefile appends
+// st.Output records to.
+func newOutputsManifest() (string, func(), error) {
+	f, err := os.CreateTemp("", "stave-outputs-*.jsonl")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("creating outputs manifest: %w", err)
+	}
+	name := f.Name()
+	cleanup := func() { _ = os.Remove(name) }
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("creating outputs manifest: %w", err)
+	}
+	return name, cleanup, nil
+}
+
+// outputsKeep returns how many sets of outputs to keep per target.
+func outputsKeep(params RunParams) int {
+	if params.OutputsKeep > 0 {
+		return params.OutputsKeep
+	}
+	if keep, err := strconv.Atoi(os.Getenv(st.OutputsKeepEnv)); err == nil && keep > 0 {
+		return keep
+	}
+	return defaultOutputsKeep
+}
+
+// collectOutputs copies the files recorded in the manifest into a
+// content-addressed directory per target, and reports where they went.
+func collectOutputs(params RunParams, manifest string) error {
+	if params.DryRun {
+		return nil
+	}
+	targets, byTarget, err := readOutputsManifest(manifest)
+	if err != nil {
+		return err
+	}
+	workDir, err := filepath.Abs(cmp.Or(params.WorkDir, params.Dir))
+	if err != nil {
+		return fmt.Errorf("resolving working directory: %w", err)
+	}
+	root := filepath.Join(workDir, filepath.FromSlash(outputsDir))
+	for _, target := range targets {
+		dir, err := storeTargetOutputs(root, workDir, target, byTarget[target], outputsKeep(params))
+		if err != nil {
+			return fmt.Errorf("storing outputs of %s: %w", target, err)
+		}
+		if dir == "" {
+			continue
+		}
+		rel, relErr := filepath.Rel(workDir, dir)
+		if relErr != nil {
+			rel = dir
+		}
+		_, _ = fmt.Fprintf(params.Stderr, "Outputs of %s stored in %s\n", target, rel)
+	}
+	return nil
+}
+
+// readOutputsManifest returns the targets that declared outputs, in the order
+// they first did so, and the deduplicated paths declared by each.
+func readOutputsManifest(manifest string) ([]string, map[string][]string, error) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading outputs manifest: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var targets []string
+	byTarget := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec st.OutputRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, nil, fmt.Errorf("reading outputs manifest: %w", err)
+		}
+		if rec.Target == "" {
+			rec.Target = "default"
+		}
+		paths, seen := byTarget[rec.Target]
+		if !seen {
+			targets = append(targets, rec.Target)
+		}
+		if !slices.Contains(paths, rec.Path) {
+			byTarget[rec.Target] = append(paths, rec.Path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading outputs manifest: %w", err)
+	}
+	return targets, byTarget, nil
+}
+
+// storeTargetOutputs copies a target's declared outputs into
+// root/<target>/<hash>/, updates the latest link and prunes old sets. Files
+// keep their path relative to workDir; see outputName for files outside it.
+// It returns the directory the outputs were stored in, or "" if none of the
+// declared files exist.
+func storeTargetOutputs(root, workDir, target string, paths []string, keep int) (string, error) {
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			slog.Warn(
+				"declared output is missing, or is not a regular file",
+				slog.String(log.Target, target),
+				slog.String(log.Path, path),
+			)
+			continue
+		}
+		files[outputName(workDir, path)] = path
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	hash, err := hashOutputs(files)
+	if err != nil {
+		return "", err
+	}
+	targetDir := filepath.Join(root, strings.ReplaceAll(strings.ToLower(target), ":", "_"))
+	dest := filepath.Join(targetDir, hash)
+	if _, err := os.Stat(dest); errors.Is(err, os.ErrNotExist) {
+		if err := copyOutputs(dest, files); err != nil {
+			_ = os.RemoveAll(dest)
+			return "", err
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("checking %s: %w", dest, err)
+	}
+	// Unchanged outputs reuse the existing set; bump its modtime so pruning
+	// treats it as recent.
+	if err := touch(dest); err != nil {
+		return "", err
+	}
+
+	latest := filepath.Join(targetDir, outputsLatestLink)
+	_ = os.Remove(latest)
+	if err := os.Symlink(hash, latest); err != nil {
+		slog.Warn("could not link latest outputs", slog.String(log.Path, latest), slog.Any(log.Error, err))
+	}
+
+	if err := pruneOutputs(targetDir, keep); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// outputName returns the slash-separated name an output is stored under: its
+// path relative to workDir, or for a file outside workDir,
+// external/<hash>/<base name>, where the hash is of its cleaned absolute
+// directory, so that files with the same base name don't overwrite each other.
+func outputName(workDir, path string) string {
+	rel, err := filepath.Rel(workDir, path)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(dir)))
+	return outputsExternalDir + "/" + hex.EncodeToString(sum[:])[:hashLengthLimit] + "/" + filepath.Base(path)
+}
+
+// hashOutputs hashes the relative names and contents of files.
+func hashOutputs(files map[string]string) (string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	hasher := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(hasher, "%s\x00", name)
+		f, err := os.Open(files[name])
+		if err != nil {
+			return "", fmt.Errorf("hashing output %s: %w", name, err)
+		}
+		_, err = io.Copy(hasher, f)
+		_ = f.Close()
+		if err != nil {
+			return "", fmt.Errorf("hashing output %s: %w", name, err)
+		}
+		_, _ = hasher.Write([]byte{0})
+	}
+	return hex.EncodeToString(hasher.Sum(nil))[:hashLengthLimit], nil
+}
+
+func copyOutputs(dest string, files map[string]string) error {
+	for name, src := range files {
+		dst := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("creating outputs dir: %w", err)
+		}
+		if err := ish.Copy(dst, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func touch(path string) error {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return fmt.Errorf("updating modtime of %s: %w", path, err)
+	}
+	return nil
+}
+
+// pruneOutputs removes all but the keep most recently used output sets in
+// targetDir.
+func pruneOutputs(targetDir string, keep int) error {
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", targetDir, err)
+	}
+	type outputSet struct {
+		name    string
+		modTime int64
+	}
+	var sets []outputSet
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", targetDir, err)
+		}
+		sets = append(sets, outputSet{name: entry.Name(), modTime: info.ModTime().UnixNano()})
+	}
+	if len(sets) <= keep {
+		return nil
+	}
+	slices.SortFunc(sets, func(a, b outputSet) int {
+		switch {
+		case a.modTime > b.modTime:
+			return -1
+		case a.modTime < b.modTime:
+			return 1
+		default:
+			return strings.Compare(a.name, b.name)
+		}
+	})
+	for _, set := range sets[keep:] {
+		slog.Debug("pruning old outputs", slog.String(log.Path, filepath.Join(targetDir, set.name)))
+		if err := os.RemoveAll(filepath.Join(targetDir, set.name)); err != nil {
+			return fmt.Errorf("pruning old outputs: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputsCollected(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "outputs")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	workDir := t.TempDir()
+	targetDir := filepath.Join(workDir, ".stave", "outputs", "build")
+
+	for _, content := range []string{"one", "two", "three"} {
+		stderr := &bytes.Buffer{}
+		runParams := RunParams{
+			BaseCtx:     t.Context(),
+			Dir:         dataDirForThisTest,
+			WorkDir:     workDir,
+			Stdout:      &bytes.Buffer{},
+			Stderr:      stderr,
+			OutputsKeep: 2,
+			Args:        []string{"build", content},
+		}
+		require.NoError(t, Run(runParams), "stderr was: %s", stderr.String())
+		assert.Contains(t, stderr.String(), "Outputs of Build stored in "+filepath.Join(".stave", "outputs", "build"))
+
+		got, err := os.ReadFile(filepath.Join(targetDir, "latest", "bin", "app"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(got))
+	}
+
+	// Only the two most recent sets survive, plus the latest link.
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestOutputsMissingWarns(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "outputs")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	workDir := t.TempDir()
+	stderr := &bytes.Buffer{}
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		WorkDir: workDir,
+		Stdout:  &bytes.Buffer{},
+		Stderr:  stderr,
+		Args:    []string{"missing"},
+	}
+	require.NoError(t, Run(runParams), "stderr was: %s", stderr.String())
+	assert.Contains(t, stderr.String(), "declared output is missing")
+	assert.Contains(t, stderr.String(), filepath.Join("bin", "missing"))
+	assert.NoDirExists(t, filepath.Join(workDir, ".stave", "outputs", "missing"))
+}
+
+func TestOutputNameOutsideWorkDir(t *testing.T) {
+	root := t.TempDir()
+	workDir := filepath.Join(root, "work")
+	inside := outputName(workDir, filepath.Join(workDir, "bin", "app"))
+	assert.Equal(t, "bin/app", inside)
+
+	first := outputName(workDir, filepath.Join(root, "a", "out.json"))
+	second := outputName(workDir, filepath.Join(root, "b", "out.json"))
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, outputName(workDir, filepath.Join(root, "a", ".", "out.json")))
+	assert.Regexp(t, `^external/[0-9a-f]+/out\.json$`, first)
+}
+
+func TestStoreTargetOutputsSameBaseName(t *testing.T) {
+	root := t.TempDir()
+	workDir := filepath.Join(root, "work")
+	var paths []string
+	for _, dir := range []string{"a", "b"} {
+		path := filepath.Join(root, dir, "out.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(dir), 0o644))
+		paths = append(paths, path)
+	}
+
+	dest, err := storeTargetOutputs(filepath.Join(workDir, ".stave", "outputs"), workDir, "build", paths, 5)
+	require.NoError(t, err)
+	for i, dir := range []string{"a", "b"} {
+		got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(outputName(workDir, paths[i]))))
+		require.NoError(t, err)
+		assert.Equal(t, dir, string(got))
+	}
+
+	// changing either file gives a new set
+	require.NoError(t, os.WriteFile(paths[1], []byte("changed"), 0o644))
+	changed, err := storeTargetOutputs(filepath.Join(workDir, ".stave", "outputs"), workDir, "build", paths, 5)
+	require.NoError(t, err)
+	assert.NotEqual(t, dest, changed)
+}
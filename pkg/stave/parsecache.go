@@ -0,0 +1,214 @@
+package stave
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yaklabco/stave/cmd/stave/version"
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// parseCacheFormat is bumped when what the parse cache holds changes, so that
+// older entries are ignored rather than misread.
+const parseCacheFormat = 1
+
+// parseCacheEntry is what the parse cache holds for a stavefiles dir: the
+// parser's result, and the hashes that say whether it's still fresh.
+type parseCacheEntry struct {
+	Format int `json:"format"`
+	// Key hashes the stavefiles and the settings that change how they parse.
+	Key string `json:"key"`
+	// Deps hashes the imported packages' files and the go.mod, go.sum and
+	// go.work files that picked them, which go list would otherwise look at.
+	Deps string         `json:"deps"`
+	Info *parse.PkgInfo `json:"info"`
+}
+
+// parseCacheFile returns the path of the parse cache entry for the
+// stavefiles in dir. There is one per dir, which each parse replaces.
+func parseCacheFile(cacheDir, dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+	sum := sha256.Sum256([]byte(absDir))
+	return filepath.Join(cacheDir, "parse", hex.EncodeToString(sum[:])+".json")
+}
+
+// cachedPrimaryPackage is parse.PrimaryPackage for files, the stavefiles in
+// params.Dir, from the parse cache if it's fresh, which skips the go list
+// commands that resolve stave:import packages. Otherwise it parses them and
+// caches the result. The cached PkgInfo has no Files or DocPkg, so it is
+// only fit for listing targets and linting. A missing, stale or corrupt
+// entry is parsed afresh, as is every one with Force.
+func cachedPrimaryPackage(ctx context.Context, params RunParams, cfg *config.Config, files []string) (*parse.PkgInfo, error) {
+	fnames := make([]string, 0, len(files))
+	for _, f := range files {
+		fnames = append(fnames, filepath.Base(f))
+	}
+	parseFiles := func() (*parse.PkgInfo, error) {
+		return parse.PrimaryPackage(ctx, params.GoCmd, params.Dir, fnames, params.Multiline, cfg.ImportAliases, params.GoEnv)
+	}
+	if params.CacheDir == "" {
+		return parseFiles()
+	}
+
+	key, err := parseCacheKey(ctx, params, cfg, files)
+	if err != nil {
+		slog.Debug("not using the parse cache", slog.Any(log.Error, err))
+		return parseFiles()
+	}
+	path := parseCacheFile(params.CacheDir, params.Dir)
+	if !params.Force {
+		if info := readParseCache(path, key, params.Dir); info != nil {
+			slog.Debug("using cached parse of stavefiles", slog.String(log.Path, path))
+			return info, nil
+		}
+	}
+
+	info, err := parseFiles()
+	if err != nil {
+		return nil, err
+	}
+	writeParseCache(path, key, params.Dir, info)
+	return info, nil
+}
+
+// parseCacheKey hashes files, and everything besides the imported packages
+// that changes what parsing them gives: the stave build, the config settings
+// that change the mainfile, and the flags passed on to go list.
+func parseCacheKey(ctx context.Context, params RunParams, cfg *config.Config, files []string) (string, error) {
+	parts := []string{
+		strconv.Itoa(parseCacheFormat),
+		version.OverallVersionString(ctx),
+		cfg.BuildHash(),
+		params.GoCmd,
+		strconv.FormatBool(params.Multiline),
+	}
+	// a development build of stave keeps its version, so its binary tells
+	// parser changes apart.
+	if exe, err := os.Executable(); err == nil {
+		if stat, err := os.Stat(exe); err == nil {
+			parts = append(parts, exe, stat.ModTime().String(), strconv.FormatInt(stat.Size(), 10))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(params.GoEnv)) {
+		parts = append(parts, key+"="+params.GoEnv[key])
+	}
+	for _, file := range files {
+		h, err := hashFile(file)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, filepath.Base(file), h)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// parseCacheDeps hashes the .go files in the dirs of info's imports, which
+// go list found them in, and the module files of dir that decided which
+// versions it found.
+func parseCacheDeps(dir string, info *parse.PkgInfo) (string, error) {
+	var paths []string
+	if absDir, err := filepath.Abs(dir); err == nil {
+		if modDir, _, err := parse.FindModuleRoot(absDir); err == nil {
+			paths = append(paths, filepath.Join(modDir, "go.mod"), filepath.Join(modDir, "go.sum"))
+			dir = modDir
+		}
+		for cur := dir; ; cur = filepath.Dir(cur) {
+			if _, err := os.Stat(filepath.Join(cur, "go.work")); err == nil {
+				paths = append(paths, filepath.Join(cur, "go.work"), filepath.Join(cur, "go.work.sum"))
+				break
+			}
+			if filepath.Dir(cur) == cur {
+				break
+			}
+		}
+	}
+	for _, imp := range info.Imports {
+		if imp.Dir == "" {
+			return "", fmt.Errorf("import %s has no dir", imp.Path)
+		}
+		goFiles, err := filepath.Glob(filepath.Join(imp.Dir, "*.go"))
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, goFiles...)
+	}
+
+	parts := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		h, err := hashFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			h = "missing"
+		case err != nil:
+			return "", err
+		}
+		parts = append(parts, path, h)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readParseCache returns the PkgInfo cached at path for the stavefiles in dir
+// if it was cached with key and its imports haven't changed since, or else
+// nil.
+func readParseCache(path, key, dir string) *parse.PkgInfo {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Debug("could not read parse cache", slog.String(log.Path, path), slog.Any(log.Error, err))
+		}
+		return nil
+	}
+	var entry parseCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Info == nil {
+		slog.Debug("ignoring corrupt parse cache", slog.String(log.Path, path), slog.Any(log.Error, err))
+		return nil
+	}
+	if entry.Format != parseCacheFormat || entry.Key != key {
+		slog.Debug("parse cache is stale", slog.String(log.Path, path))
+		return nil
+	}
+	deps, err := parseCacheDeps(dir, entry.Info)
+	if err != nil || deps != entry.Deps {
+		slog.Debug("parse cache is stale, imports changed", slog.String(log.Path, path), slog.Any(log.Error, err))
+		return nil
+	}
+	return entry.Info
+}
+
+// writeParseCache caches info at path, for the stavefiles in dir hashed as
+// key. The cache is optional, so failures are only logged.
+func writeParseCache(path, key, dir string, info *parse.PkgInfo) {
+	deps, err := parseCacheDeps(dir, info)
+	if err == nil {
+		var data []byte
+		data, err = json.Marshal(parseCacheEntry{Format: parseCacheFormat, Key: key, Deps: deps, Info: info})
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(path), 0o755)
+		}
+		if err == nil {
+			err = os.WriteFile(path, data, 0o644)
+		}
+	}
+	if err != nil {
+		slog.Debug("could not write parse cache", slog.String(log.Path, path), slog.Any(log.Error, err))
+	}
+}
@@ -0,0 +1,138 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCache(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("go.mod", "module example.com/proj\n\ngo 1.25\n")
+	write("stavefile.go", "//go:build stave\n\npackage main\n\n//stave:import ./tools tools\n\n// Build builds.\nfunc Build() {}\n")
+	write("tools/tools.go", "package tools\n\n// Lint lints.\nfunc Lint() {}\n")
+
+	list := func(force bool) string {
+		t.Helper()
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:  t.Context(),
+			Dir:      dir,
+			CacheDir: cacheDir,
+			Stdout:   stdout,
+			Stderr:   stderr,
+			List:     true,
+			Force:    force,
+		})
+		require.NoError(t, err, "stderr was: %s", stderr.String())
+		return stdout.String()
+	}
+
+	out := list(false)
+	assert.Contains(t, out, "build")
+	assert.Contains(t, out, "tools:lint")
+	path := parseCacheFile(cacheDir, dir)
+	require.FileExists(t, path)
+
+	// a fresh entry is used as it is.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry parseCacheEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	require.Len(t, entry.Info.Funcs, 1)
+	entry.Info.Funcs[0].Synopsis = "Comes from the cache."
+	data, err = json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	assert.Contains(t, list(false), "Comes from the cache.")
+	assert.NotContains(t, list(true), "Comes from the cache.", "--force parses afresh")
+
+	// changing an imported package makes the entry stale.
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	write("tools/vet.go", "package tools\n\n// Vet vets.\nfunc Vet() {}\n")
+	out = list(false)
+	assert.NotContains(t, out, "Comes from the cache.")
+	assert.Contains(t, out, "tools:vet")
+
+	// so does changing a stavefile.
+	write("stavefile.go", "//go:build stave\n\npackage main\n\n//stave:import ./tools tools\n\n// Build builds.\nfunc Build() {}\n\n// Test tests.\nfunc Test() {}\n")
+	assert.Contains(t, list(false), "test")
+
+	// a corrupt entry is ignored.
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+	assert.Contains(t, list(false), "tools:vet")
+}
+
+// listStaveImports lists the targets of testdata/staveimport, whose
+// stave:import packages are found with go list, caching the parse in
+// cacheDir unless force is set.
+func listStaveImports(tb testing.TB, cacheDir string, force bool) {
+	tb.Helper()
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  tb.Context(),
+		Dir:      testDataStaveImportDir,
+		CacheDir: cacheDir,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   stderr,
+		List:     true,
+		Force:    force,
+	})
+	require.NoError(tb, err, "stderr was: %s", stderr.String())
+}
+
+func TestParseCacheSpeedsUpList(t *testing.T) {
+	t.Parallel()
+	mu := mutexByDir(testDataStaveImportDir)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	cacheDir := t.TempDir()
+	fastest := func(force bool) time.Duration {
+		best := time.Duration(1<<63 - 1)
+		for range 3 {
+			start := time.Now()
+			listStaveImports(t, cacheDir, force)
+			best = min(best, time.Since(start))
+		}
+		return best
+	}
+	parsed := fastest(true)
+	cached := fastest(false)
+	t.Logf("parsed: %s, cached: %s", parsed, cached)
+	assert.Less(t, cached, parsed)
+}
+
+func BenchmarkListParseCache(b *testing.B) {
+	mu := mutexByDir(testDataStaveImportDir)
+	mu.Lock()
+	b.Cleanup(mu.Unlock)
+
+	cacheDir := b.TempDir()
+	b.Run("parsed", func(b *testing.B) {
+		for b.Loop() {
+			listStaveImports(b, cacheDir, true)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		listStaveImports(b, cacheDir, false)
+		for b.Loop() {
+			listStaveImports(b, cacheDir, false)
+		}
+	})
+}
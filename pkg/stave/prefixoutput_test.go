@@ -0,0 +1,78 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// runPrefixOutput runs args in testdata/prefixoutput, and returns stdout and
+// stderr. The tests that call it can't be parallel, as they set the
+// environment the compiled stavefile inherits.
+func runPrefixOutput(t *testing.T, verbose bool, args ...string) (string, string) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "prefixoutput")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Verbose: verbose,
+		Args:    args,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	return stdout.String(), stderr.String()
+}
+
+func TestPrefixOutput(t *testing.T) {
+	t.Setenv(st.PrefixOutputEnv, "1")
+	t.Setenv(st.EnableColorEnv, "")
+
+	stdout, stderr := runPrefixOutput(t, false, "lint", "test")
+	assert.Equal(t, "[lint] linting\n[lint] from lint\n[lint] partial\n[test] testing done\n", stdout)
+	assert.Equal(t, "[lint] lint warning\n[test] from test\n", stderr)
+}
+
+func TestPrefixOutputColor(t *testing.T) {
+	t.Setenv(st.PrefixOutputEnv, "1")
+	t.Setenv(st.EnableColorEnv, "1")
+	t.Setenv(st.TargetColorEnv, "green")
+	t.Setenv("TERM", "xterm-256color")
+
+	stdout, _ := runPrefixOutput(t, false, "lint")
+	assert.Contains(t, stdout, "[\x1b[32mlint\x1b[0m] linting\n")
+}
+
+func TestPrefixOutputVerbose(t *testing.T) {
+	t.Setenv(st.PrefixOutputEnv, "")
+	t.Setenv(st.EnableColorEnv, "")
+
+	stdout, stderr := runPrefixOutput(t, true, "lint", "test")
+	assert.Equal(t, "[lint] linting\n[lint] from lint\n[lint] partial\n[test] testing done\n", stdout)
+	assert.Contains(t, stderr, "[test] from test\n")
+	// stave's own messages aren't prefixed.
+	assert.Contains(t, stderr, "Running target: <Test>\n")
+
+	// -v only prefixes when more than one target runs.
+	stdout, _ = runPrefixOutput(t, true, "lint")
+	assert.Equal(t, "linting\nfrom lint\npartial", stdout)
+}
+
+func TestPrefixOutputOff(t *testing.T) {
+	t.Setenv(st.PrefixOutputEnv, "")
+	t.Setenv(st.EnableColorEnv, "")
+
+	stdout, stderr := runPrefixOutput(t, false, "lint", "test")
+	assert.Equal(t, "linting\nfrom lint\npartialtesting done\n", stdout)
+	assert.Equal(t, "lint warning\nfrom test\n", stderr)
+}
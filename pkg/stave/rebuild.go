@@ -0,0 +1,154 @@
+package stave
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// Reasons ensureCompiled gives for compiling the stavefiles.
+const (
+	rebuildForced   = "forced with --force"
+	rebuildGoCache  = "relying on go build cache"
+	rebuildNoBinary = "no compiled binary for these stavefiles"
+	rebuildVersions = "stave or go version changed"
+)
+
+// rebuildReason says why the stavefiles are being compiled: useCache is set if
+// the go build cache is relied on instead of a compiled binary, and statErr is
+// the result of looking for the compiled binary at exePath. If it's missing,
+// the files in hashFiles and the configHash build settings are compared with
+// those the newest compiled binary for the same dir was built from, to name
+// the ones that changed.
+func rebuildReason(params RunParams, useCache bool, statErr error, exePath string, hashFiles []string, configHash string) string {
+	switch {
+	case useCache:
+		return rebuildGoCache
+	case statErr == nil:
+		return rebuildForced
+	case !os.IsNotExist(statErr):
+		return "can't read compiled binary: " + statErr.Error()
+	}
+
+	previous, ok := previousManifest(params.CacheDir, params.Dir, exePath)
+	if !ok {
+		return rebuildNoBinary
+	}
+	return describeChanges(previous, buildManifest(params.Dir, hashFiles, configHash))
+}
+
+// logRebuildReason logs why the stavefiles are being compiled, as info with
+// -v and as debug otherwise, since with a go build cache it happens every run.
+func logRebuildReason(ctx context.Context, params RunParams, reason string) {
+	level := slog.LevelDebug
+	if params.Verbose {
+		level = slog.LevelInfo
+	}
+	slog.Log(ctx, level, "rebuilding", slog.String(log.Reason, reason))
+}
+
+// buildConfigKey is the manifest entry for the build settings in stave.yaml.
+const buildConfigKey = "stave.yaml build settings"
+
+// buildManifest returns the fileManifest of hashFiles, with configHash, the
+// hash of the build settings in stave.yaml, under buildConfigKey if set.
+func buildManifest(dir string, hashFiles []string, configHash string) map[string]string {
+	manifest := fileManifest(dir, hashFiles)
+	if configHash != "" {
+		manifest[buildConfigKey] = configHash
+	}
+	return manifest
+}
+
+// fileManifest returns the hashes of files, keyed by their paths relative to
+// dir. Files that can't be hashed are left out.
+func fileManifest(dir string, files []string) map[string]string {
+	manifest := make(map[string]string, len(files))
+	for _, file := range files {
+		hash, err := hashFile(file)
+		if err != nil {
+			slog.Debug("could not hash file for cache metadata", slog.String(log.Path, file), slog.Any(log.Error, err))
+			continue
+		}
+		manifest[manifestKey(dir, file)] = hash
+	}
+	return manifest
+}
+
+// manifestKey returns file relative to dir, or as is if it can't be.
+func manifestKey(dir, file string) string {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// previousManifest returns the file hashes recorded for the newest binary in
+// cacheDir, other than exePath, compiled from dir.
+func previousManifest(cacheDir, dir, exePath string) (map[string]string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+	entries, err := listCache(cacheDir)
+	if err != nil {
+		slog.Debug("could not list cache dir", slog.String(log.Path, cacheDir), slog.Any(log.Error, err))
+		return nil, false
+	}
+	for _, entry := range entries {
+		path := filepath.Join(cacheDir, entry.name)
+		if entry.project != absDir || path == exePath {
+			continue
+		}
+		if meta := readCacheMeta(path + cacheMetaSuffix); meta.Files != nil {
+			return meta.Files, true
+		}
+	}
+	return nil, false
+}
+
+// describeChanges says how the files in current differ from those in
+// previous, like "stavefile.go changed (hash mismatch)".
+func describeChanges(previous, current map[string]string) string {
+	var changed, added, removed []string
+	for file, hash := range current {
+		previousHash, ok := previous[file]
+		switch {
+		case !ok:
+			added = append(added, file)
+		case previousHash != hash:
+			changed = append(changed, file)
+		}
+	}
+	for file := range previous {
+		if _, ok := current[file]; !ok {
+			removed = append(removed, file)
+		}
+	}
+
+	var parts []string
+	if len(changed) > 0 {
+		parts = append(parts, joinSorted(changed)+" changed (hash mismatch)")
+	}
+	if len(added) > 0 {
+		parts = append(parts, joinSorted(added)+" added")
+	}
+	if len(removed) > 0 {
+		parts = append(parts, joinSorted(removed)+" removed")
+	}
+	if len(parts) == 0 {
+		return rebuildVersions
+	}
+	return strings.Join(parts, "; ")
+}
+
+func joinSorted(files []string) string {
+	slices.Sort(files)
+	return strings.Join(files, ", ")
+}
@@ -0,0 +1,123 @@
+package stave
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildReason(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+	stavefile := filepath.Join(projectDir, "stavefile.go")
+	require.NoError(t, os.WriteFile(stavefile, []byte("package main\n"), 0o644))
+	params := RunParams{Dir: projectDir, CacheDir: cacheDir}
+	exePath := filepath.Join(cacheDir, "bbbb")
+	hashFiles := []string{stavefile}
+
+	assert.Equal(t, rebuildGoCache, rebuildReason(params, true, nil, exePath, hashFiles, ""))
+	assert.Equal(t, rebuildForced, rebuildReason(params, false, nil, exePath, hashFiles, ""))
+	assert.Equal(t,
+		"can't read compiled binary: permission denied",
+		rebuildReason(params, false, fs.ErrPermission, exePath, hashFiles, ""),
+	)
+	assert.Equal(t, rebuildNoBinary, rebuildReason(params, false, fs.ErrNotExist, exePath, hashFiles, ""))
+
+	// a binary compiled from the same stavefile, so only the versions that
+	// go into the exe name can have changed.
+	previous := filepath.Join(cacheDir, "aaaa")
+	require.NoError(t, os.WriteFile(previous, []byte("binary"), 0o755))
+	writeCacheMeta(previous, projectDir, fileManifest(projectDir, hashFiles))
+	assert.Equal(t, rebuildVersions, rebuildReason(params, false, fs.ErrNotExist, exePath, hashFiles, ""))
+
+	require.NoError(t, os.WriteFile(stavefile, []byte("package main\n\nfunc Build() {}\n"), 0o644))
+	assert.Equal(t,
+		"stavefile.go changed (hash mismatch)",
+		rebuildReason(params, false, fs.ErrNotExist, exePath, hashFiles, ""),
+	)
+
+	writeCacheMeta(previous, projectDir, buildManifest(projectDir, hashFiles, "old"))
+	assert.Equal(t,
+		"stave.yaml build settings changed (hash mismatch)",
+		rebuildReason(params, false, fs.ErrNotExist, exePath, hashFiles, "new"),
+	)
+
+	// the binary being rebuilt isn't compared with itself.
+	assert.Equal(t, rebuildNoBinary, rebuildReason(params, false, fs.ErrNotExist, previous, hashFiles, ""))
+}
+
+func TestPreviousManifestNewestForDir(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+	otherDir := t.TempDir()
+
+	seed := func(name, dir string, files map[string]string, modTime time.Time) {
+		path := filepath.Join(cacheDir, name)
+		require.NoError(t, os.WriteFile(path, []byte("binary"), 0o755))
+		writeCacheMeta(path, dir, files)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	older := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	seed("aaaa", projectDir, map[string]string{"stavefile.go": "old"}, older)
+	seed("bbbb", projectDir, map[string]string{"stavefile.go": "new"}, older.Add(time.Hour))
+	seed("cccc", otherDir, map[string]string{"stavefile.go": "other"}, older.Add(2*time.Hour))
+	seed("dddd", projectDir, nil, older.Add(3*time.Hour)) // from before manifests were written
+
+	files, ok := previousManifest(cacheDir, projectDir, filepath.Join(cacheDir, "eeee"))
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"stavefile.go": "new"}, files)
+
+	_, ok = previousManifest(filepath.Join(cacheDir, "missing"), projectDir, "")
+	assert.False(t, ok)
+}
+
+func TestDescribeChanges(t *testing.T) {
+	t.Parallel()
+	previous := map[string]string{
+		"a.go":           "1",
+		"b.go":           "2",
+		"c.go":           "3",
+		"gone.go":        "4",
+		"../lib/util.go": "5",
+	}
+	current := map[string]string{
+		"a.go":           "1",
+		"b.go":           "20",
+		"c.go":           "30",
+		"new.go":         "6",
+		"../lib/util.go": "5",
+	}
+	assert.Equal(t,
+		"b.go, c.go changed (hash mismatch); new.go added; gone.go removed",
+		describeChanges(previous, current),
+	)
+	assert.Equal(t, rebuildVersions, describeChanges(previous, previous))
+}
+
+func TestFileManifest(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	libDir := t.TempDir()
+	stavefile := filepath.Join(dir, "stavefile.go")
+	lib := filepath.Join(libDir, "lib.go")
+	require.NoError(t, os.WriteFile(stavefile, []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(lib, []byte("package lib\n"), 0o644))
+
+	manifest := fileManifest(dir, []string{stavefile, lib, filepath.Join(dir, "missing.go")})
+	libKey, err := filepath.Rel(dir, lib)
+	require.NoError(t, err)
+	assert.Len(t, manifest, 2)
+	assert.Contains(t, manifest, "stavefile.go")
+	assert.Contains(t, manifest, filepath.ToSlash(libKey))
+
+	stavefileHash, err := hashFile(stavefile)
+	require.NoError(t, err)
+	assert.Equal(t, stavefileHash, manifest["stavefile.go"])
+}
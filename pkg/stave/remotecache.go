@@ -0,0 +1,182 @@
+package stave
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+)
+
+const (
+	// remoteCacheFetchTimeout bounds downloading a binary from the remote
+	// cache, after which stave compiles locally.
+	remoteCacheFetchTimeout = 30 * time.Second
+
+	// remoteCacheUploadTimeout bounds uploading a binary to the remote cache.
+	remoteCacheUploadTimeout = 60 * time.Second
+
+	// remoteCacheDigestSuffix is appended to the name of a binary in the
+	// remote cache to get the name of the file holding its sha256 digest.
+	remoteCacheDigestSuffix = ".sha256"
+)
+
+// remoteCache is a binary_cache server that compiled stavefiles are stored on,
+// under the base name of their path in the local cache dir. Because that name
+// is a hash of the stavefiles rather than of the binary, each binary is stored
+// with a sidecar holding its hex sha256 digest, which downloads are checked
+// against.
+type remoteCache struct {
+	baseURL string
+	write   bool
+	client  *http.Client
+}
+
+// newRemoteCache returns the remote cache configured in cfg, or nil if there
+// is none.
+func newRemoteCache(cfg *config.Config) *remoteCache {
+	if !cfg.BinaryCache.Enabled() {
+		return nil
+	}
+	return &remoteCache{
+		baseURL: strings.TrimSuffix(cfg.BinaryCache.URL, "/") + "/",
+		write:   cfg.BinaryCache.Write,
+		client:  http.DefaultClient,
+	}
+}
+
+// fetch downloads the binary for exePath from the remote cache and installs it
+// at exePath, reporting whether it succeeded. Failures are only logged, so the
+// caller can fall back to compiling.
+func (c *remoteCache) fetch(ctx context.Context, exePath string) bool {
+	ctx, cancel := context.WithTimeout(ctx, remoteCacheFetchTimeout)
+	defer cancel()
+
+	name := filepath.Base(exePath)
+	if err := c.download(ctx, name, exePath); err != nil {
+		slog.Debug("remote binary cache miss", slog.String(log.URL, c.baseURL+name), slog.Any(log.Error, err))
+		return false
+	}
+	slog.Debug("using binary from remote cache", slog.String(log.URL, c.baseURL+name))
+	return true
+}
+
+func (c *remoteCache) download(ctx context.Context, name, exePath string) error {
+	digest, err := c.get(ctx, name+remoteCacheDigestSuffix)
+	if err != nil {
+		return err
+	}
+	want := strings.TrimSpace(string(digest))
+
+	binary, err := c.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("sha256 of downloaded binary is %s, want %s", got, want)
+	}
+
+	// write to a temp file and rename, so a concurrent stave never runs a
+	// partly written binary.
+	if err := os.MkdirAll(filepath.Dir(exePath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exePath), name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(binary)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), exePath)
+}
+
+func (c *remoteCache) get(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", req.URL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// upload starts uploading the binary at exePath to the remote cache, if
+// writing to it is enabled, and returns a channel that is closed when the
+// upload is done or has given up. Failures are only logged.
+func (c *remoteCache) upload(ctx context.Context, exePath string) <-chan struct{} {
+	done := make(chan struct{})
+	if !c.write {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ctx, cancel := context.WithTimeout(ctx, remoteCacheUploadTimeout)
+		defer cancel()
+
+		name := filepath.Base(exePath)
+		if err := c.put(ctx, name, exePath); err != nil {
+			slog.Debug("could not upload binary to remote cache", slog.String(log.URL, c.baseURL+name), slog.Any(log.Error, err))
+			return
+		}
+		slog.Debug("uploaded binary to remote cache", slog.String(log.URL, c.baseURL+name))
+	}()
+	return done
+}
+
+func (c *remoteCache) put(ctx context.Context, name, exePath string) error {
+	binary, err := os.ReadFile(exePath)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	// the digest goes last, so an interrupted upload never looks complete.
+	if err := c.putBytes(ctx, name, binary); err != nil {
+		return err
+	}
+	return c.putBytes(ctx, name+remoteCacheDigestSuffix, []byte(hex.EncodeToString(sum[:])+"\n"))
+}
+
+func (c *remoteCache) putBytes(ctx context.Context, name string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+name, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: %s", req.URL, resp.Status)
+	}
+	return nil
+}
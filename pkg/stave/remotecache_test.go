@@ -0,0 +1,167 @@
+package stave
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinaryCache is an in-memory remote binary cache server.
+type fakeBinaryCache struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newFakeBinaryCache(t *testing.T) (*fakeBinaryCache, *httptest.Server) {
+	t.Helper()
+	cache := &fakeBinaryCache{files: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/stave/")
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := cache.files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			cache.files[name] = data
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return cache, srv
+}
+
+// newStaticBinaryCache starts a remote binary cache server that has binary,
+// with the given digest, under every name.
+func newStaticBinaryCache(t *testing.T, binary []byte, digest string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, remoteCacheDigestSuffix) {
+			_, _ = w.Write([]byte(digest + "\n"))
+			return
+		}
+		_, _ = w.Write(binary)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// runWithBinaryCache runs the Hello target of the remotecache testdata with a
+// fresh local cache dir and the given binary_cache config.
+func runWithBinaryCache(t *testing.T, binaryCache string) (string, string) {
+	t.Helper()
+	dir := filepath.Join(testDataDir, "remotecache")
+	mu := mutexByDir(dir)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	configFile := filepath.Join(t.TempDir(), "stave.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("binary_cache:\n"+binaryCache), 0o600))
+	cacheDir := t.TempDir()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:    t.Context(),
+		Dir:        dir,
+		Stdout:     stdout,
+		Stderr:     stderr,
+		Args:       []string{"hello"},
+		CacheDir:   cacheDir,
+		HashFast:   true,
+		ConfigFile: configFile,
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	return stdout.String(), cacheDir
+}
+
+func TestRemoteCacheFetch(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the cached binary is a shell script")
+	}
+	script := []byte("#!/bin/sh\necho from remote cache\n")
+	sum := sha256.Sum256(script)
+	srv := newStaticBinaryCache(t, script, hex.EncodeToString(sum[:]))
+
+	stdout, cacheDir := runWithBinaryCache(t, "  url: "+srv.URL+"/stave/\n")
+	assert.Equal(t, "from remote cache\n", stdout)
+
+	entries, err := listCache(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the fetched binary should be in the local cache")
+}
+
+func TestRemoteCacheDigestMismatch(t *testing.T) {
+	t.Parallel()
+	srv := newStaticBinaryCache(t, []byte("#!/bin/sh\necho tampered\n"), strings.Repeat("0", 64))
+
+	stdout, _ := runWithBinaryCache(t, "  url: "+srv.URL+"/stave/\n")
+	assert.Equal(t, "compiled locally\n", stdout)
+}
+
+func TestRemoteCacheUnreachable(t *testing.T) {
+	t.Parallel()
+	_, srv := newFakeBinaryCache(t)
+	srv.Close()
+
+	stdout, _ := runWithBinaryCache(t, "  url: "+srv.URL+"/stave/\n  write: true\n")
+	assert.Equal(t, "compiled locally\n", stdout)
+}
+
+func TestRemoteCacheUpload(t *testing.T) {
+	t.Parallel()
+	cache, srv := newFakeBinaryCache(t)
+
+	stdout, cacheDir := runWithBinaryCache(t, "  url: "+srv.URL+"/stave/\n  write: true\n")
+	assert.Equal(t, "compiled locally\n", stdout)
+
+	entries, err := listCache(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	local, err := os.ReadFile(filepath.Join(cacheDir, entries[0].name))
+	require.NoError(t, err)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	name := entries[0].name
+	assert.Equal(t, local, cache.files[name], "the compiled binary should be uploaded")
+	sum := sha256.Sum256(local)
+	assert.Equal(t, hex.EncodeToString(sum[:])+"\n", string(cache.files[name+remoteCacheDigestSuffix]))
+}
+
+func TestRemoteCacheNoUploadWithoutWrite(t *testing.T) {
+	t.Parallel()
+	cache, srv := newFakeBinaryCache(t)
+
+	stdout, _ := runWithBinaryCache(t, "  url: "+srv.URL+"/stave/\n")
+	assert.Equal(t, "compiled locally\n", stdout)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	assert.Empty(t, cache.files)
+}
@@ -0,0 +1,112 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+// newGitRepo returns a temp git repo on branch main with one commit.
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	testGitInit(t, repo)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README"), []byte("hello\n"), 0o644))
+	for _, args := range [][]string{
+		{"checkout", "--quiet", "-b", "main"},
+		{"add", "README"},
+		{"-c", "user.name=stave", "-c", "user.email=stave@example.com", "commit", "--quiet", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = testEnvForGit()
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	return repo
+}
+
+// runRequire runs the targets of testdata/require in repo, and returns stdout,
+// stderr and the error. The tests that call it can't be parallel, as they set
+// the environment the compiled stavefile inherits.
+func runRequire(t *testing.T, repo string, args ...string) (string, string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "require")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		WorkDir: repo,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    args,
+	})
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRequireMet(t *testing.T) {
+	t.Setenv("STAVE_SKIP_REQUIRES", "")
+	repo := newGitRepo(t)
+
+	stdout, stderr, err := runRequire(t, repo, "release")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "releasing\n", stdout)
+}
+
+func TestRequireRefused(t *testing.T) {
+	t.Setenv("STAVE_SKIP_REQUIRES", "")
+	repo := newGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README"), []byte("changed\n"), 0o644))
+
+	stdout, stderr, err := runRequire(t, repo, "release")
+	require.Error(t, err)
+	assert.Equal(t, 1, sh.ExitStatus(err))
+	assert.Contains(t, stderr, "refusing to run target release: the git working tree has uncommitted changes")
+	assert.Empty(t, stdout)
+
+	// a target on another branch is refused too.
+	_, stderr, err = runRequire(t, repo, "tag")
+	require.Error(t, err)
+	assert.Contains(t, stderr, "refusing to run target tag: on git branch main, not release")
+}
+
+func TestRequireSkipped(t *testing.T) {
+	t.Setenv("STAVE_SKIP_REQUIRES", "1")
+	repo := newGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README"), []byte("changed\n"), 0o644))
+
+	stdout, stderr, err := runRequire(t, repo, "release", "tag")
+	require.NoError(t, err, "stderr was: %s", stderr)
+	assert.Equal(t, "releasing\ntagging\n", stdout)
+}
+
+func TestRequireQuotedBranchInfo(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	stavefile := "//go:build stave\n\npackage main\n\n// Release releases.\n//\n//stave:require=branch:rel\"ease\n" +
+		"func Release() {}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/proj\n\ngo 1.25\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stavefile.go"), []byte(stavefile), 0o644))
+
+	exe, _, err := EnsureCompiled(t.Context(), RunParams{
+		Dir:      dir,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &bytes.Buffer{},
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+	out, err := exec.CommandContext(t.Context(), exe, "-i", "release").CombinedOutput()
+	require.NoError(t, err, "output was: %s", out)
+	assert.Contains(t, string(out), "Preconditions: branch:rel\"ease\n")
+}
@@ -0,0 +1,124 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+func TestRequiresEnvMissing(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "requiresenv")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	t.Setenv("STAVE_TEST_REQUIRED_REGION", "us-east-1")
+	t.Setenv("STAVE_TEST_REQUIRED_PROFILE", "")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"deploy"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Equal(t, 2, sh.ExitStatus(err))
+	assert.Contains(t, stderr.String(), "missing required environment variable STAVE_TEST_REQUIRED_PROFILE for target deploy")
+	assert.Empty(t, stdout.String())
+}
+
+func TestRequiresEnvPresent(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "requiresenv")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	t.Setenv("STAVE_TEST_REQUIRED_REGION", "us-east-1")
+	t.Setenv("STAVE_TEST_REQUIRED_PROFILE", "ci")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"deploy"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "deploying to us-east-1\n", stdout.String())
+}
+
+func TestRequiredEnvMissing(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "requiredenv")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_A", "")
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_B", "set")
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_C", "")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"hello"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Equal(t, 2, sh.ExitStatus(err))
+	assert.Contains(t, stderr.String(), "missing required environment variables: STAVE_TEST_REQUIRED_ENV_A, STAVE_TEST_REQUIRED_ENV_C")
+	assert.Empty(t, stdout.String())
+
+	// listing targets doesn't need the variables
+	stderr.Reset()
+	runParams.Args = nil
+	runParams.List = true
+	require.NoError(t, Run(runParams), "stderr was: %s", stderr.String())
+	assert.Contains(t, stdout.String(), "hello")
+}
+
+func TestRequiredEnvPresent(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "requiredenv")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_A", "a")
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_B", "b")
+	t.Setenv("STAVE_TEST_REQUIRED_ENV_C", "c")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"hello"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "hello from a\n", stdout.String())
+}
@@ -0,0 +1,145 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetriesEventuallySucceeds(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "retries")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	counterFile := filepath.Join(t.TempDir(), "counter")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"flaky", counterFile},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "flaky succeeded\n", stdout.String())
+	assert.Contains(t, stderr.String(), "target Flaky failed (attempt 1 of 4), retrying: flaky attempt 1 failed")
+	assert.Contains(t, stderr.String(), "target Flaky failed (attempt 2 of 4), retrying: flaky attempt 2 failed")
+
+	counter, err := os.ReadFile(counterFile)
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(counter))
+}
+
+func TestRetriesPanicCountsAsFailure(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "retries")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"panicky", filepath.Join(t.TempDir(), "counter")},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "panicky succeeded\n", stdout.String())
+	assert.Contains(t, stderr.String(), "target Panicky failed (attempt 1 of 2), retrying: panic: panicky attempt failed")
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "retries")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	counterFile := filepath.Join(t.TempDir(), "counter")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"broken", counterFile},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, stderr.String(), "target Broken failed after 3 attempts")
+	assert.Contains(t, stderr.String(), "Error: broken")
+
+	counter, err := os.ReadFile(counterFile)
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(counter))
+}
+
+func TestRetriesAbortOnCancellation(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "retries")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Timeout: 500 * time.Millisecond,
+		Args:    []string{"cancelled"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, stderr.String(), "target Cancelled retries cancelled: context deadline exceeded")
+	assert.NotContains(t, stderr.String(), "attempt 2 of 6")
+}
+
+func TestRetriesInfo(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "retries")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Info:    true,
+		Args:    []string{"flaky"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Contains(t, stdout.String(), "Retries: 3 (10ms between attempts)")
+	assert.NotContains(t, stdout.String(), "stave:retries")
+}
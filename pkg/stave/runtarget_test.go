@@ -0,0 +1,82 @@
+package stave
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+func TestRunTarget(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "runtarget")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"welcome"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	// greet runs once for alice, however it is reached, then again for bob
+	assert.Equal(t, "hello, alice\nhello, bob\n", stdout.String())
+}
+
+func TestRunTargetBadArg(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "runtarget")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"badarg"},
+	})
+	// the calling target gets the error instead of stave exiting
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, `error: target "count": argument "n" (position 1): can't convert "many" to int`+"\n", stdout.String())
+}
+
+func TestRunTargetErrors(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "runtarget")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"unknown", `Error: unknown target "nope"`},
+		{"missingarg", `Error: target "Greet" takes 1 arguments, got 0`},
+	}
+	for _, tt := range tests {
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx: t.Context(),
+			Dir:     dataDirForThisTest,
+			Stdout:  &bytes.Buffer{},
+			Stderr:  stderr,
+			Args:    []string{tt.target},
+		})
+		require.Error(t, err, tt.target)
+		assert.Equal(t, 1, sh.ExitStatus(err), tt.target)
+		assert.Contains(t, stderr.String(), tt.want, tt.target)
+	}
+}
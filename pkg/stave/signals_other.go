@@ -0,0 +1,19 @@
+//go:build !windows
+
+package stave
+
+import (
+	"os"
+	"os/exec"
+)
+
+// prepareSignalForwarding configures cmd so that forwardSignal can reach it.
+// Nothing is needed here: the stavefile binary stays in stave's process group,
+// so it keeps access to the terminal.
+func prepareSignalForwarding(*exec.Cmd) {}
+
+// forwardSignal passes a signal received by stave on to the running stavefile
+// binary.
+func forwardSignal(proc *os.Process, sig os.Signal) error {
+	return proc.Signal(sig)
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package stave
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// prepareSignalForwarding starts the stavefile binary in a console process
+// group of its own, which is what forwardSignal sends console events to.
+func prepareSignalForwarding(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// forwardSignal passes a signal received by stave on to the running stavefile
+// binary. Windows can't send signals to other processes, so interrupts and
+// terminations are sent as a Ctrl+Break event, which the Go runtime in the
+// stavefile reports as os.Interrupt.
+func forwardSignal(proc *os.Process, sig os.Signal) error {
+	switch sig {
+	case os.Interrupt, syscall.SIGTERM:
+		return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(proc.Pid)) //nolint:gosec // pids are positive
+	default:
+		return proc.Signal(sig)
+	}
+}
@@ -0,0 +1,48 @@
+package stave
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+// checkSignatures reports the exported functions that were skipped because
+// their signatures aren't valid for targets: as warnings, or as an error if
+// strict is set.
+func checkSignatures(info *parse.PkgInfo, strict bool) error {
+	invalid := slices.Clone(info.InvalidFuncs)
+	for _, imp := range info.Imports {
+		invalid = append(invalid, imp.Info.InvalidFuncs...)
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	if !strict {
+		for _, theFunc := range invalid {
+			slog.Warn(
+				"skipping exported function that is not a valid target",
+				slog.String(log.Func, theFunc.Name),
+				slog.String(log.Position, theFunc.Pos.String()),
+				slog.String(log.Reason, theFunc.Reason),
+				slog.String(log.Hint, theFunc.Hint),
+			)
+		}
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("exported functions with invalid target signatures:")
+	for _, theFunc := range invalid {
+		fmt.Fprintf(&builder, "\n  %s: %s", theFunc.Pos, theFunc.Reason)
+		if theFunc.Hint != "" {
+			fmt.Fprintf(&builder, "\n    hint: %s", theFunc.Hint)
+		}
+	}
+	return errors.New(builder.String())
+}
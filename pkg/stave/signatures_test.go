@@ -0,0 +1,140 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/internal/parse"
+)
+
+func TestInvalidSignaturesWarn(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "signatures")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"build"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "built\n", stdout.String())
+
+	out := stderr.String()
+	assert.Contains(t, out, "Deploy returns (string, error); targets may return only error or nothing")
+	assert.Contains(t, out, "stavefile.go:18")
+	assert.Contains(t, out, "Scale takes argument factor of type complex128")
+	assert.Contains(t, out, "accept a string and convert it inside the target")
+	assert.Contains(t, out, "NS.Run takes a context.Context that isn't its first parameter")
+	assert.NotContains(t, out, "helper")
+}
+
+// TestInvalidSignaturesQuiet isn't parallel, since it sets the level of the
+// default logger.
+func TestInvalidSignaturesQuiet(t *testing.T) {
+	dataDirForThisTest := filepath.Join(testDataDir, "signatures")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		CacheDir: t.TempDir(),
+		Quiet:    true,
+		Verbose:  true,
+		Args:     []string{"build"},
+	}
+
+	err := Run(runParams)
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Equal(t, "built\n", stdout.String())
+	// neither the warnings nor, since --quiet wins over --verbose, the
+	// stavefile's "Running target" lines.
+	assert.NotContains(t, stderr.String(), "not a valid target")
+	assert.NotContains(t, stderr.String(), "Running target")
+}
+
+func TestInvalidSignaturesStrict(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "signatures")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	runParams := RunParams{
+		BaseCtx:          t.Context(),
+		Dir:              dataDirForThisTest,
+		Stdout:           stdout,
+		Stderr:           stderr,
+		StrictSignatures: true,
+		Args:             []string{"build"},
+	}
+
+	err := Run(runParams)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exported functions with invalid target signatures:")
+	assert.Contains(t, err.Error(), "stavefile.go:18:1: Deploy returns (string, error); targets may return only error or nothing")
+	assert.Contains(t, err.Error(), "hint: return just an error")
+	assert.Empty(t, stdout.String())
+}
+
+func TestCheckSignaturesLogsWarning(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	info := &parse.PkgInfo{
+		InvalidFuncs: []parse.InvalidFunc{{
+			Name:   "Scale",
+			Pos:    token.Position{Filename: "stavefile.go", Line: 7, Column: 1},
+			Reason: "Scale takes argument factor of type complex128",
+			Hint:   "accept a string and convert it inside the target",
+		}},
+		Imports: []*parse.Import{{Info: parse.PkgInfo{InvalidFuncs: []parse.InvalidFunc{{
+			Name:   "NS.Run",
+			Reason: "NS.Run takes a context.Context that isn't its first parameter",
+		}}}}},
+	}
+	require.NoError(t, checkSignatures(info, false))
+
+	var records []map[string]any
+	for line := range bytes.Lines(buf.Bytes()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "skipping exported function that is not a valid target", records[0]["msg"])
+	assert.Equal(t, "Scale", records[0]["func"])
+	assert.Equal(t, "stavefile.go:7:1", records[0]["position"])
+	assert.Equal(t, "Scale takes argument factor of type complex128", records[0]["reason"])
+	assert.Equal(t, "accept a string and convert it inside the target", records[0]["hint"])
+	assert.Equal(t, "NS.Run", records[1]["func"])
+	assert.Equal(t, "NS.Run takes a context.Context that isn't its first parameter", records[1]["reason"])
+}
@@ -0,0 +1,160 @@
+package stave
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ignoreFailuresEnv lists, comma-separated, the indexes in the stavefile's
+// arguments of the targets whose failure doesn't stop the run, from task file
+// lines starting with "-".
+const ignoreFailuresEnv = "STAVEFILE_IGNORE_FAILURES"
+
+// expandTaskFiles replaces each "@file" argument before any "--" with the
+// target invocations listed in the file, one per line. Blank lines and lines
+// starting with "#" are skipped. The targets of lines starting with "-" are
+// recorded in params.IgnoreFailures, so that their failure doesn't stop the
+// run.
+func expandTaskFiles(params *RunParams) error {
+	var args []string
+	for i, arg := range params.Args {
+		if arg == "--" {
+			args = append(args, params.Args[i:]...)
+			break
+		}
+		path, ok := strings.CutPrefix(arg, "@")
+		if !ok {
+			args = append(args, arg)
+			continue
+		}
+		lines, err := readTaskFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if line.ignoreFailure {
+				params.IgnoreFailures = append(params.IgnoreFailures, len(args))
+			}
+			args = append(args, line.words...)
+		}
+	}
+	params.Args = args
+	return nil
+}
+
+// taskLine is a target invocation from a task file.
+type taskLine struct {
+	words         []string // words are the target name and its arguments.
+	ignoreFailure bool     // ignoreFailure is set for lines starting with "-".
+}
+
+func readTaskFile(path string) ([]taskLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading task file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	lines, err := parseTaskFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading task file %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+func parseTaskFile(r io.Reader) ([]taskLine, error) {
+	var lines []taskLine
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var line taskLine
+		if rest, ok := strings.CutPrefix(text, "-"); ok {
+			line.ignoreFailure = true
+			text = strings.TrimSpace(rest)
+		}
+		words, err := splitTaskLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("line %d: no target after \"-\"", n)
+		}
+		line.words = words
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// splitTaskLine splits a task file line into words at spaces, except inside
+// single or double quotes, which are removed.
+func splitTaskLine(text string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quoteCh rune
+	)
+	for _, r := range text {
+		switch {
+		case quoteCh != 0:
+			if r == quoteCh {
+				quoteCh = 0
+				continue
+			}
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quoteCh = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoteCh != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// printExpanded writes the arguments the stavefile would be run with, quoting
+// those that need it, for --print-expanded.
+func printExpanded(w io.Writer, params RunParams) error {
+	quoted := make([]string, 0, len(params.Args))
+	for _, arg := range params.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(quoted, " ")); err != nil {
+		return err
+	}
+	if len(params.IgnoreFailures) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(params.IgnoreFailures))
+	for _, i := range params.IgnoreFailures {
+		names = append(names, params.Args[i])
+	}
+	_, err := fmt.Fprintf(w, "# failures ignored: %s\n", strings.Join(names, ", "))
+	return err
+}
@@ -0,0 +1,110 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTaskFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestParseTaskFile(t *testing.T) {
+	t.Parallel()
+
+	lines, err := parseTaskFile(strings.NewReader(`# CI pipeline
+lint
+
+deploy staging 3
+  - test "./..." 'a b'
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []taskLine{
+		{words: []string{"lint"}},
+		{words: []string{"deploy", "staging", "3"}},
+		{words: []string{"test", "./...", "a b"}, ignoreFailure: true},
+	}, lines)
+
+	_, err = parseTaskFile(strings.NewReader("lint\nsay \"hi\n"))
+	require.EqualError(t, err, "line 2: unterminated quote")
+
+	_, err = parseTaskFile(strings.NewReader("-\n"))
+	require.EqualError(t, err, `line 1: no target after "-"`)
+}
+
+func TestExpandTaskFiles(t *testing.T) {
+	t.Parallel()
+
+	tasks := writeTaskFile(t, "lint\n-test unit\ndeploy staging\n")
+	params := RunParams{Args: []string{"build", "@" + tasks, "clean", "--", "@literal"}}
+	require.NoError(t, expandTaskFiles(&params))
+	assert.Equal(t, []string{"build", "lint", "test", "unit", "deploy", "staging", "clean", "--", "@literal"}, params.Args)
+	assert.Equal(t, []int{2}, params.IgnoreFailures)
+
+	params = RunParams{Args: []string{"@" + filepath.Join(t.TempDir(), "missing.txt")}}
+	require.ErrorContains(t, expandTaskFiles(&params), "reading task file")
+}
+
+func TestPrintExpanded(t *testing.T) {
+	t.Parallel()
+
+	tasks := writeTaskFile(t, "say \"hi there\" bob\n-count 5\n")
+	stdout := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:       t.Context(),
+		Dir:           testDataArgsDir,
+		Stdout:        stdout,
+		Stderr:        &bytes.Buffer{},
+		Args:          []string{"@" + tasks, "status"},
+		PrintExpanded: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "say \"hi there\" bob count 5 status\n# failures ignored: count\n", stdout.String())
+}
+
+func TestTaskFileRun(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "taskfile")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	// A failing line marked with "-" doesn't stop the run.
+	tasks := writeTaskFile(t, "# failures here are expected\n-returnsnonnilerror\n\nreturnsnilerror\n")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"@" + tasks},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Contains(t, stderr.String(), "Error (ignored): bang!")
+	assert.Equal(t, "stuff\n", stdout.String())
+
+	// Without the "-", it does.
+	tasks = writeTaskFile(t, "returnsnonnilerror\nreturnsnilerror\n")
+	stdout.Reset()
+	stderr.Reset()
+	err = Run(RunParams{
+		BaseCtx: t.Context(),
+		Dir:     dataDirForThisTest,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Args:    []string{"@" + tasks},
+	})
+	require.Error(t, err)
+	assert.Contains(t, stderr.String(), "Error: bang!")
+	assert.Empty(t, stdout.String())
+}
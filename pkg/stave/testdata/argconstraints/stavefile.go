@@ -0,0 +1,22 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Deploy deploys to an environment.
+// stave:arg env pattern=^(dev|prod)$
+// stave:arg replicas min=1 max=50
+// stave:arg wait max=1m
+func Deploy(env string, replicas int, wait time.Duration) {
+	fmt.Printf("deploying %d replicas to %s, waiting %s\n", replicas, env, wait)
+}
+
+// Scale scales by a factor.
+// stave:arg factor min=0.5 maximum=2
+func Scale(factor float64) {
+	fmt.Printf("scaling by %v\n", factor)
+}
@@ -5,6 +5,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yaklabco/stave/pkg/st"
@@ -53,3 +54,17 @@ func HasDep() {
 func DoubleIt(f float64) {
 	fmt.Printf("%.1f * 2 = %.1f\n", f, f*2)
 }
+
+func Repeat(msg string, times int, loud bool) {
+	for range times {
+		if loud {
+			fmt.Println(strings.ToUpper(msg))
+		} else {
+			fmt.Println(msg)
+		}
+	}
+}
+
+func Backup(at time.Time) {
+	fmt.Println("backing up as of", at.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,25 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Aliases gives Setup the name of stave's --init flag.
+var Aliases = map[string]any{
+	"init": Setup,
+}
+
+// Clean removes the build output.
+func Clean() {
+	fmt.Println("target clean ran")
+}
+
+// Setup prepares the workspace.
+func Setup() {
+	fmt.Println("target setup ran")
+}
+
+// Help prints the project's contributor notes.
+func Help() {
+	fmt.Println("target help ran")
+}
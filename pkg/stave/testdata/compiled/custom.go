@@ -28,6 +28,11 @@ func Deploy() {
 	st.Deps(f)
 }
 
+// EchoArg prints its argument.
+func EchoArg(s string) {
+	fmt.Println(s)
+}
+
 // Sleep sleeps 5 seconds.
 func Sleep() {
 	time.Sleep(5 * time.Second)
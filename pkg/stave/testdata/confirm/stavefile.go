@@ -0,0 +1,17 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Clean removes the dist directory.
+//
+// stave:confirm=This will delete the dist directory. Continue?
+func Clean() {
+	fmt.Println("cleaned")
+}
+
+// Build builds.
+func Build() {
+	fmt.Println("built")
+}
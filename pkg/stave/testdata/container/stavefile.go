@@ -0,0 +1,23 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Where prints whether it is running in its container.
+//
+//stave:container=debian:stable-slim
+func Where() {
+	fmt.Println("in container:", st.InContainer())
+}
+
+// Fail fails with exit status 3.
+//
+//stave:container=debian:stable-slim
+func Fail() error {
+	return st.Fatal(3, "failing on purpose")
+}
@@ -21,6 +21,16 @@ func Timeout(ctx context.Context) {
 	time.Sleep(200 * time.Millisecond)
 }
 
+// Sleep waits 300ms, or until the context is done.
+func Sleep(ctx context.Context) error {
+	select {
+	case <-time.After(300 * time.Millisecond):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func TakesContextWithError(ctx context.Context) error {
 	return errors.New("Something went sideways")
 }
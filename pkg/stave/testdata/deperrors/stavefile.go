@@ -0,0 +1,27 @@
+//go:build stave
+
+package main
+
+import (
+	"errors"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// All runs the checks, two of which fail.
+func All() {
+	st.Deps(Lint, Build, Test)
+}
+
+// Lint fails without an exit status.
+func Lint() error {
+	return errors.New("lint found 3 problems")
+}
+
+// Build succeeds.
+func Build() {}
+
+// Test fails with exit status 3.
+func Test() error {
+	return st.Fatal(3, "2 tests failed")
+}
@@ -0,0 +1,75 @@
+//go:build stave
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Serial runs the steps one after the other.
+func Serial() {
+	st.SerialDeps(One, Two, Three)
+	record("serial")
+}
+
+// SerialCtx runs the steps one after the other, with a context.
+func SerialCtx(ctx context.Context) {
+	st.SerialCtxDeps(ctx, Three, Two, One)
+	record("serialctx")
+}
+
+// Parallel runs the steps in parallel.
+func Parallel() {
+	st.Deps(One, Two, Three)
+	record("parallel")
+}
+
+// Limited runs the steps in parallel, one at a time.
+func Limited() {
+	st.DepsLimit(1, One, Two, Three)
+	record("limited")
+}
+
+// Fail runs the steps one after the other, stopping at Broken.
+func Fail() {
+	st.SerialDeps(One, Broken, Two)
+	record("fail")
+}
+
+// One is a step.
+func One() { step("one") }
+
+// Two is a step.
+func Two() { step("two") }
+
+// Three is a step.
+func Three() { step("three") }
+
+// Broken is a step that fails.
+func Broken() error {
+	record("broken")
+	return errors.New("broken")
+}
+
+func step(name string) {
+	record("start " + name)
+	time.Sleep(20 * time.Millisecond)
+	record("end " + name)
+}
+
+// record appends line to the file named by DEPS_ORDER_FILE.
+func record(line string) {
+	f, err := os.OpenFile(os.Getenv("DEPS_ORDER_FILE"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/sh"
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Deploy builds, tests and deploys.
+func Deploy() error {
+	st.Deps(Build, Test)
+	st.Deps(Build)
+	return sh.RunV("echo", "deployed")
+}
+
+// Build builds.
+func Build() {
+	fmt.Println("building")
+}
+
+// Test tests.
+func Test() {
+	fmt.Println("testing")
+}
@@ -0,0 +1,10 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+func ReturnsNilError() error {
+	fmt.Println("stuff")
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Echo prints the variables set by the env files.
+func Echo() {
+	for _, key := range []string{"FROM_ENV", "OVERRIDDEN", "FROM_SHELL", "FROM_CLI"} {
+		fmt.Printf("%s=%s\n", key, os.Getenv(key))
+	}
+}
@@ -0,0 +1,34 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// All runs everything in parallel.
+func All() {
+	st.Deps(Build, DB.Migrate, Lint)
+}
+
+// Build builds.
+func Build() { work("build") }
+
+// Lint lints.
+func Lint() { work("lint") }
+
+type DB st.Namespace
+
+// Migrate migrates the database, which nothing else may touch meanwhile.
+//
+//stave:exclusive
+func (DB) Migrate() { work("migrate") }
+
+func work(name string) {
+	fmt.Println("start", name)
+	time.Sleep(100 * time.Millisecond)
+	fmt.Println("end", name)
+}
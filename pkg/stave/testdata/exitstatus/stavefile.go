@@ -0,0 +1,10 @@
+//go:build stave
+
+package main
+
+import "github.com/yaklabco/stave/pkg/st"
+
+// Fail fails with the given exit status.
+func Fail(status int) error {
+	return st.Fatalf(status, "failing with status %d", status)
+}
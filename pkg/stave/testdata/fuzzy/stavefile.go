@@ -0,0 +1,21 @@
+//go:build stave
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func ReturnsNilError() error {
+	fmt.Println("stuff")
+	return nil
+}
+
+func ReturnsNonNilError() error {
+	return errors.New("bang!")
+}
+
+func TestVerbose() {
+	fmt.Println("verbose")
+}
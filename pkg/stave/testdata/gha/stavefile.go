@@ -0,0 +1,20 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Build succeeds.
+func Build() {
+	fmt.Println("building")
+}
+
+// Test fails with exit status 3.
+func Test() error {
+	fmt.Println("testing")
+	return st.Fatal(3, "2 tests failed\nsee the log")
+}
@@ -9,19 +9,40 @@ import (
 
 // HookTest writes a marker file to prove execution.
 // Set HOOK_TEST_MARKER env var to the path where the marker should be written.
-func HookTest() {
+func HookTest() error {
 	marker := os.Getenv("HOOK_TEST_MARKER")
 	if marker != "" {
 		//#nosec G306 -- test file, permissions not critical
 		if err := os.WriteFile(marker, []byte("executed"), 0o644); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to write marker: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to write marker: %w", err)
 		}
 	}
 	fmt.Println("hook target executed")
+	return nil
 }
 
 // HookFail is a target that always fails with exit code 1.
 func HookFail() error {
 	return fmt.Errorf("intentional failure for testing")
 }
+
+// HookCommitMsg copies the commit message file it is given, as commit-msg hooks
+// are, to the file named by HOOK_TEST_MARKER.
+func HookCommitMsg(path string) error {
+	return copyToMarker(path)
+}
+
+// HookCommitMsgEnv copies the commit message file named by STAVE_HOOK_ARG1 to
+// the file named by HOOK_TEST_MARKER.
+func HookCommitMsgEnv() error {
+	return copyToMarker(os.Getenv("STAVE_HOOK_ARG1"))
+}
+
+func copyToMarker(path string) error {
+	msg, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	//#nosec G306 -- test file, permissions not critical
+	return os.WriteFile(os.Getenv("HOOK_TEST_MARKER"), msg, 0o644)
+}
@@ -0,0 +1,18 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Version is set with -ldflags "-X main.Version=...".
+var Version = "dev"
+
+// PrintLdflags prints the ldflags stave was run with, and the version they set.
+func PrintLdflags() {
+	fmt.Println(st.Ldflags())
+	fmt.Println(Version)
+}
@@ -0,0 +1,18 @@
+//go:build stave
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Build prints building.
+func Build() {
+	fmt.Println("building")
+}
+
+// Fail fails.
+func Fail() error {
+	return errors.New(`broken: "x" = 1`)
+}
@@ -0,0 +1,20 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+var Aliases = map[string]any{
+	"build:a": Build.All,
+}
+
+type Build st.Namespace
+
+// All builds everything.
+func (Build) All() {
+	fmt.Println("built all")
+}
@@ -0,0 +1,23 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+var Aliases = map[string]any{
+	"build:a": Build.All,
+}
+
+type Build st.Namespace
+
+func (Build) All() {
+	fmt.Println("built all")
+}
+
+func (Build) A() {
+	fmt.Println("built a")
+}
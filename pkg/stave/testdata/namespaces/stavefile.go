@@ -15,13 +15,16 @@ func TestNamespaceDep() {
 	st.Deps(NS.Error, NS.Bare, NS.BareCtx, NS.CtxErr)
 }
 
+// NS has the namespace targets.
 type NS st.Namespace
 
+// Error says hi.
 func (NS) Error() error {
 	fmt.Println("hi!")
 	return nil
 }
 
+// Bare does nothing.
 func (NS) Bare() {
 }
 
@@ -0,0 +1,5 @@
+//go:build stave
+
+package main
+
+func Build() {}
@@ -0,0 +1,12 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+var Default = ReturnsNilError
+
+func ReturnsNilError() error {
+	fmt.Println("stuff")
+	return nil
+}
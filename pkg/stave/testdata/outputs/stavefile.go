@@ -0,0 +1,25 @@
+//go:build stave
+
+package main
+
+import (
+	"os"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Build writes bin/app with the given content and declares it as an output.
+func Build(content string) error {
+	if err := os.MkdirAll("bin", 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile("bin/app", []byte(content), 0o644); err != nil {
+		return err
+	}
+	return st.Output("bin/app")
+}
+
+// Missing declares an output it never writes.
+func Missing() error {
+	return st.Output("bin/missing")
+}
@@ -2,7 +2,11 @@
 
 package main
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/yaklabco/stave/pkg/sh"
+)
 
 // Function that panics.
 func Panics() {
@@ -13,3 +17,8 @@ func Panics() {
 func PanicsErr() error {
 	panic(errors.New("kaboom!"))
 }
+
+// Function that fails a command via sh.MustRun.
+func MustRunFails() {
+	sh.MustRun("go", "bogus-subcommand")
+}
@@ -0,0 +1,22 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Report prints the parallelism seen by a dependency.
+func Report() {
+	st.Deps(report)
+}
+
+func report() {
+	fmt.Printf("STAVE_NUM_PROCESSORS=%s\n", os.Getenv("STAVE_NUM_PROCESSORS"))
+	fmt.Printf("GOMAXPROCS=%s\n", os.Getenv("GOMAXPROCS"))
+	fmt.Printf("runtime.GOMAXPROCS=%d\n", runtime.GOMAXPROCS(0))
+}
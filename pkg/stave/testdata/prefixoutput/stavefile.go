@@ -0,0 +1,24 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yaklabco/stave/pkg/sh"
+)
+
+// Lint prints, and runs a command that doesn't end its output with a newline.
+func Lint() error {
+	fmt.Println("linting")
+	fmt.Fprintln(os.Stderr, "lint warning")
+	return sh.RunV("sh", "-c", "echo from lint; printf partial")
+}
+
+// Test prints a line in two writes, and runs a command that writes to stderr.
+func Test() error {
+	fmt.Print("testing ")
+	fmt.Println("done")
+	return sh.RunV("sh", "-c", "echo from test >&2")
+}
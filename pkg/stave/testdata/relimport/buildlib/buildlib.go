@@ -0,0 +1,8 @@
+package buildlib
+
+import "fmt"
+
+// Lib is a target shared from a local folder.
+func Lib() {
+	fmt.Println("lib")
+}
@@ -0,0 +1,8 @@
+package buildtools
+
+import "fmt"
+
+// Vet is a target from a package nested below the stavefiles dir.
+func Vet() {
+	fmt.Println("vet")
+}
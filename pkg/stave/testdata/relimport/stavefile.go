@@ -0,0 +1,17 @@
+//go:build stave
+
+package main
+
+// Relative imports can't be written as Go imports, so they are declared with
+// free-standing stave:import directives instead.
+
+//stave:import ./buildlib
+//stave:import ./tools tools
+//stave:import ./internal/buildtools
+
+import "fmt"
+
+// Local is a target in the stavefile itself.
+func Local() {
+	fmt.Println("local")
+}
@@ -0,0 +1,8 @@
+package tools
+
+import "fmt"
+
+// Lint is a target imported under an alias.
+func Lint() {
+	fmt.Println("lint")
+}
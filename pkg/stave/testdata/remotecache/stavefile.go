@@ -0,0 +1,10 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Hello prints a greeting.
+func Hello() {
+	fmt.Println("compiled locally")
+}
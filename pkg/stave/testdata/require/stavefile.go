@@ -0,0 +1,19 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Release must run from a clean checkout of main.
+//
+//stave:require=clean-git,branch:main
+func Release() {
+	fmt.Println("releasing")
+}
+
+// Tag must run on the release branch.
+//
+//stave:require=branch:release
+func Tag() {
+	fmt.Println("tagging")
+}
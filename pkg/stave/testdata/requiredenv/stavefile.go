@@ -0,0 +1,16 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// RequiredEnv must be set before any target runs.
+var RequiredEnv = []string{"STAVE_TEST_REQUIRED_ENV_A", "STAVE_TEST_REQUIRED_ENV_B", "STAVE_TEST_REQUIRED_ENV_C"}
+
+// Hello says hello.
+func Hello() {
+	fmt.Println("hello from", os.Getenv("STAVE_TEST_REQUIRED_ENV_A"))
+}
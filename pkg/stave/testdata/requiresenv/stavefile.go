@@ -0,0 +1,15 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Deploy needs to know where to deploy to.
+//
+//stave:requires-env=STAVE_TEST_REQUIRED_REGION,STAVE_TEST_REQUIRED_PROFILE
+func Deploy() {
+	fmt.Println("deploying to", os.Getenv("STAVE_TEST_REQUIRED_REGION"))
+}
@@ -0,0 +1,20 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Logger is named like a variable of the generated mainfile's.
+func Logger() {
+	fmt.Println(signal("logger"))
+}
+
+// Ctx is named like a variable of the generated mainfile's.
+func Ctx() {
+	fmt.Println(signal("ctx"))
+}
+
+// signal is named like a package the generated mainfile imports.
+func signal(name string) string {
+	return "ran " + name
+}
@@ -0,0 +1,60 @@
+//go:build stave
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// bump increments the counter stored in file and returns the new value.
+func bump(file string) int {
+	data, _ := os.ReadFile(file)
+	n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	n++
+	if err := os.WriteFile(file, []byte(strconv.Itoa(n)), 0o644); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// Flaky fails twice before succeeding.
+//
+//stave:retries=3
+//stave:retry-delay=10ms
+func Flaky(counterFile string) error {
+	if n := bump(counterFile); n < 3 {
+		return fmt.Errorf("flaky attempt %d failed", n)
+	}
+	fmt.Println("flaky succeeded")
+	return nil
+}
+
+// Panicky panics once before succeeding.
+// stave:retries=1
+func Panicky(counterFile string) {
+	if n := bump(counterFile); n < 2 {
+		panic("panicky attempt failed")
+	}
+	fmt.Println("panicky succeeded")
+}
+
+// Broken never succeeds.
+//
+//stave:retries=2
+func Broken(counterFile string) error {
+	bump(counterFile)
+	return errors.New("broken")
+}
+
+// Cancelled waits far longer between attempts than the test timeout allows.
+//
+//stave:retries=5
+//stave:retry-delay=1h
+func Cancelled(ctx context.Context) error {
+	return errors.New("cancelled attempt failed")
+}
@@ -0,0 +1,59 @@
+//go:build stave
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+var Aliases = map[string]any{
+	"hi": Greet,
+}
+
+// Greet prints a greeting.
+func Greet(name string) {
+	fmt.Println("hello,", name)
+}
+
+// Welcome greets people by running the greet target.
+func Welcome(ctx context.Context) error {
+	if err := st.RunTarget(ctx, "greet", "alice"); err != nil {
+		return err
+	}
+	// already run with these args, so this does nothing
+	if err := st.RunTarget(ctx, "Greet", "alice"); err != nil {
+		return err
+	}
+	// an alias and Deps of the same function and args don't run it again
+	if err := st.RunTarget(ctx, "hi", "alice"); err != nil {
+		return err
+	}
+	st.CtxDeps(ctx, st.F(Greet, "alice"))
+	return st.RunTarget(ctx, "hi", "bob")
+}
+
+// Count counts to n.
+func Count(n int) {
+	fmt.Println("counted to", n)
+}
+
+// BadArg runs the count target with an argument that isn't an int, and
+// handles the error.
+func BadArg(ctx context.Context) error {
+	err := st.RunTarget(ctx, "count", "many")
+	fmt.Println("error:", err)
+	return nil
+}
+
+// Unknown runs a target that doesn't exist.
+func Unknown(ctx context.Context) error {
+	return st.RunTarget(ctx, "nope")
+}
+
+// MissingArg runs the greet target without its argument.
+func MissingArg(ctx context.Context) error {
+	return st.RunTarget(ctx, "greet")
+}
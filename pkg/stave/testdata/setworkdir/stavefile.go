@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/yaklabco/stave/pkg/st"
 )
 
 func TestWorkingDir() error {
@@ -21,3 +23,9 @@ func TestWorkingDir() error {
 	fmt.Println(strings.Join(out, ", "))
 	return nil
 }
+
+// PrintDirs prints st.StaveDir() and st.WorkingDir().
+func PrintDirs() {
+	fmt.Println(st.StaveDir())
+	fmt.Println(st.WorkingDir())
+}
@@ -40,6 +40,15 @@ func ExitsAfterCancel(ctx context.Context) {
 	fmt.Println("done")
 }
 
+// Reports that it's running, then exits after ctx cancel and wait
+func ReadyThenExitsAfterCancel(ctx context.Context) {
+	fmt.Println("ready")
+	<-ctx.Done()
+	fmt.Printf("exiting...")
+	time.Sleep(200 * time.Millisecond)
+	fmt.Println("done")
+}
+
 // Ignores all signals, requires killing via timeout or second SIGINT
 func IgnoresSignals(ctx context.Context) {
 	sigC := make(chan os.Signal, 1)
@@ -48,3 +57,10 @@ func IgnoresSignals(ctx context.Context) {
 		<-sigC
 	}
 }
+
+// Takes longer than the default cleanup grace period to clean up after cancellation
+func SlowCleanup(ctx context.Context) {
+	<-ctx.Done()
+	time.Sleep(5500 * time.Millisecond)
+	fmt.Println("slow cleanup done")
+}
@@ -0,0 +1,34 @@
+//go:build stave
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// Build is a perfectly good target.
+func Build() {
+	fmt.Println("built")
+}
+
+// Deploy returns more than a target can.
+func Deploy() (string, error) {
+	return "", nil
+}
+
+// Scale takes an argument type targets can't accept.
+func Scale(factor complex128) {}
+
+// helper is unexported, so it is quietly ignored.
+func helper() string {
+	return ""
+}
+
+// NS is a namespace.
+type NS st.Namespace
+
+// Run takes its context in the wrong place.
+func (NS) Run(name string, ctx context.Context) {}
@@ -0,0 +1,7 @@
+package package1
+
+import "fmt"
+
+func Build() {
+	fmt.Println("build 1")
+}
@@ -0,0 +1,7 @@
+package package2
+
+import "fmt"
+
+func Build() {
+	fmt.Println("build 2")
+}
@@ -0,0 +1,10 @@
+//go:build stave
+
+package main
+
+import (
+	// stave:import
+	_ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/configalias/package1"
+	// stave:import
+	_ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/configalias/package2"
+)
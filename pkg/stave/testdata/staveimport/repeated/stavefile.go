@@ -0,0 +1,15 @@
+//go:build stave
+
+package main
+
+import (
+	"github.com/yaklabco/stave/pkg/st"
+
+	//stave:import
+	_ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/repeated/other"
+)
+
+// Test runs the tests.
+func Test() {
+	st.Deps(Lint)
+}
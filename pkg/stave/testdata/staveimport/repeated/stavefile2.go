@@ -0,0 +1,18 @@
+//go:build stave
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/yaklabco/stave/pkg/st"
+
+	//stave:import
+	_ "github.com/yaklabco/stave/pkg/stave/testdata/staveimport/repeated/other"
+)
+
+// Lint runs the linters.
+func Lint() {
+	st.Deps()
+	fmt.Println("lint")
+}
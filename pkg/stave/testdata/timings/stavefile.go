@@ -0,0 +1,18 @@
+//go:build stave
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// Sleep waits for d.
+func Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Fail always fails.
+func Fail() error {
+	return errors.New("failed")
+}
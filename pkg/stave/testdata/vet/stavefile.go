@@ -0,0 +1,10 @@
+//go:build stave
+
+package main
+
+import "fmt"
+
+// Greet prints a greeting, with a format verb that doesn't match its arg.
+func Greet() {
+	fmt.Printf("hello %d\n", "world")
+}
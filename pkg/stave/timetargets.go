@@ -0,0 +1,128 @@
+package stave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// timeTargetsNoisySpread is the standard deviation, as a fraction of the
+// mean, above which --time-targets warns that the runs varied too much for
+// their timings to be compared.
+const timeTargetsNoisySpread = 0.1
+
+// timingStats summarizes how long the runs of --time-targets took. Durations
+// are in nanoseconds in its JSON form.
+type timingStats struct {
+	Runs   int           `json:"runs"`
+	Min    time.Duration `json:"min"`
+	Median time.Duration `json:"median"`
+	Max    time.Duration `json:"max"`
+	Mean   time.Duration `json:"mean"`
+	StdDev time.Duration `json:"stddev"`
+	Spread float64       `json:"spread"` // Spread is StdDev as a fraction of Mean.
+	Noisy  bool          `json:"noisy"`  // Noisy is set if Spread is over timeTargetsNoisySpread.
+}
+
+// newTimingStats summarizes durations, which must not be empty.
+func newTimingStats(durations []time.Duration) timingStats {
+	sorted := slices.Sorted(slices.Values(durations))
+	count := len(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	mean := total / time.Duration(count)
+
+	median := sorted[count/2]
+	if count%2 == 0 {
+		median = (sorted[count/2-1] + sorted[count/2]) / 2
+	}
+
+	var sumSquares float64
+	for _, d := range sorted {
+		diff := float64(d - mean)
+		sumSquares += diff * diff
+	}
+	stdDev := time.Duration(math.Sqrt(sumSquares / float64(count)))
+
+	var spread float64
+	if mean > 0 {
+		spread = float64(stdDev) / float64(mean)
+	}
+	return timingStats{
+		Runs:   count,
+		Min:    sorted[0],
+		Median: median,
+		Max:    sorted[count-1],
+		Mean:   mean,
+		StdDev: stdDev,
+		Spread: spread,
+		Noisy:  spread > timeTargetsNoisySpread,
+	}
+}
+
+// timeTargets runs the compiled stavefile at exePath params.TimeTargets
+// times, each in a new process so that no st.Deps results carry over from one
+// run to the next, and reports how long the runs took. The targets' output
+// goes to stderr, leaving stdout to the report. The first failing run stops
+// the rest.
+func timeTargets(ctx context.Context, params RunParams, cfg *config.Config, exePath string) error {
+	runParams := params
+	runParams.Stdout = params.Stderr
+
+	durations := make([]time.Duration, 0, params.TimeTargets)
+	for i := 1; i <= params.TimeTargets; i++ {
+		start := time.Now()
+		if err := runCompiled(ctx, runParams, cfg, exePath); err != nil {
+			return fmt.Errorf("run %d of %d: %w", i, params.TimeTargets, err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	stats := newTimingStats(durations)
+	if stats.Noisy {
+		slog.Warn(
+			"target run times vary widely",
+			slog.String(log.Reason, fmt.Sprintf("standard deviation is %.0f%% of the mean", stats.Spread*100)),
+			slog.String(log.Hint, "close other programs, or time more runs"),
+		)
+	}
+	if params.TimeTargetsJSON {
+		enc := json.NewEncoder(params.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	return renderTimingStats(params.Stdout, stats)
+}
+
+// renderTimingStats renders the output of `stave --time-targets`.
+func renderTimingStats(out io.Writer, stats timingStats) error {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "RUNS\tMIN\tMEDIAN\tMAX\tMEAN\tSTDDEV")
+	_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s (%.0f%%)\n",
+		stats.Runs,
+		humanizeDuration(stats.Min),
+		humanizeDuration(stats.Median),
+		humanizeDuration(stats.Max),
+		humanizeDuration(stats.Mean),
+		humanizeDuration(stats.StdDev),
+		stats.Spread*100,
+	)
+	_ = tw.Flush()
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,82 @@
+package stave
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeTargets(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "timings")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	run := func(asJSON bool, args ...string) (string, error) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:         t.Context(),
+			Dir:             dataDirForThisTest,
+			Stdout:          stdout,
+			Stderr:          stderr,
+			CacheDir:        t.TempDir(),
+			Args:            args,
+			TimeTargets:     3,
+			TimeTargetsJSON: asJSON,
+		})
+		if err != nil {
+			t.Logf("stderr was: %s", stderr.String())
+		}
+		return stdout.String(), err
+	}
+
+	out, err := run(false, "sleep", "10ms")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2, out)
+	assert.Equal(t, []string{"RUNS", "MIN", "MEDIAN", "MAX", "MEAN", "STDDEV"}, strings.Fields(lines[0]))
+	assert.Equal(t, "3", strings.Fields(lines[1])[0])
+
+	out, err = run(true, "sleep", "10ms")
+	require.NoError(t, err)
+	var stats timingStats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats), out)
+	assert.Equal(t, 3, stats.Runs)
+	assert.GreaterOrEqual(t, stats.Min, 10*time.Millisecond)
+	assert.LessOrEqual(t, stats.Min, stats.Median)
+	assert.LessOrEqual(t, stats.Median, stats.Max)
+
+	_, err = run(false, "fail")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "run 1 of 3")
+}
+
+func TestNewTimingStats(t *testing.T) {
+	t.Parallel()
+	ms := time.Millisecond
+
+	stats := newTimingStats([]time.Duration{30 * ms, 10 * ms, 20 * ms, 40 * ms})
+	assert.Equal(t, timingStats{
+		Runs:   4,
+		Min:    10 * ms,
+		Median: 25 * ms,
+		Max:    40 * ms,
+		Mean:   25 * ms,
+		StdDev: stats.StdDev,
+		Spread: stats.Spread,
+		Noisy:  true,
+	}, stats)
+	assert.InDelta(t, 11.18, stats.StdDev.Seconds()*1000, 0.01)
+
+	stats = newTimingStats([]time.Duration{100 * ms, 101 * ms, 99 * ms})
+	assert.Equal(t, 100*ms, stats.Median)
+	assert.False(t, stats.Noisy)
+}
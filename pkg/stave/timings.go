@@ -0,0 +1,71 @@
+package stave
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// timingsFileEnv is the environment variable through which stave tells the
+// compiled stavefile where to record how long each target took.
+const timingsFileEnv = "STAVEFILE_TIMINGS_FILE"
+
+// targetTiming is how long a target took the last time it succeeded, as
+// recorded by the generated mainfile.
+type targetTiming struct {
+	Duration time.Duration `json:"duration"`
+	Finished time.Time     `json:"finished"`
+}
+
+// timingsFile returns the path of the file that records target durations. It
+// is in a subdirectory of the cache dir so that `stave --clean` keeps it.
+func timingsFile(cacheDir string) string {
+	return filepath.Join(cacheDir, "state", "timings.json")
+}
+
+// readTimings returns the last recorded duration of each target of the
+// stavefiles in dir, keyed by target name. A missing or corrupt timings file
+// has none; the next run replaces it.
+func readTimings(cacheDir, dir string) map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return out
+	}
+	path := timingsFile(cacheDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Debug("could not read target timings", slog.String(log.Path, path), slog.Any(log.Error, err))
+		}
+		return out
+	}
+	var timings map[string]map[string]targetTiming
+	if err := json.Unmarshal(data, &timings); err != nil {
+		slog.Debug("ignoring corrupt target timings", slog.String(log.Path, path), slog.Any(log.Error, err))
+		return out
+	}
+	for target, timing := range timings[absDir] {
+		out[target] = timing.Duration
+	}
+	return out
+}
+
+// humanizeDuration formats d for the LAST column of `stave -l`, with less
+// precision the longer it is.
+func humanizeDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return "<1ms"
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,113 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "timings")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+	cacheDir := t.TempDir()
+
+	run := func(args ...string) error {
+		stderr := &bytes.Buffer{}
+		err := Run(RunParams{
+			BaseCtx:  t.Context(),
+			Dir:      dataDirForThisTest,
+			Stdout:   &bytes.Buffer{},
+			Stderr:   stderr,
+			CacheDir: cacheDir,
+			Args:     args,
+		})
+		if err != nil {
+			t.Logf("stderr was: %s", stderr.String())
+		}
+		return err
+	}
+
+	require.NoError(t, run("sleep", "10ms"))
+	first := readTimings(cacheDir, dataDirForThisTest)["Sleep"]
+	assert.GreaterOrEqual(t, first, 10*time.Millisecond)
+
+	require.NoError(t, run("sleep", "1100ms"))
+	second := readTimings(cacheDir, dataDirForThisTest)["Sleep"]
+	assert.GreaterOrEqual(t, second, 1100*time.Millisecond)
+
+	// failed runs aren't recorded
+	require.Error(t, run("fail"))
+	assert.NotContains(t, readTimings(cacheDir, dataDirForThisTest), "Fail")
+
+	listLine := func(noTimings bool, target string) []string {
+		stdout := &bytes.Buffer{}
+		require.NoError(t, Run(RunParams{
+			BaseCtx:   t.Context(),
+			Dir:       dataDirForThisTest,
+			Stdout:    stdout,
+			Stderr:    &bytes.Buffer{},
+			CacheDir:  cacheDir,
+			List:      true,
+			NoTimings: noTimings,
+		}))
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 && fields[0] == target {
+				return fields
+			}
+		}
+		t.Fatalf("no %q line in:\n%s", target, stdout.String())
+		return nil
+	}
+	assert.Equal(t, []string{"USAGE", "LAST", "SYNOPSIS"}, listLine(false, "USAGE"))
+	assert.Equal(t, []string{"sleep", "<d>", humanizeDuration(second), "waits", "for", "d."}, listLine(false, "sleep"))
+	assert.Equal(t, []string{"fail", "-", "always", "fails."}, listLine(false, "fail"))
+	assert.Equal(t, []string{"USAGE", "SYNOPSIS"}, listLine(true, "USAGE"))
+
+	// a corrupt timings file is ignored, then replaced
+	require.NoError(t, os.WriteFile(timingsFile(cacheDir), []byte("{not json"), 0o644))
+	assert.Empty(t, readTimings(cacheDir, dataDirForThisTest))
+	require.NoError(t, run("sleep", "1ms"))
+	assert.Contains(t, readTimings(cacheDir, dataDirForThisTest), "Sleep")
+}
+
+func TestVerboseTargetDuration(t *testing.T) {
+	t.Parallel()
+	dataDirForThisTest := filepath.Join(testDataDir, "timings")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	t.Cleanup(mu.Unlock)
+
+	stderr := &bytes.Buffer{}
+	err := Run(RunParams{
+		BaseCtx:  t.Context(),
+		Dir:      dataDirForThisTest,
+		Stdout:   &bytes.Buffer{},
+		Stderr:   stderr,
+		CacheDir: t.TempDir(),
+		Verbose:  true,
+		Args:     []string{"sleep", "50ms"},
+	})
+	require.NoError(t, err, "stderr was: %s", stderr.String())
+	assert.Regexp(t, `Finished target: <Sleep> in \d+(\.\d+)?m?s\n`, stderr.String())
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	t.Parallel()
+	for d, want := range map[time.Duration]string{
+		500 * time.Microsecond:                "<1ms",
+		123456 * time.Microsecond:             "123ms",
+		12345 * time.Millisecond:              "12.3s",
+		3*time.Minute + 4600*time.Millisecond: "3m5s",
+	} {
+		assert.Equal(t, want, humanizeDuration(d), d.String())
+	}
+}
@@ -0,0 +1,64 @@
+package stave
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/log"
+)
+
+// verifyTargets runs the binary compiled to exePath with --list and fails
+// unless every target in params.VerifyTargets is among those it lists. Like
+// target names on the command line, they're compared ignoring case.
+func verifyTargets(ctx context.Context, params RunParams, exePath string) error {
+	if len(params.VerifyTargets) == 0 || dryrun.IsDryRun() {
+		return nil
+	}
+
+	slog.Debug("listing targets of compiled binary", slog.String(log.Path, exePath))
+	var stdout, stderr bytes.Buffer
+	theCmd := exec.CommandContext(ctx, exePath, "--list")
+	theCmd.Stdout = &stdout
+	theCmd.Stderr = &stderr
+	if err := theCmd.Run(); err != nil {
+		return newError(KindCompile, fmt.Errorf("listing the targets of %s: %w: %s", exePath, err, strings.TrimSpace(stderr.String())))
+	}
+
+	missing := missingTargets(stdout.String(), params.VerifyTargets)
+	if len(missing) > 0 {
+		return newError(KindCompile, errors.New(exePath+" is missing targets: "+strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// missingTargets returns those of want that aren't among the target names in
+// list, one per line.
+func missingTargets(list string, want []string) []string {
+	have := make(map[string]bool)
+	for line := range strings.Lines(list) {
+		have[strings.ToLower(strings.TrimSpace(line))] = true
+	}
+	var missing []string
+	for _, target := range want {
+		target = strings.TrimSpace(target)
+		if target != "" && !have[strings.ToLower(target)] {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+// crossCompiling reports whether params build the binary for a platform other
+// than this one, so that it can't be run here.
+func crossCompiling(params RunParams) bool {
+	return cmp.Or(params.GOOS, runtime.GOOS) != runtime.GOOS ||
+		cmp.Or(params.GOARCH, runtime.GOARCH) != runtime.GOARCH
+}
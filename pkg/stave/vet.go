@@ -0,0 +1,111 @@
+package stave
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yaklabco/stave/config"
+	"github.com/yaklabco/stave/internal/dryrun"
+	"github.com/yaklabco/stave/internal/log"
+	"github.com/yaklabco/stave/pkg/env"
+)
+
+// vetFindingRE matches a finding in go vet's output, like
+// "./stavefile.go:12:2: fmt.Printf format %d has arg ...". Type errors, which
+// vet prefixes with "vet: ", don't match: the compile reports them.
+var vetFindingRE = regexp.MustCompile(`^(\S+\.go):(\d+:\d+): (.+)$`)
+
+// vetFinding is a problem go vet found in a stavefile.
+type vetFinding struct {
+	Pos     string // Pos is the file:line:col of the finding, with the file joined to the stavefiles dir.
+	Message string
+}
+
+// verifyMode returns the verify setting for the run: --vet, or verify in
+// stave.yaml.
+func verifyMode(params RunParams, cfg *config.Config) string {
+	if params.Vet {
+		return config.VerifyVet
+	}
+	return cfg.Verify
+}
+
+// vetStavefiles runs go vet on the files about to be compiled, with the same
+// build tags, GOOS, GOARCH and modfile, if verify is set. Findings in the
+// generated mainfile are left out. With verify: vet findings fail the run;
+// with verify: warn they are logged as warnings.
+func vetStavefiles(ctx context.Context, mode, mainfile string, params CompileParams) error {
+	if mode != config.VerifyVet && mode != config.VerifyWarn {
+		return nil
+	}
+
+	args := []string{"vet", "-tags", params.tags()}
+	if params.ModFile != "" {
+		args = append(args, "-modfile", params.ModFile, "-mod=mod")
+	}
+	for _, file := range params.Gofiles {
+		args = append(args, filepath.Base(file))
+	}
+
+	theEnv := params.env()
+	slog.Debug("running go vet", slog.String(log.Cmd, params.GoCmd), slog.Any(log.Args, args))
+	var output bytes.Buffer
+	theCmd := dryrun.Wrap(ctx, theEnv, params.GoCmd, args...)
+	theCmd.Env = env.ToAssignments(theEnv)
+	theCmd.Stdout = &output
+	theCmd.Stderr = &output
+	theCmd.Dir = params.StavePath
+	if err := theCmd.Run(); err == nil {
+		return nil
+	}
+
+	findings := parseVetFindings(output.String(), params.StavePath, filepath.Base(mainfile))
+	if len(findings) == 0 {
+		// vet couldn't check the stavefiles, e.g. because they don't compile;
+		// the compile reports why.
+		slog.Debug("go vet failed without findings", slog.String(log.Stderr, output.String()))
+		return nil
+	}
+
+	if mode == config.VerifyWarn {
+		for _, finding := range findings {
+			slog.Warn(
+				"go vet finding",
+				slog.String(log.Position, finding.Pos),
+				slog.String(log.Reason, finding.Message),
+			)
+		}
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("go vet found problems in the stavefiles:")
+	for _, finding := range findings {
+		fmt.Fprintf(&builder, "\n  %s: %s", finding.Pos, finding.Message)
+	}
+	builder.WriteString("\n    hint: with verify: warn in stave.yaml, go vet findings are warnings instead")
+	return newError(KindParse, errors.New(builder.String()))
+}
+
+// parseVetFindings returns the findings in go vet's output, run in dir, other
+// than those in the file called skip.
+func parseVetFindings(output, dir, skip string) []vetFinding {
+	var findings []vetFinding
+	for line := range strings.Lines(output) {
+		match := vetFindingRE.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil || filepath.Base(match[1]) == skip {
+			continue
+		}
+		findings = append(findings, vetFinding{
+			Pos:     filepath.Join(dir, match[1]) + ":" + match[2],
+			Message: match[3],
+		})
+	}
+	return findings
+}
@@ -0,0 +1,84 @@
+package stave
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVetFindings(t *testing.T) {
+	t.Parallel()
+
+	output := "# command-line-arguments\n" +
+		"./stavefile.go:9:2: fmt.Printf format %d has arg \"world\" of wrong type string\n" +
+		"deploy.go:12:3: unreachable code\n" +
+		"stave_output_file_abc_1.go:40:2: self-assignment of x to x\n" +
+		"vet: ./other.go:3:1: undefined: thing\n"
+	findings := parseVetFindings(output, "stavefiles", "stave_output_file_abc_1.go")
+	assert.Equal(t, []vetFinding{
+		{
+			Pos:     filepath.Join("stavefiles", "stavefile.go") + ":9:2",
+			Message: `fmt.Printf format %d has arg "world" of wrong type string`,
+		},
+		{Pos: filepath.Join("stavefiles", "deploy.go") + ":12:3", Message: "unreachable code"},
+	}, findings)
+}
+
+func runVetStavefile(t *testing.T, params RunParams) (string, error) {
+	t.Helper()
+	dataDirForThisTest := filepath.Join(testDataDir, "vet")
+	mu := mutexByDir(dataDirForThisTest)
+	mu.Lock()
+	defer mu.Unlock()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	params.BaseCtx = t.Context()
+	params.Dir = dataDirForThisTest
+	params.Stdout = stdout
+	params.Stderr = stderr
+	params.CacheDir = t.TempDir()
+	params.Args = []string{"greet"}
+	err := Run(params)
+	if err != nil {
+		t.Logf("stderr was: %s", stderr.String())
+	}
+	return stdout.String(), err
+}
+
+func TestVetStavefiles(t *testing.T) {
+	// without verify, the stavefile compiles and runs despite the bug.
+	out, err := runVetStavefile(t, RunParams{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello %!d(string=world)\n", out)
+
+	out, err = runVetStavefile(t, RunParams{Vet: true})
+	require.Error(t, err)
+	assert.Empty(t, out)
+	var stErr *Error
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, KindParse, stErr.Kind)
+	assert.Contains(t, err.Error(), "go vet found problems in the stavefiles:")
+	// the column vet reports varies between Go versions.
+	assert.Contains(t, err.Error(), filepath.Join(testDataDir, "vet", "stavefile.go")+":9:")
+	assert.Contains(t, err.Error(), `fmt.Printf format %d has arg "world" of wrong type string`)
+	assert.NotContains(t, err.Error(), mainFileBase)
+}
+
+func TestVetStavefilesWarn(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "stave.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("verify: warn\n"), 0o644))
+
+	out, err := runVetStavefile(t, RunParams{ConfigFile: configFile})
+	require.NoError(t, err)
+	assert.Equal(t, "hello %!d(string=world)\n", out)
+
+	// --vet overrides verify: warn.
+	_, err = runVetStavefile(t, RunParams{ConfigFile: configFile, Vet: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "go vet found problems in the stavefiles:")
+}
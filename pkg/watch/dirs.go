@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklabco/stave/pkg/st"
+)
+
+// watchDirs returns the absolute paths of the directories that watch mode is
+// limited to with --watch-dir, or nil if it isn't.
+func watchDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(st.WatchDirsEnv)) {
+		if dir == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
+
+// inWatchDirs reports whether the absolute path is in one of dirs, or at any
+// depth below one. Every path is if dirs is empty.
+func inWatchDirs(path string, dirs []string) bool {
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,9 @@ func handleFileChange(path string) error {
 			absPath = a
 		}
 	}
+	if !inWatchDirs(absPath, watchDirs()) {
+		return nil
+	}
 
 	globalMu.Lock()
 	if info, err := os.Stat(absPath); err == nil && info.IsDir() {
@@ -107,6 +107,11 @@ func Watch(patterns ...string) {
 				dir = "."
 			}
 		}
+		// with --watch-dir, directories outside those given aren't watched,
+		// even if the pattern matches files in them.
+		if absDir, err := filepath.Abs(dir); err == nil && !inWatchDirs(absDir, watchDirs()) {
+			continue
+		}
 		globalMu.Lock()
 		if watcher != nil {
 			err := watcher.Add(dir)
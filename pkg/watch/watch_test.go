@@ -2,12 +2,14 @@ package watch
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yaklabco/stave/pkg/st"
 	"github.com/yaklabco/stave/pkg/watch/mode"
 	"github.com/yaklabco/stave/pkg/watch/wctx"
 	"github.com/yaklabco/stave/pkg/watch/wstack"
@@ -100,3 +102,46 @@ func TestCallerTargetName(t *testing.T) {
 	t.Logf("Full caller name: %s", fullName)
 	t.Logf("Caller target name: %s", name)
 }
+
+func TestWatchDirsLimitReruns(t *testing.T) {
+	root := t.TempDir()
+	inside := filepath.Join(root, "src")
+	outside := filepath.Join(root, "docs")
+	require.NoError(t, os.Mkdir(inside, 0o755))
+	require.NoError(t, os.Mkdir(outside, 0o755))
+	t.Setenv(st.WatchDirsEnv, inside)
+
+	mode.ResetForTest()
+	name := wctx.DisplayName("github.com/yaklabco/stave/pkg/watch.TestWatchDirsLimitReruns")
+	mode.AddRequestedTarget(name)
+	mode.SetOverallWatchMode(true)
+	ctx := wctx.WithCurrent(t.Context(), name)
+	wctx.Register(name, ctx)
+	defer wctx.Unregister(name)
+
+	Watch(filepath.Join(root, "**"))
+	s := GetTargetState(name)
+	rerun := func() bool {
+		select {
+		case <-s.RerunChan:
+			return true
+		default:
+			return false
+		}
+	}
+
+	require.NoError(t, handleFileChange(filepath.Join(outside, "README.md")))
+	assert.False(t, rerun(), "a change outside --watch-dir doesn't re-run the target")
+
+	require.NoError(t, handleFileChange(filepath.Join(inside, "main.go")))
+	assert.True(t, rerun(), "a change inside --watch-dir re-runs the target")
+}
+
+func TestInWatchDirs(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "repo", "src")
+	assert.True(t, inWatchDirs(filepath.Join(dir, "a", "b.go"), []string{dir}))
+	assert.True(t, inWatchDirs(dir, []string{dir}))
+	assert.False(t, inWatchDirs(filepath.Join(dir+"2", "b.go"), []string{dir}))
+	assert.False(t, inWatchDirs(filepath.Dir(dir), []string{dir}))
+	assert.True(t, inWatchDirs(filepath.Dir(dir), nil))
+}
@@ -51,16 +51,27 @@ func startWatcher() {
 		}
 	}()
 
-	// Watch current directory and its subdirectories
-	walkErr := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return watcher.Add(path)
+	// Watch the --watch-dir directories, or else the current directory, and
+	// their subdirectories
+	roots := watchDirs()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	var walkErr error
+	for _, root := range roots {
+		walkErr = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return watcher.Add(path)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			break
 		}
-		return nil
-	})
+	}
 
 	if walkErr != nil {
 		fatalErr := st.Fatalf(1, "failed to start watcher: %v", walkErr)
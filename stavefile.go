@@ -242,15 +242,15 @@ func (Setup) Hooks() error {
 		hooksSuffix = " (" + strings.Join(configuredHooks, ", ") + ")"
 	}
 
-	outputf("%s %s %s%s\n",
+	st.Outputf("%s %s %s%s\n",
 		successStyle.Render("⚙️"),
 		labelStyle.Render("Git hooks configured:"),
 		valueStyle.Render("Stave"),
 		hooksSuffix,
 	)
 	if st.Verbose() {
-		outputf("  %s %s\n", labelStyle.Render("Directory:"), valueStyle.Render(filepath.Join(".git", "hooks")+string(filepath.Separator)))
-		outputf("  %s %s\n", labelStyle.Render("Config:"), valueStyle.Render("stave.yaml"))
+		st.Outputf("  %s %s\n", labelStyle.Render("Directory:"), valueStyle.Render(filepath.Join(".git", "hooks")+string(filepath.Separator)))
+		st.Outputf("  %s %s\n", labelStyle.Render("Config:"), valueStyle.Render("stave.yaml"))
 	}
 
 	return nil
@@ -313,9 +313,9 @@ func (Lint) Go() error {
 	out, err := sh.Output("golangci-lint", lo.Slice(args, 0, len(args)-1)...)
 	if err != nil {
 		titleStyle, blockStyle := ui.GetBlockStyles()
-		outputln(titleStyle.Render("golangci-lint output"))
-		outputln(blockStyle.Render(out))
-		outputln("")
+		_, _ = fmt.Fprintln(os.Stdout, titleStyle.Render("golangci-lint output"))
+		_, _ = fmt.Fprintln(os.Stdout, blockStyle.Render(out))
+		_, _ = fmt.Fprintln(os.Stdout)
 		return err
 	}
 
@@ -511,7 +511,7 @@ func (Test) All() error {
 
 	// Print test header (unless in quiet/CI mode)
 	if !isQuietMode() {
-		outputln("🧪 Running tests...")
+		st.Outputln("🧪 Running tests...")
 	}
 
 	startTime := time.Now()
@@ -520,7 +520,7 @@ func (Test) All() error {
 
 	// Print success message with timing (unless in quiet/CI mode)
 	if !isQuietMode() {
-		outputf("👌 All tests ran successfully (%s)\n", time.Since(startTime).Round(time.Millisecond))
+		st.Outputf("👌 All tests ran successfully (%s)\n", time.Since(startTime).Round(time.Millisecond))
 	}
 
 	return nil
@@ -560,8 +560,8 @@ type Debug st.Namespace
 
 // Parallelism prints parallelism environment variables (debugging utility)
 func (Debug) Parallelism() {
-	outputf("STAVE_NUM_PROCESSORS=%q\n", os.Getenv("STAVE_NUM_PROCESSORS"))
-	outputf("GOMAXPROCS=%q\n", os.Getenv("GOMAXPROCS"))
+	st.Outputf("STAVE_NUM_PROCESSORS=%q\n", os.Getenv("STAVE_NUM_PROCESSORS"))
+	st.Outputf("GOMAXPROCS=%q\n", os.Getenv("GOMAXPROCS"))
 }
 
 // DumpStdin reads stdin and dumps each line via spew (debugging utility)
@@ -582,7 +582,7 @@ func (Debug) DumpStdin() error {
 
 // Say prints arguments with their types (example target demonstrating args)
 func (Debug) Say(msg string, i int, b bool, d time.Duration) error {
-	outputf("%v(%T) %v(%T) %v(%T) %v(%T)\n", msg, msg, i, i, b, b, d, d)
+	st.Outputf("%v(%T) %v(%T) %v(%T) %v(%T)\n", msg, msg, i, i, b, b, d, d)
 
 	return nil
 }
@@ -600,7 +600,7 @@ func (Debug) WatchFile(file string) {
 	watch.Watch(file)
 
 	contents := fsutils.MustRead(file)
-	outputln(string(contents))
+	st.Outputln(string(contents))
 }
 
 // WatchDir watches the directory specified in its single argument, and re-runs `ls` any time anything contained therein changes.
@@ -614,7 +614,7 @@ func (Debug) WatchDir(dir string) error {
 	if err != nil {
 		return err
 	}
-	outputf("%s\n", output)
+	st.Outputf("%s\n", output)
 
 	return nil
 }
@@ -627,17 +627,6 @@ func (Debug) WatchDir(dir string) error {
 // * utility functions
 // *
 
-// outputf writes a formatted string to stdout.
-// Uses fmt.Fprintf for output (avoids forbidigo which bans fmt.Print* patterns).
-func outputf(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stdout, format, args...)
-}
-
-// outputln writes a string to stdout with a trailing newline.
-func outputln(s string) {
-	_, _ = fmt.Fprintln(os.Stdout, s)
-}
-
 // isQuietMode returns true if output should be suppressed (CI environments).
 // Check STAVE_QUIET=1 first, then common CI environment variables.
 func isQuietMode() bool {
@@ -791,12 +780,12 @@ func runTrufflehog(coreArgs []string, extraFlags ...string) error {
 	)
 	if err != nil {
 		titleStyle, blockStyle := ui.GetBlockStyles()
-		outputln(titleStyle.Render("trufflehog stdout:"))
-		outputln(blockStyle.Render(stdoutBuf.String()))
-		outputln("")
-		outputln(titleStyle.Render("trufflehog stderr:"))
-		outputln(blockStyle.Render(stderrBuf.String()))
-		outputln("")
+		st.Outputln(titleStyle.Render("trufflehog stdout:"))
+		st.Outputln(blockStyle.Render(stdoutBuf.String()))
+		st.Outputln("")
+		st.Outputln(titleStyle.Render("trufflehog stderr:"))
+		st.Outputln(blockStyle.Render(stderrBuf.String()))
+		st.Outputln("")
 		return err
 	}
 